@@ -3,7 +3,8 @@ module github.com/clinaresl/pgnparser
 go 1.22.2
 
 require (
-	github.com/clinaresl/table v1.1.0-beta // indirect
-	github.com/expr-lang/expr v1.16.5 // indirect
-	golang.org/x/exp v0.0.0-20230713183714-613f0c0eb8a1 // indirect
+	github.com/clinaresl/table v1.1.0-beta
+	github.com/expr-lang/expr v1.16.5
 )
+
+require golang.org/x/exp v0.0.0-20230713183714-613f0c0eb8a1
@@ -0,0 +1,102 @@
+/*
+  lichess.go
+  Description: lichess subcommand of the PGN parser
+  -----------------------------------------------------------------------------
+
+  Made by Carlos Linares Lopez
+  Login   <clinares@atlas>
+*/
+
+package main
+
+// imports
+// ----------------------------------------------------------------------------
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/clinaresl/pgnparser/pgntools"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the time given in the format 'YYYY-MM-DD', or the zero time if none is
+// given
+func parseDay(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// Execute the lichess subcommand with the given arguments, i.e., all arguments
+// given after 'lichess'. It downloads the games of a lichess user and writes
+// them in a pgn file, so that they can be processed afterwards
+func lichessCommand(args []string) {
+
+	// parse the flags of the lichess subcommand
+	flags := flag.NewFlagSet("lichess", flag.ExitOnError)
+	output := flags.String("output", "", "name of the pgn file where the games are written. By default, the name of the user with the extension '.pgn'")
+	maxGames := flags.Int("max", 0, "maximum number of games to download, from the most recent one. If zero, all games are downloaded")
+	since := flags.String("since", "", "if given, only the games played since the given day, e.g., '2024-01-31', are downloaded")
+	until := flags.String("until", "", "if given, only the games played until the given day, e.g., '2024-12-31', are downloaded")
+	token := flags.String("token", "", "personal API token of lichess, which allows downloading games faster")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %v lichess [options] user\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(EXIT_FAILURE)
+	}
+	user := flags.Arg(0)
+	if *output == "" {
+		*output = user + ".pgn"
+	}
+
+	// compute the period when games were played. The last day is fully
+	// included
+	first, err := parseDay(*since)
+	if err != nil {
+		log.Fatalf(" Error: invalid day '%v'", *since)
+	}
+	last, err := parseDay(*until)
+	if err != nil {
+		log.Fatalf(" Error: invalid day '%v'", *until)
+	}
+	if !last.IsZero() {
+		last = last.AddDate(0, 0, 1).Add(-time.Millisecond)
+	}
+
+	// download all games
+	start := time.Now()
+	games, err := pgntools.NewPgnCollectionFromLichess(user,
+		pgntools.WithLichessMax(*maxGames),
+		pgntools.WithLichessPeriod(first, last),
+		pgntools.WithLichessToken(*token))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// and write them
+	stream, err := os.Create(*output)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer stream.Close()
+	if err := games.GetPGN(stream); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf(" %v games of '%v' written in '%v'\n", games.Len(), user, *output)
+	fmt.Printf(" [%v]\n", time.Since(start))
+}
+
+/* Local Variables: */
+/* mode:go */
+/* fill-column:80 */
+/* End: */
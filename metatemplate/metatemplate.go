@@ -17,12 +17,9 @@
 // default fields are given, prompt must appear before the default.
 //
 // In case the value of the meta-variable is unknown at the time substitution
-// takes place, then the default value is used. If prompt is given, then the
-// user is prompted the same text given in the meta-variable description to
-// provide a value for it. If both prompt and default are given, then the user
-// is requested with the same string given in the meta-variable description and
-// the default value is offered between parenthesis so that it can be
-// immediately accepted.
+// takes place, then the default value is used. Otherwise, if prompt is given,
+// then the user is prompted the same text given in the meta-variable
+// description to provide a value for it.
 //
 // Importantly, the name of the variable can consist of any combination of the
 // alphanumeric characters (both in lower and upper case) and the underscore
@@ -49,6 +46,27 @@
 // following the usage of prompt and default apply. If they are not given, then
 // the substitution is not possible and an error is returned.
 //
+// Values can be also given in other sources so that templates can be processed
+// without prompting the user, e.g., in pipelines. The value of every
+// meta-variable is taken from the first of the following sources where it is
+// found:
+//
+//  1. values given in the command line, e.g., with --var name=value
+//  2. environment variables named after the meta-variable preceded by a
+//     prefix, e.g., PGNPARSER_name
+//  3. the dictionary of values
+//  4. the default value of the meta-variable
+//  5. the answer of the user to its prompt
+//
+// Templates can include other template files with the action {{template
+// "header.tex"}} (optionally followed by the data given to it), where the name
+// of the file is resolved relative to the directory of the template including
+// it. Included files can include other files in turn, and meta-variables are
+// substituted in the whole include tree with the same values, so that every
+// meta-variable is prompted only once. Names which do not correspond to
+// existing files are left untouched so that they can refer to templates
+// defined with {{define}}.
+//
 // The services provided in this package return ordinary text/templates that can
 // then be processed with functions from template package.
 package metatemplate
@@ -101,9 +119,24 @@ type FuncMap map[string]any
 // any character but ']'
 var reTmplExtendedIdentifier = regexp.MustCompile(`\$(\{(?P<idname1>[a-zA-Z0-9_]+)(\[prompt:(?P<prompt>[^\]]+)\])?(\[default:(?P<default>[^\]]+)\])?\})`)
 
+// The following regexp looks for the inclusion of other templates with the
+// action {{template "name"}}, optionally with trimming markers
+var reTmplInclude = regexp.MustCompile(`\{\{-?\s*template\s+"(?P<name>[^"]+)"`)
+
 // types
 // ----------------------------------------------------------------------------
 
+// Values of meta-variables can be given in different sources which are
+// checked in the following order: values given in the command line (e.g., with
+// a flag such as --var name=value), environment variables named after the
+// meta-variable preceded by a prefix (e.g., "PGNPARSER_name"), which are used
+// only if a prefix is given, and a dictionary of values
+type Sources struct {
+	Flags     map[string]string // values given in the command line
+	EnvPrefix string            // prefix of environment variables, if any
+	Values    map[string]string // values given by the caller
+}
+
 // Meta-variables might be given either a prompt or a default value and
 // certainly a name
 type metaVar struct {
@@ -115,6 +148,13 @@ type metaVar struct {
 // so that metavars are defined as a dictionary indexed by the variable name
 type metaVars map[string]metaVar
 
+// Templates included by others are given the name used to include them and
+// the path of the file where they are found
+type includedFile struct {
+	name string
+	path string
+}
+
 // functions
 // ----------------------------------------------------------------------------
 
@@ -208,53 +248,115 @@ func infoMetaVars(file io.Reader) metaVars {
 	return result
 }
 
+// Add to the given meta-variables all those found in the given file, and
+// return an error if the file could not be read
+func addMetaVarsFromFile(metavars metaVars, filename string) error {
+
+	stream, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf(" Error opening file '%v': %v\n", filename, err)
+	}
+	defer stream.Close()
+
+	for name, metavar := range infoMetaVars(stream) {
+		if value, ok := metavars[name]; !ok {
+			metavars[name] = metavar
+		} else {
+			metavars[name] = unionMetaVars(value, metavar)
+		}
+	}
+	return nil
+}
+
+// Return all template files included either directly or indirectly in the
+// given file, where every name is resolved relative to the directory of the
+// file including it. Names which do not correspond to existing files are
+// ignored, as they might refer to templates defined elsewhere. Every file is
+// returned only once, even if it is included several times, so that cyclic
+// includes are not followed. The given map contains the files already visited
+func getIncludes(filename string, visited map[string]bool) ([]includedFile, error) {
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf(" Error opening file '%v': %v\n", filename, err)
+	}
+
+	includes := make([]includedFile, 0)
+	for _, match := range reTmplInclude.FindAllStringSubmatch(string(contents), -1) {
+
+		// only existing files which have not been visited yet are included
+		path := filepath.Join(filepath.Dir(filename), match[1])
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() || visited[path] {
+			continue
+		}
+		visited[path] = true
+		includes = append(includes, includedFile{name: match[1], path: path})
+
+		// and also the files included in it
+		nested, err := getIncludes(path, visited)
+		if err != nil {
+			return nil, err
+		}
+		includes = append(includes, nested...)
+	}
+	return includes, nil
+}
+
+// Return the given line after substituting all meta-variables in it with the
+// given values
+func substituteLine(line string, substitutions map[string]string) string {
+
+	result := line
+	for _, loc := range reTmplExtendedIdentifier.FindAllStringSubmatchIndex(line, -1) {
+
+		// Get the name of this occurrence and perform the corresponding
+		// substitution
+		result = strings.Replace(result, line[loc[0]:loc[1]], substitutions[line[loc[4]:loc[5]]], -1)
+	}
+	return result
+}
+
+// Return the contents of the given file after substituting all meta-variables
+// in it with the given values
+func substituteFile(filename string, substitutions map[string]string) (string, error) {
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf(" Error opening file '%v': %v\n", filename, err)
+	}
+
+	var builder strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(string(contents)))
+	for scanner.Scan() {
+		builder.WriteString(substituteLine(scanner.Text(), substitutions) + "\n")
+	}
+	return builder.String(), nil
+}
+
 // The following function performs all the necessary operations to get the value
 // of the given meta-variable and nil if no error was detected.
 //
-// If a default value is given, then it is used, unless a prompt has been given
-// also. In this case the user is prompted with a default value which is then
-// used in case RET is pressed, i.e., accepting the default value. If no default
-// value has been given the user is prompted and the result is assigned to the
-// variable. If neither a prompt nor a default value have been given an error is
-// returned
+// If a default value is given, then it is used. Otherwise, if a prompt has been
+// given, the user is prompted and the result is assigned to the variable. If
+// neither a prompt nor a default value have been given an error is returned
 func getValue(metavar metaVar) (string, error) {
 
+	// In case a default value was given, use it
+	if len(metavar.defaultValue) > 0 {
+		return metavar.defaultValue, nil
+	}
+
 	// In case a prompt was given, ask the user
 	if len(metavar.prompt) > 0 {
-
-		// The prompt to show the user must include the default value in case
-		// any has been given in addition to the prompt
-		userPrompt := metavar.prompt
-		if len(metavar.defaultValue) > 0 {
-			userPrompt += fmt.Sprintf(" (%v)", metavar.defaultValue)
-		}
-
-		// and ask the user
 		scanner := bufio.NewScanner(os.Stdin)
-		fmt.Printf(" %v: ", userPrompt)
+		fmt.Printf(" %v: ", metavar.prompt)
 		scanner.Scan()
 		if scanner.Err() != nil {
-			return "", fmt.Errorf(" Error while reading the user input for prompt '%v'\n", userPrompt)
-		}
-		result := scanner.Text()
-
-		// in case the user immediately pressed RET verify whether the empty
-		// string has to be used or whether (s)he was accepting the default
-		// value
-		if len(result) == 0 {
-			if len(metavar.defaultValue) >= 0 {
-				result = metavar.defaultValue
-			}
+			return "", fmt.Errorf(" Error while reading the user input for prompt '%v'\n", metavar.prompt)
 		}
 
 		// and return the result
-		return result, nil
-	}
-
-	// At this point, no prompt was given, so that just check whether a default
-	// value was given before returning an error
-	if len(metavar.defaultValue) >= 0 {
-		return metavar.defaultValue, nil
+		return scanner.Text(), nil
 	}
 
 	// So, if neither a prompt nor a default value was given, then return any
@@ -262,24 +364,41 @@ func getValue(metavar metaVar) (string, error) {
 	return "", errors.New("No value")
 }
 
+// Return the value of the meta-variable with the given name and true if it is
+// given in any of these sources, or false otherwise. Sources are checked in
+// order of precedence: flags, the environment (only if a prefix is given) and
+// values
+func (sources Sources) lookup(name string) (string, bool) {
+	if value, ok := sources.Flags[name]; ok {
+		return value, true
+	}
+	if len(sources.EnvPrefix) > 0 {
+		if value, ok := os.LookupEnv(sources.EnvPrefix + name); ok {
+			return value, true
+		}
+	}
+	value, ok := sources.Values[name]
+	return value, ok
+}
+
 // getValues returns a map of strings to strings with the substitions to perform
 // in the template, and nil if no error occurred.
 //
-// This function accepts a map of strings to strings. In case the name of a
-// meta-variable is found in this map, its value is given preference. Otherwise,
-// its default value and/or its prompt are used
+// In case the name of a meta-variable is found in any of the given sources, its
+// value is given preference. Otherwise, its default value or its prompt are
+// used
 //
 // If it was not possible to deduce the value of any meta-variable an error is
 // returned
-func getValues(values map[string]string, metavars metaVars) (substitutions map[string]string, err error) {
+func getValues(sources Sources, metavars metaVars) (substitutions map[string]string, err error) {
 
 	substitutions = make(map[string]string)
 
 	// process all variables
 	for k, v := range metavars {
 
-		// in case this name is also found in the dictionary of values, use it
-		if value, ok := values[k]; ok {
+		// in case this name is also found in any source of values, use it
+		if value, ok := sources.lookup(k); ok {
 			substitutions[k] = value
 		} else {
 
@@ -394,9 +513,20 @@ func (mt *MetaTemplate) Name() string {
 // result of invoking that function over temporal files where all meta-variables
 // have been properly substituted.
 func (mt *MetaTemplate) ParseFiles(values map[string]string, filenames ...string) (*MetaTemplate, error) {
+	return mt.ParseFilesFrom(Sources{Values: values}, filenames...)
+}
 
-	// create a slice to store the processed files
+// ParseFilesFrom is like ParseFiles but the values of meta-variables are taken
+// from the given sources, in their order of precedence, before using their
+// default value or prompting the user.
+func (mt *MetaTemplate) ParseFilesFrom(sources Sources, filenames ...string) (*MetaTemplate, error) {
+
+	// create a slice to store the processed files, and another one to store
+	// the files included in them along with the values of their
+	// meta-variables
 	tmpfiles := make([]string, 0)
+	includes := make([]includedFile, 0)
+	includeSubstitutions := make([]map[string]string, 0)
 
 	// create temporary files with a copy of each input file with all
 	// substitutions being performed
@@ -408,14 +538,28 @@ func (mt *MetaTemplate) ParseFiles(values map[string]string, filenames ...string
 		} else {
 
 			// First of all, parse the template and get information of all
-			// meta-variables
+			// meta-variables, including those in the files it includes
 			metavars := infoMetaVars(istream)
+			iincludes, err := getIncludes(ifile, map[string]bool{filepath.Clean(ifile): true})
+			if err != nil {
+				return nil, err
+			}
+			for _, iinclude := range iincludes {
+				if err := addMetaVarsFromFile(metavars, iinclude.path); err != nil {
+					return nil, err
+				}
+			}
 
-			// Now, compute all substitutions of all values found in the template
-			substitutions, err := getValues(values, metavars)
+			// Now, compute all substitutions of all values found in the
+			// template, which are used also in all files it includes
+			substitutions, err := getValues(sources, metavars)
 			if err != nil {
 				return nil, err
 			}
+			for _, iinclude := range iincludes {
+				includes = append(includes, iinclude)
+				includeSubstitutions = append(includeSubstitutions, substitutions)
+			}
 
 			// And now process the entire file to write the result of performing
 			// all substitutions in a temporary file. Dunno why the core Google
@@ -444,17 +588,8 @@ func (mt *MetaTemplate) ParseFiles(values map[string]string, filenames ...string
 				scanner := bufio.NewScanner(istream)
 				for scanner.Scan() {
 
-					// for all meta-template variables appearing in this file
-					contents := scanner.Text()
-
-					// and look for the ocurrence of any meta-variable in this line
-					line := contents
-					for _, loc := range reTmplExtendedIdentifier.FindAllStringSubmatchIndex(contents, -1) {
-
-						// Get the name of this occurrence and perform the
-						// corresponding substitution
-						line = strings.Replace(line, contents[loc[0]:loc[1]], substitutions[contents[loc[4]:loc[5]]], -1)
-					}
+					// substitute all meta-variables appearing in this line
+					line := substituteLine(scanner.Text(), substitutions)
 
 					// and write it in the temp file
 					if _, err := writer.WriteString(line + "\n"); err != nil {
@@ -483,6 +618,22 @@ func (mt *MetaTemplate) ParseFiles(values map[string]string, filenames ...string
 		}
 	}
 
+	// Next, parse all included files after substituting their
+	// meta-variables. They are associated with the name used to include them,
+	// so that files included several times are parsed only once
+	for idx, iinclude := range includes {
+		if err != nil {
+			break
+		}
+		if result.Lookup(iinclude.name) != nil {
+			continue
+		}
+		var contents string
+		if contents, err = substituteFile(iinclude.path, includeSubstitutions[idx]); err == nil {
+			_, err = result.New(iinclude.name).Parse(contents)
+		}
+	}
+
 	// and return the results
 	return (*MetaTemplate)(result), err
 }
@@ -0,0 +1,294 @@
+// -*- coding: utf-8 -*-
+// metatemplate_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:40:14.251173878 (1792172414)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package metatemplate
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// write the given files in the given directory, where every name is a path
+// relative to it, and return the full path of the first one
+func writeTemplates(t *testing.T, dir string, files [][2]string) string {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(dir, file[0])
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll(%q): %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(file[1]), 0644); err != nil {
+			t.Fatalf("WriteFile(%q): %v", path, err)
+		}
+	}
+	return filepath.Join(dir, files[0][0])
+}
+
+func Test_getIncludes(t *testing.T) {
+
+	// every test consists of a number of files, the first one being the one
+	// whose includes are computed, and the names expected to be found, in
+	// the order they are visited, i.e., depth first
+	tests := []struct {
+		name  string
+		files [][2]string
+		want  []string
+	}{
+		{
+			"No includes",
+			[][2]string{{"main.tpl", "Hello ${name}"}},
+			[]string{},
+		},
+		{
+			"Nested includes",
+			[][2]string{
+				{"main.tpl", `{{template "header.tpl"}} {{template "footer.tpl"}}`},
+				{"header.tpl", `{{- template "sub/title.tpl" -}}`},
+				{"sub/title.tpl", `{{template "logo.tpl"}}`},
+				{"sub/logo.tpl", "logo"},
+				{"footer.tpl", "footer"},
+			},
+			[]string{"header.tpl", "sub/title.tpl", "logo.tpl", "footer.tpl"},
+		},
+		{
+			"Files included several times",
+			[][2]string{
+				{"main.tpl", `{{template "a.tpl"}}{{template "b.tpl"}}{{template "a.tpl"}}`},
+				{"a.tpl", `{{template "b.tpl"}}`},
+				{"b.tpl", "b"},
+			},
+			[]string{"a.tpl", "b.tpl"},
+		},
+		{
+			"Cycles",
+			[][2]string{
+				{"main.tpl", `{{template "a.tpl"}}`},
+				{"a.tpl", `{{template "b.tpl"}}`},
+				{"b.tpl", `{{template "a.tpl"}}{{template "main.tpl"}}`},
+			},
+			[]string{"a.tpl", "b.tpl"},
+		},
+		{
+			"Self inclusion",
+			[][2]string{{"main.tpl", `{{template "main.tpl"}}`}},
+			[]string{},
+		},
+		{
+			"Defined templates",
+			[][2]string{
+				{"main.tpl", `{{define "row"}}{{template "cell.tpl"}}{{end}}{{template "row"}}`},
+				{"cell.tpl", `{{define "value"}}{{.}}{{end}}{{template "value" .}}`},
+			},
+			[]string{"cell.tpl"},
+		},
+		{
+			"Directories",
+			[][2]string{
+				{"main.tpl", `{{template "sub"}}`},
+				{"sub/file.tpl", "file"},
+			},
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			main := writeTemplates(t, dir, tt.files)
+			includes, err := getIncludes(main, map[string]bool{main: true})
+			if err != nil {
+				t.Fatalf("getIncludes() error = %v", err)
+			}
+
+			got := make([]string, 0)
+			for _, include := range includes {
+				got = append(got, include.name)
+
+				// the path of every included file is relative to the
+				// directory of the main template
+				if !strings.HasPrefix(include.path, dir) || filepath.Base(include.path) != filepath.Base(include.name) {
+					t.Errorf("getIncludes() path = %q for %q", include.path, include.name)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("getIncludes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getIncludesMissingFile(t *testing.T) {
+	if _, err := getIncludes(filepath.Join(t.TempDir(), "missing.tpl"), map[string]bool{}); err == nil {
+		t.Errorf("getIncludes() expected an error with a missing file")
+	}
+}
+
+func TestMetaTemplate_ParseFilesIncludes(t *testing.T) {
+
+	// meta-variables are substituted across the whole include tree, cycles
+	// are parsed only once and templates defined with {{define}} are left
+	// untouched
+	dir := t.TempDir()
+	main := writeTemplates(t, dir, [][2]string{
+		{"main.tpl", `{{define "greeting"}}Hello{{end -}}` +
+			`{{template "greeting"}} {{template "name.tpl"}}{{template "sub/tail.tpl" -}}`},
+		{"name.tpl", `${name[default:Alan Turing]}{{if false}}{{template "main.tpl"}}{{end -}}`},
+		{"sub/tail.tpl", `{{template "mark.tpl" -}}`},
+		{"sub/mark.tpl", `${mark[default:?]}{{if false}}{{template "../name.tpl"}}{{end -}}`},
+	})
+
+	tpl, err := New("main.tpl").ParseFiles(map[string]string{"mark": "!"}, main)
+	if err != nil {
+		t.Fatalf("ParseFiles() error = %v", err)
+	}
+	var builder strings.Builder
+	if err := tpl.Execute(&builder, nil); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got, want := builder.String(), "Hello Alan Turing!"; got != want {
+		t.Errorf("Execute() = %q, want %q", got, want)
+	}
+}
+
+// replace the standard input with a file containing the given text for the
+// duration of the test, so that prompts can be answered
+func setStdin(t *testing.T, text string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatalf("WriteFile(%q): %v", path, err)
+	}
+	stream, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", path, err)
+	}
+	stdin := os.Stdin
+	os.Stdin = stream
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		stream.Close()
+	})
+}
+
+func TestSources_lookup(t *testing.T) {
+
+	// sources are checked in order: flags, the environment, and values
+	t.Setenv("TEST_PGNPARSER_all", "env")
+	t.Setenv("TEST_PGNPARSER_env", "env")
+	t.Setenv("TEST_PGNPARSER_empty", "")
+	sources := Sources{
+		Flags:     map[string]string{"all": "flag", "flag": "flag"},
+		EnvPrefix: "TEST_PGNPARSER_",
+		Values:    map[string]string{"all": "value", "env": "value", "value": "value", "empty": "value"},
+	}
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{"all", "flag", true},
+		{"flag", "flag", true},
+		{"env", "env", true},
+		{"value", "value", true},
+
+		// environment variables which are set but empty are still used
+		{"empty", "", true},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sources.lookup(tt.name)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("lookup(%q) = (%q, %v), want (%q, %v)", tt.name, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	// without a prefix the environment is not used at all
+	sources.EnvPrefix = ""
+	if got, _ := sources.lookup("env"); got != "value" {
+		t.Errorf("lookup(%q) without prefix = %q, want %q", "env", got, "value")
+	}
+}
+
+func Test_getValues(t *testing.T) {
+
+	// every answer to a prompt is "prompted", so that it is possible to
+	// tell whether the user was prompted or not
+	setStdin(t, "prompted\n")
+	t.Setenv("TEST_PGNPARSER_env", "env")
+	sources := Sources{
+		Flags:     map[string]string{"flag": "flag"},
+		EnvPrefix: "TEST_PGNPARSER_",
+		Values:    map[string]string{"value": "value"},
+	}
+	metavars := metaVars{
+		"flag":     {name: "flag", prompt: "Flag?", defaultValue: "default"},
+		"env":      {name: "env", prompt: "Env?"},
+		"value":    {name: "value", prompt: "Value?"},
+		"default":  {name: "default", prompt: "Default?", defaultValue: "default"},
+		"prompted": {name: "prompted", prompt: "Prompted?"},
+	}
+	got, err := getValues(sources, metavars)
+	if err != nil {
+		t.Fatalf("getValues() error = %v", err)
+	}
+	want := map[string]string{
+		"flag":     "flag",
+		"env":      "env",
+		"value":    "value",
+		"default":  "default",
+		"prompted": "prompted",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("getValues() = %v, want %v", got, want)
+	}
+
+	// variables with neither a prompt nor a default value must be given in
+	// some source
+	if _, err := getValues(sources, metaVars{"none": {name: "none"}}); err == nil {
+		t.Errorf("getValues() expected an error with no value for a variable")
+	}
+}
+
+func TestMetaTemplate_ParseFilesFrom(t *testing.T) {
+
+	// the answer to any prompt is never used as all values are given either
+	// in flags or in the environment
+	setStdin(t, "prompted\n")
+	t.Setenv("TEST_PGNPARSER_player", "Capablanca")
+	t.Setenv("TEST_PGNPARSER_event", "Havana")
+	main := writeTemplates(t, t.TempDir(), [][2]string{
+		{"main.tpl", `${player[prompt:Player?]} - ${event[prompt:Event?]}{{template "year.tpl" -}}`},
+		{"year.tpl", ` ${year[prompt:Year?]}{{- /* */ -}}`},
+	})
+	tpl, err := New("main.tpl").ParseFilesFrom(Sources{
+		Flags:     map[string]string{"event": "Moscow", "year": "1925"},
+		EnvPrefix: "TEST_PGNPARSER_",
+		Values:    map[string]string{"player": "Lasker"},
+	}, main)
+	if err != nil {
+		t.Fatalf("ParseFilesFrom() error = %v", err)
+	}
+	var builder strings.Builder
+	if err := tpl.Execute(&builder, nil); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got, want := builder.String(), "Capablanca - Moscow 1925"; got != want {
+		t.Errorf("Execute() = %q, want %q", got, want)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
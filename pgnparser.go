@@ -21,14 +21,21 @@ package main
 // imports
 // ----------------------------------------------------------------------------
 import (
+	"errors"
 	"flag" // arg parsing
 	"fmt"  // printing msgs
+	"io"   // input/output primitives
 	"log"  // logging services
 	"os"   // operating system services
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	// also use several tools for handling games in pgn format
 	"github.com/clinaresl/pgnparser/pgntools"
+	"github.com/clinaresl/pgnparser/pgntools/book"
 )
 
 // global variables
@@ -39,19 +46,174 @@ const EMAIL string = "carlos.linares@uc3m.es"
 
 const TABLE_TEMPLATE = "templates/table/simple.tpl"
 
+// Training sheets can be written in the following formats
+var trainingFormats = map[string]pgntools.TrainingFormat{
+	"latex":    pgntools.LaTeXTraining,
+	"markdown": pgntools.MarkdownTraining,
+}
+
+// The extension of the file written for every format of training sheets
+var trainingExtensions = map[string]string{
+	"latex":    ".tex",
+	"markdown": ".md",
+}
+
+// The folding of comments can be given with the following names
+var commentFoldings = map[string]pgntools.CommentFolding{
+	"raw":      pgntools.RawComments,
+	"merge":    pgntools.MergedComments,
+	"separate": pgntools.SeparateComments,
+}
+
+// Markers of check and checkmate can be verified in the following modes
+var checkMarkerModes = map[string]pgntools.CheckMarkers{
+	"warn":  pgntools.WarnCheckMarkers,
+	"strip": pgntools.StripCheckMarkers,
+	"fix":   pgntools.FixCheckMarkers,
+}
+
+// Chapters of Lichess studies can be given with the following names
+var studyChapters = map[string]pgntools.StudyChapters{
+	"game":    pgntools.GameChapters,
+	"opening": pgntools.OpeningChapters,
+}
+
+// Duplicated games are resolved with the following policies
+var mergeConflictPolicies = map[string]pgntools.MergeConflicts{
+	"first":     pgntools.KeepFirstGame,
+	"last":      pgntools.KeepLastGame,
+	"annotated": pgntools.KeepAnnotatedGame,
+}
+
+// Heatmaps count the squares either occupied or visited by a piece
+var heatmapModes = map[string]pgntools.HeatmapMode{
+	"occupied": pgntools.OccupiedSquares,
+	"visited":  pgntools.VisitedSquares,
+}
+
+// Values of meta-variables can be given in the command line with --var as many
+// times as needed
+type templateVars map[string]string
+
+// Return a string with all values of meta-variables
+func (vars templateVars) String() string {
+	return fmt.Sprintf("%v", map[string]string(vars))
+}
+
+// Add the value of a meta-variable given as name=value
+func (vars templateVars) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf(" meta-variables must be given as name=value, but '%v' was given", value)
+	}
+	vars[name] = val
+	return nil
+}
+
 var EXIT_SUCCESS int = 0 // exit with success
 var EXIT_FAILURE int = 1 // exit with failure
 
 // Options
-var filename string      // base directory
-var list bool            // whether games should be listed or not
-var play int = 0         // number of moves between boards
-var filter string        // select query to filter games
-var histogram string     // histogram descriptor
-var sort string          // sorting descriptor
-var output string        // name of the file that stores results
-var tableTemplate string // file with the table template
-var latexTemplate string // file with the latex template
+var filename string       // base directory
+var list bool             // whether games should be listed or not
+var gamesList bool        // whether a compact list of games should be shown
+var compare string        // ids of two games to compare
+var play int = 0          // number of moves between boards
+var ascii bool            // whether boards are shown with ASCII characters
+var filter string         // select query to filter games
+var histogram string      // histogram descriptor
+var perspective string    // color or player whose points are shown
+var standings string      // tag used to group games in standings
+var heatmap string        // piece whose heatmap is computed
+var heatmapMode string    // whether squares occupied or visited are counted
+var decisions int         // width of the ranges of moves where games are decided
+var theory string         // file with the games of the reference theory
+var theoryPositions bool  // whether the theory is compared by positions
+var openingBook string    // file with a Polyglot opening book
+var position string       // FEN code of the position to search
+var explainFEN string     // FEN pattern whose matches are explained
+var positionIndex bool    // whether the index of positions is saved
+var database bool         // whether games are read from a database
+var fens string           // file with the FEN patterns of anthologies
+var fensTruncate bool     // whether games are truncated at the FEN patterns
+var splitBy string        // expression used to split games in different files
+var scoring string        // points awarded for every win, draw and loss
+var sort string           // sorting descriptor
+var output string         // name of the file that stores results
+var renumber bool         // whether games are renumbered after filter and sort
+var merge string          // file whose games are merged with the pgn file
+var dedup bool            // whether duplicated games are merged
+var mergeConflicts string // policy to resolve duplicated games
+var comments string       // how comments are folded in the output file
+var commentWidth int      // maximum width of comments in the output file
+var lineWidth int         // maximum width of the movetext in the output file
+var commentLines bool     // whether comments are written in their own lines
+var noCommands string     // commands which are not written in the output file
+var training string       // player whose moves are guessed in training sheets
+var trainingFormat string // format of the training sheets
+var trainingFrom int      // first move number used in training sheets
+var tableTemplate string  // file with the table template
+var latexTemplate string  // file with the latex template
+var diagram string        // style of the diagrams drawn in LaTeX
+var chunks int            // number of games per chunk
+var jobs int              // number of simultaneous jobs
+var split bool            // whether chunks are written in different files
+var fileNames string      // pattern of the names of files written per game
+var lenient bool          // whether games with errors are skipped
+var quarantine string     // file where rejected games are written
+var maxGameSize int       // maximum size of a single game in bytes
+var mmap bool             // whether the pgn file is mapped in memory
+var memory string         // maximum memory used for sorting games
+var memoryLimit int64     // maximum memory used for sorting games in bytes
+var index bool            // whether a player index should be saved
+var player string         // name of the player whose games are selected
+var crosslink bool        // whether related games are linked
+var transpositions bool   // whether transpositions are commented
+var language string       // language of the comments written
+var blunders bool         // whether decisive mistakes are marked
+var engine string         // path to a UCI engine
+var engineDepth int       // depth of the searches of the engine
+var engineCache string    // file where the analyses of the engine are kept
+
+// values of meta-variables in templates
+var vars = make(templateVars)
+
+var checkMarkers string // how markers of check and checkmate are verified
+var editTags string     // file with the rules used to edit tags
+var enrichTags string   // tags computed from the moves of games
+var diff bool           // whether changes made to games are shown
+var audit bool          // whether anomalies in the tags of games are shown
+var validate bool       // whether all illegal and ambiguous moves are shown
+
+var dossier string         // player whose dossier is generated
+var dossierTemplate string // file with the LaTeX template of dossiers
+var structures string      // file with the structures used in dossiers
+var recent int             // number of recent games shown in dossiers
+
+var sample int   // number of games chosen at random
+var shuffle bool // whether games are shuffled
+var seed uint64  // seed used to choose and shuffle games at random
+
+var first int        // number of games read from the PGN file
+var skip int         // number of games skipped at the beginning of the file
+var gameRange string // range of ids of the games read from the PGN file
+
+// range of ids of the games read from the PGN file computed from --first,
+// --skip and --range. No range is used if firstId is zero
+var firstId, lastId int
+
+var jsonOutput bool // whether games are written in JSON format
+var epd string      // expression selecting the positions written in EPD
+
+var epub string         // title of the EPUB book with all games
+var epubTemplate string // file with the template of chapters of EPUB books
+
+var contactSheet int   // number of plies between boards of contact sheets
+var contactColumns int // number of columns of contact sheets
+
+var study string        // name of the Lichess study with all games
+var studyChapter string // whether chapters are games or openings
+var studyDepth int      // number of plies of games merged in openings
 
 var verbose bool // has verbose output been requested?
 var version bool // has version info been requested?
@@ -63,32 +225,189 @@ var version bool // has version info been requested?
 func init() {
 
 	// Flag to store the pgn file to parse
-	flag.StringVar(&filename, "file", "", "pgn file to parse. While this utility is expected to be generic, it specifically adheres to the format of ficsgames.org as used in lichess.org")
+	flag.StringVar(&filename, "file", "", "pgn file to parse. While this utility is expected to be generic, it specifically adheres to the format of ficsgames.org as used in lichess.org. Games in JSON format (as written with --json) and positions in EPD format are recognized as well")
+
+	// Flag to map the pgn file in memory
+	flag.BoolVar(&mmap, "mmap", false, "if given, the pgn file is mapped in memory when reading its games, which is faster for very large files in 64-bit systems. If it is not possible, the file is read as usual")
+
+	// Flag to cap the memory used for sorting games
+	flag.StringVar(&memory, "memory", os.Getenv("PGNPARSER_MEMORY"), "maximum memory used for sorting games, e.g., '512M' or '2G'. If given with --sort and the pgn file is larger, games are sorted on disk with temporary files and written verbatim into the output file without processing them any further. By default, the value of the environment variable PGNPARSER_MEMORY, if any, and no limit otherwise")
+
+	// Flags to handle games that can not be parsed
+	flag.BoolVar(&lenient, "lenient", false, "if given, games that can not be parsed are skipped instead of stopping the execution")
+	flag.IntVar(&maxGameSize, "maxgamesize", pgntools.DefaultMaxGameSize, "maximum size in bytes of a single game. Text exceeding this size without recognizing any game is discarded. If zero, there is no limit")
+	flag.StringVar(&quarantine, "quarantine", "", "name of a PGN file where the raw text of all games that could not be parsed is written, each one preceded by a comment with the error found")
+
+	// Flags to handle the player index
+	flag.BoolVar(&index, "index", false, "if given, an index of the games played by every player is saved in a file named after the PGN file with extension '.idx', so that the games of any player can be extracted quickly with --player")
+	flag.StringVar(&player, "player", "", "if given, only the games played by the given player are considered. If an up-to-date index of the PGN file exists, only these games are read from the file")
+
+	// Flags to read only a slice of the games in the PGN file
+	flag.IntVar(&first, "first", 0, "if strictly positive, only the given number of games are read from the PGN file (after skipping those given with --skip), and reading stops right after them")
+	flag.IntVar(&skip, "skip", 0, "number of games at the beginning of the PGN file that are skipped without parsing them. Games keep the ids given by their location in the PGN file")
+	flag.StringVar(&gameRange, "range", "", "if given, only the games with ids in the given range, e.g., '100-200', are read from the PGN file. The upper bound can be omitted, e.g., '100-', to read all games from the first one given. It can not be used along with --first or --skip")
+
+	// Flag to request linking related games
+	flag.BoolVar(&crosslink, "crosslink", false, "if given, games are linked to other related games: rematches between the same players in the same event and date, and continuations of adjourned games given with a FEN tag. Links are shown with the field 'Links' in templates")
+
+	// Flag to request annotating transpositions
+	flag.BoolVar(&transpositions, "transpositions", false, "if given, moves reaching a position that was already reached with a different sequence of moves, either in a previous game or earlier in the same game, are given a comment with the game and move where it was reached before. Annotated games are written in the file given in --output")
+
+	// Flags to compare moves with the first choice of an engine
+	flag.StringVar(&engine, "engine", "", "if given, every move of all games is compared with the first choice of the UCI engine in the given path, and a table is shown with the percentage of moves of every player that matched it in the opening, middlegame and endgame, and overall. The percentages of every game can be used in filters and templates with 'WhiteAgreement' and 'BlackAgreement'")
+	flag.IntVar(&engineDepth, "enginedepth", 12, "depth of the searches of the engine given in --engine. By default, 12")
+	flag.StringVar(&engineCache, "enginecache", "", "if given, the analyses of all positions made by the engine given in --engine are kept in the given file, so that positions analyzed before, in this run or in previous ones, with the same depth or a larger one are not analyzed again")
+
+	// Flag to mark the decisive mistakes of games
+	flag.BoolVar(&blunders, "blunders", false, "if given, the decisive mistake of the loser of every game with evaluations given in '[%eval ...]', i.e., the last move before the winner got an advantage of at least three pawns, is qualified with '??' and commented with the evaluations before and after it and the better line given in the first variation after it, if any, in the PGN and LaTeX outputs, and when playing games with --play")
+
+	// Flag to select the language of comments
+	flag.StringVar(&language, "language", "", "if given, only the comments in the given language, e.g., 'en', are written in the PGN, LaTeX and EPUB outputs. Comments can be given in different languages by preceding the text in each one with a marker such as '[%lang en]' within the same comment. Text given before any marker is written in all languages")
 
 	// Flag to store the number of moves between boards
 	flag.BoolVar(&list, "list", false, "if given, a table with general information about all games found in the PGN file is shown")
 
+	// Flag to request a compact list of games
+	flag.BoolVar(&gamesList, "gameslist", false, "if given, a compact list of all games with one line per game is shown, in the format 'White - Black, Result, Event Round, Date, ECO, Moves'")
+
+	// Flag to request comparing two games
+	flag.StringVar(&compare, "compare", "", "if given, the moves of the two games with the given ids separated by a comma, e.g., '1,2', are shown side by side highlighting the first ply where they differ")
+
 	// Flag to store the number of moves between boards
 	flag.IntVar(&play, "play", 0, "if given, each game in the PGN file is played, and the chess board is shown between the number of consecutive plies given. The board is not shown by default")
 
+	// Flag to show boards with ASCII characters only
+	flag.BoolVar(&ascii, "ascii", false, "if given, boards are shown using only ASCII characters. It is used only in case --play is given")
+
+	// Flag to edit tags in bulk
+	flag.StringVar(&editTags, "edittags", "", "JSON file with a list of rules used to edit the tags of games before any other processing. Every rule renames, sets and/or deletes tags of the games matching an expression given with the syntax of filters. Edited games are written in the file given in --output. For more information on how to write these rules see the documentation")
+	flag.StringVar(&enrichTags, "enrichtags", "", "comma-separated list of tags computed from the moves of games after editing them: 'PlyCount', 'ECO', 'Opening', 'EndFEN' and 'Termination', or 'all' to compute all of them. PlyCount and EndFEN are always recomputed, whereas the others are given only to games where they are missing. Enriched games are written in the file given in --output")
+
+	// Flag to verify the markers of check and checkmate
+	flag.StringVar(&checkMarkers, "checkmarkers", "", "if given, the markers of check ('+') and checkmate ('#') of all moves are verified against the positions computed when playing games: 'warn' (mismatches are only shown), 'strip' (wrong markers are also removed) or 'fix' (all markers are also set according to the position). Corrected games are written in the file given in --output")
+
+	// Flag to audit the tags of games
+	flag.BoolVar(&audit, "audit", false, "if given, the tags of all games are audited and anomalies are shown with their severity (info, warning or error): ratings equal to zero or above 2900, malformed dates or dates in the future, results disagreeing with the outcome given after the moves and players facing themselves")
+
+	// Flag to validate the moves of games
+	flag.BoolVar(&validate, "validate", false, "if given, all ambiguous moves and the first illegal move of every game are shown along with the position where they were played before playing games, instead of stopping at the first illegal move found. If any illegal move is found, no further processing is done")
+
+	// Flag to show the changes made to games
+	flag.BoolVar(&diff, "diff", false, "if given, a unified diff between every game as found in the PGN file and as written in the file given in --output is shown after editing its tags and correcting its markers of check and checkmate, so that all changes can be audited before overwriting any file. Differences are colored when shown in a terminal")
+
 	// Flag to request filtering games by some criteria
-	flag.StringVar(&filter, "filter", "", "generates a new pgn file with those games satisfying the given filtering criteria. For information about the filtering criteria see the documentation.")
+	flag.StringVar(&filter, "filter", "", "generates a new pgn file with those games satisfying the given filtering criteria. Besides the tags, expressions can use variables such as Moves (the number of moves) and MoveList (the list of all plies with their number, color, san, comment and emt), e.g., 'Moves < 40 && any(MoveList, .san == \"e4\" && .number == 1)'. For information about the filtering criteria see the documentation.")
+
+	// Flags to choose and shuffle games at random
+	flag.IntVar(&sample, "sample", 0, "if strictly positive, only the given number of games chosen at random are considered after filtering them. Selected games are written in the file given in --output in the same order they are found")
+	flag.BoolVar(&shuffle, "shuffle", false, "if given, games are shuffled after filtering them and they are written in the file given in --output")
+	flag.Uint64Var(&seed, "seed", 0, "seed used to choose and shuffle games at random with --sample and --shuffle. The same seed always produces the same result in every run and machine. By default, 0")
 
 	// Flag to request sorting games by some criteria
 	flag.StringVar(&sort, "sort", "", "generates a new pgn file with games sorted according to the given criteria. For information about the sorting criteria see the documentation.")
 
+	// Flags to format comments in the output file
+	flag.StringVar(&comments, "comments", "raw", "how comments given after the same move are written in the output file: 'raw' (in the same blocks they are found), 'merge' (merged in a single block) or 'separate' (in separate blocks). By default, 'raw'")
+	flag.IntVar(&commentWidth, "commentwidth", 0, "if strictly positive, comments are re-wrapped in the output file so that no line is longer than the given width. By default, 0")
+
+	// Flags to format the movetext in the output file
+	flag.IntVar(&lineWidth, "linewidth", 0, "if strictly positive, the movetext of every game is arranged in the output file in lines which are not longer than the given width, e.g., 80 as required by the export format of the PGN standard. By default, 0, i.e., the movetext is written in a single line")
+	flag.BoolVar(&commentLines, "commentlines", false, "if given, every comment is written in its own lines in the output file, so that the movetext is broken before and after it")
+	flag.StringVar(&noCommands, "nocommands", "", "comma separated list of commands given in comments which are not written in the output file, e.g., 'emt,clk' removes the elapsed move times and clocks given in '[%emt ...]' and '[%clk ...]'")
+
+	// Flags to merge collections of games
+	flag.StringVar(&merge, "merge", "", "if given, the games of the given file (in any format accepted by --file) are added after those of the pgn file, and the result is written in the file given in --output. Games which are given an id already used are given a new one")
+	flag.BoolVar(&dedup, "dedup", false, "if given, duplicated games (played by the same players on the same date with the same moves) are merged into a single one, either in the pgn file or in the file given in --merge, and the result is written in the file given in --output. Tags missing in the game kept are taken from its duplicates")
+	flag.StringVar(&mergeConflicts, "mergeconflicts", "first", "game kept among duplicated games with --dedup: 'first' (the first one found), 'last' (the last one found) or 'annotated' (the one with more comments, NAGs and variations). By default, 'first'")
+
+	// Flag to request renumbering games
+	flag.BoolVar(&renumber, "renumber", false, "if given, games are given consecutive ids after filtering and sorting them. By default, every game keeps the id given by its location in the PGN file")
+
 	// Flag to request generating histograms
 	flag.StringVar(&histogram, "histogram", "", "generates a table with a summary about the given variables. For information about the histogram variables see the documentation.")
+	flag.StringVar(&perspective, "perspective", "White", "perspective from which games are scored in histograms: either 'White', 'Black' or the name of a player, in which case only the games played by that player are scored")
+
+	// Flags to request computing standings
+	flag.StringVar(&standings, "standings", "", "shows a table with the points obtained by every player in games grouped by the value of the given tag, e.g., 'Event'")
+	// Flags to request computing heatmaps
+	flag.StringVar(&heatmap, "heatmap", "", "if given, shows a table with the number of times every square is occupied or visited by the given piece, given with its FEN symbol, e.g., 'N' for white knights or 'n' for black knights, in all games. It is also written as a TikZ picture in a LaTeX file named after --output with the extension '.heatmap.tex'")
+	flag.StringVar(&heatmapMode, "heatmapmode", "occupied", "either 'occupied' to count the positions where the piece given in --heatmap stands on every square, or 'visited' to count the moves of the piece to every square. By default, 'occupied'")
+	flag.StringVar(&scoring, "scoring", "1-0.5-0", "points awarded for every win, draw and loss separated by dashes. It is used only in case --standings is given. By default, '1-0.5-0'")
+
+	// Flag to request the distribution of the moves where games are decided
+	flag.IntVar(&decisions, "decisions", 0, "if strictly positive, shows a histogram with the number of decisive games decided in every range of moves of the given width, i.e., where the winner got an evaluation of at least three pawns or, if games are not evaluated, a material advantage of at least three points for the first time")
+
+	// Flags to request the theory depth of games
+	flag.StringVar(&theory, "theory", "", "if given, shows statistics of how deep the games stay within the move tree of the games in the given PGN file, i.e., the number of their first plies which were played in the same order in any game of the given file")
+	flag.BoolVar(&theoryPositions, "theorypositions", false, "if given, games stay within the theory given in --theory up to the last position which was reached in any game of the given file, so that transpositions are considered")
+	flag.StringVar(&openingBook, "book", "", "if given, shows statistics of how deep the games stay within the given opening book in Polyglot format, i.e., the number of their first plies which are found in the book")
+
+	// Flags to search a position in all games
+	flag.StringVar(&position, "position", "", "if given, shows all games reaching the position given with its FEN code, along with the number of plies played to reach it. Halfmove clocks and fullmove numbers are ignored, so that transpositions and repetitions are found as well")
+	flag.StringVar(&explainFEN, "explainfen", "", "if given, shows for every game (after filtering them) the first position matching the given FEN pattern, with the same syntax used with FEN in filters, or otherwise the position closest to matching it along with the fields (and squares) that do not match, so that patterns can be debugged")
+	flag.BoolVar(&positionIndex, "positionindex", false, "if given, the index of positions used with --position is saved in a file named after the PGN file with extension '.pos', and it is read from it in subsequent executions as long as the PGN file does not change. It can not be used with a slice of the games or the games of a player")
+
+	// Flag to store games in a binary database
+	flag.BoolVar(&database, "database", false, "if given, all games are played and stored in binary format in a file named after the PGN file with extension '.db', and they are read from it in subsequent executions without parsing or playing them again as long as the PGN file does not change. It can not be used with a slice of the games")
+
+	// Flags to request extracting the games reaching a list of positions
+	flag.StringVar(&fens, "fens", "", "if given, for every FEN pattern in the given file (one per line, with the same syntax used with FEN in filters), the games reaching a position matching it are written in a file named after --output with the extension '.fen<n>.pgn', where n is the number of the pattern. Blank lines and lines starting with '#' are ignored")
+	flag.BoolVar(&fensTruncate, "fenstruncate", false, "if given, games extracted with --fens are truncated at the first position matching every pattern")
+
+	// Flag to request splitting games in different files
+	flag.StringVar(&splitBy, "splitby", "", "if given, all games (after filtering, sampling and sorting them) are split in different files, one for every different result of the given expression, e.g., 'White', 'ECO[:1]' or 'Date[:7]'. Files are named after --output with the result of the expression and extension '.pgn', e.g., 'output.Carlsen.pgn', and results which would be written in the same file are given a suffix '-1', '-2', ...")
+
+	// Flags to request generating training sheets
+	flag.StringVar(&training, "training", "", "if given, a \"guess-the-move\" training sheet is generated with the positions before every move of the given player, and the moves played in an appendix. It is written in a file with the name given in --output and an extension according to the format")
+	flag.StringVar(&trainingFormat, "trainingformat", "latex", "format of the training sheets: 'latex' or 'markdown'. It is used only in case --training is given. By default, 'latex'")
+	flag.IntVar(&trainingFrom, "trainingfrom", 1, "first move number used in the training sheets. It is used only in case --training is given. By default, 1")
+
+	// Flags to request generating a dossier of a player
+	flag.StringVar(&dossier, "dossier", "", "if given, a dossier to prepare games against the given player is shown with the results of the player with every color, in every opening and in the typical middlegame structures reached. For more information on dossiers see the documentation")
+	flag.StringVar(&dossierTemplate, "dossiertemplate", "", "file with a LaTeX template used to write the dossier along with the most recent games of the player in a file with the name given in --output and extension '.tex'. It is used only in case --dossier is given")
+	flag.StringVar(&structures, "structures", "", "JSON file with a list of named FEN patterns describing the middlegame structures searched in dossiers. By default, a number of typical pawn structures are searched. It is used only in case --dossier is given")
+	flag.IntVar(&recent, "recent", 10, "number of recent games of the player shown in dossiers. It is used only in case --dossier is given. By default, 10")
 
 	// Flag to store the output filename
 	flag.StringVar(&output, "output", "output.pgn", "name of the file where the result of any manipulations is stored. It is used only in case any of the directives --filter or --sort is given. By default, 'output.pgn'")
 
+	// Flag to write games in JSON format
+	flag.StringVar(&epd, "epd", "", "if given, all positions of all games (after filtering, sampling and sorting them) satisfying the given expression are written in EPD format, e.g., 'MoveNumber > 20' (use 'true' to write all of them), in a file with the name given in --output and extension '.epd'. Every position is identified with the id of its game and its ply, and it is given the move played in it")
+	flag.BoolVar(&jsonOutput, "json", false, "if given, all games (after filtering, sampling and sorting them) are written in JSON format with their tags, moves, comments and the FEN code of every position in a file with the name given in --output and extension '.json'")
+
+	// Flags to write games in an EPUB book
+	flag.StringVar(&epub, "epub", "", "if given, all games (after filtering, sampling and sorting them) are written in an EPUB book with the given title, with a chapter per game including an image of its final position, in a file with the name given in --output and extension '.epub'")
+	flag.StringVar(&epubTemplate, "epubtemplate", "", "file with an HTML template used to write every chapter of EPUB books. By default, a simple template is used. It is used only in case --epub is given. For more information on these templates see the documentation")
+
+	// Flags to write contact sheets
+	flag.IntVar(&contactSheet, "contactsheet", 0, "if strictly positive, a contact sheet is written for every game (after filtering, sampling and sorting them), i.e., a single SVG image with a grid of small boards showing the positions reached every given number of plies, along with the initial and final positions. Files are named after --output with the id of every game and extension '.svg'")
+	flag.IntVar(&contactColumns, "contactcolumns", 4, "number of columns of the grid of boards in contact sheets. By default, 4")
+
+	// Flag to name the files written for every game or chunk
+	flag.StringVar(&fileNames, "filenames", "", "pattern of the names of the files written for every game with --contactsheet, or for every chunk with --split, where fields are given between braces, e.g., '{White}-{Black}-{Date}'. Fields are the tags of every game (of the first game of every chunk), 'Id', 'Moves', 'Base' (the name given in --output) and 'Index' (the index of every file). Files are written in the directory of --output with their usual extension, and names already used are given a suffix '-1', '-2', ... By default, files are named after --output with the id of every game or the index of every chunk")
+
+	// Flags to write games in Lichess studies
+	flag.StringVar(&study, "study", "", "if given, all games (after filtering, sampling and sorting them) are written in a multi-chapter PGN that can be imported in a Lichess study with the given name, in a file with the name given in --output and extension '.study.pgn'. Collections with more than 64 chapters are split in various studies")
+	flag.StringVar(&studyChapter, "studychapters", "game", "chapters of Lichess studies: 'game' (every game in its own chapter) or 'opening' (all games with the same opening are merged in a single chapter with variations). By default, 'game'")
+	flag.IntVar(&studyDepth, "studydepth", 0, "number of plies of every game merged in the chapters of openings of Lichess studies. If zero, all plies are merged")
+
 	// Flag to store the template to use to generate the ascii table
 	flag.StringVar(&tableTemplate, "table", "", "file with an ASCII template that can be used to override the output shown by default. For more information on how to create and use these templates see the documentation")
 
 	// Flag to store the file with the LaTeX template
 	flag.StringVar(&latexTemplate, "latex", "", "file with a LaTeX template to use. If given, a file with the same name used in 'file' and extension '.tex' is automatically generated in the same directory where the pgn file resides. For more information on how to create and use LaTeX templates see the documentation")
 
+	// Flag to set the style of the diagrams drawn in LaTeX templates
+	flag.StringVar(&diagram, "diagram", "", "style of the diagrams drawn in LaTeX templates with GetLaTeXMovesWithCommentsTabular, given as a comma separated list of attributes: a size (tiny, small, normal or large), 'nomover' to hide the side to move, 'coordinates' to show files and ranks, and 'lastmove' to highlight the last move, e.g., 'large,lastmove'. By default, small diagrams showing the side to move are drawn")
+
+	// Flag to give values to meta-variables in templates
+	flag.Var(vars, "var", "value of a meta-variable used in the templates given as name=value. It can be given as many times as needed. Values given with --var take precedence over environment variables named after the meta-variable with prefix PGNPARSER_, e.g., PGNPARSER_title, which take precedence over the default values")
+
+	// Flags to process the LaTeX template in parallel
+	flag.IntVar(&chunks, "chunks", 0, "if strictly positive, the collection of games is split in chunks with the given number of games each, and the LaTeX template is processed over all chunks in parallel. By default, 0")
+	flag.IntVar(&jobs, "jobs", runtime.NumCPU(), "number of simultaneous jobs used for playing, sorting and computing histograms, and also the number of chunks processed simultaneously in case --chunks is given. By default, the number of CPUs")
+	flag.BoolVar(&split, "split", false, "if given, every chunk is written in a different LaTeX file. It is used only in case --chunks is given")
+
 	// other optional parameters are verbose and version
 	flag.BoolVar(&verbose, "verbose", false, "provides verbose output")
 	flag.BoolVar(&version, "version", false, "shows version info and exists")
@@ -122,11 +441,186 @@ func verify() {
 	if len(filename) == 0 {
 		log.Fatalf(" Error: a PGN file must be given with --file")
 	}
+
+	// verify the folding of comments is known
+	if _, ok := commentFoldings[comments]; !ok {
+		log.Fatalf(" Error: unknown folding of comments '%v'", comments)
+	}
+
+	// and also the mode used to verify markers of check and checkmate
+	if _, ok := mergeConflictPolicies[mergeConflicts]; !ok {
+		log.Fatalf(" Error: unknown policy to resolve duplicated games '%v'", mergeConflicts)
+	}
+	if fileNames != "" {
+		if _, err := pgntools.NewPgnFileNamer(output, fileNames); err != nil {
+			log.Fatalf(" Error: wrong pattern of file names '%v':%v", fileNames, err)
+		}
+	}
+	if _, ok := checkMarkerModes[checkMarkers]; checkMarkers != "" && !ok {
+		log.Fatalf(" Error: unknown mode to verify markers of check and checkmate '%v'", checkMarkers)
+	}
+
+	// and also the format of the training sheets
+	if _, ok := trainingFormats[trainingFormat]; !ok {
+		log.Fatalf(" Error: unknown format of training sheets '%v'", trainingFormat)
+	}
+
+	// and also the chapters of Lichess studies
+	if _, ok := studyChapters[studyChapter]; !ok {
+		log.Fatalf(" Error: unknown chapters of Lichess studies '%v'", studyChapter)
+	}
+
+	// and also the mode of heatmaps
+	if _, ok := heatmapModes[heatmapMode]; !ok {
+		log.Fatalf(" Error: unknown mode of heatmaps '%v'", heatmapMode)
+	}
+
+	// the range of games to read can be given either with --range or with
+	// --first and --skip, but not both
+	if first < 0 || skip < 0 {
+		log.Fatalf(" Error: neither --first nor --skip can be negative")
+	}
+	if gameRange != "" {
+		if first > 0 || skip > 0 {
+			log.Fatalf(" Error: --range can not be given along with --first or --skip")
+		}
+		lower, upper, ok := strings.Cut(gameRange, "-")
+		var err error
+		if firstId, err = strconv.Atoi(lower); ok && err == nil && upper != "" {
+			lastId, err = strconv.Atoi(upper)
+		}
+		if !ok || err != nil || firstId <= 0 || (upper != "" && lastId < firstId) {
+			log.Fatalf(" Error: invalid range of games '%v'", gameRange)
+		}
+	} else if first > 0 || skip > 0 {
+		firstId = skip + 1
+		if first > 0 {
+			lastId = skip + first
+		}
+	}
+
+	// and the index can not be built with only a slice of the games
+	if firstId > 0 && index {
+		log.Fatalf(" Error: --index can not be given along with --first, --skip or --range")
+	}
+	if firstId > 0 && database {
+		log.Fatalf(" Error: --database can not be given along with --first, --skip or --range")
+	}
+	if (firstId > 0 || player != "") && positionIndex {
+		log.Fatalf(" Error: --positionindex can not be given along with --first, --skip, --range or --player")
+	}
+
+	// verify the maximum memory used for sorting games
+	var err error
+	if memoryLimit, err = pgntools.ParseMemorySize(memory); err != nil {
+		log.Fatalf(" Error:%v", err)
+	}
+}
+
+// Return the games in the database of the given PgnFile if it is up to date.
+// Otherwise, all games are read from the PgnFile and played, and they are
+// stored in its database
+func readDatabase(pgnfile *pgntools.PgnFile, opts ...pgntools.PgnOption) (*pgntools.PgnCollection, error) {
+
+	if games, err := pgnfile.LoadDatabase(pgnfile.DatabaseName()); err == nil {
+		return games, nil
+	}
+	games, err := pgnfile.Games(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := games.Play(0, io.Discard, pgntools.WithWorkers(jobs)); err != nil {
+		return nil, err
+	}
+	if err := pgnfile.SaveDatabase(*games, pgnfile.DatabaseName()); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// Return the games in the given PgnFile. If the games of a player were
+// requested and an up-to-date index exists, only those are read from the file.
+// Otherwise, all games are read, the index is saved if requested and, finally,
+// the games of the player are selected if requested. In all cases, only the
+// games in the range requested, if any, are read
+func readGames(pgnfile *pgntools.PgnFile) (*pgntools.PgnCollection, error) {
+
+	// only a slice of the games is read if requested
+	var opts []pgntools.PgnOption
+	if firstId > 0 {
+		opts = append(opts, pgntools.WithRange(firstId, lastId))
+	}
+
+	// and it is mapped in memory if requested
+	if mmap {
+		opts = append(opts, pgntools.WithMmap())
+	}
+
+	// use the index in case it is possible
+	if player != "" && !index {
+		if pgnindex, err := pgntools.LoadPgnPlayerIndex(pgnfile.IndexName()); err == nil && pgnindex.IsFresh(*pgnfile) {
+			return pgnfile.PlayerGames(*pgnindex, player, opts...)
+		}
+	}
+
+	// otherwise, read all games, from the database if requested
+	var games *pgntools.PgnCollection
+	var err error
+	if database {
+		games, err = readDatabase(pgnfile, opts...)
+	} else {
+		games, err = pgnfile.Games(opts...)
+	}
+	if err != nil || (player == "" && !index) {
+		return games, err
+	}
+
+	// and build the index
+	pgnindex := pgnfile.PlayerIndex(*games)
+	if index {
+		if err := pgnindex.Save(pgnfile.IndexName()); err != nil {
+			return nil, err
+		}
+	}
+	if player == "" {
+		return games, nil
+	}
+
+	// and select only the games of the given player
+	selected := make(map[int]bool)
+	for _, entry := range pgnindex.Players[player] {
+		selected[entry.Id] = true
+	}
+	playerGames := pgntools.NewPgnCollection()
+	for _, igame := range games.GetGames() {
+		if selected[igame.Id()] {
+			playerGames.Add(igame)
+		}
+	}
+	return &playerGames, nil
 }
 
 // Main body
 func main() {
 
+	// subcommands are given as the first argument and parse their own flags
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		statsCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serveCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "service" {
+		serviceCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lichess" {
+		lichessCommand(os.Args[2:])
+		return
+	}
+
 	// verify the values parsed
 	verify()
 
@@ -139,23 +633,229 @@ func main() {
 		log.Fatalf(" Error: %v\n", err)
 	}
 
+	// Configure how games with errors are handled
+	pgnfile.SetLenient(lenient)
+	pgnfile.SetMaxGameSize(maxGameSize)
+	if quarantine != "" {
+		quarantineStream, err := os.Create(quarantine)
+		if err != nil {
+			log.Fatalf(" Error: %v\n", err)
+		}
+		defer quarantineStream.Close()
+		pgnfile.SetQuarantine(quarantineStream)
+	}
+
 	// Show information of the PgnFile provided by the user
 	fmt.Println()
 	fmt.Println(pgnfile)
 	fmt.Printf(" [%v]\n", time.Since(start))
 	fmt.Println()
 
-	// Obtain all games in this file as a collection of PgnGames
+	// Sort games on disk
+	// ------------------------------------------------------------------------
+	// In case the pgn file is larger than the memory available for sorting its
+	// games, they are sorted with temporary files and written directly into
+	// the output file
+	if sort != "" && player == "" && memoryLimit > 0 && pgnfile.Size() > memoryLimit {
+		start = time.Now()
+		opts := []pgntools.PgnOption{pgntools.WithMemoryLimit(memoryLimit)}
+		if firstId > 0 {
+			opts = append(opts, pgntools.WithRange(firstId, lastId))
+		}
+		if mmap {
+			opts = append(opts, pgntools.WithMmap())
+		}
+		stream, err := os.Create(output)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer stream.Close()
+		if sorted, err := pgnfile.SortTo(stream, sort, opts...); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v games sorted on disk\n", sorted)
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+		return
+	}
+
+	// Obtain all games in this file as a collection of PgnGames. If only the
+	// games of a player are requested and an up-to-date index exists, only
+	// those are read
 	start = time.Now()
-	games, err := pgnfile.Games()
+	games, err := readGames(pgnfile)
 	if err != nil {
 		log.Fatalln(err)
 	} else {
 		fmt.Printf(" %v games found\n", games.Len())
+
+		// show the statistics of parsing the file if requested
+		if verbose {
+			fmt.Println(games.ParseStats())
+		}
+
+		// and report any text that could not be parsed
+		if diagnostics := games.Diagnostics(); len(diagnostics) > 0 {
+			fmt.Printf(" %v fragments could not be parsed\n", len(diagnostics))
+			if verbose {
+				for _, idiagnostic := range diagnostics {
+					fmt.Println(idiagnostic)
+				}
+			}
+		}
 	}
 	fmt.Printf(" [%v]\n", time.Since(start))
 	fmt.Println()
 
+	// Merge
+	// ------------------------------------------------------------------------
+	// Games of other files are added (and duplicates are merged) right after
+	// reading them, so that all games are processed in the same way
+	if merge != "" || dedup {
+		start = time.Now()
+		other := pgntools.NewPgnCollection()
+		if merge != "" {
+			mergefile, err := pgntools.NewPgnFile(merge)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			read, err := mergefile.Games()
+			if err != nil {
+				log.Fatalln(err)
+			}
+			other = *read
+		}
+		merged, report := games.Merge(other, pgntools.PgnMergePolicy{
+			Deduplicate: dedup,
+			Conflicts:   mergeConflictPolicies[mergeConflicts],
+		})
+		games = merged
+		fmt.Println(report)
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// In case changes have to be shown, remember the games as they were found
+	var original pgntools.PgnCollection
+	if diff {
+		original = games.Clone()
+	}
+
+	// Edit tags
+	// ------------------------------------------------------------------------
+	// Tags are edited before any other processing so that filters, sorting
+	// criteria and templates use the edited ones
+	if editTags != "" {
+		start = time.Now()
+		rules, err := pgntools.LoadTagRules(editTags)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if edited, err := games.EditTags(rules, pgntools.WithWorkers(jobs)); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v games edited\n", edited)
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Enrich tags
+	// ------------------------------------------------------------------------
+	// Tags derived from the moves of games are computed right after editing
+	// them, so that they can be used in filters and sorting criteria as well
+	if enrichTags != "" {
+		start = time.Now()
+		var fields []string
+		if enrichTags != "all" {
+			fields = strings.Split(enrichTags, ",")
+		}
+		if enriched, err := games.EnrichTags(fields...); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v games enriched\n", enriched)
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// All templates are given the context where they are rendered
+	renderContext := pgntools.WithRenderContext(pgntools.PgnRenderContext{
+		Source:    filename,
+		Generated: time.Now(),
+		Filter:    filter,
+		Sort:      sort,
+		Version:   VERSION,
+	})
+
+	// and also the language of the comments to write
+	languageOption := pgntools.WithLanguage(language)
+
+	// Link games
+	// ------------------------------------------------------------------------
+	// Related games are linked before generating any output so that templates
+	// can show them together
+	if crosslink {
+		start = time.Now()
+		if nblinks, err := games.Crosslink(); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v links found\n", nblinks)
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Transpositions
+	// ------------------------------------------------------------------------
+	// Transpositions are found before writing games so that they can be
+	// annotated in the output file
+	if transpositions {
+		start = time.Now()
+		if nbtranspositions, err := games.FindTranspositions(); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v transpositions found\n", nbtranspositions)
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Engine agreement
+	// ------------------------------------------------------------------------
+	// Moves are compared with the engine before filtering games so that the
+	// agreement of every game can be used in filters
+	if engine != "" {
+		start = time.Now()
+		uci, err := pgntools.NewUCIEngine(engine, engineDepth)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		// positions analyzed before are taken from the cache, if any
+		var searcher pgntools.EngineSearcher = uci
+		var cache *pgntools.CachedEngine
+		if engineCache != "" {
+			if cache, err = pgntools.NewCachedEngine(uci, engineCache); err != nil {
+				log.Fatalln(err)
+			}
+			searcher = cache
+		}
+		if agreements, err := games.EngineAgreement(searcher, pgntools.WithWorkers(jobs)); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Println(agreements)
+		}
+		if cache != nil {
+			fmt.Printf(" %v positions taken from '%v'\n", cache.Hits(), engineCache)
+			cache.Close()
+		}
+		uci.Close()
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
 	// List games
 	// ------------------------------------------------------------------------
 	// show a table with information of the games been processed. For this,
@@ -168,34 +868,182 @@ func main() {
 		if len(tableTemplate) == 0 {
 			tableTemplate = TABLE_TEMPLATE
 		}
-		games.GamesToWriterFromTemplate(os.Stdout, tableTemplate)
+		games.GamesToWriterFromTemplate(os.Stdout, tableTemplate, pgntools.WithTemplateVars(vars), renderContext)
+	}
+
+	// in case a compact list of games was requested, show it as well
+	if gamesList {
+		if err := games.GetGamesList(os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println()
+	}
+
+	// Filter games
+	// ------------------------------------------------------------------------
+	// In case it has been requested to filter games, do so. Filtering is
+	// done before playing games so that only those selected are played
+	if filter != "" {
+		start = time.Now()
+		if filtered, err := games.Filter(filter, pgntools.WithWorkers(jobs)); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v games filtered\n", filtered.Len())
+
+			// and make the filtered collection the current one
+			games = filtered
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Sample and shuffle games
+	// ------------------------------------------------------------------------
+	if sample > 0 {
+		if sampled, err := games.Sample(sample, seed); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v games sampled\n", sampled.Len())
+			games = sampled
+		}
+		fmt.Println()
+	}
+	if shuffle {
+		games = games.Shuffle(seed)
+	}
+
+	// Compare games
+	// ------------------------------------------------------------------------
+	if compare != "" {
+
+		// get the games with the given ids
+		var ids [2]int
+		if _, err := fmt.Sscanf(compare, "%d,%d", &ids[0], &ids[1]); err != nil {
+			log.Fatalf(" Error: invalid ids '%v' given to --compare\n", compare)
+		}
+		var compared [2]*pgntools.PgnGame
+		for _, igame := range games.GetGames() {
+			for idx, id := range ids {
+				if igame.Id() == id {
+					compared[idx] = &igame
+				}
+			}
+		}
+		for idx, igame := range compared {
+			if igame == nil {
+				log.Fatalf(" Error: no game found with id %v\n", ids[idx])
+			}
+		}
+
+		// and show them side by side
+		fmt.Println(compared[0].GetComparison(*compared[1]))
+	}
+
+	// Validate games
+	// ------------------------------------------------------------------------
+	// All ambiguous moves and the first illegal move of every game are shown.
+	// As games with illegal moves can not be played, processing stops if any
+	// is found
+	if validate {
+		start = time.Now()
+		errs, err := games.Validate()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		illegal := 0
+		for _, ierr := range errs {
+			fmt.Printf("%v\n\t%v\n", strings.TrimRight(ierr.Error(), "\n"), ierr.FEN)
+			if !errors.Is(ierr, pgntools.ErrAmbiguousMove) {
+				illegal++
+			}
+		}
+		fmt.Printf(" %v illegal and %v ambiguous moves found\n", illegal, len(errs)-illegal)
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+		if illegal > 0 {
+			os.Exit(EXIT_FAILURE)
+		}
 	}
 
 	// Play/verify games
 	// ------------------------------------------------------------------------
 	// Play all games unconditionally. This is necessary to verify that the
 	// transcription of all games is correct. In case a strictly positive value
-	// is given then the board is shown on the standard output
+	// is given then the board is shown on the standard output. If games were
+	// filtered, only those selected are played, and thus verified
 	start = time.Now()
-	if err := games.Play(play, os.Stdout); err != nil {
+	playOpts := []pgntools.PgnOption{
+		pgntools.WithWorkers(jobs),
+		pgntools.WithBoardStyle(pgntools.BoardStyle{ASCII: ascii}),
+	}
+	if blunders {
+		playOpts = append(playOpts, pgntools.WithBlunders())
+	}
+	if err := games.Play(play, os.Stdout, playOpts...); err != nil {
 		log.Fatalln(err)
 	}
-	fmt.Printf(" Games verified!\n")
+	if filter != "" {
+		fmt.Printf(" %v games verified (only those selected with --filter)\n", games.Len())
+	} else {
+		fmt.Printf(" %v games verified!\n", games.Len())
+	}
 	fmt.Printf(" [%v]\n", time.Since(start))
 	fmt.Println()
 
-	// Filter games
+	// Verify markers of check and checkmate
 	// ------------------------------------------------------------------------
-	// In case it has been requested to filter games, do so
-	if filter != "" {
+	// All mismatches are shown and, if requested, games are corrected so that
+	// they are written in the output file
+	if checkMarkers != "" {
 		start = time.Now()
-		if filtered, err := games.Filter(filter); err != nil {
+		if mismatches, err := games.VerifyCheckMarkers(checkMarkerModes[checkMarkers], pgntools.WithWorkers(jobs)); err != nil {
 			log.Fatalln(err)
 		} else {
-			fmt.Printf(" %v games filtered\n", filtered.Len())
+			for _, mismatch := range mismatches {
+				fmt.Println(mismatch)
+			}
+			fmt.Printf(" %v wrong markers of check and checkmate found\n", len(mismatches))
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
 
-			// and make the filtered collection the current one
-			games = filtered
+	// Audit tags
+	// ------------------------------------------------------------------------
+	// All anomalies are shown along with the number of anomalies found with
+	// every severity
+	if audit {
+		start = time.Now()
+		anomalies := games.Audit()
+		severities := make(map[pgntools.PgnSeverity]int)
+		for _, anomaly := range anomalies {
+			fmt.Println(anomaly)
+			severities[anomaly.Severity]++
+		}
+		fmt.Printf(" %v anomalies found (%v errors, %v warnings, %v infos)\n", len(anomalies),
+			severities[pgntools.ErrorSeverity], severities[pgntools.WarningSeverity], severities[pgntools.InfoSeverity])
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Show changes
+	// ------------------------------------------------------------------------
+	// Differences are shown with the same folding of comments used in the
+	// output file and before renumbering games, so that they can be matched
+	// with the original ones
+	if diff {
+		start = time.Now()
+		opts := []pgntools.PgnOption{
+			pgntools.WithCommentFolding(commentFoldings[comments]),
+			pgntools.WithCommentWidth(commentWidth),
+		}
+		if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			opts = append(opts, pgntools.WithColor())
+		}
+		if changed, err := games.GetDiff(original, os.Stdout, opts...); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v games changed\n", changed)
 		}
 		fmt.Printf(" [%v]\n", time.Since(start))
 		fmt.Println()
@@ -205,7 +1053,7 @@ func main() {
 	// ------------------------------------------------------------------------
 	if sort != "" {
 		start = time.Now()
-		if sorted, err := games.Sort(sort); err != nil {
+		if sorted, err := games.Sort(sort, pgntools.WithWorkers(jobs)); err != nil {
 			log.Fatalln(err)
 		} else {
 			fmt.Printf(" %v games sorted\n", sorted.Len())
@@ -217,9 +1065,16 @@ func main() {
 		fmt.Println()
 	}
 
-	// In case either sorting and/or filter has been requested, write the result
+	// In case it was requested, give consecutive ids to the resulting games
+	if renumber {
+		games.Renumber()
+	}
+
+	// In case either sorting and/or filter has been requested, games were
+	// sampled, shuffled or merged, tags were edited or enriched, markers of check and
+	// checkmate were corrected or transpositions were found, write the result
 	// in the output file
-	if sort != "" || filter != "" {
+	if sort != "" || filter != "" || sample > 0 || shuffle || merge != "" || dedup || editTags != "" || enrichTags != "" || checkMarkers == "strip" || checkMarkers == "fix" || transpositions || language != "" || blunders {
 
 		// Check first whether there are some games to write
 		if games.Len() == 0 {
@@ -234,16 +1089,123 @@ func main() {
 			if err != nil {
 				log.Fatalln(err)
 			} else {
-				games.GetPGN(stream)
+				opts := []pgntools.PgnOption{
+					pgntools.WithCommentFolding(commentFoldings[comments]),
+					pgntools.WithCommentWidth(commentWidth),
+					pgntools.WithLineWidth(lineWidth),
+					languageOption,
+				}
+				if commentLines {
+					opts = append(opts, pgntools.WithCommentLines())
+				}
+				if noCommands != "" {
+					opts = append(opts, pgntools.WithoutCommands(strings.Split(noCommands, ",")...))
+				}
+				if transpositions {
+					opts = append(opts, pgntools.WithTranspositions())
+				}
+				if blunders {
+					opts = append(opts, pgntools.WithBlunders())
+				}
+				games.GetPGN(stream, opts...)
+			}
+		}
+	}
+
+	// JSON
+	// ------------------------------------------------------------------------
+	// Games have been already played so that the FEN codes of all positions are
+	// written as well
+	if jsonOutput {
+		start = time.Now()
+		if jsonStream, err := os.Create(output + ".json"); err != nil {
+			log.Fatalln(err)
+		} else {
+			defer jsonStream.Close()
+			if err := games.GetJSON(jsonStream); err != nil {
+				log.Fatalln(err)
 			}
+			fmt.Printf(" %v games written in '%v'\n", games.Len(), output+".json")
 		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// EPD
+	// ------------------------------------------------------------------------
+	if epd != "" {
+		start = time.Now()
+		records, err := games.ExtractEPD(epd, pgntools.WithWorkers(jobs))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if epdStream, err := os.Create(output + ".epd"); err != nil {
+			log.Fatalln(err)
+		} else {
+			defer epdStream.Close()
+			if err := pgntools.WriteEPD(epdStream, records); err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Printf(" %v positions written in '%v'\n", len(records), output+".epd")
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// EPUB
+	// ------------------------------------------------------------------------
+	if epub != "" {
+		start = time.Now()
+		if epubStream, err := os.Create(output + ".epub"); err != nil {
+			log.Fatalln(err)
+		} else {
+			defer epubStream.Close()
+			if err := games.GetEPUB(epubStream, epub, epubTemplate, languageOption); err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Printf(" %v games written in '%v'\n", games.Len(), output+".epub")
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Contact sheets
+	// ------------------------------------------------------------------------
+	if contactSheet > 0 {
+		start = time.Now()
+		if filenames, err := games.GetContactSheets(output+".svg", contactSheet, contactColumns, pgntools.WithFileNames(fileNames)); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Printf(" %v contact sheets written\n", len(filenames))
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Lichess studies
+	// ------------------------------------------------------------------------
+	if study != "" {
+		start = time.Now()
+		if studyStream, err := os.Create(output + ".study.pgn"); err != nil {
+			log.Fatalln(err)
+		} else {
+			defer studyStream.Close()
+			if err := games.GetStudy(studyStream, study, studyChapters[studyChapter], studyDepth,
+				pgntools.WithCommentFolding(commentFoldings[comments]),
+				pgntools.WithCommentWidth(commentWidth)); err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Printf(" %v games written in '%v'\n", games.Len(), output+".study.pgn")
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
 	}
 
 	// Histogram
 	// ------------------------------------------------------------------------
 	if histogram != "" {
 		start = time.Now()
-		if pgnhistogram, err := games.GetHistogram(histogram); err != nil {
+		if pgnhistogram, err := games.GetHistogram(histogram, pgntools.WithWorkers(jobs), pgntools.WithPerspective(perspective)); err != nil {
 			log.Fatalln(err)
 		} else {
 			fmt.Println(*pgnhistogram)
@@ -252,6 +1214,261 @@ func main() {
 		fmt.Println()
 	}
 
+	// Standings
+	// ------------------------------------------------------------------------
+	if standings != "" {
+		start = time.Now()
+		pgnscoring, err := pgntools.NewPgnScoring(scoring)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if pgnstandings, err := games.Standings(standings, pgnscoring); err != nil {
+			log.Fatalln(err)
+		} else {
+			fmt.Println(*pgnstandings)
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Heatmap
+	// ------------------------------------------------------------------------
+	if heatmap != "" {
+		start = time.Now()
+		pgnheatmap, err := games.GetHeatmap(heatmap, heatmapModes[heatmapMode], pgntools.WithWorkers(jobs))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println(*pgnheatmap)
+		if err := os.WriteFile(output+".heatmap.tex", []byte(pgnheatmap.GetLaTeX("0.8cm")), 0644); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf(" Heatmap written in '%v'\n", output+".heatmap.tex")
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Decisions
+	// ------------------------------------------------------------------------
+	if decisions > 0 {
+		start = time.Now()
+		pgndecisions, err := games.Decisions(decisions, pgntools.WithWorkers(jobs))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println(*pgndecisions)
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Theory depth
+	// ------------------------------------------------------------------------
+	if theory != "" {
+		start = time.Now()
+		theoryFile, err := pgntools.NewPgnFile(theory)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		reference, err := theoryFile.Games()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		depths, err := games.TheoryDepth(*reference, theoryPositions, pgntools.WithWorkers(jobs))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf(" Theory depth (in plies) with respect to %v games in '%v'\n", reference.Len(), theory)
+		fmt.Println(*depths)
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Book depth
+	// ------------------------------------------------------------------------
+	if openingBook != "" {
+		start = time.Now()
+		polyglot, err := book.Open(openingBook)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		depths, err := games.BookDepth(polyglot, pgntools.WithWorkers(jobs))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf(" Book depth (in plies) with respect to %v entries in '%v'\n", polyglot.Len(), openingBook)
+		fmt.Println(*depths)
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Position search
+	// ------------------------------------------------------------------------
+	// Positions are searched with an index of all positions, which is read
+	// from its file if requested and it is up to date, and it is saved
+	// otherwise
+	if position != "" {
+		start = time.Now()
+		pgnindex, err := pgntools.LoadPgnPositionIndex(pgnfile.PositionIndexName())
+		if !positionIndex || err != nil || !pgnindex.IsFresh(*pgnfile) {
+			if pgnindex, err = pgnfile.PositionIndex(*games, pgntools.WithWorkers(jobs)); err != nil {
+				log.Fatalln(err)
+			}
+			if positionIndex {
+				if err := pgnindex.Save(pgnfile.PositionIndexName()); err != nil {
+					log.Fatalln(err)
+				}
+			}
+		}
+		entries, err := pgnindex.SearchPosition(position)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, entry := range entries {
+			fmt.Printf(" %v\n", entry)
+		}
+		fmt.Printf(" %v occurrences found\n", len(entries))
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Explain FEN patterns
+	// ------------------------------------------------------------------------
+	// Games not matching a pattern are shown with the reasons why their
+	// closest position does not match it
+	if explainFEN != "" {
+		start = time.Now()
+		matches := 0
+		for _, igame := range games.GetGames() {
+			ply, mismatches, err := igame.ExplainFEN(explainFEN)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if len(mismatches) == 0 {
+				fmt.Printf(" game #%v: matched at ply %v\n", igame.Id(), ply)
+				matches++
+				continue
+			}
+			fmt.Printf(" game #%v: not matched, closest position at ply %v\n", igame.Id(), ply)
+			for _, mismatch := range mismatches {
+				fmt.Printf("\t%v\n", mismatch)
+			}
+		}
+		fmt.Printf(" %v games matched '%v'\n", matches, explainFEN)
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Anthologies
+	// ------------------------------------------------------------------------
+	// The games reaching every position given in a file are written in a
+	// different file
+	if fens != "" {
+		start = time.Now()
+		patterns, err := pgntools.LoadFENPatterns(fens)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		anthologies, err := games.ExtractByFEN(patterns, fensTruncate, pgntools.WithWorkers(jobs))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for idx, ianthology := range anthologies {
+			name := fmt.Sprintf("%v.fen%v.pgn", output, idx+1)
+			stream, err := os.Create(name)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if err := ianthology.GetPGN(stream); err != nil {
+				log.Fatalln(err)
+			}
+			stream.Close()
+			fmt.Printf(" %v games reaching '%v' written in '%v'\n", ianthology.Len(), patterns[idx], name)
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Split
+	// ------------------------------------------------------------------------
+	// Games with the same result of an expression are written in the same
+	// file, all in one pass
+	if splitBy != "" {
+		start = time.Now()
+		collections, err := games.SplitBy(splitBy, pgntools.WithWorkers(jobs))
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		// files are written in the order of their keys
+		keys := make([]string, 0, len(collections))
+		for key := range collections {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		names := pgntools.UniqueFileNames(keys)
+		for idx, key := range keys {
+			name := fmt.Sprintf("%v.%v.pgn", output, names[idx])
+			stream, err := os.Create(name)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if err := collections[key].GetPGN(stream); err != nil {
+				log.Fatalln(err)
+			}
+			stream.Close()
+			fmt.Printf(" %v games with '%v' written in '%v'\n", collections[key].Len(), key, name)
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Training
+	// ------------------------------------------------------------------------
+	if training != "" {
+		start = time.Now()
+		if trainingStream, err := os.Create(output + trainingExtensions[trainingFormat]); err != nil {
+			log.Fatalln(err)
+		} else {
+			defer trainingStream.Close()
+			if err := games.GetTraining(trainingStream, training, trainingFrom, trainingFormats[trainingFormat]); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
+	// Dossier
+	// ------------------------------------------------------------------------
+	if dossier != "" {
+		start = time.Now()
+		var pgnstructures []pgntools.PgnStructure
+		if structures != "" {
+			if pgnstructures, err = pgntools.LoadStructures(structures); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		pgndossier, err := games.Dossier(dossier, pgnstructures, recent, pgntools.WithWorkers(jobs))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println(*pgndossier)
+
+		// and write it with the given template, if any
+		if dossierTemplate != "" {
+			if dossierStream, err := os.Create(output + ".tex"); err != nil {
+				log.Fatalln(err)
+			} else {
+				defer dossierStream.Close()
+				if err := pgndossier.ToWriterFromTemplate(dossierStream, dossierTemplate, pgntools.WithTemplateVars(vars), renderContext); err != nil {
+					log.Fatalln(err)
+				}
+			}
+		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
+	}
+
 	// LaTeX
 	// ------------------------------------------------------------------------
 
@@ -260,12 +1477,54 @@ func main() {
 	// extension '.tex' from the contents given in the specified template
 	if latexTemplate != "" {
 
-		// Create a LaTeX file to write the output
-		if latexStream, err := os.Create(output + ".tex"); err != nil {
+		start = time.Now()
+
+		// diagrams are drawn with the style given, and decisive mistakes are
+		// marked if requested
+		diagramStyle, err := pgntools.ParseDiagramStyle(diagram)
+		if err != nil {
 			log.Fatalln(err)
+		}
+		latexOpts := []pgntools.PgnOption{
+			pgntools.WithTemplateVars(vars),
+			renderContext,
+			languageOption,
+			pgntools.WithDiagramStyle(diagramStyle),
+			pgntools.WithFileNames(fileNames),
+		}
+		if blunders {
+			latexOpts = append(latexOpts, pgntools.WithBlunders())
+		}
+
+		if chunks > 0 && split {
+
+			// In case chunks have to be written in different files, then do
+			// so
+			if filenames, err := games.GamesToFilesFromTemplate(output+".tex", latexTemplate, chunks, jobs, latexOpts...); err != nil {
+				log.Fatalln(err)
+			} else {
+				fmt.Printf(" %v LaTeX files generated\n", len(filenames))
+			}
 		} else {
-			games.GamesToWriterFromTemplate(latexStream, latexTemplate)
+
+			// Create a LaTeX file to write the output
+			if latexStream, err := os.Create(output + ".tex"); err != nil {
+				log.Fatalln(err)
+			} else {
+				defer latexStream.Close()
+
+				// and write it either in parallel or sequentially
+				if chunks > 0 {
+					if err := games.GamesToWriterFromTemplateParallel(latexStream, latexTemplate, chunks, jobs, latexOpts...); err != nil {
+						log.Fatalln(err)
+					}
+				} else {
+					games.GamesToWriterFromTemplate(latexStream, latexTemplate, latexOpts...)
+				}
+			}
 		}
+		fmt.Printf(" [%v]\n", time.Since(start))
+		fmt.Println()
 
 	}
 }
@@ -0,0 +1,211 @@
+// -*- coding: utf-8 -*-
+// pgnservice.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:21:55.272775486 (1792164115)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+// Package pgnservice exposes the parsing, filtering and annotation of PGN
+// games as a REST API so that pgnparser can be deployed as a service feeding
+// other tools. All endpoints accept the PGN text of the games in the body of
+// POST requests and stream back the games as soon as they are parsed, one JSON
+// object per line
+package pgnservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/clinaresl/pgnparser/pgntools"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A Service is an http.Handler serving the following endpoints:
+//
+//	POST /parse     games parsed, and realized up to the number of plies
+//	                given in the query parameter "realize", if any
+//	POST /filter    games satisfying the expression given in the query
+//	                parameter "expression"
+//	POST /annotate  games along with the mismatches of their check markers,
+//	                which are corrected as requested in the query parameter
+//	                "checks" (either "warn", "strip" or "fix"), and the
+//	                anomalies found in their tags
+//
+// In case of error before writing any game, the status code of the response
+// describes it. Otherwise, the stream of games ends with an object whose only
+// field "error" describes it
+type Service struct {
+	mux         *http.ServeMux       // routes of the service
+	options     []pgntools.PgnOption // options used to read games
+	maxBodySize int64                // maximum size of the body of requests
+}
+
+// Annotated games are written along with the mismatches of their check markers
+// and the anomalies of their tags
+type annotatedGame struct {
+	Game       *pgntools.PgnGame           `json:"game"`
+	Mismatches []pgntools.PgnCheckMismatch `json:"mismatches"`
+	Anomalies  []pgntools.PgnAnomaly       `json:"anomalies"`
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Games are streamed as newline delimited JSON
+const contentType = "application/x-ndjson"
+
+// By default, the body of requests can not exceed the following number of bytes
+const DefaultMaxBodySize = 64 << 20
+
+// globals
+// ----------------------------------------------------------------------------
+
+// Modes of the check markers accepted by the annotate endpoint
+var checkMarkers = map[string]pgntools.CheckMarkers{
+	"":      pgntools.WarnCheckMarkers,
+	"warn":  pgntools.WarnCheckMarkers,
+	"strip": pgntools.StripCheckMarkers,
+	"fix":   pgntools.FixCheckMarkers,
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a new service which reads games with the given options, e.g.,
+// WithLenient or WithMaxGameSize. The body of requests can not exceed
+// DefaultMaxBodySize bytes
+func NewService(opts ...pgntools.PgnOption) *Service {
+
+	service := &Service{mux: http.NewServeMux(), options: opts, maxBodySize: DefaultMaxBodySize}
+	service.mux.HandleFunc("POST /parse", service.parse)
+	service.mux.HandleFunc("POST /filter", service.filter)
+	service.mux.HandleFunc("POST /annotate", service.annotate)
+	return service
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Services are http handlers
+func (service *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service.mux.ServeHTTP(w, r)
+}
+
+// Set the maximum size in bytes of the body of requests. Reading stops with an
+// error once it is exceeded. Zero or negative values mean that there is no
+// limit
+func (service *Service) SetMaxBodySize(size int64) {
+	service.maxBodySize = size
+}
+
+// Write the games read from the body of the given request in the given
+// response writer as soon as they are parsed. Every game is transformed with
+// the given function before being written, and it is skipped if the function
+// returns nil. The given options are used for reading games along with those
+// of this service
+func (service *Service) stream(w http.ResponseWriter, r *http.Request, fn func(game *pgntools.PgnGame) (any, error), opts ...pgntools.PgnOption) {
+
+	// Games are written as soon as they are consumed, so that they are
+	// streamed while the input is being read and they are not kept in memory
+	// afterwards
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	written := false
+	consume := func(game *pgntools.PgnGame) error {
+		value, err := fn(game)
+		if err != nil || value == nil {
+			return err
+		}
+		if !written {
+			w.Header().Set("Content-Type", contentType)
+			written = true
+		}
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	body := r.Body
+	if service.maxBodySize > 0 {
+		body = http.MaxBytesReader(w, r.Body, service.maxBodySize)
+	}
+	options := append(slices.Clone(service.options), opts...)
+	if _, err := pgntools.ConsumeGamesFromReader(body, consume, options...); err != nil {
+
+		// errors are reported with the status code unless some games were
+		// already written
+		if !written {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		encoder.Encode(map[string]string{"error": err.Error()})
+	}
+}
+
+// Parse the games in the body of the given request
+func (service *Service) parse(w http.ResponseWriter, r *http.Request) {
+
+	// games are realized only if requested
+	realize := 0
+	if value := r.URL.Query().Get("realize"); value != "" {
+		plies, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(" Invalid number of plies '%v'", value), http.StatusBadRequest)
+			return
+		}
+		realize = plies
+	}
+	service.stream(w, r, func(game *pgntools.PgnGame) (any, error) {
+		return game, nil
+	}, pgntools.WithRealize(realize))
+}
+
+// Return only the games in the body of the given request which satisfy the
+// given expression. Games are realized so that expressions can refer to their
+// boards
+func (service *Service) filter(w http.ResponseWriter, r *http.Request) {
+
+	expression := r.URL.Query().Get("expression")
+	if expression == "" {
+		http.Error(w, " No expression was given", http.StatusBadRequest)
+		return
+	}
+	service.stream(w, r, func(game *pgntools.PgnGame) (any, error) {
+		if ok, err := game.Filter(expression); err != nil || !ok {
+			return nil, err
+		}
+		return game, nil
+	}, pgntools.WithRealize(-1))
+}
+
+// Annotate the games in the body of the given request with the mismatches of
+// their check markers and the anomalies of their tags
+func (service *Service) annotate(w http.ResponseWriter, r *http.Request) {
+
+	mode, ok := checkMarkers[r.URL.Query().Get("checks")]
+	if !ok {
+		http.Error(w, fmt.Sprintf(" Unknown mode of check markers '%v'", r.URL.Query().Get("checks")), http.StatusBadRequest)
+		return
+	}
+	service.stream(w, r, func(game *pgntools.PgnGame) (any, error) {
+		mismatches, err := game.VerifyCheckMarkers(mode)
+		if err != nil {
+			return nil, err
+		}
+		return annotatedGame{Game: game, Mismatches: mismatches, Anomalies: game.Audit()}, nil
+	})
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
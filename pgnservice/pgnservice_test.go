@@ -0,0 +1,137 @@
+// -*- coding: utf-8 -*-
+// pgnservice_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:22:14.657152704 (1792164134)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgnservice
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clinaresl/pgnparser/pgntools"
+)
+
+// Two games, where the second one misses a check marker and Black's rating is
+// suspicious
+const games = `[White "Alice"]
+[Black "Bob"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+
+[White "Carol"]
+[Black "Dave"]
+[BlackElo "0"]
+[Result "*"]
+
+1. e4 f5 2. Qh5 *
+`
+
+// A game with an illegal move
+const illegal = `[Result "*"]
+
+1. e4 e5 2. Ke3 *
+`
+
+// Return the status code and the lines of the response to the given request
+func request(t *testing.T, service *Service, target, body string) (int, []map[string]any) {
+
+	recorder := httptest.NewRecorder()
+	service.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+	var lines []map[string]any
+	scanner := bufio.NewScanner(recorder.Body)
+	for recorder.Code == http.StatusOK && scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("%v returned a wrong line '%v': %v", target, scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return recorder.Code, lines
+}
+
+func TestService_parse(t *testing.T) {
+
+	service := NewService()
+
+	// games are written one per line with their ids and, if requested, the
+	// FEN codes of every ply
+	code, lines := request(t, service, "/parse?realize=-1", games)
+	if code != http.StatusOK || len(lines) != 2 {
+		t.Fatalf("/parse = %v with %v games, want 200 with 2 games", code, len(lines))
+	}
+	for idx, line := range lines {
+		if line["id"] != float64(idx+1) {
+			t.Errorf("/parse returned the game %v with id %v", idx+1, line["id"])
+		}
+	}
+	if moves := lines[0]["moves"].([]any); moves[len(moves)-1].(map[string]any)["fen"] == nil {
+		t.Errorf("/parse?realize=-1 did not return the FEN codes of the moves")
+	}
+
+	// wrong requests are reported with their status code
+	for _, target := range []string{"/parse?realize=all", "/filter", "/annotate?checks=none"} {
+		if code, _ := request(t, service, target, games); code != http.StatusBadRequest {
+			t.Errorf("%v = %v, want %v", target, code, http.StatusBadRequest)
+		}
+	}
+	if code, _ := request(t, service, "/parse?realize=-1", illegal); code != http.StatusBadRequest {
+		t.Errorf("/parse = %v with an illegal game, want %v", code, http.StatusBadRequest)
+	}
+
+	// unless the service is lenient
+	if code, lines := request(t, NewService(pgntools.WithLenient()), "/parse?realize=-1", illegal); code != http.StatusOK || len(lines) != 0 {
+		t.Errorf("/parse = %v with %v games in lenient mode, want 200 with no games", code, len(lines))
+	}
+}
+
+func TestService_SetMaxBodySize(t *testing.T) {
+
+	// requests whose body exceeds the maximum size are rejected, while those
+	// below it are served as usual
+	service := NewService()
+	service.SetMaxBodySize(int64(len(games) / 2))
+	if code, _ := request(t, service, "/parse", games); code != http.StatusBadRequest {
+		t.Errorf("/parse = %v with a body too large, want %v", code, http.StatusBadRequest)
+	}
+	service.SetMaxBodySize(int64(len(games)))
+	if code, lines := request(t, service, "/parse", games); code != http.StatusOK || len(lines) != 2 {
+		t.Errorf("/parse = %v with %v games, want 200 with 2 games", code, len(lines))
+	}
+
+	// and zero means that there is no limit
+	service.SetMaxBodySize(0)
+	if code, lines := request(t, service, "/parse", strings.Repeat(games, 100)); code != http.StatusOK || len(lines) != 200 {
+		t.Errorf("/parse = %v with %v games, want 200 with 200 games", code, len(lines))
+	}
+}
+
+func TestService_annotate(t *testing.T) {
+
+	// the second game is reported with the missing check marker, which is
+	// fixed, and the anomaly in the rating of Black
+	code, lines := request(t, NewService(), "/annotate?checks=fix", games)
+	if code != http.StatusOK || len(lines) != 2 {
+		t.Fatalf("/annotate = %v with %v games, want 200 with 2 games", code, len(lines))
+	}
+	if lines[0]["mismatches"] != nil || lines[0]["anomalies"] != nil {
+		t.Errorf("/annotate found annotations in the first game: %v", lines[0])
+	}
+	mismatches, _ := lines[1]["mismatches"].([]any)
+	anomalies, _ := lines[1]["anomalies"].([]any)
+	if len(mismatches) != 1 || len(anomalies) != 1 || anomalies[0].(map[string]any)["Severity"] != "warning" {
+		t.Fatalf("/annotate = %v, want one mismatch and one warning", lines[1])
+	}
+	moves := lines[1]["game"].(map[string]any)["moves"].([]any)
+	if san := moves[2].(map[string]any)["san"]; san != "Qh5+" {
+		t.Errorf("/annotate?checks=fix returned '%v', want 'Qh5+'", san)
+	}
+}
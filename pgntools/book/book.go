@@ -0,0 +1,292 @@
+// -*- coding: utf-8 -*-
+// book.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:21:31.139480467 (1792171291)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+// Package book reads opening books in the Polyglot format. Books consist of
+// entries of 16 bytes sorted by the key of their position, each one with a move
+// playable in it, its weight and learning data. Keys are computed from the FEN
+// code of positions with the random numbers given in the specification of the
+// format, so that any Polyglot book can be used.
+package book
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// An entry of a book consists of a move in UCI notation, e.g., "e2e4" or
+// "e7e8q", where castling is given with the starting and ending squares of the
+// king, e.g., "e1g1". The weight is proportional to the frequency the move
+// should be played with, and the learning data is usually zero
+type Entry struct {
+	Move   string
+	Weight uint16
+	Learn  uint32
+}
+
+// A Polyglot book consists of all its entries in the same order they are given
+// in the file, i.e., sorted by their key. Books are never modified once they
+// are read, so that they can be used concurrently
+type Book struct {
+	entries []polyglotEntry
+}
+
+// Entries are stored as they are read from the file, and moves are decoded
+// only when they are looked up because castling can not be recognized without
+// the position
+type polyglotEntry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// A position consists of the contents of every square, indexed by 8*row+file
+// where the square a1 is at index 0, the side to move, the castling rights and
+// the file of the en passant target (or -1 if there is none)
+type position struct {
+	squares   [64]byte
+	white     bool
+	castling  string
+	enpassant int
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Size in bytes of every entry of a Polyglot book
+const entrySize = 16
+
+// Kinds of pieces in the order used to index the random numbers of Polyglot
+const pieceKinds = "pPnNbBrRqQkK"
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the name of the square with the given index, e.g., "e4"
+func squareName(square int) string {
+	return fmt.Sprintf("%c%d", 'a'+square%8, 1+square/8)
+}
+
+// Return the position described by the given FEN code. Only the first four
+// fields are used, and the halfmove clock and fullmove number can be missing.
+// An error is returned if the FEN code is not well formed
+func parseFEN(fen string) (pos position, err error) {
+
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return pos, fmt.Errorf(" Invalid FEN code '%v'", fen)
+	}
+
+	// piece placement, given from the eighth row to the first one
+	rows := strings.Split(fields[0], "/")
+	if len(rows) != 8 {
+		return pos, fmt.Errorf(" Invalid piece placement in the FEN code '%v'", fen)
+	}
+	for idx, irow := range rows {
+		row, file := 7-idx, 0
+		for _, ch := range []byte(irow) {
+			switch {
+			case ch >= '1' && ch <= '8':
+				file += int(ch - '0')
+			case strings.IndexByte(pieceKinds, ch) >= 0 && file < 8:
+				pos.squares[8*row+file] = ch
+				file++
+			default:
+				return pos, fmt.Errorf(" Invalid piece placement in the FEN code '%v'", fen)
+			}
+		}
+		if file != 8 {
+			return pos, fmt.Errorf(" Invalid piece placement in the FEN code '%v'", fen)
+		}
+	}
+
+	// side to move
+	switch fields[1] {
+	case "w":
+		pos.white = true
+	case "b":
+	default:
+		return pos, fmt.Errorf(" Invalid side to move in the FEN code '%v'", fen)
+	}
+
+	// castling rights and en passant target
+	pos.castling = fields[2]
+	pos.enpassant = -1
+	if fields[3] != "-" {
+		if len(fields[3]) != 2 || fields[3][0] < 'a' || fields[3][0] > 'h' {
+			return pos, fmt.Errorf(" Invalid en passant target in the FEN code '%v'", fen)
+		}
+		pos.enpassant = int(fields[3][0] - 'a')
+	}
+	return pos, nil
+}
+
+// Return the Polyglot key of the position given with its FEN code and nil, or
+// an error if the FEN code is not well formed. As in the specification of the
+// format, the en passant target is considered only if a pawn of the side to
+// move can actually capture en passant
+func Key(fen string) (uint64, error) {
+
+	pos, err := parseFEN(fen)
+	if err != nil {
+		return 0, err
+	}
+	return pos.key(), nil
+}
+
+// Return the book read from the given reader, and nil if no error was found
+func Read(reader io.Reader) (*Book, error) {
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(contents)%entrySize != 0 {
+		return nil, fmt.Errorf(" The size of the book (%v bytes) is not a multiple of %v", len(contents), entrySize)
+	}
+	book := Book{entries: make([]polyglotEntry, len(contents)/entrySize)}
+	if err := binary.Read(bytes.NewReader(contents), binary.BigEndian, book.entries); err != nil {
+		return nil, err
+	}
+
+	// entries must be sorted by their key so that they can be looked up
+	if !sort.SliceIsSorted(book.entries, func(i, j int) bool {
+		return book.entries[i].Key < book.entries[j].Key
+	}) {
+		return nil, fmt.Errorf(" The entries of the book are not sorted by their key")
+	}
+	return &book, nil
+}
+
+// Return the book stored in the given file, and nil if no error was found
+func Open(filename string) (*Book, error) {
+
+	stream, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return Read(stream)
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the Polyglot key of this position
+func (pos position) key() (key uint64) {
+
+	for square, piece := range pos.squares {
+		if piece != 0 {
+			key ^= random64[64*strings.IndexByte(pieceKinds, piece)+square]
+		}
+	}
+	for idx, right := range "KQkq" {
+		if strings.ContainsRune(pos.castling, right) {
+			key ^= random64[randomCastle+idx]
+		}
+	}
+
+	// the en passant target is considered only if there is a pawn of the side
+	// to move right next to the pawn that can be captured
+	if pos.enpassant >= 0 {
+		row, pawn := 3, byte('p')
+		if pos.white {
+			row, pawn = 4, 'P'
+		}
+		if (pos.enpassant > 0 && pos.squares[8*row+pos.enpassant-1] == pawn) ||
+			(pos.enpassant < 7 && pos.squares[8*row+pos.enpassant+1] == pawn) {
+			key ^= random64[randomEnPassant+pos.enpassant]
+		}
+	}
+	if pos.white {
+		key ^= random64[randomTurn]
+	}
+	return
+}
+
+// Return the given move of a Polyglot book in UCI notation in this position.
+// Polyglot books give castling as the king capturing its own rook, e.g.,
+// "e1h1", which is returned with the ending square of the king, e.g., "e1g1"
+func (pos position) decodeMove(move uint16) string {
+
+	to, from, promotion := int(move&0x3f), int(move>>6&0x3f), int(move>>12&0x7)
+	if piece := pos.squares[from]; (piece == 'K' && from == 4) || (piece == 'k' && from == 60) {
+		switch to - from {
+		case 3:
+			to = from + 2
+		case -4:
+			to = from - 2
+		}
+	}
+	uci := squareName(from) + squareName(to)
+	if promotion > 0 {
+		uci += string(" nbrq"[promotion])
+	}
+	return uci
+}
+
+// Return the number of entries of this book
+func (book *Book) Len() int {
+	return len(book.entries)
+}
+
+// Return all entries of this book for the position given with its FEN code, in
+// the same order they are given in the book (usually sorted by decreasing
+// weight), and nil. If the position is not in the book, no entries are
+// returned. An error is returned if the FEN code is not well formed
+func (book *Book) Lookup(fen string) ([]Entry, error) {
+
+	pos, err := parseFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	key := pos.key()
+
+	// all entries of the same position are consecutive
+	var entries []Entry
+	for idx := sort.Search(len(book.entries), func(i int) bool {
+		return book.entries[i].Key >= key
+	}); idx < len(book.entries) && book.entries[idx].Key == key; idx++ {
+		entries = append(entries, Entry{
+			Move:   pos.decodeMove(book.entries[idx].Move),
+			Weight: book.entries[idx].Weight,
+			Learn:  book.entries[idx].Learn,
+		})
+	}
+	return entries, nil
+}
+
+// Return true if the given move, in UCI notation, is given in this book for
+// the position given with its FEN code, and false otherwise, also if the FEN
+// code is not well formed
+func (book *Book) Contains(fen, move string) bool {
+
+	entries, err := book.Lookup(fen)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Move == move {
+			return true
+		}
+	}
+	return false
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,121 @@
+// -*- coding: utf-8 -*-
+// book_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:21:54.713806434 (1792171314)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package book
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Keys given in the specification of the Polyglot format for the positions
+// reached after every sequence of moves
+func TestKey(t *testing.T) {
+
+	tests := []struct {
+		moves string
+		fen   string
+		want  uint64
+	}{
+		{"", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0x463b96181691fc9c},
+		{"e2e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 0x823c9b50fd114196},
+		{"e2e4 d7d5", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", 0x0756b94461c50fb0},
+		{"e2e4 d7d5 e4e5", "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", 0x662fafb965db29d4},
+		{"e2e4 d7d5 e4e5 f7f5", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", 0x22a48b5a8e47ff78},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3", 0x652a607ca3f242c1},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2 e8f7", "rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4", 0x00fdd303c946bdd9},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4", "rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", 0x3c8123ea7b067637},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4 b4c3 a1a3", "rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", 0x5c3f9b829b279560},
+	}
+	for _, tt := range tests {
+		got, err := Key(tt.fen)
+		if err != nil {
+			t.Fatalf("Key(%q) error = %v", tt.moves, err)
+		}
+		if got != tt.want {
+			t.Errorf("Key(%q) = %016x, want %016x", tt.moves, got, tt.want)
+		}
+	}
+
+	// wrong FEN codes are rejected
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNX w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w",
+	} {
+		if _, err := Key(fen); err == nil {
+			t.Errorf("Key(%q) expected an error", fen)
+		}
+	}
+}
+
+func TestBook_Lookup(t *testing.T) {
+
+	book, err := Open(filepath.Join("testdata", "small.bin"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if book.Len() != 7 {
+		t.Errorf("Len() = %v, want 7", book.Len())
+	}
+
+	tests := []struct {
+		name string
+		fen  string
+		want []Entry
+	}{
+		{"start", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			[]Entry{{"e2e4", 100, 0}, {"d2d4", 50, 0}}},
+		{"reply", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+			[]Entry{{"e7e5", 80, 0}}},
+		{"castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			[]Entry{{"e1g1", 10, 0}, {"e1c1", 5, 0}}},
+		{"promotion", "8/P7/8/8/8/8/8/k6K w - - 0 1",
+			[]Entry{{"a7a8q", 1, 0}}},
+		{"missing", "rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq d3 0 1", nil},
+	}
+	for _, tt := range tests {
+		got, err := book.Lookup(tt.fen)
+		if err != nil {
+			t.Fatalf("Lookup(%v) error = %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%v) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// moves are looked up in UCI notation
+	start := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	if !book.Contains(start, "d2d4") || book.Contains(start, "c2c4") || book.Contains("wrong", "e2e4") {
+		t.Errorf("Contains() does not agree with the contents of the book")
+	}
+}
+
+func TestRead(t *testing.T) {
+
+	// the size of books must be a multiple of the size of entries
+	if _, err := Read(bytes.NewReader(make([]byte, 20))); err == nil {
+		t.Errorf("Read() expected an error with a truncated book")
+	}
+
+	// and entries must be sorted by their key
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.BigEndian, []polyglotEntry{{Key: 2}, {Key: 1}})
+	if _, err := Read(&buffer); err == nil {
+		t.Errorf("Read() expected an error with unsorted entries")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
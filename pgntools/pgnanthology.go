@@ -0,0 +1,144 @@
+// -*- coding: utf-8 -*-
+// pgnanthology.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:07:56.565208802 (1792166876)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the FEN patterns given in the file with the given name, one per line,
+// with the same syntax acknowledged by MatchFEN. Blank lines and lines starting
+// with '#' are ignored. It returns an error if the file can not be read or any
+// pattern is not well formed
+func LoadFENPatterns(filename string) ([]string, error) {
+
+	stream, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(stream)
+	for line := 1; scanner.Scan(); line++ {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		// patterns are verified against the initial position
+		if _, err := MatchFEN(pattern, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"); err != nil {
+			return nil, fmt.Errorf(" Invalid FEN pattern '%v' in line %v of '%v': %v", pattern, line, filename, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, scanner.Err()
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the first ply of this game where the position matches the given FEN
+// pattern, the initial position being ply 0, or -1 if no position matches it.
+// The game must have been played before
+func (game *PgnGame) findFEN(pattern string) (int, error) {
+	for ply, iboard := range game.boards {
+		if match, err := MatchFEN(pattern, iboard.fen); err != nil || match {
+			return ply, err
+		}
+	}
+	return -1, nil
+}
+
+// Return a copy of this game with the moves played until the given ply. The
+// tags are inherited from this game, and unless all moves are kept, its
+// outcome is unknown ('*')
+func (game *PgnGame) truncate(ply int) PgnGame {
+
+	if ply >= len(game.moves) {
+		return *game
+	}
+	tags := maps.Clone(game.tags)
+	outcome := PgnOutcome{-1, -1}
+	tags["Result"] = outcome.String()
+	if _, ok := tags["PlyCount"]; ok {
+		tags["PlyCount"] = ply
+	}
+	truncated := PgnGame{
+		tags:    tags,
+		moves:   slices.Clone(game.moves[:ply]),
+		boards:  game.boards[:ply+1],
+		outcome: outcome,
+		id:      game.id,
+	}
+	truncated.movetext = strings.Join(strings.Fields(truncated.getPGNMoves(newPgnOptions())), " ")
+	return truncated
+}
+
+// Return, for every FEN pattern given, a new collection with the games of this
+// one that reach a position matching it, in the same order they are found in
+// this collection. If truncate is true, games are truncated at the first
+// position matching every pattern, so that they can be used to build
+// anthologies of a specific position, e.g., a theoretical tabiya. Games are
+// played in parallel with the number of workers given WithWorkers, and they
+// keep their ids
+func (c PgnCollection) ExtractByFEN(patterns []string, truncate bool, opts ...PgnOption) ([]*PgnCollection, error) {
+
+	// find the first ply of every game matching every pattern. Because every
+	// worker accesses a different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	plies := make([][]int, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		if err := c.slice[idx].play(); err != nil {
+			return err
+		}
+		plies[idx] = make([]int, len(patterns))
+		for jdx, pattern := range patterns {
+			ply, err := c.slice[idx].findFEN(pattern)
+			if err != nil {
+				return err
+			}
+			plies[idx][jdx] = ply
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// and create a collection for every pattern
+	result := make([]*PgnCollection, len(patterns))
+	for jdx := range patterns {
+		collection := NewPgnCollection()
+		collection.idBase = c.idBase
+		for idx := range c.slice {
+			if ply := plies[idx][jdx]; ply >= 0 {
+				if truncate {
+					collection.Add(c.slice[idx].truncate(ply))
+				} else {
+					collection.Add(c.slice[idx])
+				}
+			}
+		}
+		result[jdx] = &collection
+	}
+	return result, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,98 @@
+// -*- coding: utf-8 -*-
+// pgnanthology_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:08:12.079729069 (1792166892)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadFENPatterns(t *testing.T) {
+
+	filename := filepath.Join(t.TempDir(), "tabiyas.txt")
+	contents := "# Ruy Lopez\nr1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R b KQkq * * *\n\n*8/*8/*8/*8/4P3/*8/*8/*8 b * * * *\n"
+	if err := os.WriteFile(filename, []byte(contents), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	patterns, err := LoadFENPatterns(filename)
+	if err != nil {
+		t.Fatalf("LoadFENPatterns() error = %v", err)
+	}
+	if len(patterns) != 2 || patterns[1] != "*8/*8/*8/*8/4P3/*8/*8/*8 b * * * *" {
+		t.Errorf("LoadFENPatterns() = %v", patterns)
+	}
+
+	// malformed patterns are reported
+	if err := os.WriteFile(filename, []byte("not a fen\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := LoadFENPatterns(filename); err == nil {
+		t.Errorf("LoadFENPatterns() should fail with a malformed pattern")
+	}
+}
+
+func TestPgnCollection_ExtractByFEN(t *testing.T) {
+
+	games := []string{
+		"1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Ba4 *",
+		"1. d4 d5 2. c4 e6 *",
+		"1. Nf3 Nc6 2. e4 e5 3. Bb5 Nf6 1-0",
+	}
+	c := NewPgnCollection()
+	for _, pgn := range games {
+		game, err := getGameFromString("[Event \"?\"]\n[Result \"*\"]\n\n" + pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		c.Add(*game)
+	}
+	patterns := []string{
+		"r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R b KQkq * * *",
+		"*8/*8/*8/*8/2PP4/*8/*8/*8 b * * * *",
+		"*8/*8/*8/*8/*8/*8/*8/*8 w * * * 10",
+	}
+
+	// the Ruy Lopez is reached by transposition in the last game
+	collections, err := c.ExtractByFEN(patterns, false)
+	if err != nil {
+		t.Fatalf("ExtractByFEN() error = %v", err)
+	}
+	want := [][]int{{1, 3}, {2}, nil}
+	for jdx, collection := range collections {
+		var ids []int
+		for _, igame := range collection.GetGames() {
+			ids = append(ids, igame.Id())
+		}
+		if !slices.Equal(ids, want[jdx]) {
+			t.Errorf("ExtractByFEN() pattern %v = %v, want %v", jdx, ids, want[jdx])
+		}
+	}
+
+	// and games are truncated at the first position matching every pattern
+	collections, err = c.ExtractByFEN(patterns[:1], true)
+	if err != nil {
+		t.Fatalf("ExtractByFEN() error = %v", err)
+	}
+	for idx, want := range []string{"1. e4 e5 2. Nf3 Nc6 3. Bb5 *", "1. Nf3 Nc6 2. e4 e5 3. Bb5 *"} {
+		game := collections[0].GetGame(idx)
+		if got := game.getPGNMoves(newPgnOptions()) + game.outcome.String(); got != want {
+			t.Errorf("ExtractByFEN() game %v = %q, want %q", idx, got, want)
+		}
+	}
+	if c.slice[2].outcome.String() != "1-0" || len(c.slice[0].moves) != 7 {
+		t.Errorf("ExtractByFEN() modified the games of the collection")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,178 @@
+// -*- coding: utf-8 -*-
+// pgnaudit.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:09:53.009033222 (1792163393)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Anomalies found in the tags of games are given a severity: errors are
+// certainly wrong data, e.g., a game dated in the future, warnings are most
+// likely wrong, e.g., a rating of zero, and infos are merely suspicious
+type PgnSeverity int
+
+// Every anomaly found in a game is described with the game where it was found,
+// its severity, the tag involved and a description
+type PgnAnomaly struct {
+	Game     int         // identifier of the game
+	Severity PgnSeverity // severity of the anomaly
+	Tag      string      // tag where the anomaly was found
+	Message  string      // description of the anomaly
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Severities of anomalies sorted in increasing order
+const (
+	InfoSeverity PgnSeverity = iota
+	WarningSeverity
+	ErrorSeverity
+)
+
+// Ratings above this value are suspicious, as no player ever reached it
+const maxElo = 2900
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the given PGN date as a time.Time along with the number of fields
+// known (0 if the year is unknown, 1 if only the year is known, 2 if the month
+// is known as well and 3 if the whole date is known), and false if the date is
+// malformed. Dashes and slashes are accepted as separators as well
+func auditDate(date any) (time.Time, int, bool) {
+
+	fields := strings.FieldsFunc(fmt.Sprintf("%v", date), func(r rune) bool {
+		return r == '.' || r == '-' || r == '/'
+	})
+	if len(fields) != 3 {
+		return time.Time{}, 0, false
+	}
+
+	// fields are known from the left, i.e., neither the month nor the day are
+	// known if the year is not, and unknown fields are given with question
+	// marks
+	layout := []string{"2006", "01", "02"}
+	known := 0
+	for known < 3 && strings.Trim(fields[known], "?") != "" {
+		known++
+	}
+	for _, field := range fields[known:] {
+		if strings.Trim(field, "?") != "" {
+			return time.Time{}, 0, false
+		}
+	}
+	if known == 0 {
+		return time.Time{}, 0, true
+	}
+	parsed, err := time.Parse(strings.Join(layout[:known], "."), strings.Join(fields[:known], "."))
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return parsed, known, true
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a string describing this severity
+func (severity PgnSeverity) String() string {
+	switch severity {
+	case ErrorSeverity:
+		return "error"
+	case WarningSeverity:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// Severities are written in text formats, e.g., JSON, with their names
+func (severity PgnSeverity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+// Return a string describing this anomaly
+func (anomaly PgnAnomaly) String() string {
+	return fmt.Sprintf(" Game %v [%v] %v: %v",
+		anomaly.Game, anomaly.Severity, anomaly.Tag, anomaly.Message)
+}
+
+// Audit the tags of this game and return all anomalies found in the following
+// order:
+//
+//  1. Ratings (WhiteElo and BlackElo) equal to zero, above 2900 or malformed
+//  2. Dates (Date) which are malformed or in the future
+//  3. Results (Result) disagreeing with the outcome given after the moves
+//  4. Players (White and Black) facing themselves
+//
+// Tags which are not given are not audited
+func (game *PgnGame) Audit() (anomalies []PgnAnomaly) {
+
+	anomaly := func(severity PgnSeverity, tag, format string, args ...any) {
+		anomalies = append(anomalies, PgnAnomaly{game.id, severity, tag, fmt.Sprintf(format, args...)})
+	}
+
+	// Ratings
+	for _, name := range []string{"WhiteElo", "BlackElo"} {
+		value, ok := game.tags[name]
+		if !ok {
+			continue
+		}
+		if validateElo(map[string]any{name: value}) != nil {
+			anomaly(ErrorSeverity, name, "malformed rating '%v'", value)
+		} else if rating, ok := value.(int); ok && rating == 0 {
+			anomaly(WarningSeverity, name, "rating equal to zero")
+		} else if ok && rating > maxElo {
+			anomaly(WarningSeverity, name, "rating %v above %v", rating, maxElo)
+		}
+	}
+
+	// Dates
+	if value, ok := game.tags["Date"]; ok {
+		if date, known, ok := auditDate(value); !ok {
+			anomaly(InfoSeverity, "Date", "malformed date '%v'", value)
+		} else if known > 0 && date.After(time.Now()) {
+			anomaly(ErrorSeverity, "Date", "date '%v' in the future", value)
+		}
+	}
+
+	// Results
+	if value, ok := game.tags["Result"]; ok {
+		if result := fmt.Sprintf("%v", value); result != game.outcome.String() {
+			anomaly(ErrorSeverity, "Result", "result '%v' disagrees with the outcome '%v'", result, game.outcome)
+		}
+	}
+
+	// Players
+	white, black := game.getTag("White"), game.getTag("Black")
+	if white != "?" && white != "" && white == black {
+		anomaly(ErrorSeverity, "White", "'%v' plays against itself", white)
+	}
+	return
+}
+
+// Audit the tags of all games in this collection with Audit and return all
+// anomalies found sorted by the position of games in this collection
+func (c PgnCollection) Audit() (anomalies []PgnAnomaly) {
+	for _, igame := range c.slice {
+		anomalies = append(anomalies, igame.Audit()...)
+	}
+	return
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,74 @@
+// -*- coding: utf-8 -*-
+// pgnaudit_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:10:03.365930481 (1792163403)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"testing"
+)
+
+func TestPgnGame_Audit(t *testing.T) {
+
+	tests := []struct {
+		name string
+		tags string
+		want []PgnAnomaly
+	}{
+		{"clean", `[White "alice"]
+[Black "bob"]
+[WhiteElo "2100"]
+[BlackElo "-"]
+[Date "2016.??.??"]
+[Result "1-0"]`, nil},
+		{"ratings", `[WhiteElo "0"]
+[BlackElo "3100"]
+[Result "1-0"]`, []PgnAnomaly{
+			{1, WarningSeverity, "WhiteElo", "rating equal to zero"},
+			{1, WarningSeverity, "BlackElo", "rating 3100 above 2900"},
+		}},
+		{"future", `[Date "2999.01.??"]
+[Result "1-0"]`, []PgnAnomaly{
+			{1, ErrorSeverity, "Date", "date '2999.01.??' in the future"},
+		}},
+		{"malformed date", `[Date "2016.??.12"]
+[Result "1-0"]`, []PgnAnomaly{
+			{1, InfoSeverity, "Date", "malformed date '2016.??.12'"},
+		}},
+		{"result", `[Result "0-1"]`, []PgnAnomaly{
+			{1, ErrorSeverity, "Result", "result '0-1' disagrees with the outcome '1-0'"},
+		}},
+		{"self", `[White "alice"]
+[Black "alice"]
+[Result "1-0"]`, []PgnAnomaly{
+			{1, ErrorSeverity, "White", "'alice' plays against itself"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := ParseGame(tt.tags + "\n\n1. e4 1-0")
+			if err != nil {
+				t.Fatalf("ParseGame() error = %v", err)
+			}
+			game.id = 1
+			got := game.Audit()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Audit() = %v, want %v", got, tt.want)
+			}
+			for idx := range got {
+				if got[idx] != tt.want[idx] {
+					t.Errorf("Audit() = %v, want %v", got[idx], tt.want[idx])
+				}
+			}
+		})
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
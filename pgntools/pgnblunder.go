@@ -0,0 +1,138 @@
+// -*- coding: utf-8 -*-
+// pgnblunder.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:18:56.676687229 (1792167536)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the first variation given after the specified move in PGN format
+// without annotations, or the empty string if none is given. Engines like the
+// one used by lichess give the better line after every mistake this way
+func getBetterLine(move PgnMove) string {
+
+	if len(move.variations) == 0 {
+		return ""
+	}
+	line := make([]PgnMove, len(move.variations[0]))
+	for idx, imove := range move.variations[0] {
+		line[idx] = PgnMove{
+			number:         imove.number,
+			color:          imove.color,
+			shortAlgebraic: imove.shortAlgebraic,
+			quality:        imove.quality,
+		}
+	}
+	return strings.TrimSpace(getPGNLine(line, pgnOptions{}))
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the index of the ply of this game with the decisive mistake of the
+// loser, i.e., the last move of the loser before the winner got an evaluation
+// of at least three pawns for the first time, or -1 if the game is not decisive,
+// its moves are not evaluated or the winner never got a decisive advantage
+func (game *PgnGame) LosingBlunder() int {
+
+	// only games won by any player are considered
+	color := game.outcome.winner()
+	if color == 0 {
+		return -1
+	}
+
+	for idx, move := range game.moves {
+		if pawns, ok := move.Eval(); ok && float64(color)*pawns >= decisiveEval {
+
+			// the mistake is the last move of the loser up to this one
+			for idx >= 0 && game.moves[idx].color == color {
+				idx--
+			}
+			return idx
+		}
+	}
+	return -1
+}
+
+// Return the evaluation given before the specified ply of this game, and true
+// if it is given or false otherwise
+func (game *PgnGame) evalBefore(ply int) (float64, bool) {
+	if ply <= 0 {
+		return 0, false
+	}
+	return game.moves[ply-1].Eval()
+}
+
+// Return a comment describing the decisive mistake made in the given ply of
+// this game, with the evaluations before and after it and the better line, if
+// any is given
+func (game *PgnGame) getBlunderComment(ply int) string {
+
+	comment := "Decisive mistake"
+	after, _ := game.moves[ply].Eval()
+	if before, ok := game.evalBefore(ply); ok {
+		comment += fmt.Sprintf(" (from %.2f to %.2f)", before, after)
+	} else {
+		comment += fmt.Sprintf(" (%.2f)", after)
+	}
+	if line := getBetterLine(game.moves[ply]); line != "" {
+		comment += fmt.Sprintf(". Better was %v", line)
+	}
+	return comment
+}
+
+// Return a copy of the given moves of this game, which are either its moves or
+// a copy of them, where the decisive mistake of the loser is qualified with
+// "??" and commented, see LosingBlunder. If there is no decisive mistake, the
+// moves are returned as they are
+func (game *PgnGame) markBlunder(moves []PgnMove) []PgnMove {
+
+	ply := game.LosingBlunder()
+	if ply < 0 || ply >= len(moves) {
+		return moves
+	}
+	moves = slices.Clone(moves)
+	moves[ply].quality = "??"
+	moves[ply].annotations = append(slices.Clone(moves[ply].annotations), PgnAnnotation{
+		Kind:  CommentAnnotation,
+		Value: game.getBlunderComment(ply),
+	})
+	return moves
+}
+
+// Return a copy of this game where the decisive mistake of the loser is
+// qualified with "??" and commented with the evaluations before and after it
+// and the better line given in the first variation after it, if any, see
+// LosingBlunder. The receiver is not modified
+func (game PgnGame) MarkBlunder() PgnGame {
+	game.moves = game.markBlunder(game.moves)
+	return game
+}
+
+// Return a copy of this collection where the decisive mistake of the loser of
+// every game with evaluations is marked, see MarkBlunder
+func (c PgnCollection) MarkBlunders() PgnCollection {
+
+	games := make([]PgnGame, len(c.slice))
+	for idx, game := range c.slice {
+		games[idx] = game.MarkBlunder()
+	}
+	c.slice = games
+	return c
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,58 @@
+// -*- coding: utf-8 -*-
+// pgnblunder_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:19:53.159707682 (1792167593)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPgnGame_MarkBlunder(t *testing.T) {
+
+	game, err := ParseGame(`[Result "1-0"]
+
+1. e4 { [%eval 0.3] } e5 { [%eval 0.25] } 2. Bc4 { [%eval 0.2] } Nc6 { [%eval 0.2] } 3. Qh5 { [%eval 0.1] } Nf6 { [%eval 50] } (3... g6 4. Qf3 Nf6) 4. Qxf7# 1-0`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if got := game.LosingBlunder(); got != 5 {
+		t.Fatalf("LosingBlunder() = %v, want 5", got)
+	}
+
+	// the receiver is not modified
+	marked := game.MarkBlunder()
+	if game.moves[5].quality != "" || len(game.moves[5].annotations) != 1 {
+		t.Errorf("MarkBlunder() modified the receiver")
+	}
+	if got := marked.moves[5].annotated(); got != "Nf6??" {
+		t.Errorf("MarkBlunder() = %v, want Nf6??", got)
+	}
+	want := "Decisive mistake (from 0.10 to 50.00). Better was 3... g6 4. Qf3 Nf6"
+	if got := marked.moves[5].annotations[1].Value; got != want {
+		t.Errorf("MarkBlunder() = %q, want %q", got, want)
+	}
+
+	// and it is written in PGN format and shown when playing games
+	if pgn := game.GetPGN(WithBlunders()); !strings.Contains(pgn, "Nf6?? {[%eval 50]} { "+want+" }") {
+		t.Errorf("GetPGN() = %v", pgn)
+	}
+	if got := game.prettyMoves(4, 6, 5); got != " 3. Qh5 Nf6?? \n (3... g6 4. Qf3 Nf6)" {
+		t.Errorf("prettyMoves() = %q", got)
+	}
+
+	// games without evaluations have no decisive mistake
+	if game, _ := ParseGame("[Result \"0-1\"]\n\n1. f3 e5 2. g4 Qh4# 0-1"); game.LosingBlunder() != -1 {
+		t.Errorf("LosingBlunder() should be -1")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -61,12 +61,16 @@ const (
 
 // A PgnBoard consists simply of an array of 64 integers. In addition, the
 // location of both kings has to be updated. This information is used to
-// determine whether a piece is pinned or not. Finally, every board (or
-// position) is characterized by a unique FEN code.
+// determine whether a piece is pinned or not. Every board (or position) is
+// characterized by a unique FEN code, and it is also given a Zobrist hash for
+// finding it fast. Finally, boards are updated following the rules of a
+// specific variant. If none is given, standard chess is used
 type PgnBoard struct {
 	squares      [64]content // contents of each square
 	wking, bking int         // location of the white and black king
 	fen          string
+	variant      Variant
+	hash         uint64 // Zobrist hash of the position
 }
 
 // Functions
@@ -98,6 +102,8 @@ func getPieceIndex(piece string) content {
 		return WPAWN
 	}
 	switch piece {
+	case "P": // pawn
+		return WPAWN
 	case "N": // knight
 		return WKNIGHT
 	case "B": // bishop
@@ -520,8 +526,6 @@ func (board *PgnBoard) getOriginPawn(piece content, target string, qualifier str
 			if columnsecond == qualifier && board.squares[second] == piece {
 				return second
 			}
-		} else {
-			log.Fatalf(" Fatal Error getting the origin of a white pawn (capture)")
 		}
 	} else {
 
@@ -536,8 +540,6 @@ func (board *PgnBoard) getOriginPawn(piece content, target string, qualifier str
 			// otherwise, verify there is available a second
 			// location to look up
 			return threats[target][piece][0][1]
-		} else {
-			log.Fatalf(" Fatal Error getting the origin of a pawn (ordinary)")
 		}
 	}
 
@@ -657,32 +659,48 @@ func (board *PgnBoard) getOrigin(piece content, target string, qualifier string,
 	// found. In case of ambiguity, the qualifier is used. Additionally,
 	// whether a piece is pinned or not is observed to solve ambiguity when
 	// needed. Finally, the capture flag is used only to select accordingly
-	// the lists of threats to consider for pawns
+	// the lists of threats to consider for pawns. If no origin is found, the
+	// negative value is returned so that the caller reports the illegal move
 
 	if piece == WPAWN || piece == BPAWN {
 
 		// -- Pawns
-		origin = board.getOriginPawn(piece, target, qualifier, capture)
-		if origin < 0 {
-			log.Fatalf("It was not possible to get the origin location of a pawn")
-		}
-		return origin
+		return board.getOriginPawn(piece, target, qualifier, capture)
 	} else if piece == WKNIGHT || piece == BKNIGHT {
 
 		// -- Knights
-		origin = board.getOriginKnight(piece, target, qualifier, capture)
-		if origin < 0 {
-			log.Fatalf("It was not possible to get the origin location of a knight")
-		}
-		return origin
+		return board.getOriginKnight(piece, target, qualifier, capture)
 	}
 
 	// --- Bishops, Rooks, Queens and Kings
-	origin = board.getOriginGeneric(piece, target, qualifier, capture)
-	if origin < 0 {
-		log.Fatalf("It was not possible to get the origin location of a generic piece")
+	return board.getOriginGeneric(piece, target, qualifier, capture)
+}
+
+// return the number of squares from which the given piece, other than a pawn,
+// can be moved to the given location (given as a literal coordinate) in this
+// chess board satisfying the given qualifier, if any. Pinned pieces are not
+// counted. Moves are ambiguous if this number is larger than one
+func (board *PgnBoard) countOrigins(piece content, target string, qualifier string) (count int) {
+
+	for _, direction := range threats[target][piece] {
+		for _, loc := range direction {
+
+			// count this location if it is occupied by the given piece which
+			// is not pinned and it satisfies the qualifier
+			if board.squares[loc] == piece && !board.isPinned(loc, coords[target]) {
+				if row, column := getQualifier(loc); len(qualifier) == 0 || row == qualifier || column == qualifier {
+					count++
+				}
+			}
+
+			// knights jump over other pieces, but the rest do not go further
+			// in this direction
+			if board.squares[loc] != BLANK && piece != WKNIGHT && piece != BKNIGHT {
+				break
+			}
+		}
 	}
-	return origin
+	return
 }
 
 // determine whether a piece in the given location which moves to the given
@@ -764,62 +782,169 @@ func (board *PgnBoard) isPinned(location int, dest int) bool {
 		board.isPinnedGeneric(location, dest, rook, threats[literal[king]][rook])
 }
 
-// update the contents of this board after the side of the given color makes a
-// short castling. Return the move actually played in long algebraic notation
-// (which is described simply with the starting and ending locations of the
-// king)
-func (board *PgnBoard) updateShortCastling(color int) longAlgebraic {
+// return true if the given square is attacked by any piece of the given color,
+// i.e., if any of them could capture a piece of the opposite color located in
+// it
+func (board *PgnBoard) isAttacked(square int, color int) bool {
 
-	if color < 0 {
-		board.squares[coords["e8"]] = BLANK // remove the king
-		board.squares[coords["h8"]] = BLANK // remove the rook
-		board.squares[coords["f8"]] = BROOK // relocate the rook
-		board.squares[coords["g8"]] = BKING // relocate the king
+	// consider every piece of the given color
+	for _, piece := range []content{WPAWN, WKNIGHT, WBISHOP, WROOK, WQUEEN, WKING} {
+		attacker := getPieceValue(piece, color)
+
+		// pawns are the only pieces which capture in a different way they
+		// move. Their captures are stored in all lists but the first one
+		directions := threats[literal[square]][attacker]
+		if piece == WPAWN && len(directions) > 0 {
+			directions = directions[1:]
+		}
+
+		// traverse all directions until the attacker is found or another
+		// piece is in between. Note that knights jump over other pieces and
+		// all their locations are stored in the same list
+		for _, direction := range directions {
+			for _, loc := range direction {
+				if board.squares[loc] == attacker {
+					return true
+				}
+				if board.squares[loc] != BLANK && piece != WKNIGHT {
+					break
+				}
+			}
+		}
+	}
 
-		board.bking = coords["g8"]
+	// at this point, no attacker was found
+	return false
+}
 
-		// and return the move played in long algebraic notation
-		return longAlgebraic{"e8", "g8"}
+// return true if moving the piece in the origin to the target does not leave
+// its king in check. If the piece is a pawn and the target is the given en
+// passant square, the pawn captured en passant is removed as well
+func (board *PgnBoard) isSafe(origin, target, enpassant int) bool {
+
+	// make the move in a copy of this board
+	next := *board
+	piece := next.squares[origin]
+	color := getColor(piece)
+	next.squares[origin] = BLANK
+	if (piece == WPAWN || piece == BPAWN) && target == enpassant {
+		next.squares[target-8*color] = BLANK
 	}
+	next.squares[target] = piece
 
-	board.squares[coords["e1"]] = BLANK // remove the king
-	board.squares[coords["h1"]] = BLANK // remove the rook
-	board.squares[coords["f1"]] = WROOK // relocate the rook
-	board.squares[coords["g1"]] = WKING // relocate the king
+	// updating the location of the king if necessary
+	if piece == WKING {
+		next.wking = target
+	} else if piece == BKING {
+		next.bking = target
+	}
 
-	board.wking = coords["g1"]
+	return !next.InCheck(color)
+}
 
-	// and return the move played in long algebraic notation
-	return longAlgebraic{"e1", "g1"}
+// return true if the side with the given color is checkmated in this board,
+// i.e., if its king is in check and no move avoids it. Castling is not
+// considered as it is never legal when the king is in check
+func (board *PgnBoard) isCheckmate(color int) bool {
+	return board.InCheck(color) && !board.hasLegalMove(color)
+}
+
+// return true if the side with the given color is stalemated in this board,
+// i.e., if its king is not in check but it has no legal move. Castling is not
+// considered, so that positions where castling is the only legal move are
+// wrongly taken as stalemates
+func (board *PgnBoard) isStalemate(color int) bool {
+	return !board.InCheck(color) && !board.hasLegalMove(color)
 }
 
-// update the contents of this board after the side of the given color makes a
-// long castling. Return the move actually played in long algebraic notation
-// (which is described simply with the starting and ending locations of the
-// king)
-func (board *PgnBoard) updateLongCastling(color int) longAlgebraic {
+// return true if the side with the given color has any legal move in this
+// board other than castling, i.e., any move which does not leave its king in
+// check
+func (board *PgnBoard) hasLegalMove(color int) bool {
 
-	if color < 0 {
-		board.squares[coords["e8"]] = BLANK // remove the king
-		board.squares[coords["a8"]] = BLANK // remove the rook
-		board.squares[coords["d8"]] = BROOK // relocate the rook
-		board.squares[coords["c8"]] = BKING // relocate the king
+	// get the en passant target, if any, from the FEN code of this board
+	enpassant := -1
+	if fields := strings.Fields(board.fen); len(fields) > 3 {
+		if loc, ok := coords[fields[3]]; ok {
+			enpassant = loc
+		}
+	}
+
+	// look for any move to every square not occupied by a piece of the same
+	// color
+	for target := 0; target < 64; target++ {
+		if board.squares[target] != BLANK && getColor(board.squares[target]) == color {
+			continue
+		}
+		for _, piece := range []content{WPAWN, WKNIGHT, WBISHOP, WROOK, WQUEEN, WKING} {
+			mover := getPieceValue(piece, color)
+			for idx, direction := range threats[literal[target]][mover] {
+
+				// pawns move forward only to empty squares, and capture
+				// either pieces or en passant
+				if piece == WPAWN &&
+					((idx == 0 && board.squares[target] != BLANK) ||
+						(idx > 0 && board.squares[target] == BLANK && target != enpassant)) {
+					continue
+				}
+
+				// and the first piece found in every direction could be moved
+				// to the target unless it leaves the king in check
+				for _, origin := range direction {
+					if board.squares[origin] == mover && board.isSafe(origin, target, enpassant) {
+						return true
+					}
+					if board.squares[origin] != BLANK && piece != WKNIGHT {
+						break
+					}
+				}
+			}
+		}
+	}
 
-		board.bking = coords["c8"]
+	// at this point, no legal move was found
+	return false
+}
 
-		// and return the move played in long algebraic notation
-		return longAlgebraic{"e8", "c8"}
+// return the color of the side to move in this board as given in its FEN
+// code: 1 for White and -1 for Black. If it is unknown, White is assumed to
+// move
+func (board *PgnBoard) sideToMove() int {
+	if fields := strings.Fields(board.fen); len(fields) > 1 && fields[1] == "b" {
+		return -1
+	}
+	return 1
+}
+
+// update the contents of this board after the side of the given color castles
+// either on the king side (short) or the queen side. The squares of the king
+// and the rook are given by the variant of this board. Return the move actually
+// played in long algebraic notation (which is described simply with the
+// starting and ending locations of the king) and nil if no error was found
+func (board *PgnBoard) updateCastling(color int, short bool) (longAlgebraic, error) {
+
+	// get the squares of the king and the rook from the variant
+	kingFrom, kingTo, rookFrom, rookTo, err := board.getVariant().Castling(board, color, short)
+	if err != nil {
+		return longAlgebraic{}, err
 	}
 
-	board.squares[coords["e1"]] = BLANK // remove the king
-	board.squares[coords["a1"]] = BLANK // remove the rook
-	board.squares[coords["d1"]] = WROOK // relocate the rook
-	board.squares[coords["c1"]] = WKING // relocate the king
+	// remove both pieces before relocating them, as in some variants the king
+	// or the rook might end in the square where the other one was
+	board.squares[kingFrom] = BLANK
+	board.squares[rookFrom] = BLANK
+	board.squares[rookTo] = getPieceValue(WROOK, color)
+	board.squares[kingTo] = getPieceValue(WKING, color)
 
-	board.wking = coords["c1"]
+	// update the location of the king
+	if color < 0 {
+		board.bking = kingTo
+	} else {
+		board.wking = kingTo
+	}
 
 	// and return the move played in long algebraic notation
-	return longAlgebraic{"e1", "c1"}
+	return longAlgebraic{literal[kingFrom], literal[kingTo]}, nil
 }
 
 // Compute the segment of the FEN code which describes the contents of the given
@@ -903,40 +1028,36 @@ func updateFENCastingRights(castling string, prec PgnBoard, extended longAlgebra
 	// Read the contents of the starting square
 	src := prec.squares[coords[extended.from]]
 
-	// If black lost the king side castling rights then there is no need to
-	// consider it
-	if strings.Contains(castling, "k") {
+	// Castling rights of white are given first. If white lost the king side
+	// castling rights then there is no need to consider it
+	if strings.Contains(castling, "K") {
 
 		// Then ensure that the last move was not made either by the king or the
 		// king's rook
-		if src != BKING && src != BROOK {
-			fen += "k"
+		if src != WKING && src != WROOK {
+			fen += "K"
 		}
 	}
 
-	// If black lost the queen side castling rights then there is no need to
-	// consider it
-	if strings.Contains(castling, "q") {
+	if strings.Contains(castling, "Q") {
 
-		// Then ensure that the last move was not made either by the king or the
-		// king's rook
-		if src != BKING && src != BROOK {
-			fen += "q"
+		if src != WKING && src != WROOK {
+			fen += "Q"
 		}
 	}
 
-	// And anagously for white
-	if strings.Contains(castling, "K") {
+	// And analogously for black
+	if strings.Contains(castling, "k") {
 
-		if src != WKING && src != WROOK {
-			fen += "K"
+		if src != BKING && src != BROOK {
+			fen += "k"
 		}
 	}
 
-	if strings.Contains(castling, "Q") {
+	if strings.Contains(castling, "q") {
 
-		if src != WKING && src != WROOK {
-			fen += "Q"
+		if src != BKING && src != BROOK {
+			fen += "q"
 		}
 	}
 
@@ -1001,8 +1122,9 @@ func updateFENHalfMove(halfmove string, prec PgnBoard, extended longAlgebraic) (
 	dst := prec.squares[coords[extended.to]]
 
 	// If and only if the last move changes the location of a pawn or if it is a
-	// capture
-	if src == BPAWN || src == WPAWN || dst != BLANK {
+	// capture. Note that in some variants the king might castle to the square
+	// of its own rook, which is not a capture
+	if src == BPAWN || src == WPAWN || (dst != BLANK && getColor(dst) != getColor(src)) {
 
 		// then the count is restarted
 		fen = "0"
@@ -1045,8 +1167,13 @@ func (board *PgnBoard) updateFEN(prec PgnBoard, extended longAlgebraic) {
 	// ------------------------------------------------------------------------
 	// Castling rights are computed incrementally. If either side lost the
 	// possibility of castling either king or queen side there is no possibility
-	// to do in the future.
-	fen += updateFENCastingRights(fields[2], prec, extended) + " "
+	// to do in the future. Variants with their own castling rules can update
+	// them on their own
+	if variant, ok := prec.getVariant().(castlingRightsVariant); ok {
+		fen += variant.CastlingRights(&prec, fields[2], coords[extended.from], coords[extended.to]) + " "
+	} else {
+		fen += updateFENCastingRights(fields[2], prec, extended) + " "
+	}
 
 	// En passant targets
 	// ------------------------------------------------------------------------
@@ -1077,7 +1204,7 @@ func (board *PgnBoard) updateFEN(prec PgnBoard, extended longAlgebraic) {
 
 // Create a new board initialized with Caissa
 func NewPgnBoard() PgnBoard {
-	return PgnBoard{
+	board := PgnBoard{
 		[64]content{WROOK, WKNIGHT, WBISHOP, WQUEEN, WKING, WBISHOP, WKNIGHT, WROOK,
 			WPAWN, WPAWN, WPAWN, WPAWN, WPAWN, WPAWN, WPAWN, WPAWN,
 			BLANK, BLANK, BLANK, BLANK, BLANK, BLANK, BLANK, BLANK,
@@ -1088,7 +1215,134 @@ func NewPgnBoard() PgnBoard {
 			BROOK, BKNIGHT, BBISHOP, BQUEEN, BKING, BBISHOP, BKNIGHT, BROOK},
 		4,  // initial location of the white king
 		60, // initial location of the black king
-		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"} // fen of the starting position
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", // fen of the starting position
+		nil, // standard chess
+		0}
+	board.hash = board.zobristHash()
+	return board
+}
+
+// Create a new board with the position given in the specified FEN code. The
+// halfmove clock and the fullmove number can be omitted, in which case they are
+// taken to be 0 and 1 respectively. An error is returned if the FEN code is
+// malformed or there is not exactly one king of every color
+func NewPgnBoardFromFEN(fen string) (PgnBoard, error) {
+
+	fields := strings.Fields(fen)
+	if len(fields) == 4 {
+		fields = append(fields, "0", "1")
+	}
+	if len(fields) != 6 {
+		return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': six fields were expected", fen)
+	}
+
+	// Piece placement
+	// ------------------------------------------------------------------------
+	// Ranks are given from the eighth to the first one, and every rank has to
+	// describe exactly eight squares
+	var board PgnBoard
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': eight ranks were expected", fen)
+	}
+	kings := map[content]int{}
+	for idx, rank := range ranks {
+		row, column := 7-idx, 0
+		for _, symbol := range rank {
+			if symbol >= '1' && symbol <= '8' {
+				column += int(symbol - '0')
+				continue
+			}
+			piece, ok := fenPieces[symbol]
+			if !ok || column > 7 {
+				return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': wrong rank '%v'", fen, rank)
+			}
+			board.squares[row*8+column] = piece
+			if piece == WKING || piece == BKING {
+				kings[piece]++
+				if piece == WKING {
+					board.wking = row*8 + column
+				} else {
+					board.bking = row*8 + column
+				}
+			}
+			column++
+		}
+		if column != 8 {
+			return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': wrong rank '%v'", fen, rank)
+		}
+	}
+	if kings[WKING] != 1 || kings[BKING] != 1 {
+		return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': there must be exactly one king of every color", fen)
+	}
+
+	// Other fields
+	// ------------------------------------------------------------------------
+	// The side to move, castling rights, en passant target, halfmove clock and
+	// fullmove number are just verified
+	if fields[1] != "w" && fields[1] != "b" {
+		return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': wrong side to move '%v'", fen, fields[1])
+	}
+	if fields[2] != "-" && strings.Trim(fields[2], "KQkqABCDEFGHabcdefgh") != "" {
+		return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': wrong castling rights '%v'", fen, fields[2])
+	}
+	if _, ok := coords[fields[3]]; fields[3] != "-" && (!ok || (fields[3][1] != '3' && fields[3][1] != '6')) {
+		return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': wrong en passant target '%v'", fen, fields[3])
+	}
+	for _, field := range fields[4:] {
+		if value, err := strconv.Atoi(field); err != nil || value < 0 {
+			return PgnBoard{}, fmt.Errorf(" Invalid FEN code '%v': wrong counter '%v'", fen, field)
+		}
+	}
+
+	board.fen = strings.Join(fields, " ")
+	board.hash = board.zobristHash()
+	return board, nil
+}
+
+// Return the variant whose rules are used to update this board
+func (board *PgnBoard) getVariant() Variant {
+	if board.variant == nil {
+		return defaultVariant
+	}
+	return board.variant
+}
+
+// Return the variant whose rules are used to update this board
+func (board *PgnBoard) Variant() Variant {
+	return board.getVariant()
+}
+
+// Return the outcome of the game and true if the side with the given color can
+// not continue playing in this board according to the rules of its variant.
+// Otherwise, the outcome is meaningless and false is returned
+func (board *PgnBoard) Outcome(color int) (PgnOutcome, bool) {
+	return board.getVariant().Outcome(board, color)
+}
+
+// Return true if the king of the side with the given color is in check in this
+// board. If there is no king of the given color, false is returned
+func (board *PgnBoard) InCheck(color int) bool {
+	king := board.wking
+	if color < 0 {
+		king = board.bking
+	}
+	if board.squares[king] != getPieceValue(WKING, color) {
+		return false
+	}
+	return board.isAttacked(king, -color)
+}
+
+// Return true if the side to move in this board is checkmated, i.e., if its
+// king is in check and it has no legal move
+func (board *PgnBoard) IsCheckmate() bool {
+	return board.isCheckmate(board.sideToMove())
+}
+
+// Return true if the side to move in this board is stalemated, i.e., if its
+// king is not in check and it has no legal move. Castling is not considered
+func (board *PgnBoard) IsStalemate() bool {
+	return board.isStalemate(board.sideToMove())
 }
 
 // Return the FEN code of a specific board or chess position. The FEN of a
@@ -1097,6 +1351,22 @@ func (board *PgnBoard) FEN() string {
 	return board.fen
 }
 
+// Return the FEN code of this board with its six fields: piece placement, side
+// to move, castling rights, en passant target, halfmove clock and fullmove
+// number. The piece placement is computed from the contents of this board and
+// the other fields are those tracked when updating it. Unlike FEN, it is
+// always available: boards which were never given a FEN code are assumed to
+// have White to move without castling rights nor en passant target
+func (board *PgnBoard) ToFEN() string {
+
+	fields := strings.Fields(board.fen)
+	if len(fields) != 6 {
+		fields = []string{"", "w", "-", "-", "0", "1"}
+	}
+	fields[0] = board.updateFENPiecePlacement()
+	return strings.Join(fields, " ")
+}
+
 // Updates the contents of the current board using the short algebraic
 // description of the move and computes the FEN code of the resulting board. In
 // addition, it returns the move in long algebraic notation and an error, if any
@@ -1110,6 +1380,8 @@ func (board *PgnBoard) UpdateBoard(move PgnMove) (extended longAlgebraic, err er
 		wking:   board.wking,
 		bking:   board.bking,
 		fen:     board.fen,
+		variant: board.variant,
+		hash:    board.hash,
 	}
 
 	if reTextualMove.MatchString(move.shortAlgebraic) {
@@ -1118,14 +1390,13 @@ func (board *PgnBoard) UpdateBoard(move PgnMove) (extended longAlgebraic, err er
 		// board
 		matches := reTextualMove.FindStringSubmatch(move.shortAlgebraic)
 
-		if matches[6] == "O-O" {
-
-			// -- Short castling
-			extended = board.updateShortCastling(move.color)
-		} else if matches[6] == "O-O-O" {
+		if matches[6] == "O-O" || matches[6] == "O-O-O" {
 
-			// -- Long castling
-			extended = board.updateLongCastling(move.color)
+			// -- Castling
+			extended, err = board.updateCastling(move.color, matches[6] == "O-O")
+			if err != nil {
+				return longAlgebraic{}, err
+			}
 		} else {
 
 			// -- Other moves
@@ -1137,9 +1408,17 @@ func (board *PgnBoard) UpdateBoard(move PgnMove) (extended longAlgebraic, err er
 				matches[2],        // qualifier
 				matches[3] == "x") // capture flag
 			if origin < 0 {
-				return longAlgebraic{}, fmt.Errorf("It was not possible to reproduce the move '%v'\n", move)
+				return longAlgebraic{}, fmt.Errorf(" It was not possible to reproduce the move '%v'\n", move)
 			} else {
 
+				// Verify the move is legal according to the variant of this
+				// board
+				target := coords[matches[4]]
+				if err = board.getVariant().ValidateMove(board, origin, target); err != nil {
+					return longAlgebraic{}, err
+				}
+				captured := board.squares[target]
+
 				// First, remove the piece from its origin
 				board.squares[origin] = BLANK
 
@@ -1148,7 +1427,11 @@ func (board *PgnBoard) UpdateBoard(move PgnMove) (extended longAlgebraic, err er
 				if len(matches[5]) > 0 {
 
 					// --Promotion
-					board.squares[coords[matches[4]]] = getPieceValue(getPieceIndex(string(matches[5][1])), move.color)
+					promotion := getPieceValue(getPieceIndex(string(matches[5][1])), move.color)
+					if err = board.getVariant().ValidatePromotion(promotion); err != nil {
+						return longAlgebraic{}, err
+					}
+					board.squares[coords[matches[4]]] = promotion
 				} else {
 
 					// --en passant capture
@@ -1157,6 +1440,7 @@ func (board *PgnBoard) UpdateBoard(move PgnMove) (extended longAlgebraic, err er
 						board.squares[coords[matches[4]]] == BLANK {
 
 						// remove the captured pawn
+						captured = getPieceValue(WPAWN, -move.color)
 						if move.color > 0 {
 							board.squares[coords[matches[4]]-8] = BLANK
 						} else {
@@ -1177,6 +1461,9 @@ func (board *PgnBoard) UpdateBoard(move PgnMove) (extended longAlgebraic, err er
 						}
 					}
 				}
+
+				// and give the variant the chance to apply any side effects
+				board.getVariant().AfterMove(board, origin, target, captured)
 			}
 
 			// Annotate the move in long algebraic notation
@@ -1186,44 +1473,146 @@ func (board *PgnBoard) UpdateBoard(move PgnMove) (extended longAlgebraic, err er
 		return longAlgebraic{}, fmt.Errorf(" '%v' not parsed!\n", move.shortAlgebraic)
 	}
 
-	// Before leaving, update the FEN code of this chessboard
+	// Before leaving, update the FEN code and the hash of this chessboard
 	board.updateFEN(prec, extended)
+	board.updateHash(prec)
 
 	// Otherwise the move was properly executed without error
 	return extended, nil
 }
 
+// Return the rows of a board in the order they are shown in the given style,
+// i.e., from the eighth rank to the first one unless the board is flipped
+func (style BoardStyle) rows() []int {
+	if style.Flipped {
+		return []int{0, 1, 2, 3, 4, 5, 6, 7}
+	}
+	return []int{7, 6, 5, 4, 3, 2, 1, 0}
+}
+
+// Return the columns of a board in the order they are shown in the given
+// style, i.e., from file 'a' to file 'h' unless the board is flipped
+func (style BoardStyle) columns() []int {
+	if style.Flipped {
+		return []int{7, 6, 5, 4, 3, 2, 1, 0}
+	}
+	return []int{0, 1, 2, 3, 4, 5, 6, 7}
+}
+
 // show a graphical view of this chess board
 func (board PgnBoard) String() (output string) {
+	return board.render(BoardStyle{})
+}
 
-	// Use the table package to generate chess boards with utf-8 characters
-	tab, _ := table.NewTable("||cccccccc||")
+// Return the symbol shown in the given square in the given style: the piece in
+// it or, if it is empty, its color
+func (board PgnBoard) symbol(square int, style BoardStyle) string {
 
-	// Show the border of the chess board with a double line
-	tab.AddDoubleRule()
+	row, column := square/8, square%8
+	if piece := board.squares[square]; piece != BLANK {
+		if style.ASCII {
+			return string(asciirepr[piece])
+		}
+		return string(utf8repr[piece])
+	}
 
-	// Add the contents of each row
-	for row := 7; row >= 0; row-- {
+	// When the sum of the row and colum is an even number, the square is
+	// black. ASCII boards show dark squares with dots
+	if (row+column)%2 == 0 {
+		if style.ASCII {
+			return "."
+		}
+		return string("\u2592")
+	}
+	return " "
+}
 
-		// Initialize a line to show the contents of the 8 squares in this row
-		line := make([]any, 8)
-		for column := 0; column < 8; column++ {
+// Return a string with the representation of this board in the given style.
+// If any squares are given (in literal form, e.g., "e4") they are marked with
+// brackets, e.g., to show the origin and destination of the last move
+func (board PgnBoard) render(style BoardStyle, marked ...string) string {
 
-			// when a square is empty show its color.
-			if board.squares[row*8+column] == BLANK {
+	// compute the squares to mark
+	marks := make(map[int]bool)
+	for _, square := range marked {
+		if location, ok := coords[square]; ok {
+			marks[location] = true
+		}
+	}
 
-				// When the sum of the row and colum is an odd number, the square is
-				// black
-				if (row+column)%2 == 0 {
-					line[column] = string("\u2592")
-				} else {
-					line[column] = " "
+	// ASCII boards are drawn directly. Marked squares are surrounded by
+	// brackets which replace the blanks between squares
+	if style.ASCII {
+		margin := ""
+		if style.Coordinates {
+			margin = "  "
+		}
+		var builder strings.Builder
+		builder.WriteString(margin + "+-----------------+\n")
+		for _, row := range style.rows() {
+			if style.Coordinates {
+				builder.WriteString(fmt.Sprintf("%v ", row+1))
+			}
+			builder.WriteString("|")
+			previous := false
+			for _, column := range style.columns() {
+				current := marks[row*8+column]
+				switch {
+				case previous && current:
+					builder.WriteString("|")
+				case previous:
+					builder.WriteString("]")
+				case current:
+					builder.WriteString("[")
+				default:
+					builder.WriteString(" ")
 				}
+				builder.WriteString(board.symbol(row*8+column, style))
+				previous = current
+			}
+			if previous {
+				builder.WriteString("]|\n")
 			} else {
+				builder.WriteString(" |\n")
+			}
+		}
+		builder.WriteString(margin + "+-----------------+")
+		if style.Coordinates {
+			builder.WriteString("\n" + margin + " ")
+			for _, column := range style.columns() {
+				builder.WriteString(" " + string(rune('a'+column)))
+			}
+		}
+		return builder.String()
+	}
 
-				// Otherwise, show the chess piece
-				line[column] = string(utf8repr[board.squares[row*8+column]])
+	// Use the table package to generate chess boards with utf-8 characters. If
+	// coordinates are requested, ranks are shown in an additional column
+	spec := "||cccccccc||"
+	if style.Coordinates {
+		spec = "c" + spec
+	}
+	tab, _ := table.NewTable(spec)
+
+	// Show the border of the chess board with a double line
+	tab.AddDoubleRule()
+
+	// Add the contents of each row
+	for _, row := range style.rows() {
+
+		// Initialize a line to show the contents of the 8 squares in this row
+		line := make([]any, 0, 9)
+		if style.Coordinates {
+			line = append(line, row+1)
+		}
+		for _, column := range style.columns() {
+			symbol := board.symbol(row*8+column, style)
+			if marks[row*8+column] {
+				symbol = "[" + symbol + "]"
+			} else if style.Coordinates {
+				symbol = " " + symbol + " "
 			}
+			line = append(line, symbol)
 		}
 
 		// Add this line
@@ -1232,9 +1621,19 @@ func (board PgnBoard) String() (output string) {
 
 	// Show the bottom border of the chess board with a double line
 	tab.AddDoubleRule()
+	output := fmt.Sprintf("%v", tab)
+
+	// and the files below it if requested. Note that squares are three
+	// characters wide when coordinates are shown
+	if style.Coordinates {
+		output += "\n  "
+		for _, column := range style.columns() {
+			output += " " + string(rune('a'+column)) + " "
+		}
+	}
 
 	// and return the string of this table
-	return fmt.Sprintf("%v", tab)
+	return output
 }
 
 /* Local Variables: */
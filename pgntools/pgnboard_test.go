@@ -0,0 +1,53 @@
+// -*- coding: utf-8 -*-
+// pgnboard_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:28:32.424029101 (1792171712)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdateFENCastingRights(t *testing.T) {
+
+	// castling rights are always given in the order of the initial position,
+	// i.e., white's first and the king side first, as the PGN standard
+	// requires, so that the same position is always written in the same way
+	tests := []struct {
+		name     string
+		movetext string
+		want     string
+	}{
+		{"Back to the initial position", "1. Nf3 Nf6 2. Ng1 Ng8", "KQkq"},
+		{"White king", "1. e4 e5 2. Ke2", "kq"},
+		{"Black king", "1. e4 e5 2. Nf3 Ke7", "KQ"},
+		{"Both kings", "1. e4 e5 2. Ke2 Ke7", "-"},
+		{"White castles", "1. e4 e5 2. Nf3 Nf6 3. Be2 Be7 4. O-O", "kq"},
+		{"Both castle", "1. e4 e5 2. Nf3 Nf6 3. Be2 Be7 4. O-O O-O", "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := ParseGame("[Event \"Test\"]\n\n" + tt.movetext + " *")
+			if err != nil {
+				t.Fatalf("ParseGame() error = %v", err)
+			}
+			if err := game.play(); err != nil {
+				t.Fatalf("play() error = %v", err)
+			}
+			fen := game.boards[len(game.boards)-1].fen
+			if got := strings.Fields(fen)[2]; got != tt.want {
+				t.Errorf("castling rights of %q = %q, want %q", fen, got, tt.want)
+			}
+		})
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,68 @@
+// -*- coding: utf-8 -*-
+// pgnbook.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:22:27.370053040 (1792171347)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// An opening book tells whether a move is known in a position given with its
+// FEN code, where moves are given in UCI notation, e.g., "e2e4", "e1g1" or
+// "e7e8q". Polyglot books read with the subpackage book satisfy this interface
+type PgnBook interface {
+	Contains(fen, move string) bool
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the number of plies of this game which are still in the given book,
+// i.e., the number of its first moves given in the book for the positions
+// where they were played, and any error found. Games are played if necessary
+func (game *PgnGame) BookDepth(book PgnBook) (int, error) {
+
+	if err := game.play(); err != nil {
+		return 0, err
+	}
+	for ply, move := range game.moves {
+		if !book.Contains(game.boards[ply].fen, getUCIMove(move)) {
+			return ply, nil
+		}
+	}
+	return len(game.moves), nil
+}
+
+// Return the book depth of every game in this collection, see BookDepth, along
+// with aggregate statistics, and any error found. Games with more plies than
+// their book depth leave the book with a novelty. Games are played in parallel
+// with the number of workers given WithWorkers, so that the book must be safe
+// for concurrent use, as Polyglot books are
+func (c PgnCollection) BookDepth(book PgnBook, opts ...PgnOption) (*PgnTheoryDepths, error) {
+
+	// Because every worker accesses a different game, no synchronization is
+	// needed
+	options := newPgnOptions(opts...)
+	games := make([]PgnTheoryDepth, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		depth, err := c.slice[idx].BookDepth(book)
+		games[idx] = PgnTheoryDepth{
+			Id:      c.slice[idx].id,
+			Depth:   depth,
+			Novelty: depth < len(c.slice[idx].moves),
+		}
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return newPgnTheoryDepths(games), nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
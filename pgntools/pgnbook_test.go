@@ -0,0 +1,67 @@
+// -*- coding: utf-8 -*-
+// pgnbook_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:22:38.412028253 (1792171358)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/clinaresl/pgnparser/pgntools/book"
+)
+
+func TestPgnGame_BookDepth(t *testing.T) {
+
+	polyglot, err := book.Open(filepath.Join("book", "testdata", "small.bin"))
+	if err != nil {
+		t.Fatalf("book.Open() error = %v", err)
+	}
+
+	tests := []struct {
+		pgn  string
+		want int
+	}{
+		{"[Event \"?\"]\n\n1. e4 e5 2. Nf3 Nc6 *", 3},
+		{"[Event \"?\"]\n\n1. e4 e5 2. Nf3 *", 3},
+		{"[Event \"?\"]\n\n1. d4 d5 *", 1},
+		{"[Event \"?\"]\n\n1. c4 e5 *", 0},
+		{"[SetUp \"1\"]\n[FEN \"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1\"]\n\n1. O-O-O O-O *", 1},
+		{"[SetUp \"1\"]\n[FEN \"8/P7/8/8/8/8/8/k6K w - - 0 1\"]\n\n1. a8=Q+ *", 1},
+		{"[SetUp \"1\"]\n[FEN \"8/P7/8/8/8/8/8/k6K w - - 0 1\"]\n\n1. a8=N *", 0},
+	}
+	c := NewPgnCollection()
+	for _, tt := range tests {
+		game, err := ParseGame(tt.pgn)
+		if err != nil {
+			t.Fatalf("ParseGame(%q) error = %v", tt.pgn, err)
+		}
+		if got, err := game.BookDepth(polyglot); err != nil || got != tt.want {
+			t.Errorf("BookDepth(%q) = (%v, %v), want %v", tt.pgn, got, err, tt.want)
+		}
+		c.Add(*game)
+	}
+
+	// all games but those whose moves are all found in the book have a novelty
+	depths, err := c.BookDepth(polyglot, WithWorkers(4))
+	if err != nil {
+		t.Fatalf("BookDepth() error = %v", err)
+	}
+	if depths.Novelties != 5 || depths.Min != 0 || depths.Max != 3 || depths.Median != 1 {
+		t.Errorf("BookDepth() = %+v", depths)
+	}
+	for idx, game := range depths.Games {
+		if game.Depth != tests[idx].want {
+			t.Errorf("BookDepth() game #%v = %v, want %v", game.Id, game.Depth, tests[idx].want)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,163 @@
+// -*- coding: utf-8 -*-
+// pgncheck.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:37:49.410311920 (1792161469)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Moves in short algebraic notation can be given with a marker of check ('+')
+// or checkmate ('#'). These markers are often wrong in collections of games
+// transcribed by hand or scanned, and they can be verified against the
+// positions computed when playing games. Mismatches can be just reported, or
+// also corrected in different ways
+type CheckMarkers int
+
+// Every move whose marker is not consistent with the position it leads to is
+// described with the game and ply where it was found along with the marker
+// expected
+type PgnCheckMismatch struct {
+	Game     int    // identifier of the game
+	Ply      int    // ply of the move, starting from 1
+	Move     string // move as given in the game
+	Expected string // either "+", "#" or empty
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Mismatches can be only reported (WarnCheckMarkers); or, in addition, the
+// wrong markers can be removed without adding the missing ones
+// (StripCheckMarkers); or all markers can be set according to the position
+// (FixCheckMarkers)
+const (
+	WarnCheckMarkers CheckMarkers = iota
+	StripCheckMarkers
+	FixCheckMarkers
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the marker given at the end of a move in short algebraic notation,
+// either "+", "#" or an empty string if none is given
+func getCheckMarker(move string) string {
+	if strings.HasSuffix(move, "+") || strings.HasSuffix(move, "#") {
+		return move[len(move)-1:]
+	}
+	return ""
+}
+
+// Return the given move in short algebraic notation with the given marker
+// instead of the one it had, if any
+func setCheckMarker(move, marker string) string {
+	return strings.TrimRight(move, "+#") + marker
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a string describing this mismatch
+func (mismatch PgnCheckMismatch) String() string {
+	return fmt.Sprintf(" Game %v, ply %v: '%v' should be '%v'",
+		mismatch.Game, mismatch.Ply, mismatch.Move,
+		setCheckMarker(mismatch.Move, mismatch.Expected))
+}
+
+// Return the marker expected for the move in the given ply (starting from 0) of
+// this game according to the position it leads to. The game must be already
+// played
+func (game *PgnGame) getExpectedMarker(ply int) string {
+
+	// the side checked, if any, is the one to move after this ply
+	board := game.boards[ply+1]
+	color := -game.moves[ply].color
+	if board.isCheckmate(color) {
+		return "#"
+	}
+	if board.InCheck(color) {
+		return "+"
+	}
+	return ""
+}
+
+// Verify that the markers of check and checkmate of all moves of this game are
+// consistent with the positions they lead to, playing the game if necessary.
+// Mismatches are returned in the order they are found and, depending on the
+// given mode, the moves are corrected. It returns an error if the game could
+// not be played
+func (game *PgnGame) VerifyCheckMarkers(mode CheckMarkers) ([]PgnCheckMismatch, error) {
+
+	if err := game.play(); err != nil {
+		return nil, err
+	}
+
+	var mismatches []PgnCheckMismatch
+	for ply := range game.moves {
+
+		// compare the marker given with the expected one
+		move := game.moves[ply].shortAlgebraic
+		expected := game.getExpectedMarker(ply)
+		if getCheckMarker(move) == expected {
+			continue
+		}
+		mismatches = append(mismatches, PgnCheckMismatch{
+			Game:     game.id,
+			Ply:      ply + 1,
+			Move:     move,
+			Expected: expected,
+		})
+
+		// and correct the move if requested. When stripping markers, those
+		// which are missing are not added
+		switch mode {
+		case StripCheckMarkers:
+			game.moves[ply].shortAlgebraic = setCheckMarker(move, "")
+		case FixCheckMarkers:
+			game.moves[ply].shortAlgebraic = setCheckMarker(move, expected)
+		}
+	}
+	return mismatches, nil
+}
+
+// Verify the markers of check and checkmate of all games in this collection
+// with VerifyCheckMarkers and return all mismatches sorted by the position of
+// games in this collection.
+//
+// Games are verified in parallel with the number of workers given WithWorkers,
+// and WithProgress reports the number of games verified so far
+func (c PgnCollection) VerifyCheckMarkers(mode CheckMarkers, opts ...PgnOption) ([]PgnCheckMismatch, error) {
+
+	// Because every worker accesses a different game, no synchronization is
+	// needed
+	options := newPgnOptions(opts...)
+	results := make([][]PgnCheckMismatch, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) (err error) {
+		results[idx], err = c.slice[idx].VerifyCheckMarkers(mode)
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	// and return all mismatches in order
+	var mismatches []PgnCheckMismatch
+	for _, result := range results {
+		mismatches = append(mismatches, result...)
+	}
+	return mismatches, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
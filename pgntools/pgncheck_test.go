@@ -0,0 +1,137 @@
+// -*- coding: utf-8 -*-
+// pgncheck_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:38:04.568740433 (1792161484)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPgnBoard_InCheck(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		pgn       string
+		check     bool
+		checkmate bool
+		stalemate bool
+	}{
+		{"none", "1. e4 e5 2. Nf3 *", false, false, false},
+		{"check", "1. e4 f5 2. Qh5+ *", true, false, false},
+		{"blocked", "1. e4 f5 2. Qh5+ g6 *", false, false, false},
+		{"knight", "1. e4 e5 2. Nf3 Nc6 3. Nxe5 Nd4 4. Nxf7 Nf3+ *", true, false, false},
+		{"mate", "1. f3 e5 2. g4 Qh4# 0-1", true, true, false},
+		{"capture", "1. e4 f5 2. Qh5+ g6 3. Qxg6+ hxg6 *", false, false, false},
+		{"stalemate", "1. e3 a5 2. Qh5 Ra6 3. Qxa5 h5 4. h4 Rah6 5. Qxc7 f6 6. Qxd7+ Kf7 7. Qxb7 Qd3 8. Qxb8 Qh7 9. Qxc8 Kg6 10. Qe6 1/2-1/2", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := ParseGame("[Event \"Check\"]\n\n" + tt.pgn)
+			if err != nil {
+				t.Fatalf("ParseGame() error = %v", err)
+			}
+			if err := game.play(); err != nil {
+				t.Fatalf("play() error = %v", err)
+			}
+			board := game.boards[len(game.boards)-1]
+			color := -game.moves[len(game.moves)-1].color
+			if got := board.InCheck(color); got != tt.check {
+				t.Errorf("InCheck() = %v, want %v", got, tt.check)
+			}
+			if got := board.isCheckmate(color); got != tt.checkmate {
+				t.Errorf("isCheckmate() = %v, want %v", got, tt.checkmate)
+			}
+
+			// the side to move is taken from the FEN code of the board
+			if got := board.IsCheckmate(); got != tt.checkmate {
+				t.Errorf("IsCheckmate() = %v, want %v", got, tt.checkmate)
+			}
+			if got := board.IsStalemate(); got != tt.stalemate {
+				t.Errorf("IsStalemate() = %v, want %v", got, tt.stalemate)
+			}
+
+			// and games are ended by the board only if they are either
+			// checkmated or stalemated
+			if outcome, ended := board.Outcome(color); ended != (tt.checkmate || tt.stalemate) ||
+				(ended && outcome.String() != game.Outcome().String()) {
+				t.Errorf("Outcome() = %v, %v", outcome, ended)
+			}
+		})
+	}
+}
+
+func TestPgnGame_VerifyCheckMarkers(t *testing.T) {
+
+	// the check given with the second move of White is missing and its third
+	// move is wrongly marked as a check
+	pgn := "[Event \"Markers\"]\n\n1. e4 f5 2. Qh5 g6 3. Qe2+ e5 4. f3 Qh4+ 5. g3 Qxg3+ 6. hxg3 *"
+	tests := []struct {
+		name  string
+		mode  CheckMarkers
+		moves []string
+	}{
+		{"warn", WarnCheckMarkers, []string{"Qh5", "Qe2+", "Qh4+", "Qxg3+"}},
+		{"strip", StripCheckMarkers, []string{"Qh5", "Qe2", "Qh4+", "Qxg3+"}},
+		{"fix", FixCheckMarkers, []string{"Qh5+", "Qe2", "Qh4+", "Qxg3+"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := ParseGame(pgn)
+			if err != nil {
+				t.Fatalf("ParseGame() error = %v", err)
+			}
+			mismatches, err := game.VerifyCheckMarkers(tt.mode)
+			if err != nil {
+				t.Fatalf("VerifyCheckMarkers() error = %v", err)
+			}
+			var plies []int
+			for _, mismatch := range mismatches {
+				plies = append(plies, mismatch.Ply)
+			}
+			if want := []int{3, 5}; !slices.Equal(plies, want) {
+				t.Errorf("VerifyCheckMarkers() plies = %v, want %v", plies, want)
+			}
+			moves := []string{game.moves[2].shortAlgebraic, game.moves[4].shortAlgebraic,
+				game.moves[7].shortAlgebraic, game.moves[9].shortAlgebraic}
+			if !slices.Equal(moves, tt.moves) {
+				t.Errorf("VerifyCheckMarkers() moves = %v, want %v", moves, tt.moves)
+			}
+		})
+	}
+}
+
+func TestPgnGame_GetPGNWithCheckMarkers(t *testing.T) {
+
+	game, err := ParseGame("[Event \"Markers\"]\n\n1. e4 f5 2. Qh5 g6 3. Qe2+ e5 4. f3 Qh4 *")
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	// markers are not added to games which are not realized
+	options := newPgnOptions(WithCheckMarkers())
+	if got, want := game.getPGNMoves(options), "1. e4 f5 2. Qh5 g6 3. Qe2+ e5 4. f3 Qh4 "; got != want {
+		t.Errorf("getPGNMoves() = %q, want %q", got, want)
+	}
+
+	// and otherwise only the missing ones are added
+	if err := game.play(); err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+	if got, want := game.getPGNMoves(options), "1. e4 f5 2. Qh5+ g6 3. Qe2+ e5 4. f3 Qh4+ "; got != want {
+		t.Errorf("getPGNMoves() = %q, want %q", got, want)
+	}
+	if game.moves[2].shortAlgebraic != "Qh5" {
+		t.Errorf("getPGNMoves() modified the move '%v'", game.moves[2].shortAlgebraic)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -20,12 +20,16 @@
 package pgntools
 
 import (
+	"bytes"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"log"
+	"os"
 	"path"
 	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/clinaresl/pgnparser/metatemplate"
 	"github.com/clinaresl/table"
@@ -49,10 +53,58 @@ type pgnSorting struct {
 // So that a sorting criteria consists of a sequence of pgnSorting pairs
 type criteriaSorting []pgnSorting
 
-// A PgnCollection consists of an arbitrary number of PgnGames
+// A PgnCollection consists of an arbitrary number of PgnGames. Collections can
+// be split in chunks for processing them in parallel. In this case, every
+// chunk knows its index and the overall number of chunks so that templates can
+// decide what to write at the beginning and the end of the whole output. A
+// collection which is not a chunk has no chunks at all. Chunks also know the
+// games right before and after them, so that groups of games spanning several
+// chunks can be detected.
+//
+// Collections read from PGN files also store diagnostics of all text that
+// could not be parsed and the statistics of parsing the file.
+//
+// Collections are responsible for giving ids to their games. Games added
+// without an id are given the next one available, starting from the id base
+// of the collection (1 by default), whereas games that already have an id keep
+// it. As a result, ids are stable across filters and sorts so that
+// cross-references in generated documents are consistent, unless the games of
+// a collection are explicitly renumbered
 type PgnCollection struct {
-	slice   []PgnGame
-	nbGames int
+	slice       []PgnGame
+	nbGames     int
+	chunk       int
+	nbChunks    int
+	prev, next  *PgnGame // games right before and after this chunk, if any
+	diagnostics []PgnDiagnostic
+	idBase      int // id of the first game, 1 if zero
+	lastId      int // largest id of all games in this collection
+	stats       PgnParseStats
+}
+
+// Positions are counted by their FEN code ignoring the halfmove clock and the
+// fullmove number, so that the same position reached at different moments is
+// considered only once
+type PgnPositionCount struct {
+	FEN   string // first four fields of the FEN code of the position
+	Count int    // number of games where the position was found
+}
+
+// Positions of all games in a collection can be iterated along with the move
+// played in each one and the outcome of the game
+type PgnPosition struct {
+	Game    int        // id of the game
+	Ply     int        // number of plies played to reach the position
+	Board   *PgnBoard  // position reached
+	Move    PgnMove    // move played in the position
+	Outcome PgnOutcome // outcome of the game
+}
+
+// The result of executing a template over a chunk consists of the output
+// generated and any error found
+type chunkResult struct {
+	contents bytes.Buffer
+	err      error
 }
 
 // consts
@@ -64,6 +116,10 @@ const (
 	decreasing                              // decreasing order
 )
 
+// Meta-variables in templates can be given values with environment variables
+// named after them with the following prefix, e.g., PGNPARSER_title
+const templateEnvPrefix = "PGNPARSER_"
+
 // Methods
 // ----------------------------------------------------------------------------
 
@@ -88,6 +144,30 @@ func (games PgnCollection) Len() int {
 	return games.nbGames
 }
 
+// Return the diagnostics of all text that could not be parsed when reading the
+// games of this collection
+func (games PgnCollection) Diagnostics() []PgnDiagnostic {
+	return games.diagnostics
+}
+
+// Return the statistics of parsing the PGN file this collection was read from.
+// Collections which were not read from a file have no statistics
+func (games PgnCollection) ParseStats() PgnParseStats {
+	return games.stats
+}
+
+// Return true if this collection is either the first chunk of a larger
+// collection or it is not a chunk at all
+func (games PgnCollection) IsFirstChunk() bool {
+	return games.chunk == 0
+}
+
+// Return true if this collection is either the last chunk of a larger
+// collection or it is not a chunk at all
+func (games PgnCollection) IsLastChunk() bool {
+	return games.chunk >= games.nbChunks-1
+}
+
 // Methods
 // ----------------------------------------------------------------------------
 
@@ -96,14 +176,61 @@ func NewPgnCollection() PgnCollection {
 	return PgnCollection{}
 }
 
-// Add the given PgnGame to this collection
+// Return the id given to the first game of this collection when renumbering
+func (c PgnCollection) firstId() int {
+	if c.idBase > 0 {
+		return c.idBase
+	}
+	return 1
+}
+
+// Set the id of the first game of this collection when renumbering it. It is
+// also the id given to the first game added to an empty collection. Because
+// ids are strictly positive, values smaller than one are taken as one
+func (c *PgnCollection) SetIdBase(base int) {
+	c.idBase = max(base, 1)
+}
+
+// Give consecutive ids to all games in this collection in their current order
+// starting from its id base
+func (c *PgnCollection) Renumber() {
+	for idx := range c.slice {
+		c.slice[idx].id = c.firstId() + idx
+	}
+	c.lastId = c.firstId() + len(c.slice) - 1
+}
+
+// Add the given PgnGame to this collection. If the game has no id yet, it is
+// given the next one available in this collection
 func (c *PgnCollection) Add(game PgnGame) {
 
+	// Assign an id to this game if necessary, and remember the largest one
+	if game.id <= 0 {
+		game.id = max(c.lastId+1, c.firstId())
+	}
+	c.lastId = max(c.lastId, game.id)
+
 	// Add this game to the slice of games and increment the counter
 	c.slice = append(c.slice, game)
 	c.nbGames += 1
 }
 
+// Validate all games in this collection with Validate and return all errors
+// found sorted by the position of games in this collection. An error is
+// returned if the initial board of any game could not be computed
+func (c PgnCollection) Validate() ([]*ErrIllegalMove, error) {
+
+	var errs []*ErrIllegalMove
+	for idx := range c.slice {
+		found, err := c.slice[idx].Validate()
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, found...)
+	}
+	return errs, nil
+}
+
 // Play this collection of games on the given writer showing the board
 // repeteadly after the given number of plies on the specified writer, in case
 // it is strictly positive.
@@ -113,141 +240,144 @@ func (c *PgnCollection) Add(game PgnGame) {
 // are computed and stored within each game. If this service is not used, the
 // long algebraic notation of every move is empty, and no boards are recorded.
 //
+// Games are played in parallel with the number of workers given WithWorkers,
+// and WithProgress reports the number of games played so far. Boards are shown
+// in the style given WithBoardStyle, always with the coordinates of files and
+// ranks around them and with the origin and destination squares of the last
+// move shown between brackets. WithBlunders qualifies the decisive mistake of
+// every game with "??" and shows the better line after it, if any.
+//
 // In case any error is detected it is returned and the state of the writer is
 // undefined
-func (c PgnCollection) Play(plies int, writer io.Writer) error {
+func (c PgnCollection) Play(plies int, writer io.Writer, opts ...PgnOption) error {
+
+	// First, play all games. Because every worker accesses a different game,
+	// no synchronization is needed
+	options := newPgnOptions(opts...)
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		return c.slice[idx].play()
+	}); err != nil {
+		return err
+	}
 
 	// the table has to be shown if an only if plies is greater than zero
-	showBoard := (plies > 0)
-
-	// if the board is not going to be shown, make the number of plies to be
-	// equal to one so that the game is played anyway
-	if !showBoard {
-		plies = 1
+	if plies <= 0 {
+		return nil
 	}
 
+	// boards are always shown with their coordinates, and the origin and
+	// destination of the last move are marked
+	style := options.boardStyle
+	style.Coordinates = true
+
 	// use tables to show the execution of chess games
 	tab, _ := table.NewTable(" l c", "cc")
-	if showBoard {
-		tab.AddThickRule()
-	}
+	tab.AddThickRule()
 
 	// For each game
-	for pos, igame := range c.slice {
-
-		// Only in case the board is to be shown, create a table, otherwise,
-		// skip the process
-		if showBoard {
-
-			// Create a nested table to show the tags of this game
-			tab_tags, _ := table.NewTable(" l : l")
-			for name, value := range igame.Tags() {
-				tab_tags.AddRow(name, value)
-			}
+	for _, igame := range c.slice {
 
-			// The tags are shown in a single column containing the table of tags
-			// centered
-			tab.AddRow(table.Multicolumn(2, "c", tab_tags))
-			tab.AddSingleRule()
+		// Create a nested table to show the tags of this game in the same
+		// order they are written in PGN format
+		tab_tags, _ := table.NewTable(" l : l")
+		for _, name := range getTagNames(igame.tags) {
+			tab_tags.AddRow(name, igame.tags[name])
 		}
 
-		// Create a new board and add it to the list of boards of this game
-		board := NewPgnBoard()
-		igame.boards = append(igame.boards, board)
-
-		// Access the list of moves and boards to update
-		imoves := igame.Moves()
+		// The tags are shown in a single column containing the table of tags
+		// centered
+		tab.AddRow(table.Multicolumn(2, "c", tab_tags))
+		tab.AddSingleRule()
 
 		// and now show the requested number of plies along with the resulting
-		// chess board
-		idx := 0
-		for idx < len(imoves)/plies {
-
-			// compute the resulting board
-			for jdx := idx * plies; jdx < (idx+1)*plies; jdx += 1 {
-				extended, err := board.UpdateBoard(imoves[jdx])
-				if err != nil {
-					return err
-				}
-
-				// Update this move in long algebraic notation and also the
-				// board
-				igame.boards = append(igame.boards, board)
-				imoves[idx].longAlgebraic = extended
-			}
-
-			if showBoard {
-
-				// add a new row with the list of moves in vertical mode and the
-				// updated board
-				tab.AddRow(igame.prettyMoves((idx*plies), (idx+1)*plies), board)
+		// chess board. Note that the first board of every game is the initial
+		// one
+		nbmoves := len(igame.moves)
+		blunder := -1
+		if options.blunders {
+			blunder = igame.LosingBlunder()
+		}
+		for from := 0; from < nbmoves; from += plies {
+			to := min(from+plies, nbmoves)
+
+			// add a new row with the list of moves in vertical mode and the
+			// updated board
+			last := igame.moves[to-1].longAlgebraic
+			tab.AddRow(igame.prettyMoves(from, to, blunder), igame.boards[to].render(style, last.from, last.to))
+			if to < nbmoves {
 				tab.AddRow()
 			}
-
-			// and move forward
-			idx += 1
 		}
 
-		// in case there are still moves to show
-		if idx*plies < len(imoves) {
-
-			// update the board with those additional moves
-			for jdx := idx * plies; jdx < len(imoves); jdx += 1 {
-				extended, err := board.UpdateBoard(imoves[jdx])
-				if err != nil {
-					return err
-				}
+		// and add a separator with the next game
+		tab.AddThickRule()
+	}
 
-				// Update this move in long algebraic notation and also the
-				// board
-				igame.boards = append(igame.boards, board)
-				imoves[idx].longAlgebraic = extended
-			}
+	// and write the result of the execution in the given writer
+	_, err := io.WriteString(writer, fmt.Sprintf("%v\n", tab))
+	return err
+}
 
-			if showBoard {
+// Create a brand new PgnCollection with games found in this collection which
+// satisfy the given expression.
+//
+// Filtering is performed in two stages. First, all conjuncts of the expression
+// that do not require the boards of games (e.g., those using only tags) are
+// evaluated over all games. Next, the conjuncts that require boards (e.g.,
+// FEN) are evaluated only over the games that survived the first stage, which
+// are played if necessary. As a result, selective queries avoid most of the
+// work of playing games.
+//
+// Games are evaluated in parallel with the number of workers given
+// WithWorkers, and WithProgress reports the number of games evaluated so far
+// in every stage
+func (c PgnCollection) Filter(expression string, opts ...PgnOption) (*PgnCollection, error) {
+
+	// Initially, all games are selected
+	selected := make([]bool, len(c.slice))
+	for idx := range selected {
+		selected[idx] = true
+	}
 
-				// and add the last row
-				tab.AddRow(igame.prettyMoves(idx*plies, len(imoves)), board)
-			}
+	// and then they are filtered in two stages. Because every worker accesses
+	// a different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	cheap, board := splitFilter(expression)
+	for stage, iexpression := range []string{cheap, board} {
+		if iexpression == "" {
+			continue
 		}
+		if err := options.forEach(len(c.slice), func(idx int) error {
 
-		if showBoard {
+			// games discarded in a previous stage are not considered
+			if !selected[idx] {
+				return nil
+			}
 
-			// and add a separator with the next game
-			tab.AddThickRule()
+			// in the second stage, games must be played before evaluating
+			// the expression
+			if stage == 1 {
+				if err := c.slice[idx].play(); err != nil {
+					return err
+				}
+			}
+			result, err := c.slice[idx].Filter(iexpression)
+			selected[idx] = result
+			return err
+		}); err != nil {
+			return nil, err
 		}
-
-		// and update the information of this game
-		c.slice[pos] = igame
 	}
 
-	// and write the result of the execution in the given writer only in case it
-	// has been requested
-	if showBoard {
-		io.WriteString(writer, fmt.Sprintf("%v\n", tab))
-	}
-
-	return nil
-}
-
-// Create a brand new PgnCollection with games found in this collection which
-// satisfy the given expression
-func (c PgnCollection) Filter(expression string) (*PgnCollection, error) {
-
-	// Create an empty collection of chess games
+	// Create an empty collection of chess games with the same id base, so
+	// that games keep their ids
 	collection := NewPgnCollection()
+	collection.idBase = c.idBase
 
-	// Process each game in this collection
-	for _, igame := range c.slice {
-
-		// In case this game satisfies the given query, then add it to the
-		// filtered collection
-		if result, err := igame.Filter(expression); err != nil {
-			return nil, err
-		} else {
-			if result {
-				collection.Add(igame)
-			}
+	// and add all games that satisfied both stages
+	for idx, igame := range c.slice {
+		if selected[idx] {
+			collection.Add(igame)
 		}
 	}
 
@@ -256,12 +386,17 @@ func (c PgnCollection) Filter(expression string) (*PgnCollection, error) {
 }
 
 // Write all games in this collection in the specified io.Writer in PGN format.
-// In case it was not possible it returns an error and nil otherwise
-func (c PgnCollection) GetPGN(writer io.Writer) error {
-
-	// get the contents of each game in PGN format
+// Comments are folded as requested WithCommentFolding and re-wrapped
+// WithCommentWidth, the movetext is wrapped WithLineWidth and
+// WithCommentLines, and commands are removed WithoutCommands, see
+// PgnGame.GetPGN. In case it was not possible it returns an error and nil
+// otherwise
+func (c PgnCollection) GetPGN(writer io.Writer, opts ...PgnOption) error {
+
+	// write each game in PGN format
+	pgnWriter := NewPgnWriter(writer, opts...)
 	for _, igame := range c.slice {
-		if _, err := io.WriteString(writer, igame.GetPGN()); err != nil {
+		if err := pgnWriter.Write(igame); err != nil {
 			return err
 		}
 	}
@@ -270,10 +405,46 @@ func (c PgnCollection) GetPGN(writer io.Writer) error {
 	return nil
 }
 
+// Write in the specified io.Writer a compact list of all games in this
+// collection with one line per game in the format used by many GUIs:
+//
+//	White - Black, Result, Event Round, Date, ECO, Moves
+//
+// All fields are aligned in columns to make the list easier to read and grep.
+// Tags which are not defined in a game are shown as '?'. It returns any error
+// found while writing or nil otherwise
+func (c PgnCollection) GetGamesList(writer io.Writer) error {
+
+	// Create a table without rules where every field is separated as shown
+	// above
+	tab, err := table.NewTable("l - l, c, l l, l, l, r")
+	if err != nil {
+		return err
+	}
+
+	// and add a row per game
+	for _, igame := range c.slice {
+		tab.AddRow(igame.getTag("White"), igame.getTag("Black"),
+			igame.outcome.String(),
+			igame.getTag("Event"), igame.getTag("Round"),
+			igame.getTag("Date"), igame.getTag("ECO"),
+			igame.fullMoves())
+	}
+
+	// and write the table in the given writer
+	_, err = io.WriteString(writer, fmt.Sprintf("%v", tab))
+	return err
+}
+
 // Return a histogram defined with the given specification criteria computed
 // over all games in this collection. It returns any error found or nil in case
-// the histogram was successfully computed
-func (c PgnCollection) GetHistogram(spec string) (*PgnHistogram, error) {
+// the histogram was successfully computed.
+//
+// The criteria of the histogram are evaluated in parallel with the number of
+// workers given WithWorkers, and WithProgress reports the number of games
+// evaluated so far. Games are scored from the perspective given
+// WithPerspective, see SetPerspective
+func (c PgnCollection) GetHistogram(spec string, opts ...PgnOption) (*PgnHistogram, error) {
 
 	// Create a new GetHistogram
 	histogram, err := NewPgnHistogram(spec)
@@ -281,12 +452,24 @@ func (c PgnCollection) GetHistogram(spec string) (*PgnHistogram, error) {
 		return nil, err
 	}
 
+	// evaluate the criteria of the histogram over all games in this
+	// collection. Because every worker accesses a different game, no
+	// synchronization is needed
+	options := newPgnOptions(opts...)
+	histogram.SetPerspective(options.perspective)
+	results := make([][]string, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		var err error
+		results[idx], err = histogram.getResults(c.slice[idx])
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
 	// and update the histogram with the information of all games in this
-	// collection
-	for _, igame := range c.slice {
-		if err := histogram.Add(igame); err != nil {
-			return nil, err
-		}
+	// collection in the same order
+	for idx, igame := range c.slice {
+		histogram.addResults(results[idx], igame)
 	}
 
 	// and return the histogram computed so far
@@ -300,8 +483,53 @@ func (c PgnCollection) GetHistogram(spec string) (*PgnHistogram, error) {
 // sorted according to the value of the variable or the result of the evaluation
 // of the bool expr
 //
+// Sorting criteria are evaluated in parallel with the number of workers given
+// WithWorkers, and WithProgress reports the number of games evaluated so far.
+//
 // The result is returned in a brand new collection of Pgn games
-func (c *PgnCollection) Sort(spec string) (*PgnCollection, error) {
+func (c *PgnCollection) Sort(spec string, opts ...PgnOption) (*PgnCollection, error) {
+
+	// parse the given specification string
+	criteria, err := getSortingCriteria(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Evaluate the sorting criteria over all games only once. Because every
+	// worker accesses a different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	keys := make([][]string, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		var err error
+		keys[idx], err = c.slice[idx].sortingKeys(criteria)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf(" Error while sorting games: '%v'\n", err)
+	}
+
+	// Now, sort the indexes of all games in this collection according to their
+	// keys, and then arrange games in the same order
+	order := make([]int, len(c.slice))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return lessKeys(keys[order[i]], keys[order[j]], criteria)
+	})
+	sorted := make([]PgnGame, len(c.slice))
+	for idx, jdx := range order {
+		sorted[idx] = c.slice[jdx]
+	}
+	c.slice = sorted
+
+	return c, nil
+}
+
+// Return the sorting criteria given in the specification string spec, i.e., a
+// semicolon separated list of pairs direction/criteria, where the direction is
+// either '<' (ascending) or '>' (descending) and the criteria is either a
+// variable or a bool expression
+func getSortingCriteria(spec string) (criteriaSorting, error) {
 
 	// parse the given specification string. First, distinguish the different
 	// parts and get the sorting direction and criteria (either a variable or a
@@ -345,16 +573,121 @@ func (c *PgnCollection) Sort(spec string) (*PgnCollection, error) {
 		}
 	}
 
-	// Now, sort the slice of games in this collection
-	sort.SliceStable(c.slice, func(i, j int) bool {
-		result, err := c.GetGame(i).lessGame(c.GetGame(j), criteria)
-		if err != nil {
-			log.Fatalf(" Error while sorting games: '%v'\n", err)
+	return criteria, nil
+}
+
+// Return the number of distinct positions found in all games of this collection
+// and the most frequent ones (at most top of them) sorted in decreasing order
+// of the number of games where they were found. Ties are broken in
+// lexicographic order of their FEN codes. Positions are identified by a hash of
+// the first four fields of their FEN code, and every position is counted at
+// most once per game.
+//
+// Only the boards of games that have been played are considered, see Play
+func (c PgnCollection) UniquePositions(top int) (int, []PgnPositionCount) {
+
+	// count the number of games where every position is found. Along with
+	// every hash value, the FEN code of the first position seen is stored
+	counts := make(map[uint64]*PgnPositionCount)
+	for _, igame := range c.slice {
+
+		// positions are counted only once per game
+		seen := make(map[uint64]bool)
+		for _, iboard := range igame.boards {
+
+			// compute the hash of the relevant fields of the FEN code
+			key := strings.Join(strings.Fields(iboard.fen)[:4], " ")
+			hasher := fnv.New64a()
+			hasher.Write([]byte(key))
+			hash := hasher.Sum64()
+
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+
+			// and update the count of this position
+			if position, ok := counts[hash]; ok {
+				position.Count += 1
+			} else {
+				counts[hash] = &PgnPositionCount{FEN: key, Count: 1}
+			}
 		}
-		return result
+	}
+
+	// sort all positions in decreasing order of the number of games and return
+	// only the most frequent ones
+	positions := make([]PgnPositionCount, 0, len(counts))
+	for _, position := range counts {
+		positions = append(positions, *position)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Count != positions[j].Count {
+			return positions[i].Count > positions[j].Count
+		}
+		return positions[i].FEN < positions[j].FEN
 	})
+	return len(counts), positions[:min(max(top, 0), len(positions))]
+}
 
-	return c, nil
+// Invoke the given function with every position of all games in this
+// collection, along with the move played in it and the outcome of the game, up
+// to the given number of plies (all of them if it is negative). Positions are
+// iterated in ply-major order, i.e., first the initial position of every game
+// in the order they are found in this collection, then the positions after the
+// first ply, and so on, which is the order needed to build opening explorers
+// or opening books.
+//
+// Games are played while iterating, so that only the current board of every
+// game is kept in memory, and the boards of games are neither used nor
+// modified. Therefore, the board given to the function is valid only during
+// its invocation and it must not be modified. Iteration stops as soon as the
+// function returns an error, which is returned. It also returns an error if a
+// move is illegal
+func (c PgnCollection) Positions(depth int, fn func(position PgnPosition) error) error {
+
+	// create the initial board of every game
+	boards := make([]PgnBoard, len(c.slice))
+	for idx := range c.slice {
+		board, err := c.slice[idx].initialBoard()
+		if err != nil {
+			return err
+		}
+		boards[idx] = board
+	}
+
+	// and play all games simultaneously, one ply at a time, as long as any
+	// game has moves left
+	for ply, active := 0, true; active && (depth < 0 || ply < depth); ply++ {
+		active = false
+		for idx := range c.slice {
+			igame := &c.slice[idx]
+			if ply >= len(igame.moves) {
+				continue
+			}
+			active = true
+			if err := fn(PgnPosition{
+				Game:    igame.id,
+				Ply:     ply,
+				Board:   &boards[idx],
+				Move:    igame.moves[ply],
+				Outcome: igame.outcome,
+			}); err != nil {
+				return err
+			}
+			if _, err := boards[idx].UpdateBoard(igame.moves[ply]); err != nil {
+				return &ErrIllegalMove{
+					Game:        igame.id,
+					Ply:         ply + 1,
+					Move:        igame.moves[ply].shortAlgebraic,
+					FEN:         boards[idx].FEN(),
+					Err:         err,
+					Suggestions: suggestMoves(boards[idx].FEN(), boards[idx].variant, igame.moves[ply].shortAlgebraic),
+				}
+			}
+		}
+	}
+	return nil
 }
 
 // Templates
@@ -420,28 +753,229 @@ func (games *PgnCollection) GetTable(specline string, fields []any) table.Table
 	return *table
 }
 
-// Writes into the specified writer the result of instantiating the given
-// template file with information of all games in this collection. The template
-// acknowledges all tags of a pgngame plus others. For a full description, see
-// the manual.
-func (games *PgnCollection) GamesToWriterFromTemplate(dst io.Writer, templateFile string) {
+// Return the chunks of this collection, each one with the given number of games
+// at most. The games are not copied, so that the chunks share them with this
+// collection
+func (games *PgnCollection) Chunks(size int) []PgnCollection {
+
+	// chunks are guaranteed to contain at least one game each
+	if size <= 0 {
+		size = 1
+	}
+
+	// compute the number of chunks and create them
+	nbChunks := (games.nbGames + size - 1) / size
+	chunks := make([]PgnCollection, 0, nbChunks)
+	for idx := 0; idx < nbChunks; idx++ {
+
+		// the last chunk might contain fewer games than the others
+		end := min((idx+1)*size, games.nbGames)
+		chunk := PgnCollection{
+			slice:    games.slice[idx*size : end],
+			nbGames:  end - idx*size,
+			chunk:    idx,
+			nbChunks: nbChunks,
+		}
+
+		// and remember the games right before and after it
+		if idx > 0 {
+			chunk.prev = &games.slice[idx*size-1]
+		}
+		if end < games.nbGames {
+			chunk.next = &games.slice[end]
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
 
-	// create a dictionary of meta-variables
-	variables := make(map[string]string)
+// Return the template stored in the given file after substituting all its
+// meta-variables, and nil if no error was found. Meta-variables take the values
+// given in the options, or in environment variables named after them with the
+// prefix "PGNPARSER_"
+func parseTemplate(templateFile string, options pgnOptions) (*metatemplate.MetaTemplate, error) {
 
 	// access a template and parse its contents
-	tpl, err := metatemplate.New(path.Base(templateFile)).Funcs(metatemplate.FuncMap{
+	return metatemplate.New(path.Base(templateFile)).Funcs(metatemplate.FuncMap{
 		"getSlice": func(fields ...interface{}) []interface{} {
 			return fields
 		},
-	}).ParseFiles(variables, templateFile)
+		"diagramStyle": ParseDiagramStyle,
+	}).ParseFilesFrom(metatemplate.Sources{
+		Flags:     options.templateVars,
+		EnvPrefix: templateEnvPrefix,
+	}, templateFile)
+}
+
+// Execute the given template over all chunks using the given number of jobs
+// simultaneously and the render context given in the options. It returns a
+// slice of channels, one per chunk, where the result of each execution is
+// written as soon as it is available
+func executeChunks(tpl *metatemplate.MetaTemplate, chunks []PgnCollection, jobs int, options pgnOptions) []chan *chunkResult {
+
+	// at least one job is necessary
+	if jobs <= 0 {
+		jobs = 1
+	}
+
+	// create one channel per chunk to deliver its results
+	results := make([]chan *chunkResult, len(chunks))
+	for idx := range results {
+		results[idx] = make(chan *chunkResult, 1)
+	}
+
+	// feed all workers with the index of every chunk in order, so that the
+	// first chunks are the first to be processed
+	indexes := make(chan int)
+	go func() {
+		for idx := range chunks {
+			indexes <- idx
+		}
+		close(indexes)
+	}()
+
+	// and start all workers. Templates can be safely executed in parallel as
+	// long as each execution writes into a different writer
+	for ijob := 0; ijob < jobs; ijob++ {
+		go func() {
+			for idx := range indexes {
+				result := chunkResult{}
+				chunk := chunks[idx].SelectLanguage(options.language)
+				if options.blunders {
+					chunk = chunk.MarkBlunders()
+				}
+				result.err = tpl.Execute(&result.contents, newTemplateData(&chunk, options))
+				results[idx] <- &result
+			}
+		}()
+	}
+
+	return results
+}
+
+// Writes into the specified writer the result of instantiating the given
+// template file with information of all games in this collection. The
+// collection is split in chunks with the given number of games which are
+// processed in parallel by the given number of jobs. The output of every chunk
+// is written in the same order the games are found in this collection. Because
+// the template is executed once per chunk, it should use IsFirstChunk and
+// IsLastChunk to write the contents that have to appear only once, e.g., the
+// preamble of a LaTeX document. It returns nil if no error was found
+func (games *PgnCollection) GamesToWriterFromTemplateParallel(dst io.Writer, templateFile string, chunkSize, jobs int, opts ...PgnOption) error {
+
+	// access a template and parse its contents
+	options := newPgnOptions(opts...)
+	tpl, err := parseTemplate(templateFile, options)
+	if err != nil {
+		return err
+	}
+
+	// execute the template over all chunks and write the results in order.
+	// Once an error is found, the remaining results are still consumed so that
+	// no worker is blocked, but they are not written
+	var result error
+	for _, ichunk := range executeChunks(tpl, games.Chunks(chunkSize), jobs, options) {
+		output := <-ichunk
+		if result != nil {
+			continue
+		}
+		if output.err != nil {
+			result = output.err
+		} else if _, err := output.contents.WriteTo(dst); err != nil {
+			result = err
+		}
+	}
+
+	return result
+}
+
+// Writes a different file for every chunk of this collection with the given
+// number of games, with the result of instantiating the given template file.
+// All chunks are processed in parallel by the given number of jobs. By default,
+// files are named after the given filename adding the index of each chunk
+// right before its extension, e.g., "output-0.tex", "output-1.tex", ... unless
+// a different pattern is given with WithFileNames. Every file is
+// generated as if it contained a whole collection, so that IsFirstChunk and
+// IsLastChunk are always true. It returns the names of all files generated and
+// nil if no error was found
+func (games *PgnCollection) GamesToFilesFromTemplate(filename, templateFile string, chunkSize, jobs int, opts ...PgnOption) ([]string, error) {
 
+	// access a template and parse its contents
+	options := newPgnOptions(opts...)
+	tpl, err := parseTemplate(templateFile, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// files are named after the index of every chunk unless a different
+	// pattern is given, which is instantiated with the first game of each
+	// chunk
+	namer, err := options.fileNamer(filename, "{Base}-{Index}")
+	if err != nil {
+		return nil, err
+	}
+
+	// Because every file is written separately, each chunk is processed as if
+	// it were a whole collection, i.e., being both the first and the last
+	// chunk and with no games before or after it
+	chunks := games.Chunks(chunkSize)
+	for idx := range chunks {
+		chunks[idx].chunk, chunks[idx].nbChunks = 0, 0
+		chunks[idx].prev, chunks[idx].next = nil, nil
+	}
+
+	// execute the template over all chunks and write each result in a
+	// different file. As in the ordered case, all results are consumed even
+	// after an error is found
+	var result error
+	filenames := make([]string, 0)
+	for idx, ichunk := range executeChunks(tpl, chunks, jobs, options) {
+		output := <-ichunk
+		if result != nil {
+			continue
+		}
+		if output.err != nil {
+			result = output.err
+			continue
+		}
+
+		// create the file for this chunk and write its contents
+		var first *PgnGame
+		if chunks[idx].Len() > 0 {
+			first = &chunks[idx].slice[0]
+		}
+		name := namer.Name(first, idx)
+		if err := os.WriteFile(name, output.contents.Bytes(), 0644); err != nil {
+			result = err
+			continue
+		}
+		filenames = append(filenames, name)
+	}
+
+	return filenames, result
+}
+
+// Writes into the specified writer the result of instantiating the given
+// template file with information of all games in this collection. The template
+// acknowledges all tags of a pgngame plus others. For a full description, see
+// the manual.
+func (games *PgnCollection) GamesToWriterFromTemplate(dst io.Writer, templateFile string, opts ...PgnOption) {
+
+	// access a template and parse its contents
+	options := newPgnOptions(opts...)
+	tpl, err := parseTemplate(templateFile, options)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// and now execute the template
-	err = tpl.Execute(dst, games)
+	// and now execute the template with the comments in the requested
+	// language and the decisive mistakes marked, if requested
+	chunk := games.SelectLanguage(options.language)
+	if options.blunders {
+		chunk = chunk.MarkBlunders()
+	}
+	err = tpl.Execute(dst, newTemplateData(&chunk, options))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,452 @@
+// -*- coding: utf-8 -*-
+// pgncollection_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:04:30.673377403 (1792159470)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Return the ids of all games in the given collection
+func collectionIds(c PgnCollection) []int {
+	ids := make([]int, 0, c.Len())
+	for _, igame := range c.slice {
+		ids = append(ids, igame.id)
+	}
+	return ids
+}
+
+func TestPgnCollection_Ids(t *testing.T) {
+
+	// games added without an id are numbered consecutively from the id base
+	c := NewPgnCollection()
+	c.SetIdBase(10)
+	for range 3 {
+		c.Add(PgnGame{})
+	}
+	if ids := collectionIds(c); !slices.Equal(ids, []int{10, 11, 12}) {
+		t.Fatalf("Add() ids = %v, want [10 11 12]", ids)
+	}
+
+	// games with an id keep it, and the following ones are numbered after the
+	// largest id
+	c.Add(PgnGame{id: 20})
+	c.Add(PgnGame{})
+	if ids := collectionIds(c); !slices.Equal(ids, []int{10, 11, 12, 20, 21}) {
+		t.Fatalf("Add() ids = %v, want [10 11 12 20 21]", ids)
+	}
+
+	// ids are stable when games are moved to another collection
+	other := NewPgnCollection()
+	other.Add(c.slice[3])
+	other.Add(c.slice[1])
+	if ids := collectionIds(other); !slices.Equal(ids, []int{20, 11}) {
+		t.Fatalf("Add() ids = %v, want [20 11]", ids)
+	}
+
+	// until they are renumbered
+	other.Renumber()
+	other.Add(PgnGame{})
+	if ids := collectionIds(other); !slices.Equal(ids, []int{1, 2, 3}) {
+		t.Fatalf("Renumber() ids = %v, want [1 2 3]", ids)
+	}
+}
+
+func TestPgnCollection_renderContext(t *testing.T) {
+
+	// write a template which shows the render context and the number of games
+	templateFile := filepath.Join(t.TempDir(), "context.tpl")
+	contents := `{{.Context.Source}} {{.Context.Filter}} {{.Context.Version}} {{.Context.Generated.Year}} {{len .GetGames}}{{range .GetGames}} {{$.Context.Sort}}{{end}}`
+	if err := os.WriteFile(templateFile, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewPgnCollection()
+	c.Add(PgnGame{})
+	c.Add(PgnGame{})
+	var builder strings.Builder
+	c.GamesToWriterFromTemplate(&builder, templateFile, WithRenderContext(PgnRenderContext{
+		Source:    "games.pgn",
+		Generated: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC),
+		Filter:    "Moves>40",
+		Sort:      "<Date",
+		Version:   "0.1.0",
+	}))
+	if want := "games.pgn Moves>40 0.1.0 2024 2 <Date <Date\n"; builder.String() != want {
+		t.Errorf("GamesToWriterFromTemplate() = %q, want %q", builder.String(), want)
+	}
+}
+
+func TestPgnCollection_Play(t *testing.T) {
+
+	game, err := ParseGame(`[Event "Play"]
+[Result "*"]
+
+1. e4 e5 2. Nf3 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	c := NewPgnCollection()
+	c.Add(*game)
+
+	// the board shown after the last move marks both g1 and f3 and is
+	// surrounded with the coordinates of files and ranks
+	var builder strings.Builder
+	if err := c.Play(3, &builder, WithBoardStyle(BoardStyle{ASCII: true})); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	for _, want := range []string{
+		"8 | r n b q k b n r |",
+		"3 | .   .   .[N].   |",
+		"1 | R N B Q K B[.]R |",
+		"a b c d e f g h",
+	} {
+		if !strings.Contains(builder.String(), want) {
+			t.Errorf("Play() = %v, want it to contain %q", builder.String(), want)
+		}
+	}
+}
+
+func TestPgnCollection_Positions(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, pgn := range []string{
+		"[Event \"A\"]\n[Result \"1-0\"]\n\n1. e4 e5 2. Nf3 1-0",
+		"[Event \"B\"]\n[Result \"0-1\"]\n\n1. d4 0-1",
+	} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+	ids := collectionIds(c)
+
+	// positions are given in ply-major order
+	type visit struct {
+		game, ply int
+		move      string
+		fen       string
+	}
+	var got []visit
+	if err := c.Positions(-1, func(position PgnPosition) error {
+		got = append(got, visit{
+			game: position.Game,
+			ply:  position.Ply,
+			move: position.Move.shortAlgebraic,
+			fen:  position.Board.FEN(),
+		})
+		return nil
+	}); err != nil {
+		t.Fatalf("Positions() error = %v", err)
+	}
+	want := []struct {
+		game, ply int
+		move      string
+	}{
+		{ids[0], 0, "e4"}, {ids[1], 0, "d4"}, {ids[0], 1, "e5"}, {ids[0], 2, "Nf3"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Positions() visited %v positions, want %v", len(got), len(want))
+	}
+	for idx := range want {
+		if got[idx].game != want[idx].game || got[idx].ply != want[idx].ply || got[idx].move != want[idx].move {
+			t.Errorf("Positions() visited %v at step %v, want %v", got[idx], idx, want[idx])
+		}
+	}
+	if fen := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"; got[2].fen != fen {
+		t.Errorf("Positions() FEN = %v, want %v", got[2].fen, fen)
+	}
+
+	// the depth bounds the number of plies and errors stop the iteration
+	count := 0
+	if err := c.Positions(1, func(position PgnPosition) error {
+		count++
+		return nil
+	}); err != nil || count != 2 {
+		t.Errorf("Positions(1) visited %v positions (error = %v), want 2", count, err)
+	}
+	stop := errors.New("stop")
+	if err := c.Positions(-1, func(position PgnPosition) error {
+		return stop
+	}); err != stop {
+		t.Errorf("Positions() error = %v, want %v", err, stop)
+	}
+}
+
+func TestPgnCollection_GetGamesList(t *testing.T) {
+
+	// the second and third games lack some tags, and the number of moves is
+	// rounded up when the last one is White's
+	c := NewPgnCollection()
+	for _, pgn := range []string{
+		"[White \"Carlsen\"]\n[Black \"Caruana\"]\n[Result \"1-0\"]\n[Event \"Norway Chess\"]\n[Round \"3\"]\n[Date \"2024.05.29\"]\n[ECO \"C65\"]\n\n1. e4 e5 2. Nf3 Nc6 3. Bb5 1-0",
+		"[White \"Nakamura\"]\n[Result \"*\"]\n\n1. d4 *",
+		"[Black \"Ding Liren\"]\n[Result \"1/2-1/2\"]\n\n1. c4 e5 2. Nc3 Nf6 1/2-1/2",
+	} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	var builder strings.Builder
+	if err := c.GetGamesList(&builder); err != nil {
+		t.Fatalf("GetGamesList() error = %v", err)
+	}
+	want := []string{
+		"Carlsen  - Caruana   ,   1-0  , Norway Chess 3, 2024.05.29, C65, 3",
+		"Nakamura - ?         ,    *   , ?            ?, ?         , ?  , 1",
+		"?        - Ding Liren, 1/2-1/2, ?            ?, ?         , ?  , 2",
+	}
+	if got := strings.Split(builder.String(), "\n"); !slices.Equal(got, want) {
+		t.Errorf("GetGamesList() = %q, want %q", got, want)
+	}
+
+	// empty collections produce no lines at all
+	builder.Reset()
+	if err := NewPgnCollection().GetGamesList(&builder); err != nil || strings.TrimSpace(builder.String()) != "" {
+		t.Errorf("GetGamesList() = %q (error = %v), want no lines", builder.String(), err)
+	}
+}
+
+func TestPgnCollection_UniquePositions(t *testing.T) {
+
+	// the first two games transpose to the same final position, and the last
+	// one is not played so that its positions are not considered
+	c := NewPgnCollection()
+	for idx, pgn := range []string{
+		"[Event \"A\"]\n\n1. e4 e5 2. Nf3 Nc6 *",
+		"[Event \"B\"]\n\n1. Nf3 e5 2. e4 Nc6 *",
+		"[Event \"C\"]\n\n1. d4 d5 *",
+		"[Event \"D\"]\n\n1. c4 *",
+	} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		if idx < 3 {
+			if err := game.play(); err != nil {
+				t.Fatalf("play() error = %v", err)
+			}
+		}
+		c.Add(*game)
+	}
+
+	// every position is counted once per game: the initial position, the
+	// final position of the transposition, and one position after every other
+	// ply
+	initial := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"
+	final := "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq -"
+	total, positions := c.UniquePositions(100)
+	if total != 10 || len(positions) != total {
+		t.Fatalf("UniquePositions() = (%v, %v positions), want 10", total, len(positions))
+	}
+	if positions[0] != (PgnPositionCount{initial, 3}) || positions[1] != (PgnPositionCount{final, 2}) {
+		t.Errorf("UniquePositions() = %v, want %v and %v first", positions[:2], initial, final)
+	}
+
+	// ties are broken in lexicographic order of the FEN codes
+	for idx := 2; idx < len(positions); idx++ {
+		if positions[idx].Count != 1 {
+			t.Errorf("UniquePositions() count of %v = %v, want 1", positions[idx].FEN, positions[idx].Count)
+		}
+		if idx > 2 && positions[idx-1].FEN >= positions[idx].FEN {
+			t.Errorf("UniquePositions() %v is given before %v", positions[idx-1].FEN, positions[idx].FEN)
+		}
+	}
+
+	// only the most frequent positions are returned
+	for _, top := range []int{-1, 0, 1, 3} {
+		total, got := c.UniquePositions(top)
+		if want := positions[:max(top, 0)]; total != 10 || !slices.Equal(got, want) {
+			t.Errorf("UniquePositions(%v) = (%v, %v), want (10, %v)", top, total, got, want)
+		}
+	}
+}
+
+func TestPgnCollection_Chunks(t *testing.T) {
+
+	c := NewPgnCollection()
+	for idx := 0; idx < 7; idx++ {
+		game, err := ParseGame(fmt.Sprintf("[White \"P%v\"]\n\n1. e4 e5 2. Nf3 *", idx))
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	// collections which are not chunks are both the first and the last one
+	if !c.IsFirstChunk() || !c.IsLastChunk() {
+		t.Errorf("IsFirstChunk() = %v and IsLastChunk() = %v, want true", c.IsFirstChunk(), c.IsLastChunk())
+	}
+
+	// chunks cover all games in order, and only the first and the last are
+	// acknowledged as such, also if there is only one chunk
+	for _, size := range []int{-1, 0, 1, 2, 3, 6, 7, 8} {
+		chunks := c.Chunks(size)
+		if want := (c.Len() + max(size, 1) - 1) / max(size, 1); len(chunks) != want {
+			t.Fatalf("Chunks(%v) = %v chunks, want %v", size, len(chunks), want)
+		}
+		var ids []int
+		for idx, chunk := range chunks {
+			if chunk.IsFirstChunk() != (idx == 0) || chunk.IsLastChunk() != (idx == len(chunks)-1) {
+				t.Errorf("Chunks(%v) chunk #%v: IsFirstChunk() = %v, IsLastChunk() = %v", size, idx, chunk.IsFirstChunk(), chunk.IsLastChunk())
+			}
+			if chunk.Len() == 0 || (idx < len(chunks)-1 && chunk.Len() != max(size, 1)) {
+				t.Errorf("Chunks(%v) chunk #%v has %v games", size, idx, chunk.Len())
+			}
+			ids = append(ids, collectionIds(chunk)...)
+		}
+		if !slices.Equal(ids, collectionIds(c)) {
+			t.Errorf("Chunks(%v) = %v, want %v", size, ids, collectionIds(c))
+		}
+	}
+
+	// the output of a template executed over chunks in parallel is the same
+	// as its sequential output. Headers and footers are written only by the
+	// first and last chunks, and the newline added after every execution is
+	// trimmed
+	templateFile := filepath.Join(t.TempDir(), "chunks.tpl")
+	contents := `{{if .IsFirstChunk}}begin
+{{end}}{{range .GetGames}}{{.Id}} {{.GetField "White"}} {{.GetField "Moves"}}
+{{end}}{{if .IsLastChunk}}end{{end -}}`
+	if err := os.WriteFile(templateFile, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var sequential strings.Builder
+	c.GamesToWriterFromTemplate(&sequential, templateFile)
+	if !strings.HasPrefix(sequential.String(), "begin\n1 P0 ") || !strings.HasSuffix(sequential.String(), "\n7 P6 3\nend") {
+		t.Fatalf("GamesToWriterFromTemplate() = %q", sequential.String())
+	}
+	for _, size := range []int{1, 2, 3, 7, 8} {
+		for _, jobs := range []int{1, 3, 16} {
+			var parallel strings.Builder
+			if err := c.GamesToWriterFromTemplateParallel(&parallel, templateFile, size, jobs); err != nil {
+				t.Fatalf("GamesToWriterFromTemplateParallel() error = %v", err)
+			}
+			if parallel.String() != sequential.String() {
+				t.Errorf("GamesToWriterFromTemplateParallel(%v, %v) = %q, want %q", size, jobs, parallel.String(), sequential.String())
+			}
+		}
+	}
+
+	// results of every chunk are delivered in their own channel
+	tpl, err := parseTemplate(templateFile, newPgnOptions())
+	if err != nil {
+		t.Fatalf("parseTemplate() error = %v", err)
+	}
+	chunks := c.Chunks(3)
+	for idx, result := range executeChunks(tpl, chunks, 2, newPgnOptions()) {
+		output := <-result
+		if output.err != nil {
+			t.Fatalf("executeChunks() chunk #%v error = %v", idx, output.err)
+		}
+		if got := strings.Count(output.contents.String(), " P"); got != chunks[idx].Len() {
+			t.Errorf("executeChunks() chunk #%v = %q, want %v games", idx, output.contents.String(), chunks[idx].Len())
+		}
+	}
+}
+
+func TestPgnCollection_Workers(t *testing.T) {
+
+	// create a collection with games of different players, openings, lengths
+	// and results, one of which has an illegal move if requested
+	openings := []string{
+		"1. e4 e5 2. Nf3 Nc6 3. Bb5 a6",
+		"1. d4 d5 2. c4 e6 3. Nc3 Nf6 4. Bg5",
+		"1. e4 c5 2. Nf3 d6",
+		"1. c4 e5",
+		"1. Nf3 d5 2. g3 Nf6 3. Bg2 e6 4. O-O Be7 5. d3",
+	}
+	results := []string{"1-0", "0-1", "1/2-1/2", "*"}
+	build := func(illegal bool) PgnCollection {
+		c := NewPgnCollection()
+		for idx := 0; idx < 20; idx++ {
+			movetext := openings[idx%len(openings)]
+			if illegal && idx == 13 {
+				movetext += " 9. Ke3"
+			}
+			pgn := fmt.Sprintf("[White \"P%v\"]\n[Black \"P%v\"]\n[Result \"%v\"]\n\n%v %v",
+				idx%7, idx%3, results[idx%len(results)], movetext, results[idx%len(results)])
+			game, err := ParseGame(pgn)
+			if err != nil {
+				t.Fatalf("ParseGame() error = %v", err)
+			}
+			c.Add(*game)
+		}
+		return c
+	}
+
+	// the output of every service is the same whatever the number of workers
+	// and progress is reported once per game
+	run := func(workers int) (played, sorted, histogram string) {
+		reports := 0
+		progress := WithProgress(func(done, total int64) {
+			reports++
+		})
+		c := build(false)
+		var builder strings.Builder
+		if err := c.Play(100, &builder, WithWorkers(workers), progress); err != nil {
+			t.Fatalf("Play() error = %v", err)
+		}
+		result, err := c.Sort("< White; > Moves; < Id", WithWorkers(workers), progress)
+		if err != nil {
+			t.Fatalf("Sort() error = %v", err)
+		}
+		pgnhistogram, err := c.GetHistogram("White; Result", WithWorkers(workers), progress)
+		if err != nil {
+			t.Fatalf("GetHistogram() error = %v", err)
+		}
+		if reports != 3*c.Len() {
+			t.Errorf("WithWorkers(%v) reported progress %v times, want %v", workers, reports, 3*c.Len())
+		}
+		return builder.String(), fmt.Sprint(collectionIds(*result)), pgnhistogram.String()
+	}
+	played, sorted, histogram := run(1)
+	for _, workers := range []int{2, 8} {
+		gotPlayed, gotSorted, gotHistogram := run(workers)
+		if gotPlayed != played {
+			t.Errorf("Play(WithWorkers(%v)) differs from Play(WithWorkers(1))", workers)
+		}
+		if gotSorted != sorted {
+			t.Errorf("Sort(WithWorkers(%v)) = %v, want %v", workers, gotSorted, sorted)
+		}
+		if gotHistogram != histogram {
+			t.Errorf("GetHistogram(WithWorkers(%v)) = %v, want %v", workers, gotHistogram, histogram)
+		}
+	}
+
+	// errors found by any worker are returned
+	for _, workers := range []int{1, 8} {
+		c := build(true)
+		if err := c.Play(0, io.Discard, WithWorkers(workers)); err == nil {
+			t.Errorf("Play(WithWorkers(%v)) expected an error", workers)
+		}
+		if _, err := c.Sort("< UnknownVariable", WithWorkers(workers)); err == nil {
+			t.Errorf("Sort(WithWorkers(%v)) expected an error", workers)
+		}
+		if _, err := c.GetHistogram("UnknownVariable", WithWorkers(workers)); err == nil {
+			t.Errorf("GetHistogram(WithWorkers(%v)) expected an error", workers)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
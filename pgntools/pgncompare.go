@@ -0,0 +1,137 @@
+// -*- coding: utf-8 -*-
+// pgncompare.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:08:53.292517685 (1792159733)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+
+	"github.com/clinaresl/table"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the label of the given ply in a comparison of games, i.e., the move
+// number followed by a dot if it is White's turn or an ellipsis otherwise
+func comparisonLabel(move PgnMove) string {
+	if move.color == 1 {
+		return fmt.Sprintf("%v.", move.number)
+	}
+	return fmt.Sprintf("%v...", move.number)
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the index of the first ply where this game and the other one differ,
+// assuming both start from the same position. If one game is a prefix of the
+// other, the number of plies of the shortest one is returned. If both games
+// have exactly the same moves, their number of plies is returned
+func (game *PgnGame) Divergence(other PgnGame) int {
+
+	ply := 0
+	for ply < len(game.moves) && ply < len(other.moves) &&
+		game.moves[ply].shortAlgebraic == other.moves[ply].shortAlgebraic {
+		ply++
+	}
+	return ply
+}
+
+// Return the label and the moves of both games in the given ply. Moves that do
+// not exist are returned as the empty string
+func (game *PgnGame) comparisonPly(other PgnGame, ply int) (label, move, otherMove string) {
+
+	if ply < len(game.moves) {
+		label, move = comparisonLabel(game.moves[ply]), game.moves[ply].annotated()
+	}
+	if ply < len(other.moves) {
+		label, otherMove = comparisonLabel(other.moves[ply]), other.moves[ply].annotated()
+	}
+	return
+}
+
+// Return a string with the moves of this game and the other one side by side,
+// one ply per line, so that they can be easily compared, e.g., a game played
+// against the line suggested by an engine or a repertoire line. The first ply
+// where both games differ is preceded by a horizontal rule and the moves of
+// both games in it are highlighted with asterisks. The rule is omitted if the
+// games differ from the very first ply
+func (game *PgnGame) GetComparison(other PgnGame) string {
+
+	// Create a table with the label of each ply and the moves of both games
+	tab, _ := table.NewTable(" r | l | l ")
+	tab.AddRow("", fmt.Sprintf("%v - %v", game.getTag("White"), game.getTag("Black")),
+		fmt.Sprintf("%v - %v", other.getTag("White"), other.getTag("Black")))
+	tab.AddThickRule()
+
+	// and add all plies of both games
+	divergence := game.Divergence(other)
+	for ply := 0; ply < max(len(game.moves), len(other.moves)); ply++ {
+		label, move, otherMove := game.comparisonPly(other, ply)
+		if ply == divergence {
+			if ply > 0 {
+				tab.AddSingleRule()
+			}
+			if move != "" {
+				move = "*" + move + "*"
+			}
+			if otherMove != "" {
+				otherMove = "*" + otherMove + "*"
+			}
+		}
+		tab.AddRow(label, move, otherMove)
+	}
+
+	return fmt.Sprintf("%v", tab)
+}
+
+// Return a LaTeX longtable with the moves of this game and the other one side
+// by side, one ply per line. The first ply where both games differ is preceded
+// by a horizontal rule (unless it is the first one) and all moves from it on
+// are highlighted in red. It requires the packages longtable and xcolor.
+//
+// It is intended to be used in LaTeX templates
+func (game *PgnGame) GetLaTeXComparison(other PgnGame) (output string) {
+
+	// Declare a long table which can span over several pages to show the
+	// entire games
+	output += `\begin{longtable}{r|l|l}`
+	output += "\n"
+	output += fmt.Sprintf("& %v -- %v & %v -- %v \\\\ \\hline\n",
+		substituteLaTeX(game.getTag("White")), substituteLaTeX(game.getTag("Black")),
+		substituteLaTeX(other.getTag("White")), substituteLaTeX(other.getTag("Black")))
+
+	// and add all plies of both games
+	divergence := game.Divergence(other)
+	for ply := 0; ply < max(len(game.moves), len(other.moves)); ply++ {
+		label, move, otherMove := game.comparisonPly(other, ply)
+		move, otherMove = substituteLaTeX(move), substituteLaTeX(otherMove)
+		if ply == divergence && ply > 0 {
+			output += "\\hline\n"
+		}
+		if ply >= divergence && move != "" {
+			move = fmt.Sprintf(`\textcolor{red}{%v}`, move)
+		}
+		if ply >= divergence && otherMove != "" {
+			otherMove = fmt.Sprintf(`\textcolor{red}{%v}`, otherMove)
+		}
+		output += fmt.Sprintf("%v & %v & %v \\\\\n", label, move, otherMove)
+	}
+
+	// Before leaving ensure the longtable environment is closed
+	output += `\end{longtable}`
+	output += "\n"
+
+	return
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
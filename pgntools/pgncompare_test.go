@@ -0,0 +1,133 @@
+// -*- coding: utf-8 -*-
+// pgncompare_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:36:48.292434527 (1792172208)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+// Return the games given in PGN format, failing the test if any can not be
+// parsed
+func comparedGames(t *testing.T, pgns ...string) []*PgnGame {
+	t.Helper()
+	games := make([]*PgnGame, 0, len(pgns))
+	for _, pgn := range pgns {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		games = append(games, game)
+	}
+	return games
+}
+
+func TestPgnGame_Divergence(t *testing.T) {
+
+	games := comparedGames(t,
+		"[White \"A\"]\n\n1. e4 e5 2. Nf3 Nc6 3. Bb5 *",
+		"[White \"B\"]\n\n1. e4 e5 2. Nf3! Nf6 *",
+		"[White \"C\"]\n\n1. e4 e5 *",
+		"[White \"D\"]\n\n1. d4 *",
+	)
+	tests := []struct {
+		game, other int
+		want        int
+	}{
+		{0, 0, 5},
+		{0, 1, 3},
+		{1, 0, 3},
+		{0, 2, 2},
+		{2, 0, 2},
+		{0, 3, 0},
+	}
+	for _, tt := range tests {
+		if got := games[tt.game].Divergence(*games[tt.other]); got != tt.want {
+			t.Errorf("Divergence(%v, %v) = %v, want %v", tt.game, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestPgnGame_GetComparison(t *testing.T) {
+
+	games := comparedGames(t,
+		"[White \"A\"]\n[Black \"B\"]\n\n1. e4 e5 2. Nf3 Nc6 3. Bb5 *",
+		"[White \"C_D\"]\n[Black \"E\"]\n\n1. e4 e5 2. Nf3! Nf6 *",
+		"[White \"F\"]\n[Black \"G\"]\n\n1. d4 *",
+	)
+
+	// the moves of both games are shown side by side, with the annotations
+	// of every move, and the first ply where they differ is highlighted after
+	// a horizontal rule
+	lines := strings.Split(games[0].GetComparison(*games[1]), "\n")
+	for idx, want := range []string{"A - B", "━", "e4", "e5", "Nf3!", "─", "*Nc6* │ *Nf6*", "Bb5"} {
+		if idx >= len(lines) || !strings.Contains(lines[idx], want) {
+			t.Fatalf("GetComparison() = %q, want %q in line %v", lines, want, idx)
+		}
+	}
+	if fields := strings.Fields(lines[2]); fields[0] != "1." || fields[2] != "e4" || fields[4] != "e4" {
+		t.Errorf("GetComparison() line 2 = %q, want the label 1. and e4 twice", lines[2])
+	}
+	if fields := strings.Fields(lines[7]); fields[0] != "3." || len(fields) != 4 {
+		t.Errorf("GetComparison() line 7 = %q, want only the move of the first game", lines[7])
+	}
+
+	// no rule is shown if games differ from the first ply, and nothing is
+	// highlighted in identical games
+	if got := games[0].GetComparison(*games[2]); strings.Contains(got, "─") || !strings.Contains(got, "*e4*") || !strings.Contains(got, "*d4*") {
+		t.Errorf("GetComparison() = %v, want no rule and the first ply highlighted", got)
+	}
+	if got := games[0].GetComparison(*games[0]); strings.Contains(got, "─") || strings.Contains(got, "*") {
+		t.Errorf("GetComparison() = %v, want no rule and nothing highlighted", got)
+	}
+}
+
+func TestPgnGame_GetLaTeXComparison(t *testing.T) {
+
+	games := comparedGames(t,
+		"[White \"A\"]\n[Black \"B\"]\n\n1. e4 e5 2. Nf3 Nc6 3. Bb5 *",
+		"[White \"C_D\"]\n[Black \"E\"]\n\n1. e4 e5 2. Nf3! Nf6 *",
+		"[White \"F\"]\n[Black \"G\"]\n\n1. d4 *",
+	)
+
+	// all moves from the first one which differs are shown in red, and LaTeX
+	// special characters are escaped
+	want := `\begin{longtable}{r|l|l}
+& A -- B & C\_D -- E \\ \hline
+1. & e4 & e4 \\
+1... & e5 & e5 \\
+2. & Nf3 & Nf3! \\
+\hline
+2... & \textcolor{red}{Nc6} & \textcolor{red}{Nf6} \\
+3. & \textcolor{red}{Bb5} &  \\
+\end{longtable}
+`
+	if got := games[0].GetLaTeXComparison(*games[1]); got != want {
+		t.Errorf("GetLaTeXComparison() = %q, want %q", got, want)
+	}
+
+	// no rule is shown if games differ from the first ply
+	want = `\begin{longtable}{r|l|l}
+& F -- G & A -- B \\ \hline
+1. & \textcolor{red}{d4} & \textcolor{red}{e4} \\
+1... &  & \textcolor{red}{e5} \\
+2. &  & \textcolor{red}{Nf3} \\
+2... &  & \textcolor{red}{Nc6} \\
+3. &  & \textcolor{red}{Bb5} \\
+\end{longtable}
+`
+	if got := games[2].GetLaTeXComparison(*games[0]); got != want {
+		t.Errorf("GetLaTeXComparison() = %q, want %q", got, want)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,60 @@
+// -*- coding: utf-8 -*-
+// pgncontext.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:32:41.847868154 (1792161161)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"time"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// The render context describes how the output of a template was generated, so
+// that reports can be self-describing. It is available in templates as
+// .Context, e.g., {{.Context.Source}}, or $.Context within other actions
+type PgnRenderContext struct {
+	Source    string    // name of the PGN file
+	Generated time.Time // when the output was generated
+	Filter    string    // expression used to filter games, if any
+	Sort      string    // criteria used to sort games, if any
+	Version   string    // version of pgnparser
+}
+
+// Templates are executed over a collection of games along with the context
+// where they are rendered and the style of diagrams. Because the collection is
+// embedded, all its methods are available in templates as well
+type templateData struct {
+	*PgnCollection
+	Context PgnRenderContext
+	Diagram DiagramStyle
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the data given to templates to render the given collection with the
+// render context and the style of diagrams given in the options. If no generation time was given, the
+// current time is used
+func newTemplateData(games *PgnCollection, options pgnOptions) templateData {
+
+	context := options.renderContext
+	if context.Generated.IsZero() {
+		context.Generated = time.Now()
+	}
+	return templateData{
+		PgnCollection: games,
+		Context:       context,
+		Diagram:       options.diagramStyle,
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,305 @@
+// -*- coding: utf-8 -*-
+// pgndatabase.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:45:01.646077977 (1792169101)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Databases start with a header which identifies them and gives the number of
+// games stored. Databases built from a PGN file also store its size and
+// modification time to detect whether they are stale
+type dbHeader struct {
+	Magic   string
+	Version int
+	Size    int64
+	ModTime time.Time
+	Games   int
+}
+
+// Moves are stored with all their information, including their variations
+type dbMove struct {
+	Number      int
+	Color       int
+	SAN         string
+	Quality     string
+	From, To    string
+	EMT         float32
+	Eval        float64
+	Evaluated   bool
+	Annotations []PgnAnnotation
+	Variations  [][]dbMove
+}
+
+// Games are stored with their id, tags, moves, outcome and comments, along with
+// the FEN codes of all boards which have been realized. Tags are stored as
+// strings and they are converted again as when parsing them
+type dbGame struct {
+	Id                int
+	Tags              map[string]string
+	Moves             []dbMove
+	White, Black      float32
+	MoveText          string
+	Start, End        int64
+	Leading, Trailing []PgnAnnotation
+	FENs              []string
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Every database starts with the following magic string and version
+const (
+	dbMagic   = "pgnparser database"
+	dbVersion = 1
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the given moves as they are stored in a database
+func getDBMoves(moves []PgnMove) []dbMove {
+
+	result := make([]dbMove, 0, len(moves))
+	for _, move := range moves {
+		imove := dbMove{
+			Number:      move.number,
+			Color:       move.color,
+			SAN:         move.shortAlgebraic,
+			Quality:     move.quality,
+			From:        move.from,
+			To:          move.to,
+			EMT:         move.emt,
+			Eval:        move.eval,
+			Evaluated:   move.evaluated,
+			Annotations: move.annotations,
+		}
+		for _, variation := range move.variations {
+			imove.Variations = append(imove.Variations, getDBMoves(variation))
+		}
+		result = append(result, imove)
+	}
+	return result
+}
+
+// Return the moves stored in a database as PgnMoves
+func getMovesFromDB(moves []dbMove) []PgnMove {
+
+	result := make([]PgnMove, 0, len(moves))
+	for _, move := range moves {
+		imove := PgnMove{
+			number:         move.Number,
+			color:          move.Color,
+			shortAlgebraic: move.SAN,
+			quality:        move.Quality,
+			longAlgebraic:  longAlgebraic{move.From, move.To},
+			emt:            move.EMT,
+			eval:           move.Eval,
+			evaluated:      move.Evaluated,
+			annotations:    move.Annotations,
+		}
+		for _, variation := range move.Variations {
+			imove.variations = append(imove.variations, getMovesFromDB(variation))
+		}
+		result = append(result, imove)
+	}
+	return result
+}
+
+// Write all games in the given collection in the given writer in binary
+// format, preceded by a header with the given size and modification time
+func writeDatabase(writer io.Writer, c PgnCollection, size int64, modtime time.Time) error {
+
+	output := bufio.NewWriter(writer)
+	encoder := gob.NewEncoder(output)
+	if err := encoder.Encode(dbHeader{dbMagic, dbVersion, size, modtime, len(c.slice)}); err != nil {
+		return err
+	}
+
+	// games are encoded one at a time so that they are never held twice in
+	// memory
+	for _, igame := range c.slice {
+		game := dbGame{
+			Id:       igame.id,
+			Tags:     make(map[string]string, len(igame.tags)),
+			Moves:    getDBMoves(igame.moves),
+			White:    igame.outcome.scoreWhite,
+			Black:    igame.outcome.scoreBlack,
+			MoveText: igame.movetext,
+			Start:    igame.start,
+			End:      igame.end,
+			Leading:  igame.leading,
+			Trailing: igame.trailing,
+		}
+		for name, value := range igame.tags {
+			game.Tags[name] = fmt.Sprint(value)
+		}
+		for _, board := range igame.boards {
+			game.FENs = append(game.FENs, board.fen)
+		}
+		if err := encoder.Encode(game); err != nil {
+			return err
+		}
+	}
+	return output.Flush()
+}
+
+// Return the header of the database in the given reader and a decoder to read
+// its games, or an error if it is not a database
+func readDatabaseHeader(reader io.Reader) (*dbHeader, *gob.Decoder, error) {
+
+	decoder := gob.NewDecoder(bufio.NewReader(reader))
+	var header dbHeader
+	if err := decoder.Decode(&header); err != nil || header.Magic != dbMagic {
+		return nil, nil, fmt.Errorf(" Not a database of games")
+	}
+	if header.Version != dbVersion {
+		return nil, nil, fmt.Errorf(" Unsupported version of the database: %v", header.Version)
+	}
+	return &header, decoder, nil
+}
+
+// Return all games in the database given in the reader as a collection of games
+// and its header. Boards are computed again from their FEN codes, so that games
+// are realized as they were when the database was written
+func readDatabase(reader io.Reader) (*PgnCollection, *dbHeader, error) {
+
+	header, decoder, err := readDatabaseHeader(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	games := NewPgnCollection()
+	for idx := 0; idx < header.Games; idx++ {
+		var data dbGame
+		if err := decoder.Decode(&data); err != nil {
+			return nil, nil, fmt.Errorf(" Game %v could not be read from the database: %v", idx+1, err)
+		}
+		game := PgnGame{
+			id:       data.Id,
+			tags:     make(map[string]any, len(data.Tags)),
+			moves:    getMovesFromDB(data.Moves),
+			outcome:  PgnOutcome{data.White, data.Black},
+			movetext: data.MoveText,
+			start:    data.Start,
+			end:      data.End,
+			leading:  data.Leading,
+			trailing: data.Trailing,
+		}
+		for name, value := range data.Tags {
+			game.tags[name] = getTagValue(value)
+		}
+		if len(data.FENs) > 0 {
+			variant, err := game.Variant()
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, fen := range data.FENs {
+				board, err := NewPgnBoardFromFEN(fen)
+				if err != nil {
+					return nil, nil, err
+				}
+				board.variant = variant
+				game.boards = append(game.boards, board)
+			}
+		}
+		games.Add(game)
+	}
+	return &games, header, nil
+}
+
+// Return all games stored in the database in the given file, which has been
+// written with Save, as a collection of games. Games keep their ids, and the
+// games realized when saving them are realized as well without playing them
+// again
+func LoadPgnCollection(path string) (*PgnCollection, error) {
+
+	stream, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	games, _, err := readDatabase(stream)
+	return games, err
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Write all games in this collection in the given file in binary format, so
+// that they can be read again with LoadPgnCollection without parsing them.
+// Along with their tags, moves, comments and outcome, the FEN codes of all
+// boards which have been realized are stored as well
+func (c PgnCollection) Save(path string) error {
+
+	stream, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	if err := writeDatabase(stream, c, 0, time.Time{}); err != nil {
+		return err
+	}
+	return stream.Close()
+}
+
+// Return the name of the file where the games of this PgnFile are cached by
+// default, which is named after it with extension ".db"
+func (f PgnFile) DatabaseName() string {
+	return f.name + ".db"
+}
+
+// Write the given games, which have been read from this PgnFile, in the given
+// file in binary format along with the size and modification time of this
+// PgnFile, so that they can be read again with LoadDatabase as long as it does
+// not change
+func (f PgnFile) SaveDatabase(games PgnCollection, path string) error {
+
+	stream, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	if err := writeDatabase(stream, games, f.size, f.modtime); err != nil {
+		return err
+	}
+	return stream.Close()
+}
+
+// Return the games of this PgnFile stored in the given file with SaveDatabase.
+// An error is returned if the database was not built from the current contents
+// of this PgnFile, i.e., if its size or modification time changed
+func (f PgnFile) LoadDatabase(path string) (*PgnCollection, error) {
+
+	stream, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	games, header, err := readDatabase(stream)
+	if err != nil {
+		return nil, err
+	}
+	if header.Size != f.size || !header.ModTime.Equal(f.modtime) {
+		return nil, fmt.Errorf(" The database '%v' is stale", path)
+	}
+	return games, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
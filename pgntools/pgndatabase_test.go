@@ -0,0 +1,101 @@
+// -*- coding: utf-8 -*-
+// pgndatabase_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:47:06.685728451 (1792169226)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPgnFile_SaveDatabase(t *testing.T) {
+
+	contents := `[White "alice"]
+[Black "bob"]
+[WhiteElo "1850"]
+
+{Played online} 1. e4 {best by test} e5 (1... c5 2. Nf3 $1) 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0 {A quick one}
+
+[White "bob"]
+[Black "carol"]
+[FEN "8/8/8/4k3/8/8/4P3/4K3 w - - 0 1"]
+[SetUp "1"]
+
+1. e4+ Kxe4 1/2-1/2
+`
+	name := filepath.Join(t.TempDir(), "games.pgn")
+	if err := os.WriteFile(name, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pgnfile, err := NewPgnFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	games, err := pgnfile.Games()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the first game is played so that boards are stored only for it
+	if err := games.slice[0].play(); err != nil {
+		t.Fatal(err)
+	}
+	if err := pgnfile.SaveDatabase(*games, pgnfile.DatabaseName()); err != nil {
+		t.Fatalf("SaveDatabase() error = %v", err)
+	}
+	loaded, err := pgnfile.LoadDatabase(pgnfile.DatabaseName())
+	if err != nil {
+		t.Fatalf("LoadDatabase() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.slice, games.slice) {
+		t.Fatalf("LoadDatabase() = %v, want %v", loaded.slice, games.slice)
+	}
+
+	// games which were not played can be played after loading them
+	if err := loaded.slice[1].play(); err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+	if got := loaded.slice[1].boards[2].FEN(); got != "8/8/8/8/4k3/8/8/4K3 w - - 0 2" {
+		t.Errorf("FEN() = %v", got)
+	}
+
+	// collections can also be saved in any file
+	filename := filepath.Join(t.TempDir(), "games.db")
+	if err := loaded.Save(filename); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if saved, err := LoadPgnCollection(filename); err != nil {
+		t.Fatalf("LoadPgnCollection() error = %v", err)
+	} else if !reflect.DeepEqual(saved.slice, loaded.slice) {
+		t.Fatalf("LoadPgnCollection() = %v, want %v", saved.slice, loaded.slice)
+	}
+
+	// databases are stale once the PGN file changes, and other files are not
+	// databases
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(name, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if pgnfile, err = NewPgnFile(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pgnfile.LoadDatabase(pgnfile.DatabaseName()); err == nil {
+		t.Error("LoadDatabase() should fail with a stale database")
+	}
+	if _, err := LoadPgnCollection(name); err == nil {
+		t.Error("LoadPgnCollection() should fail with a PGN file")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,78 @@
+// -*- coding: utf-8 -*-
+// pgndate.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:09:45.998374067 (1792159785)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the year and month of the given PGN date, which is expected to be
+// given in the format "YYYY.MM.DD", where unknown fields are written with
+// question marks, e.g., "2016.??.??". Unknown or malformed fields are returned
+// as the empty string. Dashes and slashes are accepted as separators as well
+func dateFields(date any) (year, month string) {
+
+	// PGN dates are strings, but tags might have been given other types
+	fields := strings.FieldsFunc(fmt.Sprintf("%v", date), func(r rune) bool {
+		return r == '.' || r == '-' || r == '/'
+	})
+
+	// a field is known only if it consists of the given number of digits
+	known := func(field string, length int) bool {
+		if len(field) != length {
+			return false
+		}
+		for _, r := range field {
+			if r < '0' || r > '9' {
+				return false
+			}
+		}
+		return true
+	}
+	if len(fields) > 0 && known(fields[0], 4) {
+		year = fields[0]
+	}
+	if len(fields) > 1 && known(fields[1], 2) {
+		month = fields[1]
+	}
+	return
+}
+
+// Return the year of the given PGN date, or "?" if it is unknown. It is
+// intended to be used in histograms, e.g., 'ByYear(Date)'
+func byYear(date any) string {
+	year, _ := dateFields(date)
+	if year == "" {
+		return "?"
+	}
+	return year
+}
+
+// Return the year and month of the given PGN date as "YYYY.MM". If the month is
+// unknown it is shown as "YYYY.??", and if the year is unknown "?" is returned.
+// It is intended to be used in histograms, e.g., 'ByMonth(Date)'
+func byMonth(date any) string {
+	year, month := dateFields(date)
+	if year == "" {
+		return "?"
+	}
+	if month == "" {
+		month = "??"
+	}
+	return year + "." + month
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
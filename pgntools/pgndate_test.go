@@ -0,0 +1,46 @@
+// -*- coding: utf-8 -*-
+// pgndate_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:09:45.852963809 (1792159785)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_byYearByMonth(t *testing.T) {
+	tests := []struct {
+		date  any
+		year  string
+		month string
+	}{
+		{"2016.05.06", "2016", "2016.05"},
+		{"2016.??.??", "2016", "2016.??"},
+		{"2016.05.??", "2016", "2016.05"},
+		{"????.??.??", "?", "?"},
+		{"2016-05-06", "2016", "2016.05"},
+		{"2016", "2016", "2016.??"},
+		{"", "?", "?"},
+		{nil, "?", "?"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.date), func(t *testing.T) {
+			if got := byYear(tt.date); got != tt.year {
+				t.Errorf("byYear() = %v, want %v", got, tt.year)
+			}
+			if got := byMonth(tt.date); got != tt.month {
+				t.Errorf("byMonth() = %v, want %v", got, tt.month)
+			}
+		})
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
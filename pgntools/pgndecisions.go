@@ -0,0 +1,206 @@
+// -*- coding: utf-8 -*-
+// pgndecisions.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:11:21.945360870 (1792167081)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clinaresl/table"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// The distribution of the moves where decisive games were decided counts the
+// number of games decided in every range of moves of the same width, e.g.,
+// Counts[0] is the number of games decided in the first ten moves if the width
+// is ten. Decisive games where the winner never had a decisive advantage are
+// counted as undecided
+type PgnDecisions struct {
+	Width     int
+	Counts    []int
+	Undecided int
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Games are decided when the winner gets an evaluation of at least three pawns
+// or, if games are not evaluated, a material advantage of at least three
+// points
+const (
+	decisiveEval     = 3.0
+	decisiveMaterial = 3
+)
+
+// globals
+// ----------------------------------------------------------------------------
+
+// Material is counted with the conventional values of pieces
+var pieceValues = map[content]int{
+	WPAWN:   1,
+	WKNIGHT: 3,
+	WBISHOP: 3,
+	WROOK:   5,
+	WQUEEN:  9,
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the color of the winner of a game with this outcome (1 for White and
+// -1 for Black), or 0 if the game was drawn or not properly ended
+func (outcome PgnOutcome) winner() int {
+	white, ok := outcome.Score(1)
+	black, _ := outcome.Score(-1)
+	if !ok || white == black {
+		return 0
+	}
+	if white > black {
+		return 1
+	}
+	return -1
+}
+
+// Return the material of White minus the material of Black in this board
+func (board *PgnBoard) material() (balance int) {
+	for _, piece := range board.squares {
+		if piece != BLANK {
+			balance += getColor(piece) * pieceValues[getPieceValue(piece, 1)]
+		}
+	}
+	return
+}
+
+// Return the number of the move of this game where the winner got a decisive
+// advantage for the first time, or -1 if the game is not decisive or the
+// winner never got it. If the moves are evaluated, the evaluations are used;
+// otherwise, the game is played and the material is used instead, and the
+// advantage has to be kept also after the reply of the opponent, so that
+// exchanges are not taken as decisive. Checkmates are always decisive. It
+// returns any error found
+func (game *PgnGame) decisiveMove() (int, error) {
+
+	// only games won by any player are considered
+	color := game.outcome.winner()
+	if color == 0 {
+		return -1, nil
+	}
+
+	// use the evaluations, if any are given
+	evaluated := false
+	for _, move := range game.moves {
+		if pawns, ok := move.Eval(); ok {
+			evaluated = true
+			if float64(color)*pawns >= decisiveEval {
+				return move.number, nil
+			}
+		}
+	}
+	if evaluated {
+		return -1, nil
+	}
+
+	// and the material otherwise. The position after every move is given in
+	// the next board
+	if err := game.play(); err != nil {
+		return -1, err
+	}
+	for ply := range game.moves {
+		decisive := func(idx int) bool {
+			return idx >= len(game.boards) || color*game.boards[idx].material() >= decisiveMaterial
+		}
+		if decisive(ply+1) && decisive(ply+2) {
+			return game.moves[ply].number, nil
+		}
+	}
+	if last := len(game.moves) - 1; last >= 0 && getCheckMarker(game.moves[last].shortAlgebraic) == "#" {
+		return game.moves[last].number, nil
+	}
+	return -1, nil
+}
+
+// The distribution of decisions is a stringer, so that it can be shown on any
+// writer. Every row shows a range of moves along with the number and
+// percentage of games decided in it
+func (decisions PgnDecisions) String() string {
+
+	total := decisions.Undecided
+	for _, count := range decisions.Counts {
+		total += count
+	}
+	percentage := func(count int) string {
+		if total == 0 {
+			return "0.00"
+		}
+		return fmt.Sprintf("%.2f", 100.0*float64(count)/float64(total))
+	}
+
+	tab, _ := table.NewTable(" l | r r | l ")
+	tab.AddRow("Moves", "Games", "%", "")
+	tab.AddThickRule()
+	for idx, count := range decisions.Counts {
+		bar := ""
+		if total > 0 {
+			bar = strings.Repeat("█", 40*count/total)
+		}
+		tab.AddRow(fmt.Sprintf("%v-%v", idx*decisions.Width+1, (idx+1)*decisions.Width),
+			count, percentage(count), bar)
+	}
+	tab.AddSingleRule()
+	tab.AddRow("Undecided", decisions.Undecided, percentage(decisions.Undecided), "")
+	return fmt.Sprintf("%v", tab)
+}
+
+// Return the distribution of the moves where the decisive games of this
+// collection were decided, i.e., where the winner got a decisive advantage for
+// the first time, in ranges of moves of the given width. The advantage is
+// taken from the evaluations given in comments with "[%eval ...]", if any, or
+// from the material otherwise. Games are analyzed in parallel with the number
+// of workers given WithWorkers
+func (c PgnCollection) Decisions(width int, opts ...PgnOption) (*PgnDecisions, error) {
+
+	if width <= 0 {
+		return nil, fmt.Errorf(" Invalid width %v. It should be strictly positive", width)
+	}
+
+	// compute the move where every game was decided. Because every worker
+	// accesses a different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	moves := make([]int, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) (err error) {
+		moves[idx], err = c.slice[idx].decisiveMove()
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	// and count them
+	decisions := PgnDecisions{Width: width}
+	for idx, move := range moves {
+		if move < 0 {
+			if c.slice[idx].outcome.winner() != 0 {
+				decisions.Undecided++
+			}
+			continue
+		}
+		bucket := (max(move, 1) - 1) / width
+		for len(decisions.Counts) <= bucket {
+			decisions.Counts = append(decisions.Counts, 0)
+		}
+		decisions.Counts[bucket]++
+	}
+	return &decisions, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,76 @@
+// -*- coding: utf-8 -*-
+// pgndecisions_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:11:35.230319116 (1792167095)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPgnCollection_Decisions(t *testing.T) {
+
+	games := []string{
+
+		// decided by the evaluation in move 3
+		"[Result \"1-0\"]\n\n1. e4 {[%eval 0.3]} e5 {[%eval 0.3]} 2. Qh5 {[%eval 0.1]} Nc6 {[%eval 0.2]} 3. Bc4 {[%eval 0.3]} Nf6 {[%eval #1]} 4. Qxf7# 1-0",
+
+		// decided by the material in move 3, once the queen is captured
+		// and it is not recaptured
+		"[Result \"1-0\"]\n\n1. e4 e5 2. Nf3 Qh4 3. Nxh4 d6 4. Nf3 Bg4 1-0",
+
+		// and by checkmate in move 2
+		"[Result \"0-1\"]\n\n1. f3 e5 2. g4 Qh4# 0-1",
+
+		// draws are not considered
+		"[Result \"1/2-1/2\"]\n\n1. e4 e5 1/2-1/2",
+
+		// and decisive games where the winner never got a decisive advantage
+		// are undecided
+		"[Result \"1-0\"]\n\n1. e4 e5 1-0",
+		"[Result \"0-1\"]\n\n1. e4 {[%eval 0.2]} e5 {[%eval 0.1]} 0-1",
+	}
+	c := NewPgnCollection()
+	for _, pgn := range games {
+		game, err := getGameFromString(pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	decisions, err := c.Decisions(2)
+	if err != nil {
+		t.Fatalf("Decisions() error = %v", err)
+	}
+	if len(decisions.Counts) != 2 || decisions.Counts[0] != 1 || decisions.Counts[1] != 2 || decisions.Undecided != 2 {
+		t.Errorf("Decisions() = %+v", decisions)
+	}
+	if table := decisions.String(); !strings.Contains(table, "3-4") || !strings.Contains(table, "Undecided") {
+		t.Errorf("String() = %v", table)
+	}
+	if _, err := c.Decisions(0); err == nil {
+		t.Errorf("Decisions(0) should fail")
+	}
+}
+
+func TestPgnBoard_material(t *testing.T) {
+
+	board, err := NewPgnBoardFromFEN("4k3/8/8/8/8/8/2P5/RN2K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("NewPgnBoardFromFEN() error = %v", err)
+	}
+	if got := board.material(); got != 9 {
+		t.Errorf("material() = %v, want 9", got)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,102 @@
+// -*- coding: utf-8 -*-
+// pgndiagram.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:15:57.331040434 (1792167357)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Diagrams drawn in LaTeX with \chessboard can be given a size (tiny, small,
+// normal or large), and they might hide the side to move, show the coordinates
+// of files and ranks and highlight the squares of the last move. The zero
+// value draws small boards showing the side to move
+type DiagramStyle struct {
+	Size        string // size of the board, small by default
+	HideMover   bool   // whether the side to move is hidden
+	Coordinates bool   // whether files and ranks are shown
+	LastMove    bool   // whether the last move is highlighted
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// The squares of the last move are highlighted with the following options of
+// \chessboard. Their names are taken from the game being played by xskak
+const lastMoveMarks = `,pgfstyle=color,opacity=0.3,color=yellow,markfields={\xskakget{movefrom},\xskakget{moveto}}`
+
+// globals
+// ----------------------------------------------------------------------------
+
+// Every size of diagrams is drawn with a different option of \chessboard
+var diagramSizes = map[string]string{
+	"tiny":   "tinyboard",
+	"small":  "smallboard",
+	"normal": "normalboard",
+	"large":  "largeboard",
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the style of diagrams given in the string spec as a comma separated
+// list of attributes, e.g., "large,coordinates,lastmove", and any error found.
+// Attributes are either a size (tiny, small, normal or large), "nomover",
+// "coordinates" or "lastmove". An empty string returns the default style
+func ParseDiagramStyle(spec string) (style DiagramStyle, err error) {
+
+	for _, attribute := range strings.Split(spec, ",") {
+		switch attribute = strings.TrimSpace(attribute); {
+		case attribute == "":
+			continue
+		case diagramSizes[attribute] != "":
+			style.Size = attribute
+		case attribute == "nomover":
+			style.HideMover = true
+		case attribute == "coordinates":
+			style.Coordinates = true
+		case attribute == "lastmove":
+			style.LastMove = true
+		default:
+			return DiagramStyle{}, fmt.Errorf(" Unknown attribute of diagrams '%v'", attribute)
+		}
+	}
+	return
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the options of \chessboard that print a diagram with this style
+func (style DiagramStyle) options() string {
+
+	size, ok := diagramSizes[style.Size]
+	if !ok {
+		size = diagramSizes["small"]
+	}
+	output := size + ",print"
+	if !style.HideMover {
+		output += ",showmover=true"
+	}
+	if style.Coordinates {
+		output += ",label=true"
+	}
+	if style.LastMove {
+		output += lastMoveMarks
+	}
+	return output
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,61 @@
+// -*- coding: utf-8 -*-
+// pgndiagram_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:16:40.245175931 (1792167400)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDiagramStyle(t *testing.T) {
+
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"", "smallboard,print,showmover=true", false},
+		{"large, coordinates", "largeboard,print,showmover=true,label=true", false},
+		{"tiny,nomover,lastmove", "tinyboard,print" + lastMoveMarks, false},
+		{"huge", "", true},
+	}
+	for _, tt := range tests {
+		style, err := ParseDiagramStyle(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDiagramStyle(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got := style.options(); err == nil && got != tt.want {
+			t.Errorf("ParseDiagramStyle(%q).options() = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestPgnGame_GetLaTeXMovesWithCommentsTabular(t *testing.T) {
+
+	game, err := ParseGame(`[Result "*"]
+
+1. e4 e5 2. Nf3 Nc6 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	// by default, small boards showing the side to move are drawn
+	if got := game.GetLaTeXMovesWithCommentsTabular("4in", "3in", 2); strings.Count(got, `\chessboard[smallboard,print,showmover=true]`) != 2 {
+		t.Errorf("GetLaTeXMovesWithCommentsTabular() = %v", got)
+	}
+	if got := game.GetLaTeXMovesWithCommentsTabular("4in", "3in", 4, DiagramStyle{Size: "large", HideMover: true}); !strings.Contains(got, `\chessboard[largeboard,print]`) {
+		t.Errorf("GetLaTeXMovesWithCommentsTabular() = %v", got)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,241 @@
+// -*- coding: utf-8 -*-
+// pgndiff.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:48:30.515648265 (1792162110)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Number of unchanged lines shown around every change in a unified diff
+const diffContext = 3
+
+// Escape sequences used to color unified diffs in a terminal
+const (
+	diffRed   = "\033[31m"
+	diffGreen = "\033[32m"
+	diffCyan  = "\033[36m"
+	diffReset = "\033[0m"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the edit script that transforms the original lines into the modified
+// ones computed with the longest common subsequence of both. Every line of the
+// result is preceded by a blank if it is common to both, '-' if it is removed
+// from the original lines, or '+' if it is added to them
+func getEditScript(original, modified []string) (script []string) {
+
+	// lcs[i][j] is the length of the longest common subsequence of the lines
+	// original[i:] and modified[j:]
+	lcs := make([][]int, len(original)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(modified)+1)
+	}
+	for i := len(original) - 1; i >= 0; i-- {
+		for j := len(modified) - 1; j >= 0; j-- {
+			if original[i] == modified[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// and walk it forward preferring removals over additions so that the
+	// lines removed are shown first
+	i, j := 0, 0
+	for i < len(original) || j < len(modified) {
+		switch {
+		case i < len(original) && j < len(modified) && original[i] == modified[j]:
+			script = append(script, " "+original[i])
+			i, j = i+1, j+1
+		case j == len(modified) || (i < len(original) && lcs[i+1][j] >= lcs[i][j+1]):
+			script = append(script, "-"+original[i])
+			i++
+		default:
+			script = append(script, "+"+modified[j])
+			j++
+		}
+	}
+	return
+}
+
+// Return the given edit script as a sequence of hunks in the format of unified
+// diffs, each one with the changes and up to diffContext unchanged lines
+// around them. Lines are colored if requested
+func getHunks(script []string, colored bool) (output string) {
+
+	// color the given line with the given escape sequence if requested
+	paint := func(line, color string) string {
+		if colored {
+			return color + line + diffReset
+		}
+		return line
+	}
+
+	// compute for every line of the script its number in the original and the
+	// modified text, starting from 1
+	lines := make([][2]int, len(script)+1)
+	lines[0] = [2]int{1, 1}
+	for idx, line := range script {
+		lines[idx+1] = lines[idx]
+		if line[0] != '+' {
+			lines[idx+1][0]++
+		}
+		if line[0] != '-' {
+			lines[idx+1][1]++
+		}
+	}
+
+	// and write every hunk, which spans from the context before a change
+	// until the context after the last change which is not farther than twice
+	// the context
+	idx := 0
+	for idx < len(script) {
+		if script[idx][0] == ' ' {
+			idx++
+			continue
+		}
+		start, end := max(idx-diffContext, 0), idx
+		for next := idx; next < len(script) && next <= end+2*diffContext; next++ {
+			if script[next][0] != ' ' {
+				end = next
+			}
+		}
+		end = min(end+diffContext+1, len(script))
+		output += paint(fmt.Sprintf("@@ -%v,%v +%v,%v @@",
+			lines[start][0], lines[end][0]-lines[start][0],
+			lines[start][1], lines[end][1]-lines[start][1]), diffCyan) + "\n"
+		for _, line := range script[start:end] {
+			switch line[0] {
+			case '-':
+				output += paint(line, diffRed) + "\n"
+			case '+':
+				output += paint(line, diffGreen) + "\n"
+			default:
+				output += line + "\n"
+			}
+		}
+		idx = end
+	}
+	return
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a copy of this game that can be modified without affecting it, e.g.,
+// to remember it before editing its tags or correcting its moves. Boards are
+// shared with this game
+func (game *PgnGame) Clone() PgnGame {
+	clone := *game
+	clone.tags = maps.Clone(game.tags)
+	clone.moves = slices.Clone(game.moves)
+	return clone
+}
+
+// Return the lines of this game compared in a diff: first, all tags sorted by
+// name, next one ply per line, with its comments folded and re-wrapped as
+// requested in the given options and followed by its variations, and finally
+// the outcome
+func (game *PgnGame) getDiffLines(options pgnOptions) (lines []string) {
+
+	names := make([]string, 0, len(game.tags))
+	for name := range game.tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("[%v \"%v\"]", name, game.tags[name]))
+	}
+	lines = append(lines, "")
+
+	// comments re-wrapped over several lines are shown in different lines
+	for _, move := range game.moves {
+		ply := fmt.Sprintf("%v %v %v", comparisonLabel(move), move.annotated(), move.getPGNAnnotations(options))
+		lines = append(lines, strings.Split(strings.TrimSpace(ply), "\n")...)
+		for _, variation := range move.variations {
+			lines = append(lines, fmt.Sprintf("(%v)", strings.TrimSpace(getPGNLine(variation, options))))
+		}
+	}
+	return append(lines, game.Outcome().String())
+}
+
+// Return a unified diff between the given original game and this one, e.g.,
+// the same game before and after editing its tags or correcting its moves, or
+// the empty string if there are no differences. Tags are compared sorted by
+// name and moves are compared one ply per line. The comments of this game are
+// folded as requested WithCommentFolding and re-wrapped WithCommentWidth,
+// whereas those of the original game are written as they were found, so that
+// their normalization is shown as well. Changes are colored for terminals
+// WithColor
+func (game *PgnGame) GetDiff(original PgnGame, opts ...PgnOption) string {
+
+	options := newPgnOptions(opts...)
+	script := getEditScript(original.getDiffLines(newPgnOptions()), game.getDiffLines(options))
+	if !slices.ContainsFunc(script, func(line string) bool { return line[0] != ' ' }) {
+		return ""
+	}
+	return fmt.Sprintf("--- Game %v (original)\n+++ Game %v (modified)\n%v",
+		original.id, game.id, getHunks(script, options.color))
+}
+
+// Return a copy of this collection whose games can be modified without
+// affecting the games of this one
+func (c PgnCollection) Clone() PgnCollection {
+	clone := c
+	clone.slice = make([]PgnGame, len(c.slice))
+	for idx := range c.slice {
+		clone.slice[idx] = c.slice[idx].Clone()
+	}
+	return clone
+}
+
+// Write in the given writer a unified diff between every game of this
+// collection and the game with the same id in the given original collection,
+// e.g., the same collection before editing tags or correcting moves, and
+// return the number of games that differ. Games which are not found in the
+// original collection are ignored. Diffs are computed with the options
+// accepted by GetDiff. It returns any error found while writing
+func (c PgnCollection) GetDiff(original PgnCollection, writer io.Writer, opts ...PgnOption) (int, error) {
+
+	// index the original games by their id
+	originals := make(map[int]*PgnGame)
+	for idx := range original.slice {
+		originals[original.slice[idx].id] = &original.slice[idx]
+	}
+
+	// and write the differences of every game
+	changed := 0
+	for idx := range c.slice {
+		if igame, ok := originals[c.slice[idx].id]; ok {
+			if diff := c.slice[idx].GetDiff(*igame, opts...); diff != "" {
+				if _, err := io.WriteString(writer, diff); err != nil {
+					return changed, err
+				}
+				changed++
+			}
+		}
+	}
+	return changed, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,85 @@
+// -*- coding: utf-8 -*-
+// pgndiff_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:48:46.085174774 (1792162126)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func Test_getEditScript(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		original []string
+		modified []string
+		want     []string
+	}{
+		{"equal", []string{"a", "b"}, []string{"a", "b"}, []string{" a", " b"}},
+		{"added", []string{"a", "c"}, []string{"a", "b", "c"}, []string{" a", "+b", " c"}},
+		{"removed", []string{"a", "b", "c"}, []string{"a", "c"}, []string{" a", "-b", " c"}},
+		{"changed", []string{"a", "b", "c"}, []string{"a", "x", "c"}, []string{" a", "-b", "+x", " c"}},
+		{"empty", []string{}, []string{"a"}, []string{"+a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getEditScript(tt.original, tt.modified); !slices.Equal(got, tt.want) {
+				t.Errorf("getEditScript() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPgnCollection_GetDiff(t *testing.T) {
+
+	game, err := ParseGame(`[Event "Rated game"]
+[White "alice"]
+[Black "bob"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7 1-0`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	games := NewPgnCollection()
+	games.Add(*game)
+
+	// modifying the clone must not change the original collection
+	original := games.Clone()
+	games.slice[0].tags["Event"] = "Casual game"
+	if _, err := games.VerifyCheckMarkers(FixCheckMarkers); err != nil {
+		t.Fatalf("VerifyCheckMarkers() error = %v", err)
+	}
+	if original.slice[0].tags["Event"] != "Rated game" || original.slice[0].moves[6].shortAlgebraic != "Qxf7" {
+		t.Fatalf("Clone() shares its contents with the collection")
+	}
+
+	var output bytes.Buffer
+	changed, err := games.GetDiff(original, &output)
+	if err != nil || changed != 1 {
+		t.Fatalf("GetDiff() = (%v, %v), want (1, nil)", changed, err)
+	}
+	for _, line := range []string{"--- Game 1 (original)", "-[Event \"Rated game\"]", "+[Event \"Casual game\"]", "-4. Qxf7", "+4. Qxf7#"} {
+		if !slices.Contains(strings.Split(output.String(), "\n"), line) {
+			t.Errorf("GetDiff() = %q does not contain %q", output.String(), line)
+		}
+	}
+
+	// and there are no differences with itself
+	if changed, _ := games.GetDiff(games, &output); changed != 0 {
+		t.Errorf("GetDiff() = %v games changed, want 0", changed)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,397 @@
+// -*- coding: utf-8 -*-
+// pgndossier.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:55:14.685808342 (1792162514)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/clinaresl/table"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A structure is a named pattern of FEN codes, with the syntax accepted by
+// MatchFEN, which describes a typical middlegame position, e.g., a pawn chain.
+// Structures can be read from JSON files, e.g.:
+//
+//	[{"name": "French advance chain",
+//	  "pattern": "*8/*8/*4p*3/*3pP*3/*3P*4/*8/*8/*8 * * * * *"}]
+type PgnStructure struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// An entry of a dossier consists of the results obtained by a player with the
+// given color in the games of a group, e.g., those played with the same
+// opening
+type PgnDossierEntry struct {
+	Name                       string
+	Color                      int
+	Games, Wins, Draws, Losses int
+}
+
+// A dossier is used to prepare games against a player. It contains the results
+// obtained by the player with every color, in the most frequent openings and in
+// the typical middlegame structures reached, and the most recent games played
+// by the player. Entries are sorted by color (first White), then in decreasing
+// order of games, and finally in lexicographical order of their names
+type PgnDossier struct {
+	Player     string
+	Colors     []PgnDossierEntry
+	Openings   []PgnDossierEntry
+	Structures []PgnDossierEntry
+	Recent     []PgnGame
+}
+
+// The data given to templates to render a dossier consists of the dossier and
+// the render context
+type dossierData struct {
+	*PgnDossier
+	Context PgnRenderContext
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Structures are searched in the positions of the middlegame, which is assumed
+// to span over the following range of plies
+const (
+	middlegameStart = 20
+	middlegameEnd   = 60
+)
+
+// Only the following number of most frequent openings with every color are
+// shown when dossiers are shown as tables
+const dossierOpenings = 10
+
+// globals
+// ----------------------------------------------------------------------------
+
+// By default, dossiers consider the following structures, which are recognized
+// by the presence of the pawns that characterize them
+var DefaultStructures = []PgnStructure{
+	{Name: "French advance chain", Pattern: "*8/*8/*4p*3/*3pP*3/*3P*4/*8/*8/*8 * * * * *"},
+	{Name: "King's Indian chain", Pattern: "*8/*8/*3p*4/*3Pp*3/*4P*3/*8/*8/*8 * * * * *"},
+	{Name: "Maroczy bind", Pattern: "*8/*8/*3p*4/*8/*2P*P*3/*8/*8/*8 * * * * *"},
+	{Name: "Hedgehog", Pattern: "*8/*8/pp*pp*3/*8/*2P*P*3/*8/*8/*8 * * * * *"},
+	{Name: "Scheveningen", Pattern: "*8/*8/*3pp*3/*8/*4P*3/*8/*8/*8 * * * * *"},
+	{Name: "Stonewall", Pattern: "*8/*8/*8/*8/*3P*P*2/*2P*P*3/*8/*8 * * * * *"},
+	{Name: "Dutch stonewall", Pattern: "*8/*8/*2p*p*3/*3p*p*2/*8/*8/*8/*8 * * * * *"},
+	{Name: "Slav triangle", Pattern: "*8/*8/*2p*p*3/*3p*4/*8/*8/*8/*8 * * * * *"},
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the structures stored in the given JSON file, and nil if no error was
+// found
+func LoadStructures(filename string) ([]PgnStructure, error) {
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var structures []PgnStructure
+	if err := json.Unmarshal(contents, &structures); err != nil {
+		return nil, fmt.Errorf(" The structures '%v' are not valid: %v", filename, err)
+	}
+	return structures, nil
+}
+
+// Return the name of the given color, 1 for White and -1 for Black
+func colorName(color int) string {
+	if color == 1 {
+		return "White"
+	}
+	return "Black"
+}
+
+// Return the entries in the given map sorted by color (first White), then in
+// decreasing order of games, and finally in lexicographical order of their
+// names
+func sortDossierEntries(entries map[string]*PgnDossierEntry) (result []PgnDossierEntry) {
+	for _, entry := range entries {
+		result = append(result, *entry)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Color != result[j].Color {
+			return result[i].Color > result[j].Color
+		}
+		if result[i].Games != result[j].Games {
+			return result[i].Games > result[j].Games
+		}
+		return result[i].Name < result[j].Name
+	})
+	return
+}
+
+// Return the given entries as a LaTeX tabular with the results of every one.
+// It requires no additional packages
+func getLaTeXDossierEntries(title string, entries []PgnDossierEntry) (output string) {
+
+	output += "\\begin{tabular}{l|l|rrrr|r}\n"
+	output += fmt.Sprintf("%v & Color & Games & W & D & L & Score \\\\ \\hline\n", title)
+	for _, entry := range entries {
+		output += fmt.Sprintf("%v & %v & %v & %v & %v & %v & %.1f\\%% \\\\\n",
+			substituteLaTeX(entry.Name), colorName(entry.Color),
+			entry.Games, entry.Wins, entry.Draws, entry.Losses, entry.Score())
+	}
+	output += "\\end{tabular}\n"
+	return
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Update this entry with a game whose outcome has been scored for the player
+// with the given score (1, 0.5 or 0)
+func (entry *PgnDossierEntry) add(score float32) {
+	entry.Games += 1
+	switch score {
+	case 1:
+		entry.Wins += 1
+	case 0:
+		entry.Losses += 1
+	default:
+		entry.Draws += 1
+	}
+}
+
+// Return the percentage of points obtained in the games of this entry
+// according to the conventional scoring system, or zero if there are none
+func (entry PgnDossierEntry) Score() float64 {
+	if entry.Games == 0 {
+		return 0
+	}
+	return 100 * (float64(entry.Wins) + 0.5*float64(entry.Draws)) / float64(entry.Games)
+}
+
+// Return the name of the opening of this game, or its ECO code if the name is
+// not known
+func (game *PgnGame) openingName() string {
+	if name := game.getTag("Opening"); name != "?" {
+		return name
+	}
+	return game.getTag("ECO")
+}
+
+// Return the indexes of all the given structures found in any position of the
+// middlegame of this game. Games are realized as needed. It returns an error if
+// the game could not be realized or a pattern is not valid
+func (game *PgnGame) findStructures(structures []PgnStructure) ([]int, error) {
+
+	if err := game.Realize(middlegameEnd); err != nil {
+		return nil, err
+	}
+
+	// the FEN code of every position is computed only once
+	found := make([]bool, len(structures))
+	for ply := middlegameStart; ply < len(game.boards); ply++ {
+		fen := game.boards[ply].FEN()
+		for idx, structure := range structures {
+			if found[idx] {
+				continue
+			}
+			ok, err := MatchFEN(structure.Pattern, fen)
+			if err != nil {
+				return nil, fmt.Errorf(" Invalid structure '%v': %w", structure.Name, err)
+			}
+			found[idx] = ok
+		}
+	}
+
+	// and return the indexes of all structures found
+	var indexes []int
+	for idx := range found {
+		if found[idx] {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes, nil
+}
+
+// Return a dossier of the given player with the games of this collection, which
+// considers the given structures (DefaultStructures if none are given) and the
+// given number of most recent games. Only games properly ended are scored.
+//
+// Games are examined in parallel with the number of workers given WithWorkers,
+// and WithProgress reports the number of games examined so far
+func (c PgnCollection) Dossier(player string, structures []PgnStructure, recent int, opts ...PgnOption) (*PgnDossier, error) {
+
+	if structures == nil {
+		structures = DefaultStructures
+	}
+
+	// select the games of the player along with the color used in each one
+	var games []*PgnGame
+	var colors []int
+	for idx := range c.slice {
+		if c.slice[idx].getTag("White") == player {
+			games, colors = append(games, &c.slice[idx]), append(colors, 1)
+		} else if c.slice[idx].getTag("Black") == player {
+			games, colors = append(games, &c.slice[idx]), append(colors, -1)
+		}
+	}
+
+	// look for the structures in all games. Because every worker accesses a
+	// different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	found := make([][]int, len(games))
+	if err := options.forEach(len(games), func(idx int) (err error) {
+		found[idx], err = games[idx].findStructures(structures)
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	// and compute the results of the player in every group of games. Entries
+	// are indexed by their name and color
+	colorEntries := make(map[string]*PgnDossierEntry)
+	openingEntries := make(map[string]*PgnDossierEntry)
+	structureEntries := make(map[string]*PgnDossierEntry)
+	update := func(entries map[string]*PgnDossierEntry, name string, color int, score float32) {
+		key := fmt.Sprintf("%v/%v", color, name)
+		if _, ok := entries[key]; !ok {
+			entries[key] = &PgnDossierEntry{Name: name, Color: color}
+		}
+		entries[key].add(score)
+	}
+	for idx, igame := range games {
+		score, ok := igame.outcome.Score(colors[idx])
+		if !ok {
+			continue
+		}
+		update(colorEntries, "All games", colors[idx], score)
+		update(openingEntries, igame.openingName(), colors[idx], score)
+		for _, istructure := range found[idx] {
+			update(structureEntries, structures[istructure].Name, colors[idx], score)
+		}
+	}
+
+	// the most recent games are those with the latest dates. Dates are
+	// compared as strings, which is correct in the format used in PGN
+	sorted := make([]*PgnGame, len(games))
+	copy(sorted, games)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].getTag("Date") > sorted[j].getTag("Date")
+	})
+	var recentGames []PgnGame
+	for _, igame := range sorted[:min(max(recent, 0), len(sorted))] {
+		recentGames = append(recentGames, *igame)
+	}
+
+	return &PgnDossier{
+		Player:     player,
+		Colors:     sortDossierEntries(colorEntries),
+		Openings:   sortDossierEntries(openingEntries),
+		Structures: sortDossierEntries(structureEntries),
+		Recent:     recentGames,
+	}, nil
+}
+
+// Return the name of the player of this dossier with all special LaTeX
+// characters substituted
+//
+// It is intended to be used in LaTeX templates
+func (dossier PgnDossier) GetLaTeXPlayer() string {
+	return substituteLaTeX(dossier.Player)
+}
+
+// Return a LaTeX tabular with the results of the player with every color
+//
+// It is intended to be used in LaTeX templates
+func (dossier PgnDossier) GetLaTeXColors() string {
+	return getLaTeXDossierEntries("Games", dossier.Colors)
+}
+
+// Return a LaTeX tabular with the results of the player in the given number of
+// most frequent openings with every color. If the number is not strictly
+// positive, all openings are shown
+//
+// It is intended to be used in LaTeX templates
+func (dossier PgnDossier) GetLaTeXOpenings(top int) string {
+	return getLaTeXDossierEntries("Opening", dossier.top(dossier.Openings, top))
+}
+
+// Return a LaTeX tabular with the results of the player in all middlegame
+// structures found
+//
+// It is intended to be used in LaTeX templates
+func (dossier PgnDossier) GetLaTeXStructures() string {
+	return getLaTeXDossierEntries("Structure", dossier.Structures)
+}
+
+// Return the given number of first entries with every color of the given ones.
+// If the number is not strictly positive, all entries are returned
+func (dossier PgnDossier) top(entries []PgnDossierEntry, n int) (result []PgnDossierEntry) {
+	count := make(map[int]int)
+	for _, entry := range entries {
+		if n <= 0 || count[entry.Color] < n {
+			result = append(result, entry)
+			count[entry.Color]++
+		}
+	}
+	return
+}
+
+// Dossiers are stringers, so that they can be shown on any writer. A table is
+// shown with the results of the player with every color, in the most frequent
+// openings and in every structure
+func (dossier PgnDossier) String() string {
+
+	tab, _ := table.NewTable(" l | l | r r r r | r ")
+	tab.AddRow(table.Multicolumn(7, "c", fmt.Sprintf("Dossier: %v", dossier.Player)))
+	for _, group := range []struct {
+		title   string
+		entries []PgnDossierEntry
+	}{
+		{"Games", dossier.Colors},
+		{"Opening", dossier.top(dossier.Openings, dossierOpenings)},
+		{"Structure", dossier.Structures},
+	} {
+		tab.AddThickRule()
+		tab.AddRow(group.title, "Color", "Games", "W", "D", "L", "Score")
+		tab.AddSingleRule()
+		for _, entry := range group.entries {
+			tab.AddRow(entry.Name, colorName(entry.Color), entry.Games,
+				entry.Wins, entry.Draws, entry.Losses, fmt.Sprintf("%.1f%%", entry.Score()))
+		}
+	}
+
+	return fmt.Sprintf("%v", tab)
+}
+
+// Write into the given writer the result of instantiating the given template
+// file with this dossier, which is available in the template along with the
+// render context given in the options (as .Context). Meta-variables in the
+// template take the values given WithTemplateVars. It returns any error found
+// or nil otherwise
+func (dossier *PgnDossier) ToWriterFromTemplate(dst io.Writer, templateFile string, opts ...PgnOption) error {
+
+	options := newPgnOptions(opts...)
+	tpl, err := parseTemplate(templateFile, options)
+	if err != nil {
+		return err
+	}
+	data := newTemplateData(nil, options)
+	return tpl.Execute(dst, dossierData{
+		PgnDossier: dossier,
+		Context:    data.Context,
+	})
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
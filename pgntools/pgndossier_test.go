@@ -0,0 +1,80 @@
+// -*- coding: utf-8 -*-
+// pgndossier_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:55:42.034051446 (1792162542)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"testing"
+)
+
+func TestDefaultStructures(t *testing.T) {
+
+	// all patterns have to be valid, and none of them matches the initial
+	// position
+	for _, structure := range DefaultStructures {
+		ok, err := MatchFEN(structure.Pattern, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+		if err != nil || ok {
+			t.Errorf("MatchFEN(%v) = (%v, %v), want (false, nil)", structure.Name, ok, err)
+		}
+	}
+}
+
+func TestPgnCollection_Dossier(t *testing.T) {
+
+	games := NewPgnCollection()
+	for _, pgn := range []string{
+		`[White "alice"] [Black "bob"] [Date "2024.01.10"] [Opening "French Defense"]
+
+1. e4 e6 2. d4 d5 3. e5 c5 4. c3 Nc6 5. Nf3 Qb6 6. a3 c4 7. Nbd2 Bd7 8. Be2 Na5
+9. O-O Ne7 10. Rb1 Nec6 11. Re1 Be7 1-0`,
+		`[White "bob"] [Black "alice"] [Date "2024.03.01"] [Opening "Sicilian Defense"]
+
+1. e4 c5 2. Nf3 d6 1/2-1/2`,
+		`[White "alice"] [Black "carol"] [Date "2024.02.15"] [Opening "French Defense"]
+
+1. e4 e6 2. d4 d5 0-1`,
+		`[White "carol"] [Black "dave"] [Date "2024.04.01"] [ECO "A00"]
+
+1. a3 e5 1-0`,
+	} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		games.Add(*game)
+	}
+
+	dossier, err := games.Dossier("alice", nil, 2)
+	if err != nil {
+		t.Fatalf("Dossier() error = %v", err)
+	}
+	if len(dossier.Colors) != 2 || dossier.Colors[0] != (PgnDossierEntry{"All games", 1, 2, 1, 0, 1}) ||
+		dossier.Colors[1] != (PgnDossierEntry{"All games", -1, 1, 0, 1, 0}) {
+		t.Errorf("Dossier() colors = %v", dossier.Colors)
+	}
+	if len(dossier.Openings) != 2 || dossier.Openings[0] != (PgnDossierEntry{"French Defense", 1, 2, 1, 0, 1}) ||
+		dossier.Openings[0].Score() != 50 {
+		t.Errorf("Dossier() openings = %v", dossier.Openings)
+	}
+	if len(dossier.Structures) != 1 || dossier.Structures[0] != (PgnDossierEntry{"French advance chain", 1, 1, 1, 0, 0}) {
+		t.Errorf("Dossier() structures = %v", dossier.Structures)
+	}
+	if len(dossier.Recent) != 2 || dossier.Recent[0].getTag("Date") != "2024.03.01" || dossier.Recent[1].getTag("Date") != "2024.02.15" {
+		t.Errorf("Dossier() recent games = %v", dossier.Recent)
+	}
+
+	// only the given number of openings with every color are shown
+	if top := dossier.top(dossier.Openings, 1); len(top) != 2 {
+		t.Errorf("top() = %v, want one opening with every color", top)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,92 @@
+// -*- coding: utf-8 -*-
+// pgnelo.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:23:28.334742438 (1792160608)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Width of the rating classes, e.g., "2200-2400"
+const ratingClassWidth = 200
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the rating given in the value of an Elo tag and true if it is known,
+// or 0 and false otherwise. Unknown ratings are given either with the empty
+// string, a dash or a question mark, and also with zero as some servers do
+func eloValue(value any) (int, bool) {
+	if rating, ok := value.(int); ok && rating > 0 {
+		return rating, true
+	}
+	return 0, false
+}
+
+// Verify that the Elo tags (WhiteElo and BlackElo) in the given tags, if any,
+// are either a non-negative integer or unknown, and return an error otherwise
+func validateElo(tags map[string]any) error {
+	for _, name := range []string{"WhiteElo", "BlackElo"} {
+		value, ok := tags[name]
+		if !ok {
+			continue
+		}
+		if rating, ok := value.(int); ok && rating >= 0 {
+			continue
+		}
+		if text, ok := value.(string); ok {
+			switch strings.TrimSpace(text) {
+			case "", "-", "?":
+				continue
+			}
+		}
+		return fmt.Errorf(" Invalid rating '%v' in tag '%v'", value, name)
+	}
+	return nil
+}
+
+// Return the rating class of the given rating, e.g., "2200-2400", or "?" if the
+// rating is unknown, i.e., if it is not strictly positive
+func ratingClass(rating int) string {
+	if rating <= 0 {
+		return "?"
+	}
+	lower := ratingClassWidth * (rating / ratingClassWidth)
+	return fmt.Sprintf("%v-%v", lower, lower+ratingClassWidth)
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the ratings of both players, with unknown ratings equal to 0, along
+// with their average and difference (White's rating minus Black's). If only
+// one rating is known, it is taken as the average; if any rating is unknown,
+// the difference is 0
+func (game *PgnGame) eloFields() (white, black, avg, diff int) {
+
+	white, whiteOk := eloValue(game.tags["WhiteElo"])
+	black, blackOk := eloValue(game.tags["BlackElo"])
+	switch {
+	case whiteOk && blackOk:
+		avg, diff = (white+black)/2, white-black
+	case whiteOk:
+		avg = white
+	case blackOk:
+		avg = black
+	}
+	return
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,78 @@
+// -*- coding: utf-8 -*-
+// pgnelo_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:24:00.786358662 (1792160640)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import "testing"
+
+func Test_validateElo(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    map[string]any
+		wantErr bool
+	}{
+		{"missing", map[string]any{}, false},
+		{"integers", map[string]any{"WhiteElo": 2200, "BlackElo": 0}, false},
+		{"unknown", map[string]any{"WhiteElo": "-", "BlackElo": "?"}, false},
+		{"empty", map[string]any{"WhiteElo": ""}, false},
+		{"negative", map[string]any{"BlackElo": -10}, true},
+		{"text", map[string]any{"WhiteElo": "2200?"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateElo(tt.tags); (err != nil) != tt.wantErr {
+				t.Errorf("validateElo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ratingClass(t *testing.T) {
+	tests := []struct {
+		rating int
+		want   string
+	}{
+		{0, "?"},
+		{150, "0-200"},
+		{2200, "2200-2400"},
+		{2399, "2200-2400"},
+	}
+	for _, tt := range tests {
+		if got := ratingClass(tt.rating); got != tt.want {
+			t.Errorf("ratingClass(%v) = %v, want %v", tt.rating, got, tt.want)
+		}
+	}
+}
+
+func TestPgnGame_eloFields(t *testing.T) {
+	tests := []struct {
+		name                    string
+		tags                    map[string]any
+		white, black, avg, diff int
+	}{
+		{"both", map[string]any{"WhiteElo": 2300, "BlackElo": 2100}, 2300, 2100, 2200, 200},
+		{"white", map[string]any{"WhiteElo": 1800, "BlackElo": "?"}, 1800, 0, 1800, 0},
+		{"black", map[string]any{"BlackElo": 1500}, 0, 1500, 1500, 0},
+		{"none", map[string]any{"WhiteElo": "-", "BlackElo": 0}, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := PgnGame{tags: tt.tags}
+			white, black, avg, diff := game.eloFields()
+			if white != tt.white || black != tt.black || avg != tt.avg || diff != tt.diff {
+				t.Errorf("eloFields() = (%v, %v, %v, %v), want (%v, %v, %v, %v)",
+					white, black, avg, diff, tt.white, tt.black, tt.avg, tt.diff)
+			}
+		})
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
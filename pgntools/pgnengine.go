@@ -0,0 +1,425 @@
+// -*- coding: utf-8 -*-
+// pgnengine.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:02:26.046702731 (1792166546)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/clinaresl/table"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// An engine returns the move it prefers in the position given with its FEN
+// code in UCI notation, i.e., with the starting and ending squares followed by
+// the piece a pawn is promoted to, if any, e.g., "e2e4" or "e7e8q", or any
+// error found. If there are no legal moves, the empty string is returned
+type EngineSearcher interface {
+	BestMove(fen string) (string, error)
+}
+
+// The analysis of a position consists of the move preferred by the engine, its
+// evaluation from the point of view of White, as given in PGN comments with
+// "[%eval ...]", e.g., "0.25" or "#-3" if Black mates in three, and the depth
+// of the search
+type EngineResult struct {
+	BestMove string
+	Eval     string
+	Depth    int
+}
+
+// An engine analyzer returns the analysis of the position given with its FEN
+// code, or any error found, and it searches all positions up to the same depth
+type EngineAnalyzer interface {
+	Analyze(fen string) (EngineResult, error)
+	Depth() int
+}
+
+// A UCI engine runs as a separate process which is given positions through its
+// standard input and which writes the moves it prefers in its standard output.
+// Every position is searched up to the same depth. Engines can be safely used
+// by different goroutines as positions are searched one at a time
+type UCIEngine struct {
+	depth   int
+	command *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Scanner
+	mutex   sync.Mutex
+}
+
+// Games are divided in three phases according to the number of plies played
+type GamePhase int
+
+// The agreement of a player with an engine consists of the number of moves
+// played by the player in every phase of the game, and the number of them that
+// matched the first choice of the engine
+type PgnAgreement struct {
+	Player  string
+	Moves   [3]int
+	Matches [3]int
+}
+
+// The agreement of all players are shown in a table
+type PgnAgreements []PgnAgreement
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Moves played before the middlegame are in the opening and those played after
+// it are in the endgame
+const (
+	OpeningPhase GamePhase = iota
+	MiddlegamePhase
+	EndgamePhase
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a new UCI engine running the executable in the given path, which
+// searches all positions up to the given depth, and any error found
+func NewUCIEngine(path string, depth int) (*UCIEngine, error) {
+
+	if depth <= 0 {
+		return nil, fmt.Errorf(" Invalid depth %v. It should be strictly positive", depth)
+	}
+
+	// start the engine connecting to both its standard input and output
+	command := exec.Command(path)
+	stdin, err := command.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := command.Start(); err != nil {
+		return nil, err
+	}
+	engine := &UCIEngine{
+		depth:   depth,
+		command: command,
+		stdin:   stdin,
+		stdout:  bufio.NewScanner(stdout),
+	}
+
+	// and wait until it is ready
+	for _, handshake := range [][2]string{{"uci", "uciok"}, {"isready", "readyok"}} {
+		if err := engine.send(handshake[0]); err != nil {
+			engine.Close()
+			return nil, err
+		}
+		if _, err := engine.wait(handshake[1]); err != nil {
+			engine.Close()
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+// Return the phase of the move played after the given number of plies
+func getPhase(ply int) GamePhase {
+	if ply < middlegameStart {
+		return OpeningPhase
+	}
+	if ply < middlegameEnd {
+		return MiddlegamePhase
+	}
+	return EndgamePhase
+}
+
+// Return the evaluation from the point of view of White given in the fields of
+// an "info" line written by a UCI engine when the given color is to move, and
+// true if a score was found. Scores are given either in
+// centipawns or in moves to mate, e.g., "score cp 25" or "score mate -3", from
+// the point of view of the side to move
+func getUCIScore(fields []string, color int) (string, bool) {
+
+	for idx := 0; idx+2 < len(fields); idx++ {
+		if fields[idx] != "score" {
+			continue
+		}
+		value, err := strconv.Atoi(fields[idx+2])
+		if err != nil {
+			return "", false
+		}
+		switch fields[idx+1] {
+		case "cp":
+			return strconv.FormatFloat(float64(color*value)/100, 'f', 2, 64), true
+		case "mate":
+			return fmt.Sprintf("#%v", color*value), true
+		}
+	}
+	return "", false
+}
+
+// Return the given move in UCI notation. The move must have been played on a
+// board
+func getUCIMove(move PgnMove) string {
+	result := move.from + move.to
+	if idx := strings.Index(move.shortAlgebraic, "="); idx >= 0 && idx+1 < len(move.shortAlgebraic) {
+		result += strings.ToLower(move.shortAlgebraic[idx+1 : idx+2])
+	}
+	return result
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Send the given command to this engine
+func (engine *UCIEngine) send(command string) error {
+	_, err := fmt.Fprintln(engine.stdin, command)
+	return err
+}
+
+// Return the first line written by this engine starting with the given prefix,
+// skipping all others, and an error if the engine stopped before
+func (engine *UCIEngine) wait(prefix string) (string, error) {
+	for engine.stdout.Scan() {
+		if line := strings.TrimSpace(engine.stdout.Text()); strings.HasPrefix(line, prefix) {
+			return line, nil
+		}
+	}
+	if err := engine.stdout.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf(" The engine stopped before writing '%v'", prefix)
+}
+
+// Return the depth of the searches of this engine
+func (engine *UCIEngine) Depth() int {
+	return engine.depth
+}
+
+// Return the analysis of the position given with its FEN code by this engine.
+// The evaluation is taken from the last score written before the best move
+func (engine *UCIEngine) Analyze(fen string) (EngineResult, error) {
+
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	if err := engine.send("position fen " + fen); err != nil {
+		return EngineResult{}, err
+	}
+	if err := engine.send(fmt.Sprintf("go depth %v", engine.depth)); err != nil {
+		return EngineResult{}, err
+	}
+
+	// scores are given from the point of view of the side to move
+	color := 1
+	if fields := strings.Fields(fen); len(fields) > 1 && fields[1] == "b" {
+		color = -1
+	}
+	result := EngineResult{Depth: engine.depth}
+	for engine.stdout.Scan() {
+		fields := strings.Fields(engine.stdout.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "info":
+			if eval, ok := getUCIScore(fields, color); ok {
+				result.Eval = eval
+			}
+
+		// the move is given right after the command, and positions without
+		// legal moves are reported either with "(none)" or "0000"
+		case "bestmove":
+			if len(fields) > 1 && fields[1] != "(none)" && fields[1] != "0000" {
+				result.BestMove = fields[1]
+			}
+			return result, nil
+		}
+	}
+	if err := engine.stdout.Err(); err != nil {
+		return EngineResult{}, err
+	}
+	return EngineResult{}, fmt.Errorf(" The engine stopped before writing 'bestmove'")
+}
+
+// Return the move preferred by this engine in the position given with its FEN
+// code
+func (engine *UCIEngine) BestMove(fen string) (string, error) {
+	result, err := engine.Analyze(fen)
+	return result.BestMove, err
+}
+
+// Stop this engine and release all its resources
+func (engine *UCIEngine) Close() error {
+	engine.send("quit")
+	engine.stdin.Close()
+	return engine.command.Wait()
+}
+
+// Return a string with the name of this phase
+func (phase GamePhase) String() string {
+	switch phase {
+	case OpeningPhase:
+		return "opening"
+	case MiddlegamePhase:
+		return "middlegame"
+	case EndgamePhase:
+		return "endgame"
+	}
+	return "unknown"
+}
+
+// Return the percentage of moves of this player in the given phase that
+// matched the first choice of the engine, or 0 if no moves were played
+func (agreement PgnAgreement) Rate(phase GamePhase) float64 {
+	if agreement.Moves[phase] == 0 {
+		return 0
+	}
+	return 100 * float64(agreement.Matches[phase]) / float64(agreement.Moves[phase])
+}
+
+// Return the percentage of all moves of this player that matched the first
+// choice of the engine, or 0 if no moves were played
+func (agreement PgnAgreement) Overall() float64 {
+	moves, matches := 0, 0
+	for phase := range agreement.Moves {
+		moves += agreement.Moves[phase]
+		matches += agreement.Matches[phase]
+	}
+	if moves == 0 {
+		return 0
+	}
+	return 100 * float64(matches) / float64(moves)
+}
+
+// Agreements are stringers, so that they can be shown on any writer. Every row
+// shows the rate of agreement of a player in every phase and overall
+func (agreements PgnAgreements) String() string {
+
+	tab, _ := table.NewTable(" l | r r r | r r ")
+	tab.AddRow("Player", "Opening", "Middlegame", "Endgame", "Overall", "Moves")
+	tab.AddThickRule()
+	for _, iagreement := range agreements {
+		moves := 0
+		for _, n := range iagreement.Moves {
+			moves += n
+		}
+		tab.AddRow(iagreement.Player,
+			fmt.Sprintf("%.1f%%", iagreement.Rate(OpeningPhase)),
+			fmt.Sprintf("%.1f%%", iagreement.Rate(MiddlegamePhase)),
+			fmt.Sprintf("%.1f%%", iagreement.Rate(EndgamePhase)),
+			fmt.Sprintf("%.1f%%", iagreement.Overall()), moves)
+	}
+	return fmt.Sprintf("%v", tab)
+}
+
+// Compare every move of this game with the first choice of the given engine,
+// and remember whether they matched. The game is played if necessary
+func (game *PgnGame) computeAgreement(engine EngineSearcher) error {
+
+	if err := game.play(); err != nil {
+		return err
+	}
+	agreement := make([]bool, len(game.moves))
+	for ply, move := range game.moves {
+		best, err := engine.BestMove(game.boards[ply].ToFEN())
+		if err != nil {
+			return err
+		}
+		agreement[ply] = best == getUCIMove(move)
+	}
+	game.agreement = agreement
+	return nil
+}
+
+// Return the agreement with the engine of the player with the given color (1
+// for White and -1 for Black) in this game. If the agreement was not computed,
+// no moves are counted
+func (game *PgnGame) getAgreement(color int) PgnAgreement {
+
+	result := PgnAgreement{Player: game.getTag("White")}
+	if color < 0 {
+		result.Player = game.getTag("Black")
+	}
+	for ply, matched := range game.agreement {
+		if game.moves[ply].color != color {
+			continue
+		}
+		result.Moves[getPhase(ply)]++
+		if matched {
+			result.Matches[getPhase(ply)]++
+		}
+	}
+	return result
+}
+
+// Return the percentage of moves of White that matched the first choice of the
+// engine, as computed with EngineAgreement, or 0 if it was not computed
+func (game *PgnGame) WhiteAgreement() float64 {
+	return game.getAgreement(1).Overall()
+}
+
+// Return the percentage of moves of Black that matched the first choice of the
+// engine, as computed with EngineAgreement, or 0 if it was not computed
+func (game *PgnGame) BlackAgreement() float64 {
+	return game.getAgreement(-1).Overall()
+}
+
+// Compare every move of all games in this collection with the first choice of
+// the given engine, and return the agreement of every player in all their
+// games, sorted by name. The agreement of every game is remembered so that it
+// can be used in filters and templates with WhiteAgreement and
+// BlackAgreement. Games are analyzed in parallel with the number of workers
+// given WithWorkers, and WithProgress reports the number of games analyzed so
+// far
+func (c PgnCollection) EngineAgreement(engine EngineSearcher, opts ...PgnOption) (PgnAgreements, error) {
+
+	// analyze all games. Because every worker accesses a different game, no
+	// synchronization is needed
+	options := newPgnOptions(opts...)
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		return c.slice[idx].computeAgreement(engine)
+	}); err != nil {
+		return nil, err
+	}
+
+	// and add the agreement of every player in all games
+	players := make(map[string]*PgnAgreement)
+	for idx := range c.slice {
+		for _, color := range []int{1, -1} {
+			agreement := c.slice[idx].getAgreement(color)
+			total, ok := players[agreement.Player]
+			if !ok {
+				total = &PgnAgreement{Player: agreement.Player}
+				players[agreement.Player] = total
+			}
+			for phase := range agreement.Moves {
+				total.Moves[phase] += agreement.Moves[phase]
+				total.Matches[phase] += agreement.Matches[phase]
+			}
+		}
+	}
+	result := make(PgnAgreements, 0, len(players))
+	for _, iagreement := range players {
+		result = append(result, *iagreement)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Player < result[j].Player
+	})
+	return result, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,126 @@
+// -*- coding: utf-8 -*-
+// pgnengine_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:02:40.164497084 (1792166560)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// An engine which always prefers the given moves, in the same order, in the
+// successive positions it is given
+type scriptedEngine struct {
+	moves []string
+}
+
+func (engine *scriptedEngine) BestMove(fen string) (string, error) {
+	if len(engine.moves) == 0 {
+		return "", nil
+	}
+	move := engine.moves[0]
+	engine.moves = engine.moves[1:]
+	return move, nil
+}
+
+func TestPgnCollection_EngineAgreement(t *testing.T) {
+
+	game, err := getGameFromString("[White \"Alice\"]\n[Black \"Bob\"]\n\n1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 *")
+	if err != nil {
+		t.Fatalf("getGameFromString() error = %v", err)
+	}
+	c := NewPgnCollection()
+	c.Add(*game)
+
+	// White matches the engine in all moves and Black only in the first one
+	engine := scriptedEngine{moves: []string{"e2e4", "e7e5", "g1f3", "g8f6", "f1b5", "g8f6"}}
+	agreements, err := c.EngineAgreement(&engine)
+	if err != nil {
+		t.Fatalf("EngineAgreement() error = %v", err)
+	}
+	want := PgnAgreements{
+		{Player: "Alice", Moves: [3]int{3, 0, 0}, Matches: [3]int{3, 0, 0}},
+		{Player: "Bob", Moves: [3]int{3, 0, 0}, Matches: [3]int{1, 0, 0}},
+	}
+	if len(agreements) != len(want) || agreements[0] != want[0] || agreements[1] != want[1] {
+		t.Errorf("EngineAgreement() = %v, want %v", agreements, want)
+	}
+	if got := agreements[1].Rate(OpeningPhase); got != 100.0/3 {
+		t.Errorf("Rate() = %v, want %v", got, 100.0/3)
+	}
+	if got := agreements[1].Rate(EndgamePhase); got != 0 {
+		t.Errorf("Rate() = %v, want 0", got)
+	}
+
+	// the agreement is remembered in every game
+	if got := c.slice[0].WhiteAgreement(); got != 100 {
+		t.Errorf("WhiteAgreement() = %v, want 100", got)
+	}
+	if got := c.slice[0].BlackAgreement(); got != 100.0/3 {
+		t.Errorf("BlackAgreement() = %v, want %v", got, 100.0/3)
+	}
+}
+
+func TestGetUCIMove(t *testing.T) {
+
+	game, err := getGameFromString("[Event \"?\"]\n[SetUp \"1\"]\n[FEN \"8/4P3/8/8/8/8/8/K1k5 w - - 0 1\"]\n\n1. e8=N *")
+	if err != nil {
+		t.Fatalf("getGameFromString() error = %v", err)
+	}
+	if err := game.play(); err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+	if got := getUCIMove(game.moves[0]); got != "e7e8n" {
+		t.Errorf("getUCIMove() = %v, want e7e8n", got)
+	}
+}
+
+func TestUCIEngine_BestMove(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the engine is simulated with a shell script")
+	}
+
+	// simulate an engine which always prefers the same move
+	path := filepath.Join(t.TempDir(), "engine")
+	script := `#!/bin/sh
+while read line; do
+	case "$line" in
+		uci) echo "id name scripted"; echo "uciok";;
+		isready) echo "readyok";;
+		go*) echo "info depth 1 score cp 20"; echo "bestmove e2e4 ponder e7e5";;
+		quit) exit 0;;
+	esac
+done
+`
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	engine, err := NewUCIEngine(path, 1)
+	if err != nil {
+		t.Fatalf("NewUCIEngine() error = %v", err)
+	}
+	defer engine.Close()
+	if got, err := engine.BestMove("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"); err != nil || got != "e2e4" {
+		t.Errorf("BestMove() = %v, %v, want e2e4", got, err)
+	}
+	if got, err := engine.Analyze("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"); err != nil || got != (EngineResult{"e2e4", "-0.20", 1}) {
+		t.Errorf("Analyze() = %v, %v", got, err)
+	}
+	if _, err := NewUCIEngine(path, 0); err == nil {
+		t.Errorf("NewUCIEngine() with depth 0 should fail")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
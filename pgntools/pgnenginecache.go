@@ -0,0 +1,187 @@
+// -*- coding: utf-8 -*-
+// pgnenginecache.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:05:48.155713645 (1792166748)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A cached engine remembers the analysis of every position given to an engine
+// in a file, so that positions analyzed before, either in the same run or in
+// previous ones, are not analyzed again unless the depth of the previous
+// analysis is smaller. Positions are identified by a hash of their FEN code,
+// so that transpositions are analyzed only once. Cached engines can be safely
+// used by different goroutines
+type CachedEngine struct {
+	engine  EngineAnalyzer
+	entries map[uint64]EngineResult
+	file    *os.File
+	hits    int
+	mutex   sync.Mutex
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the hash of the position given with its FEN code, which considers the
+// first four fields, i.e., the piece placement, the side to move, the castling
+// rights and the en passant square, and ignores the move counters
+func getPositionHash(fen string) uint64 {
+	fields := strings.Fields(fen)
+	hasher := fnv.New64a()
+	hasher.Write([]byte(strings.Join(fields[:min(4, len(fields))], " ")))
+	return hasher.Sum64()
+}
+
+// Return the entry of the cache given in the specified line, and true if it is
+// well formed. Every line consists of the hash of the position in hexadecimal,
+// the depth of the analysis, the best move and the evaluation, where unknown
+// values are given with a dash
+func getCacheEntry(line string) (uint64, EngineResult, bool) {
+
+	fields := strings.Fields(line)
+	if len(fields) != 4 {
+		return 0, EngineResult{}, false
+	}
+	hash, err := strconv.ParseUint(fields[0], 16, 64)
+	if err != nil {
+		return 0, EngineResult{}, false
+	}
+	depth, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, EngineResult{}, false
+	}
+	result := EngineResult{BestMove: fields[2], Eval: fields[3], Depth: depth}
+	if result.BestMove == "-" {
+		result.BestMove = ""
+	}
+	if result.Eval == "-" {
+		result.Eval = ""
+	}
+	return hash, result, true
+}
+
+// Return a new cached engine which analyzes positions with the given engine
+// and remembers all analyses in the file with the given name. If the file
+// exists, all analyses found in it are loaded first, and malformed lines, e.g.,
+// those written partially in an interrupted run, are ignored. It returns any
+// error found
+func NewCachedEngine(engine EngineAnalyzer, filename string) (*CachedEngine, error) {
+
+	cache := &CachedEngine{
+		engine:  engine,
+		entries: make(map[uint64]EngineResult),
+	}
+
+	// load the analyses of previous runs, keeping the deepest analysis of
+	// every position
+	if stream, err := os.Open(filename); err == nil {
+		scanner := bufio.NewScanner(stream)
+		for scanner.Scan() {
+			if hash, result, ok := getCacheEntry(scanner.Text()); ok {
+				if previous, found := cache.entries[hash]; !found || previous.Depth <= result.Depth {
+					cache.entries[hash] = result
+				}
+			}
+		}
+		stream.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// and new analyses are appended as soon as they are computed, so that
+	// they are kept even if the run is interrupted
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	cache.file = file
+	return cache, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the depth of the searches of the engine of this cache
+func (cache *CachedEngine) Depth() int {
+	return cache.engine.Depth()
+}
+
+// Return the analysis of the position given with its FEN code, which is taken
+// from this cache if it was analyzed before at least with the same depth of the
+// engine, or computed by the engine otherwise
+func (cache *CachedEngine) Analyze(fen string) (EngineResult, error) {
+
+	hash := getPositionHash(fen)
+	cache.mutex.Lock()
+	if result, ok := cache.entries[hash]; ok && result.Depth >= cache.engine.Depth() {
+		cache.hits++
+		cache.mutex.Unlock()
+		return result, nil
+	}
+	cache.mutex.Unlock()
+
+	// analyze the position and remember the result
+	result, err := cache.engine.Analyze(fen)
+	if err != nil {
+		return EngineResult{}, err
+	}
+	bestMove, eval := result.BestMove, result.Eval
+	if bestMove == "" {
+		bestMove = "-"
+	}
+	if eval == "" {
+		eval = "-"
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries[hash] = result
+	if _, err := fmt.Fprintf(cache.file, "%016x %v %v %v\n", hash, result.Depth, bestMove, eval); err != nil {
+		return EngineResult{}, err
+	}
+	return result, nil
+}
+
+// Return the move preferred by the engine in the position given with its FEN
+// code, see Analyze
+func (cache *CachedEngine) BestMove(fen string) (string, error) {
+	result, err := cache.Analyze(fen)
+	return result.BestMove, err
+}
+
+// Return the number of positions analyzed so far which were found in this
+// cache
+func (cache *CachedEngine) Hits() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.hits
+}
+
+// Close the file of this cache. The engine is not closed
+func (cache *CachedEngine) Close() error {
+	return cache.file.Close()
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,109 @@
+// -*- coding: utf-8 -*-
+// pgnenginecache_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:06:05.357370158 (1792166765)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// An analyzer which prefers the same move in all positions and counts the
+// number of positions analyzed
+type countingAnalyzer struct {
+	depth, analyzed int
+}
+
+func (analyzer *countingAnalyzer) Analyze(fen string) (EngineResult, error) {
+	analyzer.analyzed++
+	return EngineResult{BestMove: "e2e4", Eval: "0.30", Depth: analyzer.depth}, nil
+}
+
+func (analyzer *countingAnalyzer) Depth() int {
+	return analyzer.depth
+}
+
+func TestCachedEngine_Analyze(t *testing.T) {
+
+	filename := filepath.Join(t.TempDir(), "evals.cache")
+	initial := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	transposed := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 4 3"
+	other := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+
+	// positions which only differ in the move counters are analyzed once
+	analyzer := countingAnalyzer{depth: 10}
+	cache, err := NewCachedEngine(&analyzer, filename)
+	if err != nil {
+		t.Fatalf("NewCachedEngine() error = %v", err)
+	}
+	for _, fen := range []string{initial, transposed, other} {
+		if result, err := cache.Analyze(fen); err != nil || result.BestMove != "e2e4" || result.Eval != "0.30" {
+			t.Errorf("Analyze() = %v, %v", result, err)
+		}
+	}
+	if analyzer.analyzed != 2 || cache.Hits() != 1 {
+		t.Errorf("Analyze() analyzed %v positions with %v hits, want 2 and 1", analyzer.analyzed, cache.Hits())
+	}
+	cache.Close()
+
+	// analyses are kept in the file even if it is partially written
+	stream, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	stream.WriteString("00ab 1")
+	stream.Close()
+	analyzer = countingAnalyzer{depth: 10}
+	cache, err = NewCachedEngine(&analyzer, filename)
+	if err != nil {
+		t.Fatalf("NewCachedEngine() error = %v", err)
+	}
+	if move, err := cache.BestMove(other); err != nil || move != "e2e4" || analyzer.analyzed != 0 {
+		t.Errorf("BestMove() = %v, %v after analyzing %v positions, want e2e4 from the cache", move, err, analyzer.analyzed)
+	}
+	cache.Close()
+
+	// but positions are analyzed again with deeper searches
+	analyzer = countingAnalyzer{depth: 20}
+	cache, err = NewCachedEngine(&analyzer, filename)
+	if err != nil {
+		t.Fatalf("NewCachedEngine() error = %v", err)
+	}
+	defer cache.Close()
+	if _, err := cache.Analyze(initial); err != nil || analyzer.analyzed != 1 {
+		t.Errorf("Analyze() analyzed %v positions, want 1", analyzer.analyzed)
+	}
+}
+
+func TestGetUCIScore(t *testing.T) {
+
+	tests := []struct {
+		line  string
+		color int
+		eval  string
+		ok    bool
+	}{
+		{"info depth 12 seldepth 18 score cp 25 nodes 1000 pv e2e4", 1, "0.25", true},
+		{"info depth 12 score cp 25 lowerbound", -1, "-0.25", true},
+		{"info depth 20 score mate 3 pv f3f7", -1, "#-3", true},
+		{"info depth 20 score mate -2", 1, "#-2", true},
+		{"info string NNUE evaluation enabled", 1, "", false},
+	}
+	for _, tt := range tests {
+		if eval, ok := getUCIScore(strings.Fields(tt.line), tt.color); eval != tt.eval || ok != tt.ok {
+			t.Errorf("getUCIScore(%q) = %v, %v, want %v, %v", tt.line, eval, ok, tt.eval, tt.ok)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
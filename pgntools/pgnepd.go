@@ -0,0 +1,454 @@
+// -*- coding: utf-8 -*-
+// pgnepd.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:54:22.461058179 (1792169662)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// An EPD operation consists of an opcode and its operands, e.g., "bm Nf3 c4"
+type PgnEPDOperation struct {
+	Opcode   string
+	Operands []string
+}
+
+// Collections of positions consist of EPD records, e.g., the positions of an
+// engine test suite, which can be selected by the values of their operations
+// or converted to games to be processed as any other collection of games
+type PgnPositionCollection struct {
+	records []PgnEPDRecord
+}
+
+// An EPD record consists of a position given with its FEN code and a sequence
+// of operations, e.g., the best moves (bm), the moves to avoid (am) or the
+// identifier (id) of the position in a test suite. The halfmove clock and the
+// fullmove number of the FEN code are given in the operations hmvc and fmvn, if
+// any, or they are 0 and 1 respectively.
+//
+// EPD records are not named PgnPosition because that type already exists: it
+// is the position of a game given by Positions, along with the move played in
+// it and the outcome of the game, whereas EPD records stand on their own
+type PgnEPDRecord struct {
+	FEN        string
+	Operations []PgnEPDOperation
+}
+
+// globals
+// ----------------------------------------------------------------------------
+
+// Operands of the following opcodes are always written between double quotes,
+// as they are strings: the identifier, comments and variation names
+var reEPDStringOpcode = regexp.MustCompile(`^(?:id|[cv]\d)$`)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the operands given in the given string, which are separated by blanks
+// unless they are given within double quotes, which are removed
+func splitEPDOperands(operands string) (result []string) {
+
+	var operand strings.Builder
+	quoted, started := false, false
+	for _, c := range strings.TrimSpace(operands) {
+		if c == '"' {
+			quoted, started = !quoted, true
+			continue
+		}
+		if !quoted && (c == ' ' || c == '\t') {
+			if started {
+				result = append(result, operand.String())
+				operand.Reset()
+				started = false
+			}
+			continue
+		}
+		operand.WriteRune(c)
+		started = true
+	}
+	if started {
+		result = append(result, operand.String())
+	}
+	return
+}
+
+// Return the EPD record given in the given line, and any error found. The
+// position has to be valid, and operations are separated by semicolons which
+// are not given within double quotes
+func ParseEPD(line string) (*PgnEPDRecord, error) {
+
+	line = strings.TrimSpace(line)
+	fields := reEPD.FindString(line)
+	if fields == "" {
+		return nil, fmt.Errorf(" Invalid EPD record '%v'", line)
+	}
+
+	// parse all operations, each one consisting of an opcode followed by its
+	// operands
+	record := PgnEPDRecord{}
+	for rest := strings.TrimSpace(line[len(fields):]); rest != ""; {
+		quoted := false
+		idx := 0
+		for ; idx < len(rest) && (quoted || rest[idx] != ';'); idx++ {
+			if rest[idx] == '"' {
+				quoted = !quoted
+			}
+		}
+		if quoted {
+			return nil, fmt.Errorf(" Unterminated string in the EPD record '%v'", line)
+		}
+		opcode, operands, _ := strings.Cut(strings.TrimSpace(rest[:idx]), " ")
+		rest = strings.TrimSpace(rest[min(idx+1, len(rest)):])
+		if opcode != "" {
+			record.Operations = append(record.Operations, PgnEPDOperation{opcode, splitEPDOperands(operands)})
+		}
+	}
+
+	// finally, complete the FEN code with the move counters and verify it
+	hmvc, fmvn := "0", "1"
+	if value, ok := record.Operation("hmvc"); ok {
+		hmvc = value
+	}
+	if value, ok := record.Operation("fmvn"); ok {
+		fmvn = value
+	}
+	record.FEN = strings.Join(append(strings.Fields(fields), hmvc, fmvn), " ")
+	if _, err := NewPgnBoardFromFEN(record.FEN); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Return all EPD records given in the given reader, one per line, and any error
+// found. Blank lines and lines starting with '#' are ignored
+func ReadEPD(reader io.Reader) ([]PgnEPDRecord, error) {
+
+	records := make([]PgnEPDRecord, 0)
+	scanner := bufio.NewScanner(reader)
+	for nbline := 1; scanner.Scan(); nbline++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		record, err := ParseEPD(line)
+		if err != nil {
+			return nil, fmt.Errorf(" Line %v:%w", nbline, err)
+		}
+		records = append(records, *record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Write the given EPD records in the given writer, one per line, and return
+// any error found
+func WriteEPD(writer io.Writer, records []PgnEPDRecord) error {
+
+	output := bufio.NewWriter(writer)
+	for _, record := range records {
+		if _, err := fmt.Fprintln(output, record); err != nil {
+			return err
+		}
+	}
+	return output.Flush()
+}
+
+// Return a collection with the given EPD records
+func NewPgnPositionCollection(records []PgnEPDRecord) PgnPositionCollection {
+	return PgnPositionCollection{records: records}
+}
+
+// Return a collection with all EPD records given in the given reader, see
+// ReadEPD, and any error found
+func NewPgnPositionCollectionFromEPD(reader io.Reader) (*PgnPositionCollection, error) {
+	records, err := ReadEPD(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &PgnPositionCollection{records: records}, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the operands of the first operation of this record with the given
+// opcode separated by a single blank, and whether it was found
+func (record PgnEPDRecord) Operation(opcode string) (string, bool) {
+	for _, operation := range record.Operations {
+		if operation.Opcode == opcode {
+			return strings.Join(operation.Operands, " "), true
+		}
+	}
+	return "", false
+}
+
+// Return this record in EPD format, i.e., the first four fields of its FEN
+// code followed by all its operations, each one ended with a semicolon.
+// Operands are given between double quotes if they are strings or they
+// contain blanks or semicolons
+func (record PgnEPDRecord) String() string {
+
+	fields := strings.Fields(record.FEN)
+	output := strings.Join(fields[:min(4, len(fields))], " ")
+	for _, operation := range record.Operations {
+		output += " " + operation.Opcode
+		for _, operand := range operation.Operands {
+			if reEPDStringOpcode.MatchString(operation.Opcode) || operand == "" || strings.ContainsAny(operand, " \t;") {
+				operand = strconv.Quote(operand)
+			}
+			output += " " + operand
+		}
+		output += ";"
+	}
+	return output
+}
+
+// Return the move of this record which is played in the game given by Game:
+// the first best move (bm) or, if none is given, the supplied move (sm). If
+// none is given, the empty string is returned
+func (record PgnEPDRecord) move() string {
+	for _, opcode := range []string{"bm", "sm"} {
+		for _, operation := range record.Operations {
+			if operation.Opcode == opcode && len(operation.Operands) > 0 {
+				return operation.Operands[0]
+			}
+		}
+	}
+	return ""
+}
+
+// Return a one-move game which starts in the position of this record, and any
+// error found. The game is given the tags "SetUp" and "FEN" with the position,
+// along with a tag per operation named after its opcode, and the identifier of
+// the position, if given, is also used as the event of the game. Its only move
+// is the first best move (bm) or, if none is given, the supplied move (sm), so
+// that the solution of the position is shown along with it. If neither is
+// given, the game has no moves at all. An error is returned if the move is
+// illegal in the position
+func (record PgnEPDRecord) Game() (*PgnGame, error) {
+
+	tags := map[string]any{"Event": "?", "SetUp": "1", "FEN": record.FEN}
+	for _, operation := range record.Operations {
+		tags[operation.Opcode] = getTagValue(strings.Join(operation.Operands, " "))
+	}
+	if id, ok := record.Operation("id"); ok {
+		tags["Event"] = id
+	}
+	game := PgnGame{
+		tags:    tags,
+		outcome: PgnOutcome{-1, -1},
+	}
+	board, err := game.initialBoard()
+	if err != nil {
+		return nil, err
+	}
+
+	// the move is verified on the initial board, but the game is not
+	// realized
+	san := record.move()
+	if san == "" {
+		return &game, nil
+	}
+	fields := strings.Fields(record.FEN)
+	move := PgnMove{color: 1, shortAlgebraic: san, emt: -1}
+	move.number, _ = strconv.Atoi(fields[5])
+	game.movetext = fmt.Sprintf("%v. %v", move.number, san)
+	if fields[1] == "b" {
+		move.color = -1
+		game.movetext = fmt.Sprintf("%v... %v", move.number, san)
+	}
+	if _, err := board.UpdateBoard(move); err != nil {
+		return nil, &ErrIllegalMove{
+			Ply:         1,
+			Move:        san,
+			FEN:         record.FEN,
+			Err:         err,
+			Suggestions: suggestMoves(record.FEN, board.variant, san),
+		}
+	}
+	game.moves = []PgnMove{move}
+	return &game, nil
+}
+
+// Return the number of positions in this collection
+func (c PgnPositionCollection) Len() int {
+	return len(c.records)
+}
+
+// Return all EPD records in this collection
+func (c PgnPositionCollection) GetRecords() []PgnEPDRecord {
+	return c.records
+}
+
+// Return a collection with the positions of this collection which are given an
+// operation with the given opcode. If any values are given, only those
+// positions where any operand of the operation equals any of them are
+// selected, e.g., the positions whose best moves include "Nf3"
+func (c PgnPositionCollection) SelectOpcode(opcode string, values ...string) *PgnPositionCollection {
+
+	result := PgnPositionCollection{records: make([]PgnEPDRecord, 0)}
+	for _, record := range c.records {
+		for _, operation := range record.Operations {
+			if operation.Opcode == opcode && (len(values) == 0 || slices.ContainsFunc(operation.Operands, func(operand string) bool {
+				return slices.Contains(values, operand)
+			})) {
+				result.records = append(result.records, record)
+				break
+			}
+		}
+	}
+	return &result
+}
+
+// Return a collection of games with the one-move game of every position in this
+// collection, see PgnEPDRecord.Game, and any error found. Games are given ids
+// in the order positions are found, starting from 1
+func (c PgnPositionCollection) Games() (*PgnCollection, error) {
+
+	games := NewPgnCollection()
+	for idx, record := range c.records {
+		game, err := record.Game()
+		if illegal, ok := err.(*ErrIllegalMove); ok {
+			illegal.Game = idx + 1
+			return nil, illegal
+		} else if err != nil {
+			return nil, fmt.Errorf(" Position %v:%w", idx+1, err)
+		}
+		games.Add(*game)
+	}
+	return &games, nil
+}
+
+// Return a collection with the positions of this collection whose games (see
+// Games) satisfy the given expression, which follows the syntax of filters
+// over collections of games, e.g., 'bm == "Nf3" && WhiteElo == 0'. Operations
+// are available as variables named after their opcodes. The options are the
+// same accepted by PgnCollection.Filter
+func (c PgnPositionCollection) Filter(expression string, opts ...PgnOption) (*PgnPositionCollection, error) {
+
+	games, err := c.Games()
+	if err != nil {
+		return nil, err
+	}
+	filtered, err := games.Filter(expression, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// games keep their ids, which are given by the location of every
+	// position
+	result := PgnPositionCollection{records: make([]PgnEPDRecord, 0, filtered.Len())}
+	for _, igame := range filtered.slice {
+		result.records = append(result.records, c.records[igame.id-1])
+	}
+	return &result, nil
+}
+
+// Write all positions of this collection in EPD format in the given writer, and
+// return any error found
+func (c PgnPositionCollection) WriteEPD(writer io.Writer) error {
+	return WriteEPD(writer, c.records)
+}
+
+// Return the EPD records of all positions of this game which satisfy the given
+// expression, see ExtractEPD. The game must have been realized
+func (game *PgnGame) epdRecords(expression string) ([]PgnEPDRecord, error) {
+
+	records := make([]PgnEPDRecord, 0)
+	env := game.getEnv(expression)
+	for ply, board := range game.boards {
+
+		// add the variables of this position to the environment of the game
+		fen := board.FEN()
+		fields := strings.Fields(fen)
+		number, _ := strconv.Atoi(fields[5])
+		next := ""
+		if ply < len(game.moves) {
+			next = game.moves[ply].shortAlgebraic
+		}
+		env["Ply"], env["MoveNumber"], env["SideToMove"] = ply, number, fields[1]
+		env["Position"], env["NextMove"] = fen, next
+
+		// and select it if the expression is satisfied
+		if expression != "" {
+			output, err := evaluateExpr(expression, env)
+			if err != nil {
+				return nil, err
+			}
+			if selected, ok := output.(bool); !ok {
+				return nil, fmt.Errorf(" The expression '%v' does not produced a boolean value!", expression)
+			} else if !selected {
+				continue
+			}
+		}
+		record := PgnEPDRecord{
+			FEN: fen,
+			Operations: []PgnEPDOperation{
+				{"id", []string{fmt.Sprintf("game #%v (ply %v)", game.id, ply)}},
+			},
+		}
+		if next != "" {
+			record.Operations = append(record.Operations, PgnEPDOperation{"sm", []string{next}})
+		}
+		record.Operations = append(record.Operations,
+			PgnEPDOperation{"hmvc", []string{fields[4]}},
+			PgnEPDOperation{"fmvn", []string{fields[5]}})
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Return the EPD records of all positions of all games in this collection that
+// satisfy the given expression, or all of them if no expression is given. The
+// expression is evaluated with all variables of the game along with the
+// following ones of every position: Ply (number of plies played to reach it),
+// MoveNumber (its fullmove number), SideToMove ("w" or "b"), Position (its FEN
+// code) and NextMove (the move played in it, or the empty string in the final
+// position), e.g., "MoveNumber > 20". Every record is identified with the id of
+// its game and its ply, and it is given the move played in it (sm) and the
+// move counters of its FEN code (hmvc and fmvn). Games are played if
+// necessary, in parallel with the number of workers given WithWorkers
+func (c PgnCollection) ExtractEPD(expression string, opts ...PgnOption) ([]PgnEPDRecord, error) {
+
+	// play all games first. Because every worker accesses a different game,
+	// no synchronization is needed
+	options := newPgnOptions(opts...)
+	records := make([][]PgnEPDRecord, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) (err error) {
+		if err := c.slice[idx].play(); err != nil {
+			return err
+		}
+		records[idx], err = c.slice[idx].epdRecords(expression)
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	// and return all records in the order games are found
+	result := make([]PgnEPDRecord, 0)
+	for _, irecords := range records {
+		result = append(result, irecords...)
+	}
+	return result, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,182 @@
+// -*- coding: utf-8 -*-
+// pgnepd_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:54:46.383454962 (1792169686)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEPD(t *testing.T) {
+
+	line := `r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5 Bc4; id "Open; game"; c0 ""; hmvc 2; fmvn 3;`
+	record, err := ParseEPD(line)
+	if err != nil {
+		t.Fatalf("ParseEPD() error = %v", err)
+	}
+	if want := "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3"; record.FEN != want {
+		t.Errorf("ParseEPD() = %v, want %v", record.FEN, want)
+	}
+	want := []PgnEPDOperation{
+		{"bm", []string{"Bb5", "Bc4"}},
+		{"id", []string{"Open; game"}},
+		{"c0", []string{""}},
+		{"hmvc", []string{"2"}},
+		{"fmvn", []string{"3"}},
+	}
+	if !reflect.DeepEqual(record.Operations, want) {
+		t.Errorf("ParseEPD() operations = %v, want %v", record.Operations, want)
+	}
+	if bm, ok := record.Operation("bm"); !ok || bm != "Bb5 Bc4" {
+		t.Errorf("Operation(bm) = (%v, %v)", bm, ok)
+	}
+	if _, ok := record.Operation("am"); ok {
+		t.Error("Operation(am) should not be found")
+	}
+
+	// records are written as they were read
+	if got := record.String(); got != line {
+		t.Errorf("String() = %v, want %v", got, line)
+	}
+
+	for _, line := range []string{
+		"8/8/8 w - - bm Kd4;",
+		`8/8/8/4k3/8/8/4P3/4K3 w - - id "unterminated;`,
+		"8/8/8/8/8/8/4P3/4K3 w - - bm Kd2;",
+	} {
+		if _, err := ParseEPD(line); err == nil {
+			t.Errorf("ParseEPD(%q) should fail", line)
+		}
+	}
+}
+
+func TestReadEPD(t *testing.T) {
+
+	contents := "# test suite\n\n8/8/8/4k3/8/8/4P3/4K3 w - - bm Kd2; id \"KP 1\";\n8/8/8/4k3/8/8/4P3/4K3 b - - am Kd4;\n"
+	records, err := ReadEPD(strings.NewReader(contents))
+	if err != nil {
+		t.Fatalf("ReadEPD() error = %v", err)
+	}
+	if len(records) != 2 || records[1].FEN != "8/8/8/4k3/8/8/4P3/4K3 b - - 0 1" {
+		t.Fatalf("ReadEPD() = %v", records)
+	}
+	var builder strings.Builder
+	if err := WriteEPD(&builder, records); err != nil {
+		t.Fatalf("WriteEPD() error = %v", err)
+	}
+	if want := "8/8/8/4k3/8/8/4P3/4K3 w - - bm Kd2; id \"KP 1\";\n8/8/8/4k3/8/8/4P3/4K3 b - - am Kd4;\n"; builder.String() != want {
+		t.Errorf("WriteEPD() = %q, want %q", builder.String(), want)
+	}
+
+	// errors are given with the line where they were found
+	if _, err := ReadEPD(strings.NewReader("8/8/8/4k3/8/8/4P3/4K3 w - -\n8/8 w - -\n")); err == nil || !strings.Contains(err.Error(), "Line 2") {
+		t.Errorf("ReadEPD() error = %v, want an error in line 2", err)
+	}
+}
+
+func TestPgnCollection_ExtractEPD(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, pgn := range []string{"1. e4 e5 2. Nf3 *", "1. d4 *"} {
+		game, err := getGameFromString("[Event \"?\"]\n\n" + pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		c.Add(*game)
+	}
+	records, err := c.ExtractEPD("", WithWorkers(2))
+	if err != nil {
+		t.Fatalf("ExtractEPD() error = %v", err)
+	}
+	var lines []string
+	for _, record := range records {
+		lines = append(lines, record.String())
+	}
+	want := []string{
+		`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - id "game #1 (ply 0)"; sm e4; hmvc 0; fmvn 1;`,
+		`rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 id "game #1 (ply 1)"; sm e5; hmvc 0; fmvn 1;`,
+		`rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 id "game #1 (ply 2)"; sm Nf3; hmvc 0; fmvn 2;`,
+		`rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - id "game #1 (ply 3)"; hmvc 1; fmvn 2;`,
+		`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - id "game #2 (ply 0)"; sm d4; hmvc 0; fmvn 1;`,
+		`rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq d3 id "game #2 (ply 1)"; hmvc 0; fmvn 1;`,
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("ExtractEPD() = %v, want %v", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	// and records can be read back as positions
+	for _, line := range lines {
+		record, err := ParseEPD(line)
+		if err != nil {
+			t.Fatalf("ParseEPD() error = %v", err)
+		}
+		if game, err := record.Game(); err != nil || game.getTag("FEN") != record.FEN {
+			t.Errorf("Game() = (%v, %v)", game, err)
+		}
+	}
+}
+
+func TestPgnPositionCollection(t *testing.T) {
+
+	contents := `8/8/8/4k3/8/8/4P3/4K3 w - - bm Kd2 Kf2; id "KP 1";
+8/8/8/4k3/8/8/4P3/4K3 b - - am Kd4; id "KP 2";
+r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5; fmvn 3;
+`
+	positions, err := NewPgnPositionCollectionFromEPD(strings.NewReader(contents))
+	if err != nil {
+		t.Fatalf("NewPgnPositionCollectionFromEPD() error = %v", err)
+	}
+
+	// positions are selected by the values of their operations
+	for _, tt := range []struct {
+		opcode string
+		values []string
+		want   int
+	}{
+		{"bm", nil, 2},
+		{"bm", []string{"Kf2", "Bb5"}, 2},
+		{"bm", []string{"Kd4"}, 0},
+		{"am", []string{"Kd4"}, 1},
+		{"id", []string{"KP 2"}, 1},
+	} {
+		if got := positions.SelectOpcode(tt.opcode, tt.values...).Len(); got != tt.want {
+			t.Errorf("SelectOpcode(%v, %v) = %v positions, want %v", tt.opcode, tt.values, got, tt.want)
+		}
+	}
+
+	// and they are converted to games with their best move, if any
+	games, err := positions.Games()
+	if err != nil {
+		t.Fatalf("Games() error = %v", err)
+	}
+	for idx, want := range []string{"1. Kd2 *", "*", "3. Bb5 *"} {
+		igame := games.slice[idx]
+		if got := strings.TrimSpace(igame.GetPGN()[strings.LastIndex(igame.GetPGN(), "]")+1:]); got != want {
+			t.Errorf("Games() game #%v = %q, want %q", idx+1, got, want)
+		}
+		if err := igame.Realize(-1); err != nil {
+			t.Errorf("Realize() error = %v", err)
+		}
+	}
+	if games.slice[0].getTag("Event") != "KP 1" || games.slice[0].getTag("bm") != "Kd2 Kf2" {
+		t.Errorf("Games() tags = %v", games.slice[0].tags)
+	}
+
+	// unless it is illegal
+	illegal := NewPgnPositionCollection([]PgnEPDRecord{positions.records[0], {FEN: positions.records[1].FEN, Operations: []PgnEPDOperation{{"bm", []string{"Ke3"}}}}})
+	if _, err := illegal.Games(); err == nil || !strings.Contains(err.Error(), "game #2") {
+		t.Errorf("Games() error = %v, want an illegal move in game #2", err)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,329 @@
+// -*- coding: utf-8 -*-
+// pgnepub.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:12:51.573303177 (1792163571)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"html/template"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Every game is written in a different chapter of EPUB books, which is
+// generated with a template executed over the following data
+type epubChapter struct {
+	Id     int               // id of the game
+	Title  string            // title of the chapter, e.g., "White - Black"
+	Tags   map[string]string // tags of the game
+	Moves  template.HTML     // moves of the game with comments and variations
+	Result string            // outcome of the game
+	Image  string            // relative path to the image of the final position
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Size in pixels of every square in the images of boards
+const svgSquare = 40
+
+// Colors of light, dark and highlighted squares in the images of boards
+const (
+	svgLight     = "#f0d9b5"
+	svgDark      = "#b58863"
+	svgHighlight = "#cdd26a"
+)
+
+// Declaration written at the beginning of every XML file. It is not given in
+// the templates of chapters, as html/template would escape it
+const xmlDeclaration = `<?xml version="1.0" encoding="UTF-8"?>
+`
+
+// Template used by default to write every chapter of EPUB books
+const epubChapterTemplate = `<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{index .Tags "Event"}}{{with index .Tags "Site"}}, {{.}}{{end}}{{with index .Tags "Date"}}, {{.}}{{end}}</p>
+<p>{{with index .Tags "ECO"}}{{.}} {{end}}{{index .Tags "Opening"}}</p>
+<p>{{.Moves}} <strong>{{.Result}}</strong></p>
+<p><img src="{{.Image}}" alt="Final position"/></p>
+</body>
+</html>
+`
+
+// Contents of the container of EPUB books, which just points to the package
+// document
+const epubContainer = xmlDeclaration + `<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the given moves in HTML format in a single line, with comments in
+// italics and the variations of every move between parenthesis right after it
+func getHTMLLine(moves []PgnMove) string {
+
+	var builder strings.Builder
+	for idx, move := range moves {
+
+		// the same move numbers are shown than in PGN format
+		if move.color > 0 || idx == 0 || len(moves[idx-1].variations) > 0 {
+			fmt.Fprintf(&builder, "%v%v ", move.number, move.getColorPrefix())
+		}
+		fmt.Fprintf(&builder, "<b>%v</b> ", html.EscapeString(move.annotated()))
+		for _, annotation := range move.annotations {
+			if annotation.Kind == CommentAnnotation {
+				fmt.Fprintf(&builder, "<i>%v</i> ", html.EscapeString(annotation.Value))
+			}
+		}
+		for _, variation := range move.variations {
+			fmt.Fprintf(&builder, "(%v) ", strings.TrimSpace(getHTMLLine(variation)))
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// Write in the given zip archive a file with the given name and contents, and
+// return any error found
+func writeZipFile(archive *zip.Writer, name, contents string) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, contents)
+	return err
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return an image of this board in SVG format, with White at the bottom
+func (board *PgnBoard) getSVG() string {
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %v %v">`+"\n",
+		8*svgSquare, 8*svgSquare, 8*svgSquare, 8*svgSquare)
+	board.writeSVG(&builder, 0, 0, svgSquare)
+	builder.WriteString("</svg>\n")
+	return builder.String()
+}
+
+// Write the SVG elements of this board in the given builder, with White at the
+// bottom, its upper left corner at (x0, y0) and squares of the given size. The
+// given squares, if any, are highlighted
+func (board *PgnBoard) writeSVG(builder *strings.Builder, x0, y0, square int, highlighted ...int) {
+
+	for row := 7; row >= 0; row-- {
+		for column := 0; column < 8; column++ {
+
+			// as in the other representations of boards, a1 is a dark square
+			x, y := x0+column*square, y0+(7-row)*square
+			color := svgLight
+			if (row+column)%2 == 0 {
+				color = svgDark
+			}
+			if slices.Contains(highlighted, row*8+column) {
+				color = svgHighlight
+			}
+			fmt.Fprintf(builder, `<rect x="%v" y="%v" width="%v" height="%v" fill="%v"/>`+"\n",
+				x, y, square, square, color)
+			if piece := board.squares[row*8+column]; piece != BLANK {
+				fmt.Fprintf(builder, `<text x="%v" y="%v" font-size="%v" text-anchor="middle" dominant-baseline="central">%c</text>`+"\n",
+					x+square/2, y+square/2, 4*square/5, utf8repr[piece])
+			}
+		}
+	}
+}
+
+// Return the board reached at the end of this game, and any error found. If
+// the game was not fully realized, it is played on a different board, so that
+// this game is not modified
+func (game *PgnGame) finalBoard() (PgnBoard, error) {
+
+	if len(game.boards) > 0 && game.Realized() == len(game.moves) {
+		return game.boards[len(game.boards)-1], nil
+	}
+	board, err := game.initialBoard()
+	if err != nil {
+		return PgnBoard{}, err
+	}
+	for idx, move := range game.moves {
+		if _, err := board.UpdateBoard(move); err != nil {
+			return PgnBoard{}, &ErrIllegalMove{
+				Game:        game.id,
+				Ply:         idx + 1,
+				Move:        move.shortAlgebraic,
+				FEN:         board.FEN(),
+				Err:         err,
+				Suggestions: suggestMoves(board.FEN(), board.variant, move.shortAlgebraic),
+			}
+		}
+	}
+	return board, nil
+}
+
+// Return the data used to write this game in a chapter of an EPUB book whose
+// image of the final position is found in the given path
+func (game *PgnGame) getEPUBChapter(image string) epubChapter {
+
+	tags := make(map[string]string)
+	for name := range game.tags {
+		tags[name] = game.getTag(name)
+	}
+	return epubChapter{
+		Id:     game.id,
+		Title:  fmt.Sprintf("%v - %v", game.getTag("White"), game.getTag("Black")),
+		Tags:   tags,
+		Moves:  template.HTML(getHTMLLine(game.moves)),
+		Result: game.outcome.String(),
+		Image:  image,
+	}
+}
+
+// Write all games in this collection in the specified io.Writer as an EPUB book
+// with the given title, so that they can be read on e-readers. Every game is
+// written in a different chapter, including an image of its final position,
+// and the book contains a navigation index with all chapters.
+//
+// Chapters are written in XHTML with the given html/template, which is
+// executed with the id, title, tags, moves (already in HTML) and result of
+// every game, and the relative path to the image of its final position. If no
+// template is given, a simple one is used. The XML declaration is written at
+// the beginning of every chapter, so that it must not be given in the
+// template. Only the comments in the language given WithLanguage are written.
+// It returns any error found
+func (c PgnCollection) GetEPUB(writer io.Writer, title, templateFile string, opts ...PgnOption) error {
+
+	options := newPgnOptions(opts...)
+	c = c.SelectLanguage(options.language)
+
+	// Parse the template used to write chapters
+	contents := epubChapterTemplate
+	if templateFile != "" {
+		raw, err := os.ReadFile(templateFile)
+		if err != nil {
+			return err
+		}
+		contents = string(raw)
+	}
+	tpl, err := template.New("chapter").Parse(contents)
+	if err != nil {
+		return err
+	}
+
+	// The mimetype has to be the first file of the archive, and it must be
+	// stored without compression
+	archive := zip.NewWriter(writer)
+	mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+	if err := writeZipFile(archive, "META-INF/container.xml", epubContainer); err != nil {
+		return err
+	}
+
+	// Next, write a chapter and an image for every game. The manifest, spine
+	// and navigation index are computed at the same time. The identifier of
+	// the book is computed from its title and the ids of its games so that
+	// the same book is always given the same identifier
+	var manifest, spine, nav strings.Builder
+	hash := fnv.New64a()
+	io.WriteString(hash, title)
+	for _, igame := range c.slice {
+		name := fmt.Sprintf("game-%v", igame.id)
+		fmt.Fprintf(hash, "/%v", igame.id)
+
+		board, err := igame.finalBoard()
+		if err != nil {
+			return err
+		}
+		if err := writeZipFile(archive, "OEBPS/images/"+name+".svg", board.getSVG()); err != nil {
+			return err
+		}
+
+		var chapter strings.Builder
+		chapter.WriteString(xmlDeclaration)
+		data := igame.getEPUBChapter("images/" + name + ".svg")
+		if err := tpl.Execute(&chapter, data); err != nil {
+			return err
+		}
+		if err := writeZipFile(archive, "OEBPS/"+name+".xhtml", chapter.String()); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&manifest, `<item id="%v" href="%v.xhtml" media-type="application/xhtml+xml"/>`+"\n", name, name)
+		fmt.Fprintf(&manifest, `<item id="%v-image" href="images/%v.svg" media-type="image/svg+xml"/>`+"\n", name, name)
+		fmt.Fprintf(&spine, `<itemref idref="%v"/>`+"\n", name)
+		fmt.Fprintf(&nav, `<li><a href="%v.xhtml">%v. %v</a></li>`+"\n", name, igame.id, html.EscapeString(data.Title))
+	}
+
+	// Write the navigation index
+	if err := writeZipFile(archive, "OEBPS/nav.xhtml", fmt.Sprintf(xmlDeclaration+`<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+<title>%v</title>
+</head>
+<body>
+<nav epub:type="toc" id="toc">
+<h1>%v</h1>
+<ol>
+%v</ol>
+</nav>
+</body>
+</html>
+`, html.EscapeString(title), html.EscapeString(title), nav.String())); err != nil {
+		return err
+	}
+
+	// and the package document with the metadata, manifest and spine
+	if err := writeZipFile(archive, "OEBPS/content.opf", fmt.Sprintf(xmlDeclaration+`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="bookid">urn:pgnparser:%x</dc:identifier>
+<dc:title>%v</dc:title>
+<dc:language>en</dc:language>
+<meta property="dcterms:modified">%v</meta>
+</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+%v</manifest>
+<spine>
+%v</spine>
+</package>
+`, hash.Sum64(), html.EscapeString(title), time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		manifest.String(), spine.String())); err != nil {
+		return err
+	}
+
+	// Finally, close the archive
+	return archive.Close()
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
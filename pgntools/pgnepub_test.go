@@ -0,0 +1,109 @@
+// -*- coding: utf-8 -*-
+// pgnepub_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:13:05.160295094 (1792163585)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Return the contents of all files in the given EPUB book along with their
+// names in the order they are found
+func readEPUB(t *testing.T, book []byte) (names []string, files map[string]string) {
+
+	archive, err := zip.NewReader(bytes.NewReader(book), int64(len(book)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	files = make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open(%v) error = %v", file.Name, err)
+		}
+		contents, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("ReadAll(%v) error = %v", file.Name, err)
+		}
+		names = append(names, file.Name)
+		files[file.Name] = string(contents)
+	}
+	if archive.File[0].Method != zip.Store {
+		t.Errorf("GetEPUB() mimetype is compressed")
+	}
+	return
+}
+
+func TestPgnCollection_GetEPUB(t *testing.T) {
+
+	game, err := ParseGame(`[White "alice"]
+[Black "bob"]
+[Event "R&D"]
+[Result "1-0"]
+
+1. e4 { <best> } (1. d4) 1... e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	c := NewPgnCollection()
+	c.Add(*game)
+
+	// every game is written in a chapter with an image of its final position,
+	// and both are listed in the manifest and the navigation index
+	var book bytes.Buffer
+	if err := c.GetEPUB(&book, "Miniatures", ""); err != nil {
+		t.Fatalf("GetEPUB() error = %v", err)
+	}
+	names, files := readEPUB(t, book.Bytes())
+	if names[0] != "mimetype" || files["mimetype"] != "application/epub+zip" {
+		t.Errorf("GetEPUB() first file = %v", names[0])
+	}
+	for name, want := range map[string][]string{
+		"META-INF/container.xml":  {"OEBPS/content.opf"},
+		"OEBPS/content.opf":       {"<dc:title>Miniatures</dc:title>", `href="game-1.xhtml"`, `href="images/game-1.svg"`, `<itemref idref="game-1"/>`},
+		"OEBPS/nav.xhtml":         {`<a href="game-1.xhtml">1. alice - bob</a>`},
+		"OEBPS/game-1.xhtml":      {"<h1>alice - bob</h1>", "R&amp;D", "<i>&lt;best&gt;</i>", "(1. <b>d4</b>)", "<b>Qxf7#</b>", `src="images/game-1.svg"`},
+		"OEBPS/images/game-1.svg": {"♕", "<svg"},
+	} {
+		for _, text := range want {
+			if !strings.Contains(files[name], text) {
+				t.Errorf("GetEPUB() %v = %v, want it to contain %q", name, files[name], text)
+			}
+		}
+	}
+
+	// the game is not modified
+	if c.slice[0].Realized() != 0 {
+		t.Errorf("GetEPUB() realized %v plies", c.slice[0].Realized())
+	}
+
+	// and chapters can be written with other templates
+	templateFile := filepath.Join(t.TempDir(), "chapter.tpl")
+	if err := os.WriteFile(templateFile, []byte(`<p>{{.Id}}: {{index .Tags "Event"}} {{.Result}}</p>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	book.Reset()
+	if err := c.GetEPUB(&book, "Miniatures", templateFile); err != nil {
+		t.Fatalf("GetEPUB() error = %v", err)
+	}
+	if _, files := readEPUB(t, book.Bytes()); files["OEBPS/game-1.xhtml"] != xmlDeclaration+"<p>1: R&amp;D 1-0</p>" {
+		t.Errorf("GetEPUB() chapter = %v", files["OEBPS/game-1.xhtml"])
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,68 @@
+// -*- coding: utf-8 -*-
+// pgnerrors.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:27:53.411601680 (1792160873)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// globals
+// ----------------------------------------------------------------------------
+
+// The following errors are returned, possibly wrapped with further
+// information, so that the category of a failure can be checked with
+// errors.Is
+var (
+	ErrNoTags         = errors.New(" No tags were found")
+	ErrBadFEN         = errors.New(" Invalid FEN code")
+	ErrUnknownOutcome = errors.New(" Unknown outcome")
+	ErrMissingOutcome = errors.New(" The result is missing and the game was closed with '*'")
+	ErrAmbiguousMove  = errors.New(" More than one piece can play this move")
+	ErrNotAllowed     = errors.New(" Positions can not be sent to remote services unless explicitly allowed")
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Errors found when playing a move of a game on a chess board. They can be
+// retrieved with errors.As to know the game, ply and position where the error
+// happened, and they wrap the error returned by the board. To ease repairing
+// them, they suggest the legal moves closest to the one given, if any
+type ErrIllegalMove struct {
+	Game        int      // id of the game
+	Ply         int      // number of the ply, starting from 1
+	Move        string   // move in short algebraic notation
+	FEN         string   // FEN code of the position where the move was played
+	Err         error    // reason why the move could not be played
+	Suggestions []string // closest legal moves, if any
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a description of this error
+func (err *ErrIllegalMove) Error() string {
+	suggestion := ""
+	if len(err.Suggestions) > 0 {
+		suggestion = fmt.Sprintf(" (did you mean '%v'?)", strings.Join(err.Suggestions, "' or '"))
+	}
+	return fmt.Sprintf(" Illegal move '%v' in ply %v of game #%v%v:%v", err.Move, err.Ply, err.Game, suggestion, err.Err)
+}
+
+// Return the error which made the move illegal
+func (err *ErrIllegalMove) Unwrap() error {
+	return err.Err
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,49 @@
+// -*- coding: utf-8 -*-
+// pgnerrors_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:28:09.519248180 (1792160889)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+
+	// games without tags
+	if _, err := getGameFromString("1. e4 e5 1-0"); !errors.Is(err, ErrNoTags) {
+		t.Errorf("getGameFromString() error = %v, want %v", err, ErrNoTags)
+	}
+
+	// unknown outcomes
+	if _, err := getOutcome("2-1"); !errors.Is(err, ErrUnknownOutcome) {
+		t.Errorf("getOutcome() error = %v, want %v", err, ErrUnknownOutcome)
+	}
+
+	// illegal moves
+	game, err := getGameFromString(`[Event "Test"]
+
+1. e4 e5 2. Ke3 Nc6 3. Kd5 1-0`)
+	if err != nil {
+		t.Fatalf("getGameFromString() error = %v", err)
+	}
+	game.id = 7
+	var illegal *ErrIllegalMove
+	if err := game.play(); !errors.As(err, &illegal) {
+		t.Fatalf("play() error = %v, want an illegal move", err)
+	}
+	if illegal.Game != 7 || illegal.Ply != 3 || illegal.Move != "Ke3" ||
+		illegal.FEN != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2" {
+		t.Errorf("play() error = %+v, want game 7, ply 3 and move Ke3", *illegal)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
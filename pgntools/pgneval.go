@@ -0,0 +1,81 @@
+// -*- coding: utf-8 -*-
+// pgneval.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:14:06.883030389 (1792167246)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Mates are evaluated with a large number of pawns. However, evaluations are
+// bounded when computing swings, so that finding a mate in a position which
+// was already won is not taken as a swing
+const (
+	mateEval  = 100.0
+	swingEval = 10.0
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the evaluation in pawns from the point of view of White given in the
+// value of an "[%eval ...]" command, e.g., "0.25" or "#-3", and true if it is
+// well formed. Mates are evaluated with a large number of pawns
+func getEvalPawns(value string) (float64, bool) {
+
+	value = strings.TrimSpace(value)
+	if mate, ok := strings.CutPrefix(value, "#"); ok {
+		moves, err := strconv.Atoi(mate)
+		if err != nil {
+			return 0, false
+		}
+		if moves < 0 || strings.HasPrefix(mate, "-") {
+			return -mateEval, true
+		}
+		return mateEval, true
+	}
+	pawns, err := strconv.ParseFloat(value, 64)
+	return pawns, err == nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the evaluation in pawns from the point of view of White given after
+// this move in "[%eval ...]", and true if it is given or false otherwise
+func (move PgnMove) Eval() (float64, bool) {
+	return move.eval, move.evaluated
+}
+
+// Return the largest difference in pawns between the evaluations given after
+// two consecutive moves of the main line of this game, or 0 if there are no
+// consecutive evaluated moves. Evaluations are bounded to ten pawns, so that
+// swings are always within [0, 20]
+func (game *PgnGame) MaxEvalSwing() (swing float64) {
+
+	for idx := 1; idx < len(game.moves); idx++ {
+		previous, ok1 := game.moves[idx-1].Eval()
+		current, ok2 := game.moves[idx].Eval()
+		if ok1 && ok2 {
+			previous = max(-swingEval, min(swingEval, previous))
+			current = max(-swingEval, min(swingEval, current))
+			swing = max(swing, math.Abs(current-previous))
+		}
+	}
+	return
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
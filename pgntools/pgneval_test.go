@@ -0,0 +1,50 @@
+// -*- coding: utf-8 -*-
+// pgneval_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:14:17.037234734 (1792167257)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPgnGame_MaxEvalSwing(t *testing.T) {
+
+	game, err := ParseGame(`[Result "1-0"]
+
+1. e4 { [%eval 0.3] } e5 { [%eval 0.25] } 2. Qh5 { [%eval -0.5] } Nc6 { [%eval -0.4] } 3. Bc4 { [%eval -0.3] } Nf6?? { [%eval #1] } 4. Qxf7# 1-0`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if eval, ok := game.moves[2].Eval(); !ok || eval != -0.5 {
+		t.Errorf("Eval() = (%v, %v), want (-0.5, true)", eval, ok)
+	}
+	if eval, ok := game.moves[5].Eval(); !ok || eval != mateEval {
+		t.Errorf("Eval() = (%v, %v), want (%v, true)", eval, ok, mateEval)
+	}
+	if _, ok := game.moves[6].Eval(); ok {
+		t.Errorf("Eval() should be unknown")
+	}
+
+	// mates are bounded to ten pawns
+	if got := game.MaxEvalSwing(); got != 10.3 {
+		t.Errorf("MaxEvalSwing() = %v, want 10.3", got)
+	}
+
+	// and malformed evaluations are reported
+	if _, err := ParseGame(`[Result "*"]
+
+1. e4 { [%eval abc] } *`); err == nil || !strings.Contains(err.Error(), "eval") {
+		t.Errorf("ParseGame() should fail with a malformed evaluation")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
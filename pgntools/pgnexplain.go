@@ -0,0 +1,201 @@
+// -*- coding: utf-8 -*-
+// pgnexplain.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:04:04.400805287 (1792170244)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A mismatch explains why a FEN code does not match a pattern. It refers to
+// one of the fields of FEN codes and, for the piece placement, either to a
+// single square or to a whole rank when the rank of the pattern can not be
+// compared square by square with the rank of the FEN code
+type PgnFENMismatch struct {
+	Field    string // name of the field of the FEN code
+	Rank     int    // rank of the piece placement (1-8), or 0 otherwise
+	Square   string // square of the piece placement, e.g., "e4", if any
+	Expected string // contents given in the pattern
+	Found    string // contents given in the FEN code
+}
+
+// global variables
+// ----------------------------------------------------------------------------
+
+// Names of the fields of FEN codes, in the same order they are given
+var fenFields = []string{"piece placement", "active color", "castling rights",
+	"en passant target", "halfmove clock", "fullmove number"}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the contents of every square of the given rank of a pattern of piece
+// placement: empty squares are given as '.' and undefined squares as '*'
+func expandFENRank(rank string) string {
+
+	var builder strings.Builder
+	for idx := 0; idx < len(rank); idx++ {
+		if advance, cardinality := cardinalityUndefined(rank[idx:]); cardinality > 0 {
+			builder.WriteString(strings.Repeat("*", cardinality))
+			idx += advance - 1
+		} else if rank[idx] >= '0' && rank[idx] <= '9' {
+			builder.WriteString(strings.Repeat(".", int(rank[idx]-'0')))
+		} else {
+			builder.WriteByte(rank[idx])
+		}
+	}
+	return builder.String()
+}
+
+// Return a human readable description of the contents of a square
+func describeSquare(contents byte) string {
+	if contents == '.' {
+		return "empty"
+	}
+	return fmt.Sprintf("'%c'", contents)
+}
+
+// Return the mismatches between the piece placement of the given pattern and
+// FEN code. Every rank is compared square by square unless it describes a
+// number of squares other than eight
+func explainFENPiecePlacement(expr, code string) (mismatches []PgnFENMismatch) {
+
+	// a single wildcard matches any placement
+	if expr == "*" {
+		return
+	}
+	exprRanks, codeRanks := strings.Split(expr, "/"), strings.Split(code, "/")
+	if len(exprRanks) != len(codeRanks) {
+		return []PgnFENMismatch{{Field: fenFields[0], Expected: expr, Found: code}}
+	}
+	for idx := range exprRanks {
+		rank := len(exprRanks) - idx
+		exprSquares, codeSquares := expandFENRank(exprRanks[idx]), expandFENRank(codeRanks[idx])
+		if len(exprSquares) != len(codeSquares) {
+			mismatches = append(mismatches, PgnFENMismatch{
+				Field:    fenFields[0],
+				Rank:     rank,
+				Expected: exprRanks[idx],
+				Found:    codeRanks[idx],
+			})
+			continue
+		}
+		for file := range exprSquares {
+			if exprSquares[file] != '*' && exprSquares[file] != codeSquares[file] {
+				mismatches = append(mismatches, PgnFENMismatch{
+					Field:    fenFields[0],
+					Rank:     rank,
+					Square:   fmt.Sprintf("%c%d", 'a'+file, rank),
+					Expected: describeSquare(exprSquares[file]),
+					Found:    describeSquare(codeSquares[file]),
+				})
+			}
+		}
+	}
+	return
+}
+
+// ExplainFEN returns the reasons why the given FEN code does not match the
+// given pattern, with the same syntax acknowledged by MatchFEN, so that users
+// can debug their patterns. In the piece placement, every square that does not
+// match is reported separately. If the FEN code matches the pattern, no
+// mismatch is returned.
+//
+// An error wrapping ErrBadFEN is returned in the same cases MatchFEN does
+func ExplainFEN(pattern, fen string) ([]PgnFENMismatch, error) {
+
+	match, err := MatchFEN(pattern, fen)
+	if err != nil || match {
+		return nil, err
+	}
+
+	// split both fen codes into their fields. Both are known to be
+	// syntactically correct at this point
+	exprIndex := reFEN.FindStringSubmatchIndex(pattern)
+	codeIndex := reFEN.FindStringSubmatchIndex(fen)
+	fields := func(idx int) (string, string) {
+		return pattern[exprIndex[2*idx+2]:exprIndex[2*idx+3]], fen[codeIndex[2*idx+2]:codeIndex[2*idx+3]]
+	}
+
+	// the piece placement is explained square by square, whereas the other
+	// fields are just compared with their matching functions
+	mismatches := explainFENPiecePlacement(fields(0))
+	for idx, matcher := range []func(string, string) bool{
+		matchFENActiveColor, matchFENCastlingRights, matchFENEnPassantTargets,
+		matchFENHalfMoveClock, matchFENFullMoveNumber,
+	} {
+		if expr, code := fields(idx + 1); !matcher(expr, code) {
+			mismatches = append(mismatches, PgnFENMismatch{
+				Field:    fenFields[idx+1],
+				Expected: expr,
+				Found:    code,
+			})
+		}
+	}
+
+	// though it should never happen, make sure that a failed match is always
+	// explained
+	if len(mismatches) == 0 {
+		expr, code := fields(0)
+		mismatches = append(mismatches, PgnFENMismatch{Field: fenFields[0], Expected: expr, Found: code})
+	}
+	return mismatches, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a human readable description of this mismatch
+func (mismatch PgnFENMismatch) String() string {
+	if mismatch.Square != "" {
+		return fmt.Sprintf("%v: square %v is %v but %v is expected",
+			mismatch.Field, mismatch.Square, mismatch.Found, mismatch.Expected)
+	}
+	if mismatch.Rank > 0 {
+		return fmt.Sprintf("%v: rank %v '%v' can not be compared with '%v'",
+			mismatch.Field, mismatch.Rank, mismatch.Found, mismatch.Expected)
+	}
+	return fmt.Sprintf("%v: '%v' found but '%v' is expected",
+		mismatch.Field, mismatch.Found, mismatch.Expected)
+}
+
+// Return the ply of the position of this game which is closest to matching the
+// given pattern, i.e., the one with the fewest mismatches, along with the
+// reasons why it does not match, see ExplainFEN. If any position matches the
+// pattern, the ply of the first one is returned with no mismatches. Games are
+// played if necessary. An error is returned if the game could not be played or
+// the pattern is not well formed
+func (game *PgnGame) ExplainFEN(pattern string) (int, []PgnFENMismatch, error) {
+
+	if err := game.play(); err != nil {
+		return 0, nil, err
+	}
+	closest, explanation := 0, []PgnFENMismatch(nil)
+	for ply, iboard := range game.boards {
+		mismatches, err := ExplainFEN(pattern, iboard.fen)
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(mismatches) == 0 {
+			return ply, nil, nil
+		}
+		if explanation == nil || len(mismatches) < len(explanation) {
+			closest, explanation = ply, mismatches
+		}
+	}
+	return closest, explanation, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,80 @@
+// -*- coding: utf-8 -*-
+// pgnexplain_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:04:53.778481844 (1792170293)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestExplainFEN(t *testing.T) {
+
+	const fen = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*8/*8/*8/*8/4P3/*8/*8/*8 b * * * *", nil},
+		{"*8/*8/*8/*8/3PP3/*8/*8/*8 b * * * *", []string{
+			"piece placement: square d4 is empty but 'P' is expected",
+		}},
+		{"*8/*8/*8/*8/*8/*8/*8/*8 w KQ - 0 1", []string{
+			"active color: 'b' found but 'w' is expected",
+			"castling rights: 'KQkq' found but 'KQ' is expected",
+			"en passant target: 'e3' found but '-' is expected",
+		}},
+		{"*8/*8/*8/*8/4P4/*8/*8/*8 * * * 3 *", []string{
+			"piece placement: rank 4 '4P3' can not be compared with '4P4'",
+			"halfmove clock: '0' found but '3' is expected",
+		}},
+	}
+	for _, tt := range tests {
+		mismatches, err := ExplainFEN(tt.pattern, fen)
+		if err != nil {
+			t.Fatalf("ExplainFEN(%q) error = %v", tt.pattern, err)
+		}
+		var got []string
+		for _, mismatch := range mismatches {
+			got = append(got, mismatch.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ExplainFEN(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	// wrong patterns are reported just as MatchFEN does
+	if _, err := ExplainFEN("*8/*8 w", fen); !errors.Is(err, ErrBadFEN) {
+		t.Errorf("ExplainFEN() error = %v, want %v", err, ErrBadFEN)
+	}
+}
+
+func TestPgnGame_ExplainFEN(t *testing.T) {
+
+	game, err := ParseGame("[Event \"?\"]\n\n1. e4 e5 2. Nf3 *")
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	// the first position matching the pattern is found
+	if ply, mismatches, err := game.ExplainFEN("*8/*8/*8/4p3/4P3/*8/*8/*8 * * * * *"); err != nil || ply != 2 || mismatches != nil {
+		t.Errorf("ExplainFEN() = (%v, %v, %v), want (2, [], nil)", ply, mismatches, err)
+	}
+
+	// otherwise, the closest position is returned
+	ply, mismatches, err := game.ExplainFEN("*8/*8/*8/4p3/4P3/5N2/*8/*8 b KQ * * *")
+	if err != nil || ply != 3 || len(mismatches) != 1 || mismatches[0].Field != "castling rights" {
+		t.Errorf("ExplainFEN() = (%v, %v, %v), want a mismatch of the castling rights in ply 3", ply, mismatches, err)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
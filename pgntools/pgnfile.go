@@ -12,10 +12,12 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/clinaresl/table"
@@ -26,13 +28,57 @@ import (
 
 // A PgnFile contains a collection of chess games in PGN format. It stores no
 // information related to the chess games contained in it and it should be used
-// solely for creating a PgnCollection
+// solely for creating a PgnCollection.
+//
+// By default, games that can not be parsed are considered fatal errors. In
+// lenient mode they are skipped instead. In any case, the raw text of rejected
+// games can be written into a quarantine writer for further inspection.
+//
+// To avoid consuming unbounded memory with malformed input, the text of a
+// single game can not exceed a maximum size
 type PgnFile struct {
-	name    string    // filename
-	size    int64     // size of the file
-	modtime time.Time // Last modification time
+	name        string                  // filename
+	size        int64                   // size of the file
+	modtime     time.Time               // Last modification time
+	lenient     bool                    // whether games with errors are skipped
+	quarantine  io.Writer               // where rejected games are written, if any
+	maxGameSize int                     // maximum size of a game in bytes
+	progress    func(done, total int64) // reports the number of bytes read
+	parseHook   func(*PgnGame) error    // invoked after parsing every game
+	realize     int                     // number of plies realized after parsing
+	first, last int                     // range of ids of the games read
+	mmap        bool                    // whether the file is mapped in memory
+
+	// if given, games read are consumed by this function instead of being
+	// added to the collection returned
+	consume func(game *PgnGame, text string) error
+}
+
+// A PgnDiagnostic describes a range of bytes [Start, End) of a PGN file that
+// could not be parsed and the reason why. Diagnostics are errors so that they
+// can be returned when parsing stops because of them
+type PgnDiagnostic struct {
+	Start, End int64 // byte range of the offending text
+	Err        error // reason why it could not be parsed
+}
+
+// Statistics of parsing a PGN file, which are helpful for logs and for tuning
+// the performance options
+type PgnParseStats struct {
+	Games    int           // number of games parsed
+	Bytes    int64         // number of bytes processed
+	Elapsed  time.Duration // time spent parsing
+	Skipped  int           // number of fragments that could not be parsed
+	Comments int           // number of comments parsed
+	Plies    int           // number of plies of all games parsed
 }
 
+// consts
+// ----------------------------------------------------------------------------
+
+// By default, games longer than the following number of bytes are discarded
+const DefaultMaxGameSize = 1 << 20
+
 // functions
 // ----------------------------------------------------------------------------
 
@@ -98,18 +144,9 @@ func getTags(pgn string) (tags map[string]any) {
 		// <begin/end>-string, <begin/end>-tagname, <begin/end>-tagvalue
 		if len(tag) >= 6 {
 
-			// add this tag to the map to return. In case this
-			// string can be interpreted as an integer number
-			value, err := strconv.Atoi(pgn[tag[4]:tag[5]])
-			if err == nil {
-
-				// then store it as an integer constant
-				tags[pgn[tag[2]:tag[3]]] = value
-			} else {
-
-				// otherwise, store it as a string constant
-				tags[pgn[tag[2]:tag[3]]] = pgn[tag[4]:tag[5]]
-			}
+			// add this tag to the map to return either as an integer
+			// constant or a string constant
+			tags[pgn[tag[2]:tag[3]]] = getTagValue(pgn[tag[4]:tag[5]])
 		}
 	}
 	return
@@ -117,8 +154,10 @@ func getTags(pgn string) (tags map[string]any) {
 
 // Return a slice of PgnMove with the information in the string 'pgn' which
 // shall consist of a legal transcription of legal PGN moves that might be
-// annotated (an arbitrary number of times) or not. 'emt' annotations are also
-// acknowledged and their information is added to the slice of PgnMove.
+// annotated (an arbitrary number of times) or not. 'emt' and 'eval' annotations
+// are also acknowledged and their information is added to the slice of PgnMove.
+// Recursive annotation variations given between parenthesis are stored in the
+// move they are an alternative to, i.e., the move preceding them.
 //
 // Even if the string given in pgn has already matched a regular expression
 // other errors might be found and thus an error is returned which can be empty
@@ -126,17 +165,67 @@ func getTags(pgn string) (tags map[string]any) {
 // returns all moves processed so far
 func getMoves(pgn string) (moves []PgnMove, err error) {
 
-	moveNumber := -1          // initialize the move counter to unknown
-	color := 0                // initialize the color to unknown
-	var shortAlgebraic string // move actually parsed in PGN format
-	var emt float64           // elapsed move time
-	var comments string       // comments of each move
+	// the main line is not preceded by any move, so that both the move number
+	// and the color are unknown
+	moves, pgn, err = getLine(pgn, -1, 0, 0)
+	if err == nil && len(pgn) > 0 {
+		err = errors.New(" A variation was closed but never opened")
+	}
+	return
+}
+
+// Return a slice of PgnMove with the moves of the line given at the beginning
+// of the string 'pgn', along with the text following it, which is empty for the
+// main line (with depth 0) or starts right after the parenthesis closing a
+// variation (with depth strictly positive). The move number and color of the
+// move preceding the line are given so that the first move of a variation is
+// not required to have them.
+//
+// In case of an error, the slice in moves returns all moves processed so far
+func getLine(pgn string, moveNumber, color, depth int) (moves []PgnMove, rest string, err error) {
+
+	var shortAlgebraic string       // move actually parsed in PGN format
+	var quality string              // quality of the move given by annotators
+	var emt float64                 // elapsed move time
+	var eval float64                // evaluation in pawns
+	var evaluated bool              // whether the move is evaluated
+	var annotations []PgnAnnotation // annotations of each move
+
+	// process plies in sequence until the whole string is exhausted or the
+	// variation is closed
+	for pgn = strings.TrimSpace(pgn); len(pgn) > 0; pgn = strings.TrimSpace(pgn) {
+
+		// Variations are closed only in case one was opened
+		if pgn[0] == ')' {
+			if depth == 0 {
+				return moves, pgn, nil
+			}
+			return moves, pgn[1:], nil
+		}
 
-	// process plies in sequence until the whole string is exhausted
-	for len(pgn) > 0 {
+		// and they are opened only after a move, which they are an
+		// alternative to. Annotations found after the variation are added to
+		// the annotations of that move
+		if pgn[0] == '(' {
+			if len(moves) == 0 {
+				return moves, pgn, errors.New(" A variation was found before any move")
+			}
+			last := &moves[len(moves)-1]
+			variation, remaining, err := getLine(pgn[1:], last.number, -last.color, depth+1)
+			if err != nil {
+				return moves, pgn, err
+			}
+			last.variations = append(last.variations, variation)
+			annotations, pgn = getMoveAnnotations(remaining)
+			last.annotations = append(last.annotations, annotations...)
+			continue
+		}
 
 		// get the next move
 		tag := reGroupMoves.FindStringSubmatchIndex(pgn)
+		if tag == nil {
+			return moves, pgn, fmt.Errorf(" No move was found in '%v'", pgn)
+		}
 
 		// reGroupMoves contains three groups and therefore legal matches
 		// contain 8 characters
@@ -149,7 +238,7 @@ func getMoves(pgn string) (moves []PgnMove, err error) {
 				// update the move counter
 				moveNumber, err = strconv.Atoi(pgn[tag[2]:tag[3]])
 				if err != nil {
-					return moves, errors.New(" Error while extracting the move number")
+					return moves, pgn, errors.New(" Error while extracting the move number")
 				}
 
 				// and the color, in case only one character ('.') is found,
@@ -165,53 +254,132 @@ func getMoves(pgn string) (moves []PgnMove, err error) {
 				color *= -1
 			}
 
-			// and in any case extract the move value
-			shortAlgebraic = pgn[tag[6]:tag[7]]
+			// and in any case extract the move value, separating the quality
+			// symbols given by the annotator, if any
+			shortAlgebraic, quality = getQuality(pgn[tag[6]:tag[7]])
 		}
 
-		// and move forward
-		pgn = pgn[tag[1]:]
-
-		// are there any comments immediately after? The following loop aims at
-		// processing an arbitrary number of comments
-		emt = -1.0    // initialize the elapsed move time to unknown
-		comments = "" // initialize the comments to the empty string
-		for reGroupComment.MatchString(pgn) {
-
-			// Yeah, a comment has been found! extract it
-			tag = reGroupComment.FindStringSubmatchIndex(pgn)
+		// and move forward processing the annotations given immediately
+		// after
+		annotations, pgn = getMoveAnnotations(pgn[tag[1]:])
 
-			// is this an emt field?
-			if reGroupEMT.MatchString(pgn) {
-				tagEMT := reGroupEMT.FindStringSubmatchIndex(pgn)
-				emt, err = strconv.ParseFloat(pgn[tagEMT[2]:tagEMT[3]], 32)
+		// the elapsed move time is also stored separately, if any is given
+		emt = -1.0 // initialize the elapsed move time to unknown
+		for _, annotation := range annotations {
+			if annotation.Kind == EMTAnnotation {
+				emt, err = strconv.ParseFloat(annotation.Value, 32)
 				if err != nil {
-					return moves, errors.New(" Error while converting emt")
+					return moves, pgn, errors.New(" Error while converting emt")
 				}
-			} else {
-				// if not, then just add these comments. In case some comments
-				// were already written, make sure to add this in a new line
-				if len(comments) > 0 {
-					comments += "\n"
+				break
+			}
+		}
+
+		// and also the evaluation, if any is given
+		eval, evaluated = 0, false
+		for _, annotation := range annotations {
+			if annotation.Kind == EvalAnnotation {
+				if eval, evaluated = getEvalPawns(annotation.Value); !evaluated {
+					return moves, pgn, errors.New(" Error while converting eval")
 				}
-				comments += pgn[1+tag[2] : tag[3]-1]
+				break
 			}
-			pgn = pgn[tag[1]:]
 		}
 
 		// and add this move to the list of moves to return unless there are
 		// unknown fields
 		if moveNumber == -1 || color == 0 {
-			return moves, errors.New(" Either the move number or the color were incorrect")
+			return moves, pgn, errors.New(" Either the move number or the color were incorrect")
 		}
 
 		// Note that the move is initialized in long algebraic notation as empty
-		moves = append(moves, PgnMove{moveNumber, color, shortAlgebraic, longAlgebraic{}, float32(emt), comments})
+		moves = append(moves, PgnMove{moveNumber, color, shortAlgebraic, quality, longAlgebraic{}, float32(emt), eval, evaluated, annotations, nil})
 	}
 
+	// variations must be closed before the end of the movetext
+	if depth > 0 {
+		return moves, pgn, errors.New(" A variation was opened but never closed")
+	}
+	return moves, pgn, nil
+}
+
+// Return all annotations found at the beginning of the given string and the
+// text following them. The following loop aims at processing an arbitrary
+// number of comments and NAGs which are returned in the same order they are
+// found
+func getMoveAnnotations(pgn string) (annotations []PgnAnnotation, rest string) {
+	for {
+		if tag := reGroupComment.FindStringSubmatchIndex(pgn); tag != nil {
+
+			// Yeah, a comment has been found! extract all annotations in it
+			annotations = append(annotations, getAnnotations(pgn[1+tag[2]:tag[3]-1])...)
+			pgn = pgn[tag[1]:]
+		} else if tag = reGroupNAG.FindStringSubmatchIndex(pgn); tag != nil {
+			annotations = append(annotations, PgnAnnotation{Kind: NAGAnnotation, Value: pgn[tag[2]:tag[3]]})
+			pgn = pgn[tag[1]:]
+		} else {
+			return annotations, pgn
+		}
+	}
+}
+
+// Return all annotations found in the given comment (without braces) in the
+// same order they are found. Commands such as "[%emt 2.1]", "[%clk 0:03:00]"
+// or "[%eval 0.17]" are returned as typed annotations, and the text between
+// them as comments. Unknown commands are kept verbatim in comments. All
+// annotations but the first one are marked as joined so that the comment can be
+// written again as a single block. Empty comments are returned as an empty
+// comment annotation
+func getAnnotations(comment string) (annotations []PgnAnnotation) {
+
+	// add the given annotation, joining it to the previous ones
+	add := func(kind PgnAnnotationKind, value string) {
+		annotations = append(annotations, PgnAnnotation{Kind: kind, Value: value, Joined: len(annotations) > 0})
+	}
+
+	// process all commands in the comment along with the text preceding them
+	start := 0
+	for _, tag := range reGroupCommand.FindAllStringSubmatchIndex(comment, -1) {
+		if text := strings.TrimSpace(comment[start:tag[0]]); text != "" {
+			add(CommentAnnotation, text)
+		}
+		if kind, ok := commandAnnotations[comment[tag[2]:tag[3]]]; ok {
+			add(kind, strings.TrimSpace(comment[tag[4]:tag[5]]))
+		} else {
+			add(CommentAnnotation, comment[tag[0]:tag[1]])
+		}
+		start = tag[1]
+	}
+
+	// and the text after the last command, if any
+	if text := strings.TrimSpace(comment[start:]); text != "" || len(annotations) == 0 {
+		add(CommentAnnotation, text)
+	}
 	return
 }
 
+// Return the given move in short algebraic notation without the symbols used by
+// annotators to qualify it, and the quality of the move normalized to one of
+// the six symbols acknowledged by the PGN standard: "!", "?", "!!", "??", "!?"
+// and "?!". Longer sequences are truncated to the first two symbols, e.g.,
+// "!!!" is normalized as "!!". If the move is not qualified, the quality is
+// the empty string
+func getQuality(move string) (string, string) {
+
+	// look for the quality symbols at the end of the move
+	tag := reGroupQuality.FindStringSubmatchIndex(move)
+	if tag == nil {
+		return move, ""
+	}
+
+	// and normalize them
+	quality := move[tag[2]:tag[3]]
+	if len(quality) > 2 {
+		quality = quality[:2]
+	}
+	return move[:tag[0]], quality
+}
+
 // Return an instance of PgnOutcome with the score of each player as specified
 // in the given string.
 //
@@ -236,7 +404,7 @@ func getOutcome(pgn string) (outcome *PgnOutcome, err error) {
 			// otherwise, one side won the match
 			scoreWhite, err := strconv.Atoi(pgn[tag[2]:tag[3]])
 			if err != nil {
-				return nil, fmt.Errorf(" Illegal outcome found in string '%s'", pgn)
+				return nil, fmt.Errorf("%w found in string '%s'", ErrUnknownOutcome, pgn)
 			}
 			outcome = &PgnOutcome{float32(scoreWhite), 1.0 - float32(scoreWhite)}
 		}
@@ -247,7 +415,7 @@ func getOutcome(pgn string) (outcome *PgnOutcome, err error) {
 		// (ungrouped) regexp for the outcome and '*' is not considered in the
 		// grouped regexp
 		if pgn != "*" {
-			return nil, fmt.Errorf(" Unknown outcome found '%v'", pgn)
+			return nil, fmt.Errorf("%w found '%v'", ErrUnknownOutcome, pgn)
 		} else {
 
 			// In that case the outcome is registered as -1, -1
@@ -269,16 +437,18 @@ func getGameFromString(pgn string) (*PgnGame, error) {
 
 	// create variables to store different sections of a single PGN game
 	var strTags, strMoves, strOutcome string
+	var leading, trailing []PgnAnnotation
 
 	// The game must start with tags. Extract them
 	endpoints := reTags.FindStringIndex(pgn)
 	if endpoints == nil {
-		return nil, fmt.Errorf(" No tags were found in the chunk: %v", pgn)
+		return nil, fmt.Errorf("%w in the chunk: %v", ErrNoTags, pgn)
 	} else {
 
-		// copy the section of the tags and move forward in the pgn string
+		// copy the section of the tags and move forward in the pgn string,
+		// skipping the comments given before the first move, if any
 		strTags = pgn[endpoints[0]:endpoints[1]]
-		pgn = pgn[endpoints[1]:]
+		leading, pgn = getMoveAnnotations(strings.TrimSpace(pgn[endpoints[1]:]))
 
 		// now, check that this is followed by a legal transcription of chess
 		// moves in PGN format
@@ -295,13 +465,14 @@ func getGameFromString(pgn string) (*PgnGame, error) {
 			// now, check that the final result is properly written
 			endpoints = reOutcome.FindStringIndex(pgn)
 			if endpoints == nil {
-				return nil, fmt.Errorf(" No lega transcription of the final result was found in the chunk: %v", pgn)
+				return nil, fmt.Errorf("%w: no legal transcription of the final result was found in the chunk: %v", ErrUnknownOutcome, pgn)
 			} else {
 
-				// again, copy the section with the final
-				// outcome and move forward in the pgn file
+				// again, copy the section with the final outcome and move
+				// forward in the pgn file, where comments might be given
+				// after it
 				strOutcome = pgn[endpoints[0]:endpoints[1]]
-				pgn = pgn[endpoints[1]:]
+				trailing, _ = getMoveAnnotations(strings.TrimSpace(pgn[endpoints[1]:]))
 			}
 		}
 	}
@@ -317,13 +488,53 @@ func getGameFromString(pgn string) (*PgnGame, error) {
 	if errOutcome != nil {
 		return nil, errOutcome
 	}
+	tags := getTags(strTags)
+	if err := validateElo(tags); err != nil {
+		return nil, err
+	}
 	return &PgnGame{
-		tags:    getTags(strTags),
-		moves:   moves,
-		outcome: *outcome,
+		tags:     tags,
+		moves:    moves,
+		outcome:  *outcome,
+		movetext: strings.Join(strings.Fields(strMoves), " "),
+		leading:  leading,
+		trailing: trailing,
 	}, nil
 }
 
+// ParseGame is the first phase of processing a game. It returns the game given
+// in PGN format in the given string with its tags, moves, comments and outcome,
+// and nil if it could be parsed, or an error otherwise. Moves are not played on
+// a chess board, so that the boards of the game are not available until it is
+// realized with Realize, which is the second phase
+func ParseGame(pgn string) (*PgnGame, error) {
+	return getGameFromString(pgn)
+}
+
+// Return all games read from the given reader as a collection of PgnGames, in
+// the same way games are read from a PgnFile with Games, which describes the
+// options accepted. As the size of the input is unknown, WithProgress reports
+// zero as the total number of bytes
+func NewPgnCollectionFromReader(reader io.Reader, opts ...PgnOption) (*PgnCollection, error) {
+	f := PgnFile{maxGameSize: DefaultMaxGameSize}
+	return f.withOptions(opts...).readGames(reader)
+}
+
+// Give every game found in the given reader to the given function right after
+// parsing it, instead of adding it to the collection returned, so that the
+// memory used does not grow with the number of games. The collection returned
+// only contains the diagnostics and statistics of parsing them. Options are
+// applied as in NewPgnCollectionFromReader, and the parse hook, if any, is
+// invoked before consuming every game
+func ConsumeGamesFromReader(reader io.Reader, consume func(game *PgnGame) error, opts ...PgnOption) (*PgnCollection, error) {
+	f := PgnFile{maxGameSize: DefaultMaxGameSize}
+	f = f.withOptions(opts...)
+	f.consume = func(game *PgnGame, text string) error {
+		return consume(game)
+	}
+	return f.readGames(reader)
+}
+
 // methods
 // ----------------------------------------------------------------------------
 
@@ -354,12 +565,25 @@ func NewPgnFile(filepath string) (*PgnFile, error) {
 
 	// and return an instance of PgnFile
 	return &PgnFile{
-		name:    fullname,
-		size:    fileinfo.Size(),
-		modtime: fileinfo.ModTime(),
+		name:        fullname,
+		size:        fileinfo.Size(),
+		modtime:     fileinfo.ModTime(),
+		maxGameSize: DefaultMaxGameSize,
 	}, nil
 }
 
+// Diagnostics are errors which show the range of bytes and the reason why they
+// could not be parsed
+func (diagnostic PgnDiagnostic) Error() string {
+	return fmt.Sprintf(" Error in bytes [%v, %v): %v", diagnostic.Start, diagnostic.End, strings.TrimSpace(diagnostic.Err.Error()))
+}
+
+// Return the reason why the text described in this diagnostic could not be
+// parsed
+func (diagnostic PgnDiagnostic) Unwrap() error {
+	return diagnostic.Err
+}
+
 // Return the filepath of a PgnFile
 func (f PgnFile) Name() string {
 	return f.name
@@ -375,11 +599,80 @@ func (f PgnFile) ModTime() time.Time {
 	return f.modtime
 }
 
-// Return all games stored in the PgnFile f as a collection of PgnGames. The
-// games returned by this service do not include the successive boards of each
-// game, but just the moves. To get the boards it is necessary to "Play" the
-// game
-func (f PgnFile) Games() (*PgnCollection, error) {
+// Set the lenient mode of this PgnFile. If enabled, games that can not be
+// parsed are skipped instead of returning an error
+func (f *PgnFile) SetLenient(lenient bool) {
+	f.lenient = lenient
+}
+
+// Set the maximum size in bytes of the text of a single game. If the text
+// accumulated without recognizing a game exceeds it, it is discarded. Zero or
+// negative values mean that there is no limit
+func (f *PgnFile) SetMaxGameSize(size int) {
+	f.maxGameSize = size
+}
+
+// Set the writer where the raw text of all rejected games is written. Each
+// rejected game is preceded by a comment with the error found so that the
+// result can be inspected and fixed. If nil is given, rejected games are not
+// written anywhere
+func (f *PgnFile) SetQuarantine(quarantine io.Writer) {
+	f.quarantine = quarantine
+}
+
+// In case a quarantine writer was given, write the given text there preceded by
+// a comment with the given error. Braces are removed from the error message so
+// that the comment is properly closed. It returns nil if no error was found
+// while writing
+func (f PgnFile) quarantineText(text string, err error) error {
+
+	if f.quarantine != nil {
+		msg := strings.TrimSpace(strings.NewReplacer("{", "", "}", "", "\n", " ").Replace(err.Error()))
+		if _, werr := io.WriteString(f.quarantine, fmt.Sprintf("{ %v }\n%v\n\n", msg, strings.TrimSpace(text))); werr != nil {
+			return werr
+		}
+	}
+	return nil
+}
+
+// Process the given text which could not be parsed as described in the given
+// diagnostic. Its text is written into the quarantine writer, if any was
+// given. It returns nil in lenient mode, so that parsing can go on, and the
+// diagnostic otherwise
+func (f PgnFile) reject(text string, diagnostic PgnDiagnostic) error {
+
+	// write the rejected text into the quarantine writer
+	if werr := f.quarantineText(text, diagnostic); werr != nil {
+		return werr
+	}
+
+	// and return an error unless lenient parsing was requested
+	if f.lenient {
+		return nil
+	}
+	return diagnostic
+}
+
+// Return all games stored in the PgnFile f as a collection of PgnGames. By
+// default, the games returned by this service do not include the successive
+// boards of each game, but just the moves, i.e., they are only parsed. To get
+// the boards it is necessary to "Play" the game, or to Realize it
+//
+// The options given override the configuration of this PgnFile: WithLenient,
+// WithQuarantine and WithMaxGameSize are equivalent to the corresponding
+// setters, WithProgress reports the number of bytes read so far and the size of
+// the file, WithRealize plays the given number of plies of every game,
+// WithParseHook is invoked with every game right after parsing (and realizing)
+// it, WithRange reads only the games in the given range of ids, and WithMmap
+// maps the file in memory if possible.
+//
+// The format of the file is detected from its contents (see DetectFormat), so
+// that games given in JSON and positions given in EPD, which are taken as
+// games without moves, are read as well
+func (f PgnFile) Games(opts ...PgnOption) (*PgnCollection, error) {
+
+	// Apply the given options. As f is a copy, this PgnFile is not modified
+	f = f.withOptions(opts...)
 
 	// Open the PgnFile
 	stream, err := os.OpenFile(f.name, os.O_RDONLY, 0644)
@@ -388,52 +681,342 @@ func (f PgnFile) Games() (*PgnCollection, error) {
 		// in case of error, return a nil collection of pgn games and the error
 		return nil, err
 	}
+	defer stream.Close()
+
+	// files in other formats are read with their own readers
+	format, err := detectFileFormat(stream)
+	if err != nil {
+		return nil, err
+	}
+	if format != PGNFormat {
+		return f.readFormat(stream, format)
+	}
+
+	// and otherwise, read all games from it, directly from memory if requested
+	// and possible
+	if f.mmap {
+		if mapped, err := mapFile(stream); err == nil {
+			defer mapped.Close()
+			return f.readGames(mapped)
+		}
+	}
+	return f.readGames(stream)
+}
+
+// Return a copy of this PgnFile configured with the given options
+func (f PgnFile) withOptions(opts ...PgnOption) PgnFile {
+
+	options := newPgnOptions(opts...)
+	f.lenient = f.lenient || options.lenient
+	if options.quarantine != nil {
+		f.quarantine = options.quarantine
+	}
+	if options.maxGameSize != nil {
+		f.maxGameSize = *options.maxGameSize
+	}
+	f.progress = options.progress
+	f.parseHook = options.parseHook
+	f.realize = options.realize
+	f.first, f.last = options.first, options.last
+	f.mmap = options.mmap
+	return f
+}
+
+// Return whether the game with the given id is in the range of games read from
+// this PgnFile
+func (f PgnFile) inRange(id int) bool {
+	return id >= f.first && (f.last <= 0 || id <= f.last)
+}
+
+// Return the game in the given text with the given id and nil if it could be
+// parsed, the requested number of plies could be realized and the parse hook of
+// this PgnFile, if any, accepted it. Otherwise, an error is returned
+func (f PgnFile) parseGame(text string, id int) (*PgnGame, error) {
+
+	game, err := ParseGame(text)
+	if err != nil {
+		return nil, err
+	}
+	game.id = id
+	if f.realize != 0 {
+		if err := game.Realize(f.realize); err != nil {
+			return nil, err
+		}
+	}
+	if f.parseHook != nil {
+		if err := f.parseHook(game); err != nil {
+			return nil, err
+		}
+	}
+	return game, nil
+}
 
-	// Initialize an empty slice of PgGames to return within a PgnCollection
-	games := make([]PgnGame, 0)
+// Return the game in the given text, which could not be parsed, if it is a game
+// whose result is missing, e.g., because it is followed by the tags of the next
+// one in a broken export. In this case, the game is closed with '*' and it is
+// given the given id. Otherwise, nil is returned
+func (f PgnFile) recoverGame(text string, id int) *PgnGame {
+
+	// the whole text has to be a game once it is closed
+	closed := text + " *"
+	tag := reGame.FindStringIndex(closed)
+	if tag == nil ||
+		len(strings.TrimSpace(closed[:tag[0]])) > 0 ||
+		len(strings.TrimSpace(closed[tag[1]:])) > 0 {
+		return nil
+	}
+	game, err := f.parseGame(closed, id)
+	if err != nil {
+		return nil
+	}
+	return game
+}
+
+// Return the given text after replacing all UTF-8 byte order marks (which might
+// appear anywhere when various files are concatenated) with blanks and
+// normalizing line terminators so that they are always '\n'. The result has
+// the same length than the given text so that offsets computed over it are
+// also valid in the original text
+func normalizeLine(line string) string {
+	line = strings.ReplaceAll(line, "\ufeff", "   ")
+	line = strings.ReplaceAll(line, "\r\n", " \n")
+	return strings.ReplaceAll(line, "\r", "\n")
+}
 
-	// Next, scan the lines of the input file using a buffered input stream
-	var id int
+// Return all games read from the given reader as a collection of PgnGames.
+//
+// The reader is processed line by line, and lines are allowed to have any
+// length. Readers which provide lines themselves, e.g., mapped files, are read
+// directly, and the others are read through a buffered input stream. Games can
+// be separated by any amount of blank characters or even no separator at all,
+// i.e., a game might start right after the outcome of the preceding one.
+//
+// If this PgnFile consumes games, they are given to its consume function along
+// with their raw text instead of being added to the collection returned, which
+// then only contains the diagnostics and statistics of parsing them.
+//
+// All text that could not be parsed is reported in the diagnostics of the
+// collection returned. If the text accumulated without finding a game exceeds
+// the maximum game size of this PgnFile, it is discarded.
+//
+// If a range of games is given, games are numbered in the order they are found,
+// including those that could not be parsed, and games preceding the range are
+// neither parsed nor reported. Reading stops right after the last game of the
+// range
+func (f PgnFile) readGames(reader io.Reader) (*PgnCollection, error) {
+
+	// Initialize an empty collection of PgnGames to return
+	start := time.Now()
+	games := NewPgnCollection()
+	diagnostics := make([]PgnDiagnostic, 0)
+	var stats PgnParseStats
+
+	// Games are added to the collection to return along with the range of
+	// bytes they occupy in the input, which gives them a unique id, unless
+	// they are consumed. In this case, ids are also remembered
+	nbgames := 0
+	add := func(game *PgnGame, text string, start, end int64) error {
+		game.start, game.end = start, end
+		nbgames++
+		stats.Plies += len(game.moves)
+		for _, move := range game.moves {
+			for _, annotation := range move.annotations {
+				if annotation.Kind == CommentAnnotation {
+					stats.Comments += 1
+				}
+			}
+		}
+		if f.consume != nil {
+			games.lastId = max(games.lastId, game.id)
+			return f.consume(game, text)
+		}
+		games.Add(*game)
+		return nil
+	}
+
+	// Text that can not be parsed is reported and written into the quarantine
+	// writer, unless it is a game whose result is missing. These games are
+	// closed with '*' and they are reported as well, but they are not skipped
+	recovered := 0
+	unparsed := func(text string, start int64) error {
+		diagnostic := PgnDiagnostic{start, start + int64(len(text)), errors.New(" No game could be parsed")}
+		if game := f.recoverGame(text, games.lastId+1); game != nil {
+			if err := add(game, text, start, start+int64(len(text))); err != nil {
+				return err
+			}
+			diagnostic.Err = ErrMissingOutcome
+			recovered++
+		} else if err := f.quarantineText(text, diagnostic); err != nil {
+			return err
+		}
+		diagnostics = append(diagnostics, diagnostic)
+		return nil
+	}
+
+	// Next, read the input file using a buffered input stream, unless it
+	// provides lines itself. Along with the text read, the offset of its first
+	// byte in the input file is stored
 	var text string
-	scanner := bufio.NewScanner(stream)
+	var offset int64
+	input, ok := reader.(lineReader)
+	if !ok {
+		input = bufio.NewReader(reader)
+	}
 
-	// Scanning goes line by line
-	for scanner.Scan() {
+	// Games found so far are counted to select only those in the range given,
+	// if any
+	found, finished := 0, false
+
+	// As comments might follow the outcome of a game, games are extracted
+	// only once some other text follows them. Meanwhile, the location of the
+	// last game found is remembered so that only the text read afterwards is
+	// examined
+	var pending []int
+
+	// Reading goes line by line
+	for {
+
+		// text is accumulated until a whole game is found. Line terminators
+		// are preserved so that the raw text of games can be recovered. Note
+		// that the last line might be returned along with io.EOF. Lines longer
+		// than the buffer are read in chunks so that the memory used is
+		// bounded by the maximum game size
+		line, err := input.ReadSlice('\n')
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		text = text + normalizeLine(string(line))
+		stats.Bytes += int64(len(line))
+		if f.progress != nil {
+			f.progress(offset+int64(len(text)), f.size)
+		}
+
+		// Because various games might be glued together in the same line,
+		// extract all games found so far
+		for !finished {
 
-		// text is accumulated until a whole game is found
-		text = text + scanner.Text()
-		if reGame.MatchString(text) {
+			// In case a match has been found, extend it with all comments
+			// read after it. The game is extracted only once some other text
+			// follows it or the whole input has been read
+			if pending == nil {
+				if pending = reGame.FindStringIndex(text); pending == nil {
+					break
+				}
+			}
+			pending[1] += len(reTrailingComments.FindString(text[pending[1]:]))
+			if err != io.EOF && (pending[1] == len(text) || text[pending[1]] == '{') {
+				break
+			}
+			tag := pending
+			pending = nil
+
+			// Games preceding the range requested are just skipped
+			found++
+			if found < f.first {
+				text = text[tag[1]:]
+				offset += int64(tag[1])
+				continue
+			}
 
-			// In case a match has been found, extract the next game
-			tag := reGame.FindStringSubmatchIndex(text)
+			// Any text preceding the game could not be parsed. Usually, it
+			// is ignored but it is reported and written into the quarantine
+			// writer. However, it might be a game whose result is missing
+			if len(strings.TrimSpace(text[:tag[0]])) > 0 {
+				if err := unparsed(text[:tag[0]], offset); err != nil {
+					return nil, err
+				}
+			}
 
 			// Parse this game and get an instance of PgnGame with the
-			// information in it
-			game, err := getGameFromString(text[tag[0]:tag[1]])
+			// information in it. If a range of games was requested, its id is
+			// its location in the input
+			id := games.lastId + 1
+			if f.first > 0 {
+				id = found
+			}
+			game, err := f.parseGame(text[tag[0]:tag[1]], id)
 			if err != nil {
-				return nil, err
+
+				// if the game is rejected without errors skip it
+				diagnostic := PgnDiagnostic{offset + int64(tag[0]), offset + int64(tag[1]), err}
+				diagnostics = append(diagnostics, diagnostic)
+				if err := f.reject(text[tag[0]:tag[1]], diagnostic); err != nil {
+					return nil, err
+				}
+			} else {
+
+				// remember the range of bytes of this game in the input, and
+				// add it to the collection of games to return
+				if err := add(game, text[tag[0]:tag[1]], offset+int64(tag[0]), offset+int64(tag[1])); err != nil {
+					return nil, err
+				}
 			}
 
-			// give it a unique id
-			id++
-			game.id = id
+			// stop after the last game of the range requested, if any
+			finished = f.last > 0 && found >= f.last
+
+			// and keep only the text after the game just found, which might
+			// contain the beginning of the next one
+			text = text[tag[1]:]
+			offset += int64(tag[1])
+		}
+
+		// In case the text accumulated exceeds the maximum game size, discard
+		// it
+		if f.maxGameSize > 0 && len(text) > f.maxGameSize {
+			diagnostic := PgnDiagnostic{offset, offset + int64(len(text)), fmt.Errorf(" The maximum game size (%v bytes) was exceeded", f.maxGameSize)}
+			diagnostics = append(diagnostics, diagnostic)
+			if err := f.reject(text, diagnostic); err != nil {
+				return nil, err
+			}
+			offset += int64(len(text))
+			text, pending = "", nil
+		}
 
-			// add this game to the collection of games to return
-			games = append(games, *game)
+		// and stop once the whole input (or the range requested) has been read
+		if err == io.EOF || finished {
+			break
+		}
+	}
 
-			// reset the text containing the game just found
-			text = ""
+	// Likewise, in case some text remains which could not be parsed, report it
+	// and write it into the quarantine writer unless it is a game whose result
+	// is missing
+	if !finished && len(strings.TrimSpace(text)) > 0 {
+		if err := unparsed(text, offset); err != nil {
+			return nil, err
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+
+	// Once done return the collection with all these games along with the
+	// statistics of parsing them
+	stats.Games, stats.Skipped = nbgames, len(diagnostics)-recovered
+	stats.Elapsed = time.Since(start)
+	games.diagnostics, games.stats = diagnostics, stats
+	return &games, nil
+}
+
+// Return the average number of moves (not plies) per game parsed, or zero if
+// no game was parsed
+func (stats PgnParseStats) AverageMoves() float64 {
+	if stats.Games == 0 {
+		return 0
 	}
+	return float64(stats.Plies) / float64(2*stats.Games)
+}
 
-	// Once done return an instance of PgCollection with all these games
-	return &PgnCollection{
-		slice:   games,
-		nbGames: len(games),
-	}, nil
+// Parse statistics are stringers. They are shown using a table
+func (stats PgnParseStats) String() string {
+
+	tab, _ := table.NewTable(" l: r")
+	tab.AddRow("▶ Games", stats.Games)
+	tab.AddRow("▶ Skipped", stats.Skipped)
+	tab.AddRow("▶ Bytes", stats.Bytes)
+	tab.AddRow("▶ Elapsed", stats.Elapsed)
+	tab.AddRow("▶ Comments", stats.Comments)
+	tab.AddRow("▶ Avg. Moves", fmt.Sprintf("%.2f", stats.AverageMoves()))
+	tab.AddDoubleRule()
+	return fmt.Sprintf("%v", tab)
 }
 
 // PgnFile are stringers. They just show the information of a PgnFile using a
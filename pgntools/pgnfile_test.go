@@ -0,0 +1,613 @@
+// -*- coding: utf-8 -*-
+// pgnfile_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 13:58:04.558263958 (1792159084)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func Test_readGames(t *testing.T) {
+
+	// a couple of games used to build all inputs
+	game1 := `[Event "Rated game"]
+[White "clinares"]
+[Black "yerken"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Nd4 4. Nxe5 Qg5 5. Nxf7 Qxg2 6. Rf1 Qxe4+ 7. Be2 Nf3# 1-0`
+	game2 := `[Event "Rated game"]
+[White "yerken"]
+[Black "clinares"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0`
+
+	tests := []struct {
+		name    string
+		input   string
+		nbGames int
+		nbMoves []int
+	}{
+		{name: "LF",
+			input:   game1 + "\n\n" + game2 + "\n",
+			nbGames: 2,
+			nbMoves: []int{14, 7}},
+
+		{name: "CRLF",
+			input:   strings.ReplaceAll(game1+"\n\n"+game2+"\n", "\n", "\r\n"),
+			nbGames: 2,
+			nbMoves: []int{14, 7}},
+
+		{name: "CR",
+			input:   strings.ReplaceAll(game1+"\n\n"+game2+"\n", "\n", "\r"),
+			nbGames: 2,
+			nbMoves: []int{14, 7}},
+
+		{name: "Mixed CRLF/LF",
+			input:   strings.ReplaceAll(game1, "\n", "\r\n") + "\n\n" + game2 + "\n",
+			nbGames: 2,
+			nbMoves: []int{14, 7}},
+
+		{name: "BOM",
+			input:   "\ufeff" + game1 + "\n\n" + game2 + "\n",
+			nbGames: 2,
+			nbMoves: []int{14, 7}},
+
+		{name: "Concatenated files with BOMs",
+			input:   "\ufeff" + game1 + "\r\n\ufeff" + game2,
+			nbGames: 2,
+			nbMoves: []int{14, 7}},
+
+		{name: "No trailing newline",
+			input:   game1 + "\n\n" + game2,
+			nbGames: 2,
+			nbMoves: []int{14, 7}},
+
+		{name: "Glued games",
+			input:   game1 + game2,
+			nbGames: 2,
+			nbMoves: []int{14, 7}},
+
+		{name: "Glued games in a single line",
+			input:   strings.ReplaceAll(game1+game2+game1, "\n", " "),
+			nbGames: 3,
+			nbMoves: []int{14, 7, 14}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games, err := PgnFile{}.readGames(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("readGames() error = %v", err)
+			}
+			if games.Len() != tt.nbGames {
+				t.Fatalf("readGames() = %v games, want %v", games.Len(), tt.nbGames)
+			}
+			for idx, igame := range games.GetGames() {
+				if len(igame.moves) != tt.nbMoves[idx] {
+					t.Errorf("readGames() game #%v has %v plies, want %v", idx, len(igame.moves), tt.nbMoves[idx])
+				}
+				if _, ok := igame.tags["Event"]; !ok {
+					t.Errorf("readGames() game #%v has no tag 'Event'", idx)
+				}
+				if igame.outcome != (PgnOutcome{1, 0}) {
+					t.Errorf("readGames() game #%v has outcome %v, want 1-0", idx, igame.outcome)
+				}
+			}
+		})
+	}
+}
+
+func Test_readGamesDiagnostics(t *testing.T) {
+
+	game := `[Event "Rated game"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+`
+	garbage := "this is not a game\n"
+
+	// Note that blank characters preceding a game are considered to be part of
+	// it, so that they are not included in the diagnostics
+
+	tests := []struct {
+		name        string
+		input       string
+		maxGameSize int
+		nbGames     int
+		diagnostics [][2]int64
+	}{
+		{name: "No diagnostics",
+			input:       game + game,
+			nbGames:     2,
+			diagnostics: [][2]int64{}},
+
+		{name: "Garbage between games",
+			input:       game + garbage + game,
+			nbGames:     2,
+			diagnostics: [][2]int64{{int64(len(game)), int64(len(game+garbage) - 1)}}},
+
+		{name: "Trailing garbage",
+			input:       game + game + garbage,
+			nbGames:     2,
+			diagnostics: [][2]int64{{int64(2 * len(game)), int64(2*len(game) + len(garbage))}}},
+
+		{name: "BOM and CRLF preserve offsets",
+			input:       "\ufeff" + strings.ReplaceAll(garbage+game, "\n", "\r\n"),
+			nbGames:     1,
+			diagnostics: [][2]int64{{0, int64(3 + len(garbage) - 1)}}},
+
+		{name: "Maximum game size exceeded",
+			input:       strings.Repeat(garbage, 12) + game,
+			maxGameSize: 100,
+			nbGames:     1,
+			diagnostics: [][2]int64{{0, int64(6 * len(garbage))}, {int64(6 * len(garbage)), int64(12 * len(garbage))}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games, err := PgnFile{maxGameSize: tt.maxGameSize, lenient: true}.readGames(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("readGames() error = %v", err)
+			}
+			if games.Len() != tt.nbGames {
+				t.Fatalf("readGames() = %v games, want %v", games.Len(), tt.nbGames)
+			}
+			if len(games.Diagnostics()) != len(tt.diagnostics) {
+				t.Fatalf("readGames() = %v diagnostics, want %v", games.Diagnostics(), tt.diagnostics)
+			}
+			for idx, diagnostic := range games.Diagnostics() {
+				if diagnostic.Start != tt.diagnostics[idx][0] || diagnostic.End != tt.diagnostics[idx][1] {
+					t.Errorf("readGames() diagnostic #%v = [%v, %v), want [%v, %v)", idx, diagnostic.Start, diagnostic.End, tt.diagnostics[idx][0], tt.diagnostics[idx][1])
+				}
+			}
+		})
+	}
+}
+
+func Test_readGamesParseHook(t *testing.T) {
+
+	game := `[Event "Rated game"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+`
+	short := `[Event "Rated game"]
+[Result "1-0"]
+
+1. e4 e5 1-0
+`
+
+	// the hook enriches games with new tags and rejects short games. Games
+	// are given their ids before invoking it
+	hook := func(game *PgnGame) error {
+		if len(game.moves) < 4 {
+			return errors.New(" Too short")
+		}
+		game.tags["Plies"] = len(game.moves)
+		game.tags["Id"] = game.id
+		return nil
+	}
+	games, err := PgnFile{lenient: true, parseHook: hook}.readGames(strings.NewReader(game + short + game))
+	if err != nil {
+		t.Fatalf("readGames() error = %v", err)
+	}
+	if games.Len() != 2 || len(games.Diagnostics()) != 1 {
+		t.Fatalf("readGames() = %v games and %v diagnostics, want 2 and 1", games.Len(), len(games.Diagnostics()))
+	}
+	for idx, igame := range games.GetGames() {
+		if igame.tags["Plies"] != 7 {
+			t.Errorf("readGames() game #%v has %v plies, want 7", idx, igame.tags["Plies"])
+		}
+		if igame.tags["Id"] != idx+1 || igame.id != idx+1 {
+			t.Errorf("readGames() game #%v has id %v (%v in the hook), want %v", idx, igame.id, igame.tags["Id"], idx+1)
+		}
+	}
+}
+
+func TestConsumeGamesFromReader(t *testing.T) {
+
+	game := `[Event "Rated game"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+`
+
+	// games are given to the consume function with their ids after invoking
+	// the parse hook, and they are not kept in the collection returned
+	ids := make([]int, 0)
+	hook := func(game *PgnGame) error {
+		game.tags["Hooked"] = true
+		return nil
+	}
+	consume := func(game *PgnGame) error {
+		if game.tags["Hooked"] != true {
+			t.Errorf("ConsumeGamesFromReader() consumed game %v before the hook", game.id)
+		}
+		ids = append(ids, game.id)
+		return nil
+	}
+	games, err := ConsumeGamesFromReader(strings.NewReader(game+game+game), consume, WithParseHook(hook))
+	if err != nil {
+		t.Fatalf("ConsumeGamesFromReader() error = %v", err)
+	}
+	if games.Len() != 0 || games.ParseStats().Games != 3 || !slices.Equal(ids, []int{1, 2, 3}) {
+		t.Errorf("ConsumeGamesFromReader() = %v games (%v parsed) with ids %v consumed, want no games and ids [1 2 3]", games.Len(), games.ParseStats().Games, ids)
+	}
+
+	// and errors returned when consuming games stop reading
+	stop := errors.New(" Stop")
+	if _, err := ConsumeGamesFromReader(strings.NewReader(game+game), func(game *PgnGame) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ConsumeGamesFromReader() error = %v, want %v", err, stop)
+	}
+}
+
+func Test_readGamesMissingOutcome(t *testing.T) {
+
+	game := `[Event "Rated game"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+`
+	broken := `[Event "Broken game"]
+[Result "*"]
+
+1. d4 d5 2. c4
+`
+
+	// the game whose result is missing is closed with '*' instead of being
+	// skipped, both when it is followed by another game and at the end
+	for _, input := range []string{broken + game, game + broken} {
+		games, err := PgnFile{}.readGames(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("readGames() error = %v", err)
+		}
+		if games.Len() != 2 || len(games.Diagnostics()) != 1 {
+			t.Fatalf("readGames() = %v games and %v diagnostics, want 2 and 1", games.Len(), len(games.Diagnostics()))
+		}
+		if !errors.Is(games.Diagnostics()[0].Err, ErrMissingOutcome) {
+			t.Errorf("readGames() diagnostic = %v, want %v", games.Diagnostics()[0].Err, ErrMissingOutcome)
+		}
+		for _, igame := range games.GetGames() {
+			if igame.tags["Event"] == "Broken game" && (len(igame.moves) != 3 || igame.outcome.scoreWhite != -1) {
+				t.Errorf("readGames() broken game has %v plies and outcome %v, want 3 and *", len(igame.moves), igame.outcome)
+			}
+		}
+	}
+}
+
+func Test_readGamesRange(t *testing.T) {
+
+	// five games named after their location in the input
+	var input string
+	for idx := 1; idx <= 5; idx++ {
+		input += fmt.Sprintf("[Event \"Game %v\"]\n[Result \"*\"]\n\n1. e4 *\n\n", idx)
+	}
+
+	tests := []struct {
+		name        string
+		first, last int
+		want        []int
+	}{
+		{"all", 0, 0, []int{1, 2, 3, 4, 5}},
+		{"first", 1, 2, []int{1, 2}},
+		{"skip", 4, 0, []int{4, 5}},
+		{"range", 2, 4, []int{2, 3, 4}},
+		{"beyond", 4, 10, []int{4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := PgnFile{}
+			if tt.first > 0 {
+				options := newPgnOptions(WithRange(tt.first, tt.last))
+				f.first, f.last = options.first, options.last
+			}
+			games, err := f.readGames(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("readGames() error = %v", err)
+			}
+			if got := collectionIds(*games); !slices.Equal(got, tt.want) {
+				t.Fatalf("readGames() = %v, want %v", got, tt.want)
+			}
+
+			// games keep the ids given by their location in the input
+			for _, igame := range games.GetGames() {
+				if want := fmt.Sprintf("Game %v", igame.id); igame.tags["Event"] != want {
+					t.Errorf("readGames() game %v = %v, want %v", igame.id, igame.tags["Event"], want)
+				}
+			}
+		})
+	}
+}
+
+func Test_readGamesLenient(t *testing.T) {
+
+	game := `[Event "Rated game"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+`
+
+	// malformed games are either games which can not be parsed or games
+	// with illegal moves, which are rejected only if they are realized
+	tests := []struct {
+		name    string
+		invalid string
+		opts    []PgnOption
+	}{
+		{"Unopened variation", "[Event \"Bad\"]\n[Result \"1-0\"]\n\n1. e4 e5 2. Qh5 ) Nc6 1-0", nil},
+		{"Unclosed variation", "[Event \"Bad\"]\n[Result \"1-0\"]\n\n1. e4 e5 2. Qh5 (Nc6 {a comment}\n3. Bc4 1-0", nil},
+		{"Illegal move", "[Event \"Bad\"]\n[Result \"1-0\"]\n\n1. e4 e5 2. Ke3 1-0", []PgnOption{WithRealize(-1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// in lenient mode, the malformed game is skipped and its text is
+			// written verbatim into the quarantine writer after a comment
+			// with the error found
+			input := game + "\n" + tt.invalid + "\n\n" + game
+			var quarantine strings.Builder
+			games, err := NewPgnCollectionFromReader(strings.NewReader(input), append(tt.opts, WithLenient(), WithQuarantine(&quarantine))...)
+			if err != nil {
+				t.Fatalf("NewPgnCollectionFromReader() error = %v", err)
+			}
+			if games.Len() != 2 || len(games.Diagnostics()) != 1 {
+				t.Fatalf("NewPgnCollectionFromReader() = %v games and %v diagnostics, want 2 and 1", games.Len(), len(games.Diagnostics()))
+			}
+			diagnostic := games.Diagnostics()[0]
+			if got := strings.TrimSpace(input[diagnostic.Start:diagnostic.End]); got != tt.invalid {
+				t.Errorf("NewPgnCollectionFromReader() diagnostic = %q, want %q", got, tt.invalid)
+			}
+			comment := strings.TrimSpace(strings.NewReplacer("{", "", "}", "").Replace(diagnostic.Error()))
+			if want := "{ " + comment + " }\n" + tt.invalid + "\n\n"; quarantine.String() != want {
+				t.Errorf("NewPgnCollectionFromReader() quarantine = %q, want %q", quarantine.String(), want)
+			}
+
+			// in strict mode, parsing stops at the malformed game, whose
+			// diagnostic is returned, though it is still quarantined
+			quarantine.Reset()
+			parsed := 0
+			hook := WithParseHook(func(game *PgnGame) error {
+				parsed++
+				return nil
+			})
+			games, err = NewPgnCollectionFromReader(strings.NewReader(input), append(tt.opts, hook, WithQuarantine(&quarantine))...)
+			var strict PgnDiagnostic
+			if games != nil || !errors.As(err, &strict) || strict.Error() != diagnostic.Error() {
+				t.Fatalf("NewPgnCollectionFromReader() = (%v, %v), want the diagnostic %v", games, err, diagnostic)
+			}
+			if parsed != 1 {
+				t.Errorf("NewPgnCollectionFromReader() parsed %v games, want 1", parsed)
+			}
+			if !strings.Contains(quarantine.String(), "\n"+tt.invalid+"\n") {
+				t.Errorf("NewPgnCollectionFromReader() quarantine = %q, want %q", quarantine.String(), tt.invalid)
+			}
+		})
+	}
+}
+
+func TestPgnCollection_ParseStats(t *testing.T) {
+
+	annotated := `[Event "Annotated"]
+[Result "1-0"]
+
+1. e4 {best by test} e5 2. Qh5 Nc6 3. Bc4 {threatening mate} Nf6 4. Qxf7# 1-0
+`
+	plain := `[Event "Plain"]
+[Result "0-1"]
+
+1. f3 e5 2. g4 Qh4# 0-1
+`
+	broken := `[Event "Broken"]
+[Result "*"]
+
+1. d4 d5 2. c4
+`
+	invalid := `[Event "Invalid"]
+[Result "1-0"]
+
+1. e4 ) e5 1-0
+`
+	garbage := "this is not a game\n"
+
+	// all bytes are processed, games whose result is missing are parsed
+	// (and closed with '*') while the others that can not be parsed are
+	// skipped
+	input := annotated + "\n" + garbage + plain + "\n" + invalid + "\n" + broken + "\n" + annotated
+	path := filepath.Join(t.TempDir(), "stats.pgn")
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pgnfile, err := NewPgnFile(path)
+	if err != nil {
+		t.Fatalf("NewPgnFile() error = %v", err)
+	}
+	games, err := pgnfile.Games(WithLenient())
+	if err != nil {
+		t.Fatalf("Games() error = %v", err)
+	}
+	stats := games.ParseStats()
+	want := PgnParseStats{Games: 4, Bytes: int64(len(input)), Skipped: 2, Comments: 4, Plies: 7 + 4 + 3 + 7, Elapsed: stats.Elapsed}
+	if stats != want {
+		t.Errorf("ParseStats() = %+v, want %+v", stats, want)
+	}
+	if got, want := stats.AverageMoves(), 21.0/8; got != want {
+		t.Errorf("AverageMoves() = %v, want %v", got, want)
+	}
+	for _, want := range []string{"4", "2", fmt.Sprintf("%v", len(input)), "2.62"} {
+		if !strings.Contains(stats.String(), want) {
+			t.Errorf("String() = %v, want it to contain %v", stats.String(), want)
+		}
+	}
+
+	// games read from other sources are counted as well, and collections
+	// which were not read have no statistics
+	games, err = NewPgnCollectionFromReader(strings.NewReader(plain + "\n" + plain))
+	if err != nil {
+		t.Fatalf("NewPgnCollectionFromReader() error = %v", err)
+	}
+	if stats := games.ParseStats(); stats.Games != 2 || stats.Bytes != int64(2*len(plain)+1) || stats.Skipped != 0 || stats.Plies != 8 {
+		t.Errorf("ParseStats() = %+v, want 2 games, %v bytes and 8 plies", stats, 2*len(plain)+1)
+	}
+	if stats := NewPgnCollection().ParseStats(); stats != (PgnParseStats{}) || stats.AverageMoves() != 0 {
+		t.Errorf("ParseStats() = %+v, want no statistics", stats)
+	}
+}
+
+func Test_getMovesVariations(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		pgn        string
+		nbMoves    int
+		variations int
+		err        bool
+		want       string
+	}{
+		{name: "No variations",
+			pgn:     "1. e4 e5 2. Nf3 Nc6",
+			nbMoves: 4,
+			want:    "1. e4 e5 2. Nf3 Nc6"},
+
+		{name: "Variation of black",
+			pgn:        "1. e4 e5 (1... c5 2. Nf3) 2. Nf3",
+			nbMoves:    3,
+			variations: 1,
+			want:       "1. e4 e5 (1... c5 2. Nf3) 2. Nf3"},
+
+		{name: "Variation of white with comments",
+			pgn:        "1. e4 {best by test} (1. d4 {solid} d5) e5",
+			nbMoves:    2,
+			variations: 1,
+			want:       "1. e4 { best by test } (1. d4 { solid } d5) 1... e5"},
+
+		{name: "Nested and consecutive variations",
+			pgn:        "1. e4 e5 (1... c5 (1... e6 2. d4) 2. Nf3) (1... d5) 2. Nf3",
+			nbMoves:    3,
+			variations: 2,
+			want:       "1. e4 e5 (1... c5 (1... e6 2. d4) 2. Nf3) (1... d5) 2. Nf3"},
+
+		{name: "Variation without move numbers",
+			pgn:        "1. e4 e5 (c5) 2. Nf3",
+			nbMoves:    3,
+			variations: 1,
+			want:       "1. e4 e5 (1... c5) 2. Nf3"},
+
+		{name: "Unclosed variation",
+			pgn: "1. e4 e5 (1... c5 2. Nf3",
+			err: true},
+
+		{name: "Unopened variation",
+			pgn: "1. e4 e5 1... c5) 2. Nf3",
+			err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves, err := getMoves(tt.pgn)
+			if (err != nil) != tt.err {
+				t.Fatalf("getMoves() error = %v, want error %v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if len(moves) != tt.nbMoves {
+				t.Fatalf("getMoves() = %v moves, want %v", len(moves), tt.nbMoves)
+			}
+			variations := 0
+			for _, move := range moves {
+				variations += len(move.variations)
+			}
+			if variations != tt.variations {
+				t.Errorf("getMoves() = %v variations, want %v", variations, tt.variations)
+			}
+			if got := strings.TrimSpace(getPGNLine(moves, newPgnOptions())); got != tt.want {
+				t.Errorf("getPGNLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readGamesComments(t *testing.T) {
+
+	// comments are given before the first move and after the outcome, even
+	// over several lines
+	input := `[Event "Rated game"]
+[Result "1-0"]
+
+{An introduction to this game} {with two comments}
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0 {A well-known trap,
+which is found very often}
+
+[Event "Casual game"]
+[Result "*"]
+
+1. d4 d5 *
+
+{To be continued}
+{in the next game}`
+	games, err := NewPgnCollectionFromReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if games.Len() != 2 || len(games.Diagnostics()) != 0 {
+		t.Fatalf("%v games and %v diagnostics were found, want 2 games", games.Len(), len(games.Diagnostics()))
+	}
+	game := games.GetGames()[0]
+	if got, want := game.LeadingComment(), "An introduction to this game\nwith two comments"; got != want {
+		t.Errorf("LeadingComment() = %q, want %q", got, want)
+	}
+	if got, want := game.TrailingComment(), "A well-known trap,\nwhich is found very often"; got != want {
+		t.Errorf("TrailingComment() = %q, want %q", got, want)
+	}
+	if other := games.GetGames()[1]; other.LeadingComment() != "" || other.TrailingComment() != "To be continued\nin the next game" {
+		t.Errorf("the second game has comments %q and %q", other.LeadingComment(), other.TrailingComment())
+	}
+
+	// the range of bytes of every game includes its trailing comments
+	if first, second := games.GetGames()[0], games.GetGames()[1]; first.end != second.start || second.end != int64(len(input)) {
+		t.Errorf("ranges = [%v, %v) and [%v, %v)", first.start, first.end, second.start, second.end)
+	}
+
+	// and they are written again in PGN format, so that they are preserved
+	// when parsing the game again
+	pgn := game.GetPGN()
+	want := "{ An introduction to this game } { with two comments } 1. e4 e5"
+	if !strings.Contains(pgn, want) || !strings.Contains(pgn, "1-0 { A well-known trap,\nwhich is found very often }") {
+		t.Errorf("GetPGN() = %q", pgn)
+	}
+	parsed, err := ParseGame(pgn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.LeadingComment() != game.LeadingComment() || parsed.TrailingComment() != game.TrailingComment() {
+		t.Errorf("ParseGame() = %q and %q", parsed.LeadingComment(), parsed.TrailingComment())
+	}
+	contents, err := game.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err = NewPgnGameFromJSON(contents); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.LeadingComment() != game.LeadingComment() || parsed.TrailingComment() != game.TrailingComment() {
+		t.Errorf("NewPgnGameFromJSON() = %q and %q", parsed.LeadingComment(), parsed.TrailingComment())
+	}
+	if latex := game.GetLaTeXMovesWithComments(); !strings.HasPrefix(latex, `\textcolor{CadetBlue}{An introduction`) {
+		t.Errorf("GetLaTeXMovesWithComments() = %q", latex)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
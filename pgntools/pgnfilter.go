@@ -0,0 +1,117 @@
+// -*- coding: utf-8 -*-
+// pgnfilter.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:07:40.799428873 (1792159660)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"regexp"
+	"strings"
+)
+
+// global variables
+// ----------------------------------------------------------------------------
+
+// Functions available in filters that require the boards of every game, so
+// that games have to be played before evaluating them
+var boardFunctions = map[string]bool{
+	"FEN":        true,
+	"KingMarch":  true,
+	"KnightTour": true,
+}
+
+// Identifiers used in filter expressions
+var reIdentifier = regexp.MustCompile(`[A-Za-z_]\w*`)
+
+// String literals used in filter expressions
+var reStringLiteral = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`")
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the conjuncts of the given expression, i.e., all subexpressions which
+// are joined with the operator '&&' at the outermost level. Operators found
+// within parenthesis, brackets, braces or string literals are not considered
+func splitConjuncts(expression string) (conjuncts []string) {
+
+	depth, start := 0, 0
+	var quote byte
+	for idx := 0; idx < len(expression); idx++ {
+		ch := expression[idx]
+
+		// within string literals, only their end is relevant
+		if quote != 0 {
+			if ch == '\\' && quote != '`' {
+				idx++
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch ch {
+		case '"', '\'', '`':
+			quote = ch
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '&':
+			if depth == 0 && idx+1 < len(expression) && expression[idx+1] == '&' {
+				conjuncts = append(conjuncts, strings.TrimSpace(expression[start:idx]))
+				start = idx + 2
+				idx++
+			}
+		}
+	}
+
+	// and add the last conjunct
+	return append(conjuncts, strings.TrimSpace(expression[start:]))
+}
+
+// Return true if the given expression uses any function that requires the
+// boards of games
+func needsBoards(expression string) bool {
+
+	// string literals are ignored so that their contents are not taken as
+	// identifiers
+	for _, identifier := range reIdentifier.FindAllString(reStringLiteral.ReplaceAllString(expression, ""), -1) {
+		if boardFunctions[identifier] {
+			return true
+		}
+	}
+	return false
+}
+
+// Split the given filter expression into two stages: the first one contains
+// all conjuncts that can be evaluated without the boards of games (e.g., those
+// using only tags), and the second one contains the rest. Games satisfy the
+// given expression if and only if they satisfy both stages. Stages without
+// conjuncts are returned as the empty string
+func splitFilter(expression string) (cheap, board string) {
+
+	// classify all conjuncts
+	stages := [2][]string{}
+	for _, iconjunct := range splitConjuncts(expression) {
+		if iconjunct == "" {
+			continue
+		}
+		if needsBoards(iconjunct) {
+			stages[1] = append(stages[1], "("+iconjunct+")")
+		} else {
+			stages[0] = append(stages[0], "("+iconjunct+")")
+		}
+	}
+
+	// and join them again
+	return strings.Join(stages[0], " && "), strings.Join(stages[1], " && ")
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,39 @@
+// -*- coding: utf-8 -*-
+// pgnfilter_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:07:54.735690009 (1792159674)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import "testing"
+
+func Test_splitFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		cheap      string
+		board      string
+	}{
+		{"OnlyTags", `ECO=="C25" && Moves<40`, `(ECO=="C25") && (Moves<40)`, ``},
+		{"OnlyBoards", `FEN("* * * * * *")`, ``, `(FEN("* * * * * *"))`},
+		{"Mixed", `White=="clinares" && FEN("* * * * * *") && Moves<40`, `(White=="clinares") && (Moves<40)`, `(FEN("* * * * * *"))`},
+		{"Nested", `(ECO=="C25" && FEN("* * * * * *")) || Moves<40`, ``, `((ECO=="C25" && FEN("* * * * * *")) || Moves<40)`},
+		{"StringLiterals", `Event=="FEN && more" && White=='a&&b'`, `(Event=="FEN && more") && (White=='a&&b')`, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cheap, board := splitFilter(tt.expression)
+			if cheap != tt.cheap || board != tt.board {
+				t.Errorf("splitFilter() = (%v, %v), want (%v, %v)", cheap, board, tt.cheap, tt.board)
+			}
+		})
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,192 @@
+// -*- coding: utf-8 -*-
+// pgnformat.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:51:29.141181622 (1792169489)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Games can be read in different formats
+type PgnFormat int
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Games are given either in PGN format, as an array of games in JSON format (as
+// written by GetJSON) or as a list of positions in EPD format
+const (
+	PGNFormat  PgnFormat = iota // games in PGN format
+	JSONFormat                  // an array of games in JSON format
+	EPDFormat                   // one position per line in EPD format
+)
+
+// Only the first bytes of the input are inspected to detect its format
+const formatPrefixSize = 4096
+
+// globals
+// ----------------------------------------------------------------------------
+
+// EPD records start with the first four fields of a FEN code: the piece
+// placement, the side to move, the castling rights and the en passant square
+var reEPD = regexp.MustCompile(`^(?:[pnbrqkPNBRQK1-8]+/){7}[pnbrqkPNBRQK1-8]+\s+[wb]\s+(?:-|[KQkqA-Ha-h]+)\s+(?:-|[a-h][36])(?:\s|$)`)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the format of the input which starts with the given bytes. Arrays
+// whose first element is an object (or which are empty) are taken to be JSON,
+// and inputs whose first line (ignoring blank lines and lines starting with '#')
+// starts with the fields of a FEN code are taken to be EPD. Otherwise, the
+// input is taken to be PGN
+func detectFormat(prefix []byte) PgnFormat {
+
+	prefix = bytes.TrimSpace(bytes.TrimPrefix(prefix, []byte("\ufeff")))
+	if len(prefix) > 0 && prefix[0] == '[' {
+		if rest := bytes.TrimSpace(prefix[1:]); len(rest) > 0 && (rest[0] == '{' || rest[0] == ']') {
+			return JSONFormat
+		}
+	}
+	for _, line := range bytes.Split(prefix, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 && line[0] != '#' {
+			if reEPD.Match(line) {
+				return EPDFormat
+			}
+			break
+		}
+	}
+	return PGNFormat
+}
+
+// Return the format of the input given in the given buffered reader, see
+// detectFormat. Only its first bytes are peeked, so that the reader can be read
+// afterwards from the beginning
+func DetectFormat(reader *bufio.Reader) (PgnFormat, error) {
+
+	prefix, err := reader.Peek(formatPrefixSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return PGNFormat, err
+	}
+	return detectFormat(prefix), nil
+}
+
+// Return the format of the given file from its first bytes, see detectFormat.
+// The file is read at its beginning without moving its offset
+func detectFileFormat(file *os.File) (PgnFormat, error) {
+
+	prefix := make([]byte, formatPrefixSize)
+	n, err := file.ReadAt(prefix, 0)
+	if err != nil && err != io.EOF {
+		return PGNFormat, err
+	}
+	return detectFormat(prefix[:n]), nil
+}
+
+// Return a collection with one game per position given in EPD format in the
+// given reader, see PgnPositionCollection.Games
+func NewPgnCollectionFromEPD(reader io.Reader) (*PgnCollection, error) {
+
+	positions, err := NewPgnPositionCollectionFromEPD(reader)
+	if err != nil {
+		return nil, err
+	}
+	return positions.Games()
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the name of this format
+func (format PgnFormat) String() string {
+	switch format {
+	case JSONFormat:
+		return "JSON"
+	case EPDFormat:
+		return "EPD"
+	default:
+		return "PGN"
+	}
+}
+
+// Return the format of the games given in this PgnFile, which is detected
+// from its first bytes, see DetectFormat
+func (f PgnFile) Format() (PgnFormat, error) {
+
+	stream, err := os.Open(f.name)
+	if err != nil {
+		return PGNFormat, err
+	}
+	defer stream.Close()
+	return detectFileFormat(stream)
+}
+
+// Return all games given in the given reader in the given format other than
+// PGN. Games are processed as if they were read from a PGN file: only those in
+// the range of this PgnFile are kept, they are realized and given to its parse
+// hook if requested, and they are consumed if this PgnFile consumes games, in
+// which case they are given in PGN format. Games which can not be processed
+// are skipped in lenient mode
+func (f PgnFile) readFormat(reader io.Reader, format PgnFormat) (*PgnCollection, error) {
+
+	var all *PgnCollection
+	var err error
+	switch format {
+	case JSONFormat:
+		all, err = NewPgnCollectionFromJSON(reader)
+	case EPDFormat:
+		all, err = NewPgnCollectionFromEPD(reader)
+	default:
+		return nil, errors.New(" PGN files have to be read with readGames")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	games := NewPgnCollection()
+	for _, igame := range all.slice {
+		if !f.inRange(igame.id) {
+			continue
+		}
+		game := igame
+		var err error
+		if f.realize != 0 {
+			err = game.Realize(f.realize)
+		}
+		if err == nil && f.parseHook != nil {
+			err = f.parseHook(&game)
+		}
+		if err != nil {
+			if f.lenient {
+				continue
+			}
+			return nil, fmt.Errorf(" Game %v:%w", game.id, err)
+		}
+		if f.consume != nil {
+			if err := f.consume(&game, game.GetPGN()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		games.Add(game)
+	}
+	return &games, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
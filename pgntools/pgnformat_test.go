@@ -0,0 +1,98 @@
+// -*- coding: utf-8 -*-
+// pgnformat_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:52:33.267573573 (1792169553)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_detectFormat(t *testing.T) {
+
+	for _, tt := range []struct {
+		contents string
+		want     PgnFormat
+	}{
+		{"[Event \"?\"]\n\n1. e4 *", PGNFormat},
+		{"\ufeff  [Event \"?\"]", PGNFormat},
+		{"[\n  {\"id\": 1}]", JSONFormat},
+		{"\ufeff[]", JSONFormat},
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 bm e5; id \"test\";", EPDFormat},
+		{"\n8/8/8/4k3/8/8/4P3/4K3 w - -\n", EPDFormat},
+		{"8/8/8/4k3/8/8/4P3 w - -", PGNFormat},
+		{"", PGNFormat},
+	} {
+		if got := detectFormat([]byte(tt.contents)); got != tt.want {
+			t.Errorf("detectFormat(%q) = %v, want %v", tt.contents, got, tt.want)
+		}
+	}
+}
+
+func TestPgnFile_GamesFormats(t *testing.T) {
+
+	game, err := ParseGame("[Event \"JSON\"]\n[White \"alice\"]\n\n1. e4 e5 2. Nf3 1-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewPgnCollection()
+	c.Add(*game)
+	c.Add(*game)
+	var contents strings.Builder
+	if err := c.GetJSON(&contents); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		contents string
+		format   PgnFormat
+		events   []string
+	}{
+		{"games.json", contents.String(), JSONFormat, []string{"JSON", "JSON"}},
+		{"games.epd", "# test suite\n8/8/8/4k3/8/8/4P3/4K3 w - - bm Kd2; id \"KP 1\";\n\n8/8/8/4k3/8/8/4P3/4K3 b - - am Kd4;\n", EPDFormat, []string{"KP 1", "?"}},
+	} {
+		name := filepath.Join(t.TempDir(), tt.name)
+		if err := os.WriteFile(name, []byte(tt.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		pgnfile, err := NewPgnFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format, err := pgnfile.Format(); err != nil || format != tt.format {
+			t.Fatalf("Format() = (%v, %v), want %v", format, err, tt.format)
+		}
+
+		// all games are read and realized
+		games, err := pgnfile.Games(WithRealize(-1))
+		if err != nil {
+			t.Fatalf("Games(%v) error = %v", tt.name, err)
+		}
+		if games.Len() != len(tt.events) {
+			t.Fatalf("Games(%v) = %v games, want %v", tt.name, games.Len(), len(tt.events))
+		}
+		for idx, igame := range games.slice {
+			if igame.getTag("Event") != tt.events[idx] || igame.id != idx+1 || len(igame.boards) != len(igame.moves)+1 {
+				t.Errorf("Games(%v) game #%v = %v", tt.name, idx+1, igame.tags)
+			}
+		}
+
+		// and slices of the games are read as well
+		if games, err = pgnfile.Games(WithRange(2, 2)); err != nil || games.Len() != 1 || games.slice[0].id != 2 {
+			t.Errorf("Games(%v) with a range = (%v, %v)", tt.name, games, err)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -20,11 +20,12 @@ package pgntools
 
 import (
 	// for signaling errors
-	"errors"
 	"fmt" // printing msgs
 	"io"
 	"log" // logging services
+	"maps"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -37,19 +38,52 @@ import (
 // A PGN move consist of a single ply. For each move the move number, color
 // (with -1 representing black and +1 representing white) and actual move value
 // (both in short and long algebraic notation) is stored. Additionally, in case
-// that the elapsed move time was present in the PGN file, it is also stored
-// here.
+// that the elapsed move time or the evaluation of an engine were present in
+// the PGN file, they are also stored here.
 //
-// Finally, any combination of moves after the move are combined into the
-// same field (comments). In case various comments were given they are then
-// separated by '\n'.
+// The quality of the move given by annotators (e.g., "!" or "??") is stored
+// separately from the move in short algebraic notation.
+//
+// Finally, all annotations given after the move (comments, commands given in
+// comments and NAGs) are stored in the same order they were found, so that
+// they can be faithfully written again. Recursive annotation variations given
+// after the move are alternatives to it, and every one is stored as a sequence
+// of moves which can have variations as well, so that they form a tree.
 type PgnMove struct {
 	number         int
 	color          int
 	shortAlgebraic string
+	quality        string
 	longAlgebraic
-	emt      float32
-	comments string
+	emt         float32
+	eval        float64
+	evaluated   bool
+	annotations []PgnAnnotation
+	variations  [][]PgnMove
+}
+
+// Annotations can be of different kinds
+type PgnAnnotationKind int
+
+// An annotation given after a move consists of its kind and its value, e.g.,
+// the text of a comment, the number of a NAG, or the value of a command given
+// in a comment such as "2.1" in "[%emt 2.1]". Annotations given within the
+// same comment are marked as joined to the previous one
+type PgnAnnotation struct {
+	Kind   PgnAnnotationKind
+	Value  string
+	Joined bool
+}
+
+// Moves are given to expressions with their number, color (1 for White and -1
+// for Black), short algebraic notation, comments and thinking time in seconds
+// (-1 if it is unknown), with the same names used in JSON, e.g., .san
+type exprMove struct {
+	Number  int     `expr:"number"`
+	Color   int     `expr:"color"`
+	SAN     string  `expr:"san"`
+	Comment string  `expr:"comment"`
+	EMT     float64 `expr:"emt"`
 }
 
 // A move in the long algebraic notation consists of a explicity description of
@@ -69,15 +103,42 @@ type PgnOutcome struct {
 // A game consists just of a map that stores information of all PGN tags, the
 // sequence of moves and successive boards and the outcome. For various purposes
 // it contains also an id which is an integer index and is used to uniquely
-// refer to each game.
+// refer to each game. The raw movetext (with all blanks collapsed into single
+// spaces) is also kept so that textual searches can be performed without
+// playing the game. Games read from files also know the range of bytes [start,
+// end) they occupy in it, and games can be linked to other related games
 type PgnGame struct {
-	tags    map[string]any
-	moves   []PgnMove
-	boards  []PgnBoard
-	outcome PgnOutcome
-	id      int
+	tags       map[string]any
+	moves      []PgnMove
+	boards     []PgnBoard
+	outcome    PgnOutcome
+	id         int
+	movetext   string
+	start, end int64
+	links      []PgnLink
+
+	transpositions []PgnTransposition
+	agreement      []bool
+
+	// annotations given before the first move and after the outcome
+	leading, trailing []PgnAnnotation
 }
 
+// consts
+// ----------------------------------------------------------------------------
+
+// Annotations are either comments, commands given in comments or NAGs
+const (
+	CommentAnnotation   PgnAnnotationKind = iota // text of a comment
+	EMTAnnotation                                // elapsed move time, [%emt ...]
+	ClockAnnotation                              // remaining time, [%clk ...]
+	EvalAnnotation                               // evaluation, [%eval ...]
+	NAGAnnotation                                // numeric annotation glyph, $n
+	LanguageAnnotation                           // language of the text that follows, [%lang ...]
+	ArrowAnnotation                              // colored arrows, [%cal ...]
+	HighlightAnnotation                          // colored squares, [%csl ...]
+)
+
 // Functions
 // ----------------------------------------------------------------------------
 // Evaluate the given expression in the specified environment and return the
@@ -115,7 +176,7 @@ func cardinalityUndefined(expr string) (int, int) {
 	// At this point, we know the pattern consists of at least two characters,
 	// the first one being a *. Determine whether the second element is a digit
 	// or not
-	if match, _ := regexp.MatchString(`^\d.*`, expr[1:]); match {
+	if reDigit.MatchString(expr[1:]) {
 
 		// then convert the digit to a number and return it
 		cardinality, _ := strconv.Atoi(expr[1:2])
@@ -140,12 +201,12 @@ func consumeUndefined(n int, code string) (int, int, error) {
 
 			// then it is not possible to consume the requested number of
 			// characters
-			return consumed, 0, errors.New(" The FEN code was exhausted")
+			return consumed, 0, fmt.Errorf("%w: the FEN code was exhausted", ErrBadFEN)
 		}
 
 		// If the first character in code is a digit, then it represents a number of
 		// consecutive cells
-		if match, _ := regexp.MatchString(`^\d.*`, code); match {
+		if reDigit.MatchString(code) {
 
 			// Annotate one position has been consumed
 			consumed++
@@ -171,7 +232,7 @@ func consumeUndefined(n int, code string) (int, int, error) {
 
 			// If a slash is found, then we are exceeding the current row and an
 			// error should be reported
-			return consumed, 0, errors.New(" The current row has been exhausted")
+			return consumed, 0, fmt.Errorf("%w: the current row has been exhausted", ErrBadFEN)
 		} else {
 
 			// In any other case, just simply consume the character and decrement
@@ -201,11 +262,11 @@ func consumeDigits(n int, expr string) (bool, int, int, error) {
 
 			// then it is not possible to consume the requested number of
 			// characters
-			return false, 0, 0, errors.New("The FEN code was exhausted")
+			return false, 0, 0, fmt.Errorf("%w: the FEN code was exhausted", ErrBadFEN)
 		}
 
 		// If the first character is a digit, then consme it
-		if match, _ := regexp.MatchString(`^\d.*`, expr); match {
+		if reDigit.MatchString(expr) {
 
 			// Annotate one position has been consumed
 			consumed++
@@ -219,7 +280,7 @@ func consumeDigits(n int, expr string) (bool, int, int, error) {
 			// consecutive empty cells given there should be found.
 			if spaces > n {
 
-				return false, 0, 0, errors.New(" The number of consecutive empty squares has been exceeded")
+				return false, 0, 0, fmt.Errorf("%w: the number of consecutive empty squares has been exceeded", ErrBadFEN)
 			}
 
 			// Otherwise, decrement the number of consecutive empty squares to
@@ -249,7 +310,7 @@ func consumeDigits(n int, expr string) (bool, int, int, error) {
 		} else if expr[0] == '/' {
 
 			// In case the end of the row has been found then return an error
-			return false, consumed, 0, errors.New(" The current row has been exhausted")
+			return false, consumed, 0, fmt.Errorf("%w: the current row has been exhausted", ErrBadFEN)
 		} else {
 
 			// In case any other character is found, then it is not possible to
@@ -265,13 +326,14 @@ func consumeDigits(n int, expr string) (bool, int, int, error) {
 // Return true if and only if the FEN piece placement of the first string
 // matches the FEN piece placement of the second, and false otherwise. Both
 // strings are supposed to contain only the piece placement of the FEN code and
-// not the entire FEN code
-func matchFENPiecePlacement(expr, code string, digits, undefined int) bool {
+// not the entire FEN code. An error is returned if the pattern can not be
+// matched because it is not consistent with the rows of the FEN code
+func matchFENPiecePlacement(expr, code string, digits, undefined int) (bool, error) {
 
 	// This algorithm is implemented recursively. The base case is reached when
 	// both strings become empty
 	if len(expr) == 0 && len(code) == 0 {
-		return true
+		return true, nil
 	}
 
 	// The general case considers all different cases
@@ -289,18 +351,18 @@ func matchFENPiecePlacement(expr, code string, digits, undefined int) bool {
 
 			// Otherwise, if an error occurred then immediately stop
 			if err != nil {
-				log.Fatalf(" Error while consuming consecutive empty squares: %v\n", err)
+				return false, fmt.Errorf(" Error while consuming consecutive empty squares: %w", err)
 			} else {
 
 				// If there was no matching then return false
-				return false
+				return false, nil
 			}
 		}
 	}
 
 	// If now, any of the input strings is empty there is no match
 	if len(expr) == 0 || len(code) == 0 {
-		return false
+		return false, nil
 	}
 
 	// In case there are some undefined characters to consume in the FEN code
@@ -311,7 +373,7 @@ func matchFENPiecePlacement(expr, code string, digits, undefined int) bool {
 		// Note this operation always succeeds unless an error happened (e.g., a
 		// row was exhausted) in which case the process must stop immediately
 		if err != nil {
-			log.Fatalf(" Error while consuming undefined characters: %v\n", err)
+			return false, fmt.Errorf(" Error while consuming undefined characters: %w", err)
 		} else {
 
 			// If no error happened, then move forward the number of characters
@@ -334,7 +396,7 @@ func matchFENPiecePlacement(expr, code string, digits, undefined int) bool {
 		}
 
 		// Otherwise there is no match
-		return false
+		return false, nil
 	}
 
 	// If a piece is given in the pattern, then make sure it appears in the FEN
@@ -347,7 +409,7 @@ func matchFENPiecePlacement(expr, code string, digits, undefined int) bool {
 		}
 
 		// otherwise, there is no match between both codes
-		return false
+		return false, nil
 	}
 
 	// In case the pattern contains a wildcard, then try to consume characters
@@ -357,7 +419,7 @@ func matchFENPiecePlacement(expr, code string, digits, undefined int) bool {
 		// then consume the given number of characters from the FEN code
 		advcode, digits, err := consumeUndefined(cardinality, code)
 		if err != nil {
-			log.Fatalf(" Error while consuming undefined characters: %v\n", err)
+			return false, fmt.Errorf(" Error while consuming undefined characters: %w", err)
 		} else {
 
 			// At this point, compute the number of empty cells awaiting to be
@@ -368,13 +430,12 @@ func matchFENPiecePlacement(expr, code string, digits, undefined int) bool {
 
 	// Finally, check whether the pattern starts with a number of consecutive
 	// empty squares
-	if match, _ := regexp.MatchString(`^\d.*`, expr); match {
+	if reDigit.MatchString(expr) {
 
 		// There is a match if and only if the code also starts with a number of
 		// consecutive empty cells
-		match, _ := regexp.MatchString(`^\d.*`, code)
-		if !match {
-			return false
+		if !reDigit.MatchString(code) {
+			return false, nil
 		}
 
 		// The number of empty cells in the code has to be greater or equal than
@@ -400,12 +461,12 @@ func matchFENPiecePlacement(expr, code string, digits, undefined int) bool {
 
 		// If the number given in the code is strictly less than the number of
 		// empty squares given in the pattern, then there is no match
-		return false
+		return false, nil
 	}
 
 	// This case should never happen, but anyway to avoid compiler errors ...
 	log.Println(" Warning: Unreachable code ... reached!")
-	return true
+	return true, nil
 }
 
 // Return true if and only if the FEN active color of the first string matches
@@ -473,6 +534,11 @@ func matchFENEnPassantTargets(expr, code string) bool {
 	// cases
 	if len(expr) == 2 {
 
+		// If no en passant target is given in the code, there is no match
+		if len(code) != 2 {
+			return false
+		}
+
 		// In case the first character is the wildcard
 		if expr[0] == '*' {
 
@@ -535,53 +601,67 @@ func matchFENFullMoveNumber(expr, code string) bool {
 	return expr == code
 }
 
-// Return true if and only if the first fen code matches the second. Matching
-// means that they are actually the same even if they are written in different
-// ways
-func matchFEN(expr, code string) bool {
+// MatchFEN returns true if and only if the given FEN code matches the given
+// pattern, and false otherwise. Patterns are FEN codes where any field, or part
+// of it, can be replaced with wildcards: in the piece placement, '*' stands for
+// the contents of one square, either empty or not, or as many as the digit
+// following it, e.g., '*8' for a whole row; in the other fields, it stands for
+// any value. For example, the pattern "*8/*8/*8/*8/4P3/*8/*8/*8 b * * * *"
+// matches all positions with a white pawn in e4 and Black to move.
+//
+// An error wrapping ErrBadFEN is returned if either the pattern or the FEN code
+// are not syntactically correct, or if the pattern is not consistent with the
+// rows of the FEN code
+func MatchFEN(pattern, fen string) (bool, error) {
 
 	// split both fen codes into their fields
-	exprIndex := reFEN.FindStringSubmatchIndex(expr)
-	codeIndex := reFEN.FindStringSubmatchIndex(code)
+	exprIndex := reFEN.FindStringSubmatchIndex(pattern)
+	if exprIndex == nil {
+		return false, fmt.Errorf("%w: syntax error in the pattern '%v'", ErrBadFEN, pattern)
+	}
+	codeIndex := reFEN.FindStringSubmatchIndex(fen)
+	if codeIndex == nil {
+		return false, fmt.Errorf("%w: syntax error in the FEN code '%v'", ErrBadFEN, fen)
+	}
 
 	// Piece placement
-	if !matchFENPiecePlacement(expr[exprIndex[2]:exprIndex[3]],
-		code[codeIndex[2]:codeIndex[3]], 0, 0) {
-		return false
+	if match, err := matchFENPiecePlacement(pattern[exprIndex[2]:exprIndex[3]],
+		fen[codeIndex[2]:codeIndex[3]], 0, 0); err != nil || !match {
+		return false, err
 	}
 
 	// Active Color
-	if !matchFENActiveColor(expr[exprIndex[4]:exprIndex[5]],
-		code[codeIndex[4]:codeIndex[5]]) {
-		return false
+	if !matchFENActiveColor(pattern[exprIndex[4]:exprIndex[5]],
+		fen[codeIndex[4]:codeIndex[5]]) {
+		return false, nil
 	}
 
 	// Castling rights
-	if !matchFENCastlingRights(expr[exprIndex[6]:exprIndex[7]],
-		code[codeIndex[6]:codeIndex[7]]) {
-		return false
+	if !matchFENCastlingRights(pattern[exprIndex[6]:exprIndex[7]],
+		fen[codeIndex[6]:codeIndex[7]]) {
+		return false, nil
 	}
 
 	// En passant targets
-	if !matchFENEnPassantTargets(expr[exprIndex[8]:exprIndex[9]],
-		code[codeIndex[8]:codeIndex[9]]) {
-		return false
+	if !matchFENEnPassantTargets(pattern[exprIndex[8]:exprIndex[9]],
+		fen[codeIndex[8]:codeIndex[9]]) {
+		return false, nil
 	}
 
 	// Half move clock
-	if !matchFENHalfMoveClock(expr[exprIndex[10]:exprIndex[11]],
-		code[codeIndex[10]:codeIndex[11]]) {
-		return false
+	if !matchFENHalfMoveClock(pattern[exprIndex[10]:exprIndex[11]],
+		fen[codeIndex[10]:codeIndex[11]]) {
+		return false, nil
 	}
 
 	// Fullmove number
-	if !matchFENFullMoveNumber(expr[exprIndex[12]:exprIndex[13]],
-		code[codeIndex[12]:codeIndex[13]]) {
-		return false
+	if !matchFENFullMoveNumber(pattern[exprIndex[12]:exprIndex[13]],
+		fen[codeIndex[12]:codeIndex[13]]) {
+		return false, nil
 	}
 
 	// at this point, they are proven to be equal
-	return true
+	return true, nil
 }
 
 // Methods
@@ -602,9 +682,208 @@ func (move PgnMove) Move() string {
 	return move.shortAlgebraic
 }
 
-// Return comments of the given PgnMove
+// Return the quality of the given PgnMove as given by annotators, i.e., one
+// among "!", "?", "!!", "??", "!?" and "?!", or the empty string if the move was
+// not qualified. Moves which were not qualified with a suffix are qualified with
+// the first NAG between $1 and $6 given after them, if any, so that the suffix
+// prevails in case both are given
+func (move PgnMove) Quality() string {
+	if move.quality != "" {
+		return move.quality
+	}
+	for _, nag := range move.Nags() {
+		if quality, ok := nagQualities[nag]; ok {
+			return quality
+		}
+	}
+	return ""
+}
+
+// Return the Numeric Annotation Glyph (NAG) of the quality of the given PgnMove,
+// see Quality, or zero if the move was not qualified
+func (move PgnMove) QualityNAG() int {
+	return qualityNAGs[move.Quality()]
+}
+
+// Return the Numeric Annotation Glyphs (NAGs) given after the given PgnMove in
+// the same order they were found, e.g., 1 for "$1"
+func (move PgnMove) Nags() (nags []int) {
+	for _, annotation := range move.annotations {
+		if annotation.Kind == NAGAnnotation {
+			if nag, err := strconv.Atoi(annotation.Value); err == nil {
+				nags = append(nags, nag)
+			}
+		}
+	}
+	return
+}
+
+// Return the given PgnMove in short algebraic notation followed by its quality
+// to be shown in LaTeX, and the symbols of all other NAGs given after it
+// separated by blanks. Moves which were not qualified by the annotator with a
+// suffix are qualified with the first NAG qualifying moves, if any. NAGs
+// without a symbol are ignored
+func (move PgnMove) getLaTeXNags() (annotated, symbols string) {
+
+	quality := move.quality
+	var others []string
+	for _, nag := range move.Nags() {
+		if nag <= 6 && quality == "" {
+			quality = nagLaTeX[nag]
+		} else if symbol, ok := nagLaTeX[nag]; ok && nag > 6 {
+			others = append(others, symbol)
+		}
+	}
+	return move.shortAlgebraic + quality, strings.Join(others, " ")
+}
+
+// Return comments of the given PgnMove. In case various comments were given
+// they are separated by '\n'. Commands given in comments (such as the
+// elapsed move time) are not included
 func (move PgnMove) Comments() string {
-	return move.comments
+	return getComments(move.annotations)
+}
+
+// Return the text of all comments in the given annotations
+func getCommentList(annotations []PgnAnnotation) (comments []string) {
+	for _, annotation := range annotations {
+		if annotation.Kind == CommentAnnotation {
+			comments = append(comments, annotation.Value)
+		}
+	}
+	return
+}
+
+// Return the text of all comments in the given annotations separated by
+// newlines
+func getComments(annotations []PgnAnnotation) string {
+	return strings.Join(getCommentList(annotations), "\n")
+}
+
+// Return true if the given PgnMove has any comments
+func (move PgnMove) hasComments() bool {
+	for _, annotation := range move.annotations {
+		if annotation.Kind == CommentAnnotation {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the annotations of the given PgnMove in the same order they were found
+func (move PgnMove) Annotations() []PgnAnnotation {
+	return move.annotations
+}
+
+// Return the text of this annotation as it is written in PGN format, i.e.,
+// commands are enclosed in brackets and NAGs are preceded by a dollar sign
+func (annotation PgnAnnotation) String() string {
+	switch annotation.Kind {
+	case NAGAnnotation:
+		return "$" + annotation.Value
+	case CommentAnnotation:
+		return annotation.Value
+	default:
+		return fmt.Sprintf("[%%%v %v]", annotationCommands[annotation.Kind], annotation.Value)
+	}
+}
+
+// Return the given tokens arranged in lines which are not longer than the
+// given width, unless a single token is longer. Tokens are separated by blanks
+// and lines are separated by '\n'
+func wrapTokens(tokens []string, width int) string {
+
+	var output, line string
+	for _, token := range tokens {
+		if line != "" && len(line)+1+len(token) > width {
+			output += line + "\n"
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += token
+	}
+	return output + line
+}
+
+// Return the given annotations written in a single brace block. If a width is
+// given, the text is re-wrapped so that no line is longer than it, though
+// commands are never split
+func getPGNBlock(annotations []PgnAnnotation, width int) string {
+
+	// a single command is written without blanks, as ficsgames.org does
+	if len(annotations) == 1 && annotations[0].Kind != CommentAnnotation {
+		return fmt.Sprintf("{%v} ", annotations[0])
+	}
+
+	// otherwise, all annotations are written in sequence
+	var tokens []string
+	for _, annotation := range annotations {
+		if annotation.Kind == CommentAnnotation && width > 0 {
+			tokens = append(tokens, strings.Fields(annotation.Value)...)
+		} else if annotation.Value != "" {
+			tokens = append(tokens, annotation.String())
+		}
+	}
+	if width <= 0 {
+		return fmt.Sprintf("{ %v } ", strings.Join(tokens, " "))
+	}
+	return fmt.Sprintf("{ %v } ", wrapTokens(tokens, width))
+}
+
+// Return the annotations of the given PgnMove in PGN format folded as
+// requested in the given options, or the empty string if there are none
+func (move PgnMove) getPGNAnnotations(options pgnOptions) (output string) {
+
+	// NAGs are never written within braces, and they are written first when
+	// comments are merged
+	var blocks [][]PgnAnnotation
+	var merged []PgnAnnotation
+	newBlock := false
+	for _, annotation := range move.annotations {
+
+		// excluded commands are skipped, and if they start a brace block, the
+		// annotations joined to them start a new one
+		if command, ok := annotationCommands[annotation.Kind]; ok && slices.Contains(options.excludedCommands, command) {
+			newBlock = newBlock || !annotation.Joined
+			continue
+		}
+
+		switch {
+		case annotation.Kind == NAGAnnotation:
+			if options.commentFolding == MergedComments {
+				output += annotation.String() + " "
+			} else {
+				blocks = append(blocks, []PgnAnnotation{annotation})
+			}
+		case options.commentFolding == MergedComments:
+			merged = append(merged, annotation)
+		case options.commentFolding == SeparateComments || !annotation.Joined || newBlock || len(blocks) == 0:
+			blocks = append(blocks, []PgnAnnotation{annotation})
+		default:
+			blocks[len(blocks)-1] = append(blocks[len(blocks)-1], annotation)
+		}
+		newBlock = false
+	}
+	if len(merged) > 0 {
+		blocks = append(blocks, merged)
+	}
+
+	// and write all blocks
+	for _, block := range blocks {
+		if block[0].Kind == NAGAnnotation {
+			output += block[0].String() + " "
+		} else {
+			output += getPGNBlock(block, options.commentWidth)
+		}
+	}
+	return
+}
+
+// Return the move in short algebraic notation followed by its quality, if any
+func (move PgnMove) annotated() string {
+	return move.shortAlgebraic + move.quality
 }
 
 // Produces a string with the actual content of this move
@@ -618,7 +897,7 @@ func (move PgnMove) String() string {
 		output += fmt.Sprintf("%v. ... ", move.number)
 	}
 
-	output += fmt.Sprintf("%v ", move.shortAlgebraic)
+	output += fmt.Sprintf("%v ", move.annotated())
 	return output
 }
 
@@ -640,39 +919,65 @@ func (outcome PgnOutcome) String() string {
 	return fmt.Sprintf("%v-%v", outcome.scoreWhite, outcome.scoreBlack)
 }
 
-// Return true if and only if a board in this game contains a position with the
-// given fen code
-func (game *PgnGame) checkFEN(fencode string) bool {
+// Return true if this outcome corresponds to a game that was properly ended,
+// i.e., if it is not '*', and false otherwise
+func (outcome PgnOutcome) IsScored() bool {
+	return !(outcome.scoreWhite == outcome.scoreBlack &&
+		outcome.scoreWhite == -1)
+}
+
+// Return the points obtained by the player with the given color, which follows
+// the same convention used in moves: 1 for White and -1 for Black. The second
+// value is false if the game was not properly ended, in which case the points
+// returned are meaningless
+func (outcome PgnOutcome) Score(color int) (float32, bool) {
 
-	// First of all, verify the given fencode is syntactically correct
-	if !reFEN.MatchString(fencode) {
-		log.Fatalf(" Syntax error in FEN code: '%v'\n", fencode)
+	// games which were not properly ended are not scored at all
+	if !outcome.IsScored() {
+		return 0, false
 	}
 
+	// otherwise return the points of the given player
+	if color == 1 {
+		return outcome.scoreWhite, true
+	}
+	return outcome.scoreBlack, true
+}
+
+// Return true if and only if a board in this game contains a position with the
+// given fen code, and any error found while matching them
+func (game *PgnGame) checkFEN(fencode string) (bool, error) {
+
 	// Examine all positions in this game
 	for _, iboard := range game.boards {
 
 		// if this board has the given fen code immediately return true
-		if matchFEN(fencode, iboard.fen) {
-			return true
+		if match, err := MatchFEN(fencode, iboard.fen); err != nil || match {
+			return match, err
 		}
 	}
 
 	// At this point, no position in this game has the given fen fencode
-	return false
+	return false, nil
 }
 
 // return a string showing all moves in the specified interval in vertical mode,
-// i.e. from move number 'from' until move number 'to' not included.
-func (game *PgnGame) prettyMoves(from, to int) (output string) {
+// i.e. from move number 'from' until move number 'to' not included. If the ply
+// of a decisive mistake is given (or -1 otherwise), it is qualified with "??"
+// and followed by the better line, if any.
+func (game *PgnGame) prettyMoves(from, to, blunder int) (output string) {
 
 	// in case no moves were given just return the empty string
 	if from == to {
 		return
 	}
 
-	// get the slice of moves to show
+	// get the slice of moves to show, with the decisive mistake marked
 	moves := game.moves[from:to]
+	if blunder >= from && blunder < to {
+		moves = slices.Clone(moves)
+		moves[blunder-from].quality = "??"
+	}
 
 	// add the first move. This is important because in case it is black to move,
 	// an ellipsis should be shown first and, in case it is white's turn
@@ -695,18 +1000,27 @@ func (game *PgnGame) prettyMoves(from, to int) (output string) {
 		}
 
 		// Add the next move and proceed
-		output += fmt.Sprintf("%v ", moves[idx].Move())
+		output += fmt.Sprintf("%v ", moves[idx].annotated())
 
 		// and proceed to the next move
 		idx += 1
 	}
 
+	// the better line is shown after all moves
+	if blunder >= from && blunder < to {
+		if line := getBetterLine(game.moves[blunder]); line != "" {
+			output += fmt.Sprintf("\n (%v)", line)
+		}
+	}
+
 	// and return the string computed so far
 	return
 }
 
-// Return an environment for the evaluation of expressions
-func (game *PgnGame) getEnv() (env map[string]any) {
+// Return an environment for the evaluation of the given expressions. Variables
+// which are expensive to compute, e.g., "MoveList", are added only if they are
+// mentioned in any of them
+func (game *PgnGame) getEnv(expressions ...string) (env map[string]any) {
 
 	env = make(map[string]any)
 
@@ -715,29 +1029,227 @@ func (game *PgnGame) getEnv() (env map[string]any) {
 		env[variable] = value
 	}
 
+	// Ratings are always given as integers, unknown ratings being 0, so that
+	// they can be safely compared, along with their average, difference and
+	// rating class
+	white, black, avg, diff := game.eloFields()
+	env["WhiteElo"], env["BlackElo"] = white, black
+	env["EloAvg"], env["EloDiff"] = avg, diff
+	env["RatingClass"] = ratingClass(avg)
+
+	// The classification of the opening is always given as strings, unknown
+	// values being empty, so that they can be safely used in any game
+	env["ECO"], env["Opening"], env["Variation"] = game.openingFields()
+
+	// The percentage of moves of every player that matched the first choice
+	// of an engine, which is 0 unless it was computed before
+	env["WhiteAgreement"], env["BlackAgreement"] = game.WhiteAgreement(), game.BlackAgreement()
+
+	// The overall thinking time of every player in seconds, which is 0 if it
+	// is unknown
+	env["WhiteTimeUsed"], env["BlackTimeUsed"] = game.TimeUsage(1).Total, game.TimeUsage(-1).Total
+
+	// The largest swing of the evaluations given in "[%eval ...]" between
+	// consecutive moves in pawns, which is 0 if moves are not evaluated
+	env["MaxEvalSwing"] = game.MaxEvalSwing()
+
+	// The id of the game is available as well
+	env["Id"] = game.id
+
 	// In addition, create the variable "Moves" representing the number of moves
 	// (not plies)
-	if len(game.moves)%2 == 0 {
-		env["Moves"] = len(game.moves) / 2
-	} else {
-		env["Moves"] = 1 + len(game.moves)/2
+	env["Moves"] = game.fullMoves()
+
+	// and the variable "MoveList" with all plies, so that expressions can
+	// inspect them, e.g., any(MoveList, .san == "e4" && .number == 1)
+	if slices.ContainsFunc(expressions, func(expression string) bool {
+		return strings.Contains(expression, "MoveList")
+	}) {
+		env["MoveList"] = game.exprMoves()
+	}
+
+	// Add also the number of moves qualified by annotators with every symbol
+	for name, quality := range qualityFields {
+		env[name] = game.countQuality(quality)
 	}
 
 	// And also, add all the available functions
-	env["FEN"] = func(fen string) bool {
+	env["FEN"] = func(fen string) (bool, error) {
 		return game.checkFEN(fen)
 	}
+	env["MatchesOpeningLine"] = func(line string, transpositions ...bool) (bool, error) {
+		return game.matchOpeningLine(line, len(transpositions) > 0 && transpositions[0])
+	}
+	env["KingMarch"] = func() (int, error) {
+		return game.kingMarch()
+	}
+	env["KnightTour"] = func() (int, error) {
+		return game.knightTour()
+	}
+	env["MoveTextContains"] = func(text string) bool {
+		return strings.Contains(game.movetext, text)
+	}
+	env["MoveRegex"] = func(expression string) (bool, error) {
+		return game.matchMoveText(expression)
+	}
+	env["ByYear"] = byYear
+	env["ByMonth"] = byMonth
 
 	// and return the environment
 	return
 }
 
+// Return all moves of this game as they are given to expressions
+func (game *PgnGame) exprMoves() []exprMove {
+
+	times := game.ThinkingTimes()
+	moves := make([]exprMove, len(game.moves))
+	for idx, move := range game.moves {
+		moves[idx] = exprMove{
+			Number:  move.number,
+			Color:   move.color,
+			SAN:     move.shortAlgebraic,
+			Comment: move.Comments(),
+			EMT:     times[idx],
+		}
+	}
+	return moves
+}
+
+// Return the ECO code, the name of the opening and its variation of this game.
+// The variation is given in the tag "Variation" or, otherwise, it is taken from
+// the name of the opening when it is given after a colon as in lichess, e.g.,
+// "Sicilian Defense: Najdorf Variation". Unknown values are the empty string
+func (game *PgnGame) openingFields() (eco, opening, variation string) {
+
+	if value, ok := game.tags["ECO"]; ok {
+		eco = fmt.Sprintf("%v", value)
+	}
+	if value, ok := game.tags["Opening"]; ok {
+		opening = fmt.Sprintf("%v", value)
+	}
+	if value, ok := game.tags["Variation"]; ok {
+		variation = fmt.Sprintf("%v", value)
+	} else if _, after, ok := strings.Cut(opening, ":"); ok {
+		variation = strings.TrimSpace(after)
+	}
+	return
+}
+
+// Return true if the movetext of this game matches the given regular
+// expression. Regular expressions are compiled only once, so that filters
+// remain fast on large collections. If the expression is not valid, an error
+// is returned
+func (game *PgnGame) matchMoveText(expression string) (bool, error) {
+
+	// look up the compiled regular expression, and compile it if it was not
+	// found
+	value, ok := movetextRegexps.Load(expression)
+	if !ok {
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return false, fmt.Errorf(" Invalid regular expression '%v': %v", expression, err)
+		}
+		value, _ = movetextRegexps.LoadOrStore(expression, re)
+	}
+	return value.(*regexp.Regexp).MatchString(game.movetext), nil
+}
+
+// Return the moves of the given opening line written in short algebraic
+// notation with move numbers, e.g., "1.e4 c5 2.Nf3 d6", without move numbers,
+// qualifiers and markers of check and checkmate. Lines are parsed only once.
+// It returns an error if the line has no moves
+func getOpeningLine(line string) ([]string, error) {
+
+	if value, ok := openingLines.Load(line); ok {
+		return value.([]string), nil
+	}
+	moves := strings.Fields(reOpeningMoveNumber.ReplaceAllString(line, " "))
+	if len(moves) == 0 {
+		return nil, fmt.Errorf(" The opening line '%v' has no moves", line)
+	}
+	for idx, move := range moves {
+		moves[idx] = strings.TrimRight(move, "+#!?")
+	}
+	value, _ := openingLines.LoadOrStore(line, moves)
+	return value.([]string), nil
+}
+
+// Return true if this game starts with the given opening line, e.g., "1.e4 c5
+// 2.Nf3 d6". If transpositions is true, it also returns true if the position
+// reached after the line is reached in this game after the same number of
+// plies with a different order of moves. In this case, the game is realized as
+// needed. It returns an error if the line has no moves
+func (game *PgnGame) matchOpeningLine(line string, transpositions bool) (bool, error) {
+
+	moves, err := getOpeningLine(line)
+	if err != nil || len(moves) > len(game.moves) {
+		return false, err
+	}
+
+	// first, compare the moves of this game with those of the line
+	matches := true
+	for idx, move := range moves {
+		if setCheckMarker(game.moves[idx].shortAlgebraic, "") != move {
+			matches = false
+			break
+		}
+	}
+	if matches || !transpositions {
+		return matches, nil
+	}
+
+	// otherwise, play the line from the initial position of this game and
+	// compare the positions reached. Lines that can not be played from it,
+	// e.g., because the game starts from another position, do not match
+	board, err := game.initialBoard()
+	if err != nil {
+		return false, err
+	}
+	color := board.sideToMove()
+	for _, move := range moves {
+		if _, err := board.UpdateBoard(PgnMove{color: color, shortAlgebraic: move}); err != nil {
+			return false, nil
+		}
+		color = -color
+	}
+	if err := game.Realize(len(moves)); err != nil {
+		return false, err
+	}
+	return transpositionKey(game.boards[len(moves)]) == transpositionKey(board), nil
+}
+
+// Return the number of moves (not plies) of this game
+func (game *PgnGame) fullMoves() int {
+	return (len(game.moves) + 1) / 2
+}
+
+// Return the value of the given tag as a string, or "?" if it is not defined in
+// this game, as it is customary in PGN files for unknown values
+func (game *PgnGame) getTag(name string) string {
+	if value, ok := game.tags[name]; ok {
+		return fmt.Sprintf("%v", value)
+	}
+	return "?"
+}
+
+// Return the number of moves in this game qualified with the given symbol,
+// either as a suffix or with its NAG, see Quality
+func (game *PgnGame) countQuality(quality string) (count int) {
+	for _, move := range game.moves {
+		if move.Quality() == quality {
+			count += 1
+		}
+	}
+	return
+}
+
 // Return the result of executing the given criteria as a string with
 // information in this game and nil if no error happened.
 func (game *PgnGame) getResult(criteria string) (string, error) {
 
 	// execute the ith-criteria of this histogram
-	env := game.getEnv()
+	env := game.getEnv(criteria)
 	output, err := evaluateExpr(criteria, env)
 	if err != nil {
 		return "", err
@@ -747,42 +1259,72 @@ func (game *PgnGame) getResult(criteria string) (string, error) {
 	return fmt.Sprintf("%v", output), nil
 }
 
-// return true if the receiver must go before the other game and false otherwise
-// according to the given sorting criteria. If the evaluation of any criteria
-// produced an error it is returned and the boolean result is invalid
-func (game PgnGame) lessGame(other PgnGame, criteria criteriaSorting) (bool, error) {
+// Return the result of evaluating all the given sorting criteria in this game.
+// If the evaluation of any criteria produced an error it is returned and the
+// result is invalid
+func (game *PgnGame) sortingKeys(criteria criteriaSorting) ([]string, error) {
 
-	// process all criteria given
-	for _, icriteria := range criteria {
-
-		// get the result of this criteria both in this game and the other
-		iresult, ierr := game.getResult(icriteria.criteria)
-		if ierr != nil {
-			return false, ierr
-		}
-		jresult, jerr := other.getResult(icriteria.criteria)
-		if jerr != nil {
-			return false, jerr
+	keys := make([]string, len(criteria))
+	for idx, icriteria := range criteria {
+		result, err := game.getResult(icriteria.criteria)
+		if err != nil {
+			return nil, err
 		}
+		keys[idx] = result
+	}
+	return keys, nil
+}
+
+// return true if the game with the first sorting keys must go before the game
+// with the second sorting keys according to the given sorting criteria
+func lessKeys(ikeys, jkeys []string, criteria criteriaSorting) bool {
+
+	// process all criteria given
+	for idx, icriteria := range criteria {
 
 		// The result of an execution could be anything. However sorting is done
 		// lexicographically on the given criteria and thus comparisons are done
 		// as strings (note that "false" < "true"). Next in case one of the
 		// values is either gt or lt than the other a comparison is performed.
 		// Otherwise, the next sorting criteria should be visited
+		iresult, jresult := ikeys[idx], jkeys[idx]
 		if (iresult < jresult && icriteria.direction == increasing) ||
 			(iresult > jresult && icriteria.direction == decreasing) {
-			return true, nil
+			return true
 		}
 		if (iresult > jresult && icriteria.direction == increasing) ||
 			(iresult < jresult && icriteria.direction == decreasing) {
-			return false, nil
+			return false
 		}
 	}
 
 	// At this point, both games have been proven to be strinctly equal
 	// according to the given criteria
-	return false, nil
+	return false
+}
+
+// Return the id of this game, which is zero if it was not given any
+func (game *PgnGame) Id() int {
+	return game.id
+}
+
+// Return the movetext of this game as found in the PGN file, including
+// comments, with all blanks collapsed into single spaces
+func (game *PgnGame) MoveText() string {
+	return game.movetext
+}
+
+// Return the text of the comments given before the first move of this game,
+// e.g., an introduction, separated by newlines, or the empty string if there
+// are none
+func (game *PgnGame) LeadingComment() string {
+	return getComments(game.leading)
+}
+
+// Return the text of the comments given after the outcome of this game
+// separated by newlines, or the empty string if there are none
+func (game *PgnGame) TrailingComment() string {
+	return getComments(game.trailing)
 }
 
 // Return the tags of this game
@@ -805,11 +1347,252 @@ func (game *PgnGame) Outcome() PgnOutcome {
 	return game.outcome
 }
 
+// Return the variant of this game as given in the tag "Variant" and nil. If the
+// tag is not given, standard chess is used. If the variant has not been
+// registered an error is returned
+func (game *PgnGame) Variant() (Variant, error) {
+
+	// if no variant is given use the default one
+	name, ok := game.tags["Variant"]
+	if !ok {
+		return defaultVariant, nil
+	}
+
+	// otherwise, look it up in the registry of variants
+	if variant, ok := GetVariant(fmt.Sprintf("%v", name)); ok {
+		return variant, nil
+	}
+	return nil, fmt.Errorf(" Unknown variant '%v'\n", name)
+}
+
+// Play all moves of this game from the initial board of its variant. As a
+// result, all moves are updated with their long algebraic notation and the
+// boards of this game are computed, the first one being the initial board. It
+// returns any error found or nil otherwise. Games which have been already
+// played are not played again
+func (game *PgnGame) play() error {
+	return game.Realize(-1)
+}
+
+// Return the number of plies of this game which have been already played on a
+// board with Realize
+func (game *PgnGame) Realized() int {
+	return max(0, len(game.boards)-1)
+}
+
+// Return the board where this game starts according to its variant, and any
+// error found
+func (game *PgnGame) initialBoard() (PgnBoard, error) {
+
+	variant, err := game.Variant()
+	if err != nil {
+		return PgnBoard{}, err
+	}
+	board, err := variant.InitialBoard(game.tags)
+	if err != nil {
+		return PgnBoard{}, err
+	}
+	board.variant = variant
+	return board, nil
+}
+
+// Realize is the second phase of processing a game, after parsing it with
+// ParseGame. It plays the first n plies of this game (all of them if n is
+// negative or greater than the number of plies) on a board starting from the
+// initial board of its variant. As a result, these moves are updated with their
+// long algebraic notation and the boards after each one are computed, the
+// first one being the initial board. Plies which were already played are not
+// played again, so that games can be realized lazily, e.g., first the opening
+// and later on the whole game. It returns any error found or nil otherwise
+func (game *PgnGame) Realize(n int) error {
+
+	if n < 0 || n > len(game.moves) {
+		n = len(game.moves)
+	}
+
+	// Create a new board according to the variant of this game, unless it
+	// already exists
+	if len(game.boards) == 0 {
+		board, err := game.initialBoard()
+		if err != nil {
+			return err
+		}
+		game.boards = []PgnBoard{board}
+	}
+
+	// and update the last board with every move not played yet, storing all
+	// boards in turn. Because playing a game is deterministic, there is no need
+	// to play again the moves whose boards are known
+	board := game.boards[len(game.boards)-1]
+	for idx := len(game.boards) - 1; idx < n; idx++ {
+		extended, err := board.UpdateBoard(game.moves[idx])
+		if err != nil {
+			return &ErrIllegalMove{
+				Game:        game.id,
+				Ply:         idx + 1,
+				Move:        game.moves[idx].shortAlgebraic,
+				FEN:         board.FEN(),
+				Err:         err,
+				Suggestions: suggestMoves(board.FEN(), board.variant, game.moves[idx].shortAlgebraic),
+			}
+		}
+		game.moves[idx].longAlgebraic = extended
+		game.boards = append(game.boards, board)
+	}
+	return nil
+}
+
+// Validate plays all moves of this game on a board of its own, i.e., without
+// realizing it, and returns all errors found in the order they were found:
+// moves which could be played by more than one piece (wrapping
+// ErrAmbiguousMove) and the first illegal move, as the game can not be played
+// any further. An error is returned only if the initial board of this game
+// could not be computed
+func (game *PgnGame) Validate() ([]*ErrIllegalMove, error) {
+
+	board, err := game.initialBoard()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []*ErrIllegalMove
+	for idx, move := range game.moves {
+
+		// moves of pieces other than pawns are ambiguous if they can be
+		// played from more than one square with the qualifier given
+		fen := board.FEN()
+		if matches := reTextualMove.FindStringSubmatch(move.shortAlgebraic); matches != nil && matches[6] == "" {
+			if piece := getPieceIndex(matches[1]); piece != WPAWN &&
+				board.countOrigins(getPieceValue(piece, move.color), matches[4], matches[2]) > 1 {
+				errs = append(errs, &ErrIllegalMove{
+					Game:        game.id,
+					Ply:         idx + 1,
+					Move:        move.shortAlgebraic,
+					FEN:         fen,
+					Err:         ErrAmbiguousMove,
+					Suggestions: suggestMoves(fen, board.variant, move.shortAlgebraic),
+				})
+			}
+		}
+
+		// and stop at the first move that can not be played
+		if _, err := board.UpdateBoard(move); err != nil {
+			errs = append(errs, &ErrIllegalMove{
+				Game:        game.id,
+				Ply:         idx + 1,
+				Move:        move.shortAlgebraic,
+				FEN:         fen,
+				Err:         err,
+				Suggestions: suggestMoves(fen, board.variant, move.shortAlgebraic),
+			})
+			break
+		}
+	}
+	return errs, nil
+}
+
+// Replay plays all moves of this game on a board of its own, i.e., without
+// realizing it, and invokes the given function after every ply with its number
+// (starting from 1), the move played, which is given its long algebraic
+// notation, and the board reached after it. Boards are given by value so that
+// they can be kept or modified by the function without affecting the replay.
+// Replay stops as soon as the function returns an error, which is returned. It
+// also returns an error if the initial board could not be computed or a move is
+// illegal
+func (game *PgnGame) Replay(fn func(ply int, move PgnMove, board PgnBoard) error) error {
+
+	board, err := game.initialBoard()
+	if err != nil {
+		return err
+	}
+	for idx, move := range game.moves {
+		fen := board.FEN()
+		extended, err := board.UpdateBoard(move)
+		if err != nil {
+			return &ErrIllegalMove{
+				Game:        game.id,
+				Ply:         idx + 1,
+				Move:        move.shortAlgebraic,
+				FEN:         fen,
+				Err:         err,
+				Suggestions: suggestMoves(fen, board.variant, move.shortAlgebraic),
+			}
+		}
+		move.longAlgebraic = extended
+		if err := fn(idx+1, move, board); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return the FEN code of the position reached after the given number of plies
+// of this game, the initial position being the one reached after 0 plies. The
+// game is realized up to that ply if necessary. It returns an error if the ply
+// is out of range or the game could not be realized
+func (game *PgnGame) FENAt(ply int) (string, error) {
+
+	if ply < 0 || ply > len(game.moves) {
+		return "", fmt.Errorf(" Invalid ply %v in a game with %v plies", ply, len(game.moves))
+	}
+	if err := game.Realize(ply); err != nil {
+		return "", err
+	}
+	return game.boards[ply].ToFEN(), nil
+}
+
+// Return a lightweight view of this game with the plies in the range (from, to],
+// i.e., starting from the position reached after the first ply given, e.g.,
+// Window(80, 100) returns the twenty plies starting with move 41 for White.
+// The game is realized up to the last ply if necessary.
+//
+// The view inherits the tags of this game, but it is given a FEN tag with its
+// starting position, and it keeps the number of every move so that they
+// correspond to that position. Its boards are shared with this game. Unless it
+// reaches the end of this game, its outcome is unknown ('*'). It returns an
+// error if the range is not valid or the game could not be realized
+func (game *PgnGame) Window(from, to int) (*PgnGame, error) {
+
+	if from < 0 || from > to || to > len(game.moves) {
+		return nil, fmt.Errorf(" Invalid range of plies (%v, %v] in a game with %v plies", from, to, len(game.moves))
+	}
+	if err := game.Realize(to); err != nil {
+		return nil, err
+	}
+
+	// inherit the tags of this game, setting up the starting position
+	tags := maps.Clone(game.tags)
+	tags["FEN"] = game.boards[from].FEN()
+	tags["SetUp"] = 1
+	if _, ok := tags["PlyCount"]; ok {
+		tags["PlyCount"] = to - from
+	}
+
+	// the outcome is known only if the view reaches the end of the game
+	outcome := game.outcome
+	if to < len(game.moves) {
+		outcome = PgnOutcome{-1, -1}
+		tags["Result"] = outcome.String()
+	}
+
+	window := PgnGame{
+		tags:    tags,
+		moves:   slices.Clone(game.moves[from:to]),
+		boards:  game.boards[from : to+1],
+		outcome: outcome,
+		id:      game.id,
+	}
+
+	// the movetext of the view is computed from its moves
+	window.movetext = strings.Join(strings.Fields(window.getPGNMoves(newPgnOptions())), " ")
+	return &window, nil
+}
+
 // Return whether the given expression is true or not for this specific game
 func (game *PgnGame) Filter(expression string) (bool, error) {
 
 	// First of all, create an environment for the evaluation of the given expression
-	env := game.getEnv()
+	env := game.getEnv(expression)
 
 	// evaluate the given expression within the environment
 	output, err := evaluateExpr(expression, env)
@@ -827,48 +1610,113 @@ func (game *PgnGame) Filter(expression string) (bool, error) {
 	return result, nil
 }
 
-// Return the contents of this game in PGN format
-func (game *PgnGame) GetPGN() (output string) {
+// Return all moves of this game in PGN format in a single line, with comments
+// folded and re-wrapped as requested in the given options. If requested, and
+// the whole game has been realized, missing markers of check and checkmate are
+// added to the moves of the main line. Also, if requested, transpositions are
+// written as comments after the moves that reach them
+func (game *PgnGame) getPGNMoves(options pgnOptions) string {
+
+	// comments in other languages are removed first, if requested, and the
+	// decisive mistake is marked afterwards
+	moves := game.moves
+	if options.language != "" {
+		moves = selectLanguageLine(moves, options.language)
+	}
+	if options.blunders {
+		moves = game.markBlunder(moves)
+	}
 
-	// First, show all tags followed by a blank line
-	for variable, value := range game.tags {
-		output += fmt.Sprintf("[%v \"%v\"]\n", variable, value)
+	markers := options.checkMarkers && len(game.moves) > 0 && game.Realized() == len(game.moves)
+	transpositions := options.transpositions && len(game.transpositions) > 0
+	if !markers && !transpositions {
+		return getPGNLine(moves, options)
 	}
-	output += "\n"
+	moves = slices.Clone(moves)
+	if markers {
+		for ply := range moves {
+			if getCheckMarker(moves[ply].shortAlgebraic) == "" {
+				moves[ply].shortAlgebraic = setCheckMarker(moves[ply].shortAlgebraic, game.getExpectedMarker(ply))
+			}
+		}
+	}
+
+	// annotations are cloned before adding the comments so that the moves of
+	// this game are not modified
+	if transpositions {
+		for _, transposition := range game.transpositions {
+			move := &moves[transposition.Ply-1]
+			move.annotations = append(slices.Clone(move.annotations),
+				PgnAnnotation{Kind: CommentAnnotation, Value: game.getTranspositionComment(transposition)})
+		}
+	}
+	return getPGNLine(moves, options)
+}
 
-	// Next, write all moves of this game in a single line
-	idx := 0
-	for idx < len(game.moves) {
+// Return the given annotations of a game, i.e., those given before the first
+// move or after the outcome, in PGN format in a single line. They are written
+// in the same way than the annotations of moves
+func getPGNGameAnnotations(annotations []PgnAnnotation, options pgnOptions) string {
+	if options.language != "" {
+		annotations = selectLanguage(annotations, options.language)
+	}
+	return PgnMove{annotations: annotations}.getPGNAnnotations(options)
+}
 
-		// Write the move number and the white's move
-		output += fmt.Sprintf("%v. %v ", game.moves[idx].number, game.moves[idx].shortAlgebraic)
+// Return all the given moves in PGN format in a single line, with comments
+// folded and re-wrapped as requested in the given options, and the variations
+// of every move between parenthesis right after it
+func getPGNLine(moves []PgnMove, options pgnOptions) (output string) {
 
-		// and in case this move has an emt/ comments add them
-		if game.moves[idx].emt > 0.0 {
-			output += fmt.Sprintf("{[%%emt %v]} ", game.moves[idx].emt)
-		}
-		if game.moves[idx].comments != "" {
-			output += fmt.Sprintf("{ %v } ", game.moves[idx].comments)
+	for idx, move := range moves {
+
+		// Write the move number of all white's moves. Lines which start with
+		// a move of black, e.g., windows of other games or variations, are
+		// given an ellipsis instead, and so are black's moves that follow a
+		// variation
+		if move.color > 0 || idx == 0 || len(moves[idx-1].variations) > 0 {
+			output += fmt.Sprintf("%v%v ", move.number, move.getColorPrefix())
 		}
-		idx += 1
+		output += fmt.Sprintf("%v ", move.annotated())
 
-		// in case there is a move for black, then add it immediately after
-		if idx < len(game.moves) {
-			output += fmt.Sprintf("%v ", game.moves[idx].shortAlgebraic)
+		// and in case this move has an emt/ comments add them
+		output += move.getPGNAnnotations(options)
 
-			// and in case this move has any emt/comments add them
-			if game.moves[idx].emt > 0.0 {
-				output += fmt.Sprintf("{[%%emt %v]} ", game.moves[idx].emt)
-			}
-			if game.moves[idx].comments != "" {
-				output += fmt.Sprintf("{ %v } ", game.moves[idx].comments)
-			}
-			idx += 1
+		// and finally its variations
+		for _, variation := range move.variations {
+			output += fmt.Sprintf("(%v) ", strings.TrimSpace(getPGNLine(variation, options)))
 		}
 	}
+	return
+}
 
-	// Next, show the result which is used as a token of end of game
-	output += fmt.Sprintf("%v", game.Outcome())
+// Return the contents of this game in PGN format. Tags are written in the order
+// of the export format, i.e., the Seven Tag Roster first and the others in
+// alphabetical order. Comments are folded as requested WithCommentFolding and
+// re-wrapped WithCommentWidth, and only those in the language given
+// WithLanguage are written. The movetext is wrapped WithLineWidth and
+// WithCommentLines, and the commands given WithoutCommands are not written.
+// WithCheckMarkers adds the markers of check and checkmate missing in realized
+// games, WithTranspositions adds comments with the transpositions of the game,
+// and WithBlunders marks the decisive mistake of the loser
+func (game *PgnGame) GetPGN(opts ...PgnOption) (output string) {
+
+	options := newPgnOptions(opts...)
+
+	// First, show all tags in the order of the export format followed by a
+	// blank line
+	output += getPGNTags(game.tags)
+	output += "\n"
+
+	// Next, write all moves of this game followed by the result which is used
+	// as a token of end of game, either in a single line or wrapped as
+	// requested. Comments given before the first move and after the result are
+	// written as well
+	movetext := fmt.Sprintf("%v%v%v", getPGNGameAnnotations(game.leading, options), game.getPGNMoves(options), game.Outcome())
+	if trailing := getPGNGameAnnotations(game.trailing, options); trailing != "" {
+		movetext += " " + strings.TrimSpace(trailing)
+	}
+	output += wrapMoveText(movetext, options)
 
 	// and add a blank line
 	output += "\n\n"
@@ -976,6 +1824,10 @@ func (game *PgnGame) getMainLineWithComments(nbplies int) func() (string, error)
 		last := min(start+nbplies, len(game.moves))
 		for idx, move := range game.moves[start:last] {
 
+			// NAGs qualifying moves are shown as part of them, whereas the
+			// others are shown after the mainline as comments are
+			annotated, symbols := move.getLaTeXNags()
+
 			// if we are starting a new mainline (either because we are about to
 			// generate the first move or because a comment or other information
 			// was printed in the last iteration)
@@ -989,26 +1841,31 @@ func (game *PgnGame) getMainLineWithComments(nbplies int) func() (string, error)
 			if newMainLine || move.color == 1 {
 
 				// now, show the actual move with all details
-				output += fmt.Sprintf("%v%v %v ", move.number, move.getColorPrefix(), move.shortAlgebraic)
+				output += fmt.Sprintf("%v%v %v ", move.number, move.getColorPrefix(), annotated)
 			} else {
 
 				// otherwise, just show the actual move
-				output += fmt.Sprintf("%v ", move.shortAlgebraic)
+				output += fmt.Sprintf("%v ", annotated)
 			}
 
-			// if this move contains either a comment or the emt
-			if move.emt != -1 || move.comments != "" {
+			// if this move contains either a comment, the emt or NAGs
+			if move.emt != -1 || move.hasComments() || symbols != "" {
 
 				output += "} "
 
+				// first, show the symbols of NAGs
+				if symbols != "" {
+					output += fmt.Sprintf("%v ", symbols)
+				}
+
 				// now, in case emt is present, show it
 				if move.emt != -1 {
 					output += fmt.Sprintf(`({\it %v}) `, move.emt)
 				}
 
 				// if a comment is present, show it as well
-				if move.comments != "" {
-					output += fmt.Sprintf("\\textcolor{CadetBlue}{%v}", substituteLaTeX(move.comments))
+				if move.hasComments() {
+					output += fmt.Sprintf("\\textcolor{CadetBlue}{%v}", substituteLaTeX(move.Comments()))
 				}
 			} else if idx == last-start-1 {
 
@@ -1020,7 +1877,7 @@ func (game *PgnGame) getMainLineWithComments(nbplies int) func() (string, error)
 
 			// and check whether a new mainline has to be started in the
 			// next iteration
-			newMainLine = (move.emt != -1 || move.comments != "")
+			newMainLine = (move.emt != -1 || move.hasComments() || symbols != "")
 		}
 
 		// update the position of the next location to examine
@@ -1043,7 +1900,14 @@ func (game *PgnGame) GetLaTeXMovesWithComments() string {
 	// capture the closure that generates the moves
 	result, _ := game.getMainLineWithComments(len(game.moves))()
 
-	// and return all moves of this game
+	// and return all moves of this game, preceded and followed by the comments
+	// of the game, if any
+	if comment := game.LeadingComment(); comment != "" {
+		result = fmt.Sprintf("\\textcolor{CadetBlue}{%v}\n\n", substituteLaTeX(comment)) + result
+	}
+	if comment := game.TrailingComment(); comment != "" {
+		result += fmt.Sprintf("\n\n\\textcolor{CadetBlue}{%v}\n", substituteLaTeX(comment))
+	}
 	return result
 }
 
@@ -1051,10 +1915,17 @@ func (game *PgnGame) GetLaTeXMovesWithComments() string {
 // the chess board
 //
 // This method successively processes the moves in this PgnGame until a comment
-// is found.
+// is found. Boards are drawn with the style of diagrams given, if any, e.g., the
+// one given to templates in $.Diagram
 //
 // It is intended to be used in LaTeX templates
-func (game *PgnGame) GetLaTeXMovesWithCommentsTabular(width1, width2 string, nbplies int) (output string) {
+func (game *PgnGame) GetLaTeXMovesWithCommentsTabular(width1, width2 string, nbplies int, styles ...DiagramStyle) (output string) {
+
+	// Boards are drawn with the first style given, if any
+	var style DiagramStyle
+	if len(styles) > 0 {
+		style = styles[0]
+	}
 
 	// Declare a long table which can span over several pages to show the entire
 	// game
@@ -1068,7 +1939,7 @@ func (game *PgnGame) GetLaTeXMovesWithCommentsTabular(width1, width2 string, nbp
 	// Now, produce the lines of the table. Each line shows a mainline (along
 	// with comments and other information) in the left cell, and the resulting
 	// chess board to the right
-	for {
+	for shown := 0; ; {
 
 		// get the next mainline to show and in case the game was exhausted,
 		// exit from the main loop
@@ -1076,8 +1947,11 @@ func (game *PgnGame) GetLaTeXMovesWithCommentsTabular(width1, width2 string, nbp
 			break
 		} else {
 
-			// Otherwise, add a new line to the table
-			output += fmt.Sprintf("%v & \\chessboard[smallboard,print,showmover=true] \\\\ \n", mainline)
+			// Otherwise, add a new line to the table. The board shows the
+			// arrows and colored squares given after the last move of the
+			// mainline
+			shown = min(shown+nbplies, len(game.moves))
+			output += fmt.Sprintf("%v & \\chessboard[%v%v] \\\\ \n", mainline, style.options(), game.moves[shown-1].GetLaTeXMarks())
 		}
 	}
 
@@ -1136,6 +2010,26 @@ func (game *PgnGame) GetField(field string) string {
 		}
 	}
 
+	// -- Links
+	if field == "Links" {
+
+		// Return all links of this game to other related games separated by
+		// commas, e.g., "rematch #3, adjourned #5"
+		links := make([]string, 0, len(game.links))
+		for _, link := range game.links {
+			links = append(links, link.String())
+		}
+		return substituteLaTeX(strings.Join(links, ", "))
+	}
+
+	// -- Move quality
+	if quality, ok := qualityFields[field]; ok {
+
+		// return the number of moves qualified with the symbol associated to
+		// this field
+		return fmt.Sprintf("%d", game.countQuality(quality))
+	}
+
 	// -- tags
 
 	// after trying special fields, then tags defined in this game are
@@ -1148,6 +2042,40 @@ func (game *PgnGame) GetField(field string) string {
 	return ""
 }
 
+// Return true if the given tag is defined in this game. Along with TagOr and
+// RawTag, it allows templates to write conditional blocks depending on the tags
+// of every game, e.g., {{if .HasTag "Brilliancy"}}...{{end}}
+//
+// It is intended to be used in LaTeX templates
+func (game *PgnGame) HasTag(name string) bool {
+	_, ok := game.tags[name]
+	return ok
+}
+
+// Return the value of the given tag in this game or, if it is not defined, the
+// given value. In both cases, special LaTeX characters are substituted as in
+// GetField
+//
+// It is intended to be used in LaTeX templates
+func (game *PgnGame) TagOr(name, value string) string {
+	if tag, ok := game.tags[name]; ok {
+		value = fmt.Sprintf("%v", tag)
+	}
+	return substituteLaTeX(value)
+}
+
+// Return the value of the given tag in this game verbatim, or the empty string
+// if it is not defined, so that tags can give LaTeX code to be injected in
+// templates
+//
+// It is intended to be used in LaTeX templates
+func (game *PgnGame) RawTag(name string) string {
+	if tag, ok := game.tags[name]; ok {
+		return fmt.Sprintf("%v", tag)
+	}
+	return ""
+}
+
 // Return an index entry of a specific game for any slice of fields. The first
 // argument serves to determine where to add a horizontal single rule so that
 // every block consists of sep entries.
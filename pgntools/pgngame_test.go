@@ -19,6 +19,11 @@
 package pgntools
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/clinaresl/pgnparser/pgntools/testdata"
@@ -659,8 +664,8 @@ func Test_matchFENPiecePlacement(t *testing.T) {
 	// Execution of ad-hoc cases
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := matchFENPiecePlacement(tt.args.expr, tt.args.code, tt.args.digits, tt.args.undefined); got != tt.want {
-				t.Errorf("matchFENPiecePlacement() = %v, want %v", got, tt.want)
+			if got, err := matchFENPiecePlacement(tt.args.expr, tt.args.code, tt.args.digits, tt.args.undefined); err != nil || got != tt.want {
+				t.Errorf("matchFENPiecePlacement() = (%v, %v), want %v", got, err, tt.want)
 			}
 		})
 	}
@@ -696,11 +701,11 @@ func Test_matchFENPiecePlacement(t *testing.T) {
 
 			// and execute it
 			t.Run(positivecase.name, func(t *testing.T) {
-				if got := matchFENPiecePlacement(positivecase.args.expr,
+				if got, err := matchFENPiecePlacement(positivecase.args.expr,
 					positivecase.args.code,
 					positivecase.args.digits,
-					positivecase.args.undefined); got != positivecase.want {
-					t.Errorf("matchFENPiecePlacement() = %v, want %v", got, positivecase.want)
+					positivecase.args.undefined); err != nil || got != positivecase.want {
+					t.Errorf("matchFENPiecePlacement() = (%v, %v), want %v", got, err, positivecase.want)
 				}
 			})
 
@@ -725,11 +730,11 @@ func Test_matchFENPiecePlacement(t *testing.T) {
 
 			// and execute it
 			t.Run(negativecase.name, func(t *testing.T) {
-				if got := matchFENPiecePlacement(negativecase.args.expr,
+				if got, err := matchFENPiecePlacement(negativecase.args.expr,
 					negativecase.args.code,
 					negativecase.args.digits,
-					negativecase.args.undefined); got != negativecase.want {
-					t.Errorf("matchFENPiecePlacement() = %v, want %v", got, negativecase.want)
+					negativecase.args.undefined); err != nil || got != negativecase.want {
+					t.Errorf("matchFENPiecePlacement() = (%v, %v), want %v", got, err, negativecase.want)
 				}
 			})
 		}
@@ -763,13 +768,13 @@ func Test_matchFENPiecePlacement(t *testing.T) {
 
 			// and execute it
 			t.Run(positivecase.name, func(t *testing.T) {
-				if got := matchFENPiecePlacement(positivecase.args.expr,
+				if got, err := matchFENPiecePlacement(positivecase.args.expr,
 					positivecase.args.code,
 					positivecase.args.digits,
-					positivecase.args.undefined); got != positivecase.want {
+					positivecase.args.undefined); err != nil || got != positivecase.want {
 					t.Logf("\t> expr: %v\n", positivecase.args.expr)
 					t.Logf("\t> code: %v\n", positivecase.args.code)
-					t.Errorf("matchFENPiecePlacement() = %v, want %v", got, positivecase.want)
+					t.Errorf("matchFENPiecePlacement() = (%v, %v), want %v", got, err, positivecase.want)
 				}
 			})
 
@@ -794,17 +799,697 @@ func Test_matchFENPiecePlacement(t *testing.T) {
 
 			// and execute it
 			t.Run(negativecase.name, func(t *testing.T) {
-				if got := matchFENPiecePlacement(negativecase.args.expr,
+				if got, err := matchFENPiecePlacement(negativecase.args.expr,
 					negativecase.args.code,
 					negativecase.args.digits,
-					negativecase.args.undefined); got != negativecase.want {
-					t.Errorf("matchFENPiecePlacement() = %v, want %v", got, negativecase.want)
+					negativecase.args.undefined); err != nil || got != negativecase.want {
+					t.Errorf("matchFENPiecePlacement() = (%v, %v), want %v", got, err, negativecase.want)
 				}
 			})
 		}
 	}
 }
 
+func TestMatchFEN(t *testing.T) {
+
+	fen := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+	tests := []struct {
+		pattern string
+		want    bool
+		err     bool
+	}{
+		{fen, true, false},
+		{"*8/*8/*8/*8/4P3/*8/*8/*8 b * * * *", true, false},
+		{"*8/*8/*8/*8/4P3/*8/*8/*8 w * * * *", false, false},
+		{"*8/*8/*8/*8/*8/*8/*8/*8 * KQ * * *", false, false},
+		{"*8/*8/*8/*8/*8/*8/*8/*8 * * e* * *", true, false},
+		{"*7*2/*/*/*/*/*/*/* * * * * *", false, true},
+		{"*/*/*/*/4P3/*/*/*", false, true},
+	}
+	for _, tt := range tests {
+		got, err := MatchFEN(tt.pattern, fen)
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("MatchFEN(%v) = (%v, %v), want %v", tt.pattern, got, err, tt.want)
+		}
+		if err != nil && !errors.Is(err, ErrBadFEN) {
+			t.Errorf("MatchFEN(%v) error = %v, want ErrBadFEN", tt.pattern, err)
+		}
+	}
+
+	// en passant targets given in patterns do not match FEN codes without them
+	if got, err := MatchFEN("8/8/8/8/8/8/8/8 * * e* * *", "8/8/8/8/8/8/8/8 w - - 0 1"); got || err != nil {
+		t.Errorf("MatchFEN() = (%v, %v), want false", got, err)
+	}
+}
+
+func Test_matchMoveText(t *testing.T) {
+	game := PgnGame{movetext: "1. e4 e5 2. Bc4 Nc6 3. Qh5 Nf6 { blunder } 4. Qxf7# 1-0"}
+	tests := []struct {
+		expression string
+		want       bool
+		wantErr    bool
+	}{
+		{`Qxf7`, true, false},
+		{`Nf6.*Qxf7#`, true, false},
+		{`O-O-O.*#`, false, false},
+		{`Qxf7(`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := game.matchMoveText(tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchMoveText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("matchMoveText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPgnMove_getPGNAnnotations(t *testing.T) {
+
+	// annotations are parsed from the movetext of a single move
+	moves, err := getMoves("1. e4 {[%emt 2.1]} $1 { a long   comment [%clk 0:03:00] } { another one }")
+	if err != nil {
+		t.Fatalf("getMoves() error = %v", err)
+	}
+	move := moves[0]
+	if move.emt != 2.1 || move.Comments() != "a long   comment\nanother one" {
+		t.Fatalf("getMoves() = %v, %q", move.emt, move.Comments())
+	}
+	tests := []struct {
+		name string
+		opts []PgnOption
+		want string
+	}{
+		{"Raw", nil, "{[%emt 2.1]} $1 { a long   comment [%clk 0:03:00] } { another one } "},
+		{"Merged", []PgnOption{WithCommentFolding(MergedComments)}, "$1 { [%emt 2.1] a long   comment [%clk 0:03:00] another one } "},
+		{"Separate", []PgnOption{WithCommentFolding(SeparateComments)}, "{[%emt 2.1]} $1 { a long   comment } {[%clk 0:03:00]} { another one } "},
+		{"Wrapped", []PgnOption{WithCommentWidth(14)}, "{[%emt 2.1]} $1 { a long comment\n[%clk 0:03:00] } { another one } "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := move.getPGNAnnotations(newPgnOptions(tt.opts...)); got != tt.want {
+				t.Errorf("getPGNAnnotations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPgnMove_Nags(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		pgn       string
+		nags      []int
+		annotated string
+		symbols   string
+	}{
+		{"None", "1. e4", nil, "e4", ""},
+		{"Quality", "1. e4 $1", []int{1}, "e4!", ""},
+		{"Suffix first", "1. e4!? $2", []int{2}, "e4!?", ""},
+		{"Evaluation", "1. e4 $5 {a comment} $14 $999", []int{5, 14, 999}, "e4!?", `\wbetter`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves, err := getMoves(tt.pgn)
+			if err != nil {
+				t.Fatalf("getMoves() error = %v", err)
+			}
+			if got := moves[0].Nags(); !slices.Equal(got, tt.nags) {
+				t.Errorf("Nags() = %v, want %v", got, tt.nags)
+			}
+			if annotated, symbols := moves[0].getLaTeXNags(); annotated != tt.annotated || symbols != tt.symbols {
+				t.Errorf("getLaTeXNags() = (%q, %q), want (%q, %q)", annotated, symbols, tt.annotated, tt.symbols)
+			}
+		})
+	}
+}
+
+func TestPgnMove_Quality(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		pgn     string
+		quality string
+		nag     int
+	}{
+		{"None", "1. e4", "", 0},
+		{"Suffix", "1. e4??", "??", 4},
+		{"NAG", "1. e4 $4", "??", 4},
+		{"First NAG", "1. e4 $14 $6 $1", "?!", 6},
+		{"Other NAGs", "1. e4 $14 $7", "", 0},
+		{"Conflicting", "1. e4! $4", "!", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves, err := getMoves(tt.pgn)
+			if err != nil {
+				t.Fatalf("getMoves() error = %v", err)
+			}
+			if got := moves[0].Quality(); got != tt.quality {
+				t.Errorf("Quality() = %q, want %q", got, tt.quality)
+			}
+			if got := moves[0].QualityNAG(); got != tt.nag {
+				t.Errorf("QualityNAG() = %v, want %v", got, tt.nag)
+			}
+		})
+	}
+
+	// moves qualified either way are counted alike, and the suffix prevails
+	game, err := ParseGame(`[Event "Test"]
+
+1. e4 $4 e5?? 2. Nf3! $4 Nc6 $2 $4 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	for field, want := range map[string]string{"Blunders": "2", "GoodMoves": "1", "Mistakes": "1"} {
+		if got := game.GetField(field); got != want {
+			t.Errorf("GetField(%q) = %v, want %v", field, got, want)
+		}
+	}
+	if pgn := game.GetPGN(); !strings.Contains(pgn, "1. e4 $4 e5?? 2. Nf3! $4") {
+		t.Errorf("GetPGN() = %q, NAGs are not preserved", pgn)
+	}
+}
+
+func TestPgnGame_Realize(t *testing.T) {
+
+	game, err := ParseGame(`[Event "Test"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 1/2-1/2`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if game.Realized() != 0 {
+		t.Fatalf("Realized() = %v, want 0", game.Realized())
+	}
+
+	// realize the game lazily, first the opening and then all of it
+	tests := []struct {
+		plies int
+		want  int
+		fen   string
+	}{
+		{2, 2, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"},
+		{1, 2, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"},
+		{-1, 6, "r1bqkbnr/1ppp1ppp/p1n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 0 4"},
+	}
+	for _, tt := range tests {
+		if err := game.Realize(tt.plies); err != nil {
+			t.Fatalf("Realize(%v) error = %v", tt.plies, err)
+		}
+		if game.Realized() != tt.want {
+			t.Errorf("Realize(%v) realized %v plies, want %v", tt.plies, game.Realized(), tt.want)
+		}
+		if fen := game.boards[len(game.boards)-1].FEN(); fen != tt.fen {
+			t.Errorf("Realize(%v) FEN = %v, want %v", tt.plies, fen, tt.fen)
+		}
+	}
+}
+
+func TestPgnGame_openingFields(t *testing.T) {
+
+	tests := []struct {
+		tags                    string
+		eco, opening, variation string
+	}{
+		{`[ECO "B90"]
+[Opening "Sicilian Defense: Najdorf Variation"]`, "B90", "Sicilian Defense: Najdorf Variation", "Najdorf Variation"},
+		{`[ECO "C60"]
+[Opening "Ruy Lopez"]
+[Variation "Berlin Defense"]`, "C60", "Ruy Lopez", "Berlin Defense"},
+		{`[Event "?"]`, "", "", ""},
+	}
+	for _, tt := range tests {
+		game, err := ParseGame(tt.tags + "\n\n1. e4 *")
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		env := game.getEnv()
+		if env["ECO"] != tt.eco || env["Opening"] != tt.opening || env["Variation"] != tt.variation {
+			t.Errorf("getEnv() = (%q, %q, %q), want (%q, %q, %q)",
+				env["ECO"], env["Opening"], env["Variation"], tt.eco, tt.opening, tt.variation)
+		}
+	}
+}
+
+func TestPgnGame_exprMoves(t *testing.T) {
+
+	game, err := ParseGame(`[Event "?"]
+
+1. e4 { [%clk 0:03:00] } 1... c5 { Sicilian [%clk 0:02:58] } 2. Nf3 {[%emt 4.0]} *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	want := []exprMove{
+		{Number: 1, Color: 1, SAN: "e4", EMT: -1},
+		{Number: 1, Color: -1, SAN: "c5", Comment: "Sicilian", EMT: -1},
+		{Number: 2, Color: 1, SAN: "Nf3", EMT: 4},
+	}
+	env := game.getEnv("len(MoveList) > 2")
+	if got := env["MoveList"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("getEnv() MoveList = %+v, want %+v", got, want)
+	}
+	if env["Moves"] != 2 {
+		t.Errorf("getEnv() Moves = %v, want 2", env["Moves"])
+	}
+
+	// moves are given only to expressions which use them
+	if _, ok := game.getEnv("Moves > 1", "White == 'Lasker'")["MoveList"]; ok {
+		t.Errorf("getEnv() gave MoveList to expressions which do not use it")
+	}
+	for _, expression := range []string{"any(MoveList, .san == 'Nf3' && .emt > 3)", "Moves == 2 && MoveList[1].comment == 'Sicilian'"} {
+		if ok, err := game.Filter(expression); err != nil || !ok {
+			t.Errorf("Filter(%q) = %v (error = %v), want true", expression, ok, err)
+		}
+	}
+}
+
+func TestPgnGame_matchOpeningLine(t *testing.T) {
+
+	game, err := ParseGame(`[Event "Opening"]
+
+1. Nf3 d6 2. e4 c5+ 3. Bb5+ Nc6 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	tests := []struct {
+		line           string
+		transpositions bool
+		want           bool
+	}{
+		{"1.Nf3 d6 2.e4", false, true},
+		{"1. Nf3 d6 2. e4 c5 3. Bb5+", false, true},
+		{"1.e4 c5 2.Nf3 d6", false, false},
+		{"1.e4 c5 2.Nf3 d6", true, true},
+		{"1.e4 c5 2.Nf3 d6 3.Bb5", true, true},
+		{"1.e4 c5 2.Nf3 d6 3.Bc4", true, false},
+		{"1.e4 e5 2.Nf3 d6", true, false},
+		{"1.Nf3 d6 2.e4 c5 3.Bb5 Nc6 4.O-O", false, false},
+	}
+	for _, tt := range tests {
+		got, err := game.matchOpeningLine(tt.line, tt.transpositions)
+		if err != nil {
+			t.Fatalf("matchOpeningLine(%v, %v) error = %v", tt.line, tt.transpositions, err)
+		}
+		if got != tt.want {
+			t.Errorf("matchOpeningLine(%v, %v) = %v, want %v", tt.line, tt.transpositions, got, tt.want)
+		}
+	}
+
+	// lines without moves are rejected
+	if _, err := game.matchOpeningLine("1.", false); err == nil {
+		t.Errorf("matchOpeningLine(1.) error = nil")
+	}
+}
+
+func TestPgnGame_TagHelpers(t *testing.T) {
+
+	game, err := ParseGame(`[Event "Brilliancy & co."]
+[LaTeXHeader "\textbf{Game of the year}"]
+
+1. e4 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if !game.HasTag("Event") || game.HasTag("Brilliancy") {
+		t.Errorf("HasTag() = %v, %v, want true, false", game.HasTag("Event"), game.HasTag("Brilliancy"))
+	}
+	if got := game.TagOr("Event", "?"); got != `Brilliancy \& co.` {
+		t.Errorf("TagOr(Event) = %v", got)
+	}
+	if got := game.TagOr("Brilliancy", "First prize"); got != "First prize" {
+		t.Errorf("TagOr(Brilliancy) = %v", got)
+	}
+	if got := game.RawTag("LaTeXHeader"); got != `\textbf{Game of the year}` {
+		t.Errorf("RawTag(LaTeXHeader) = %v", got)
+	}
+	if got := game.RawTag("Brilliancy"); got != "" {
+		t.Errorf("RawTag(Brilliancy) = %v", got)
+	}
+}
+
+func TestPgnGame_Replay(t *testing.T) {
+
+	game, err := ParseGame(`[Event "Replay"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 1/2-1/2`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	// the boards given are the same computed when realizing the game, though
+	// the game is not realized
+	var plies []int
+	var fens []string
+	err = game.Replay(func(ply int, move PgnMove, board PgnBoard) error {
+		plies = append(plies, ply)
+		fens = append(fens, board.FEN())
+		if move.longAlgebraic == (longAlgebraic{}) {
+			t.Errorf("Replay() move %v at ply %v without long algebraic notation", move.shortAlgebraic, ply)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if game.Realized() != 0 {
+		t.Errorf("Replay() realized %v plies, want 0", game.Realized())
+	}
+	if !slices.Equal(plies, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("Replay() plies = %v", plies)
+	}
+	if err := game.play(); err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+	for idx, fen := range fens {
+		if want := game.boards[idx+1].FEN(); fen != want {
+			t.Errorf("Replay() FEN at ply %v = %v, want %v", idx+1, fen, want)
+		}
+	}
+
+	// replaying stops with the first error returned by the function
+	stop := errors.New("stop")
+	count := 0
+	err = game.Replay(func(ply int, move PgnMove, board PgnBoard) error {
+		count++
+		if ply == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 3 {
+		t.Errorf("Replay() = %v after %v plies, want %v after 3 plies", err, count, stop)
+	}
+
+	// and illegal moves are reported
+	game, err = ParseGame(`[Event "Replay"]
+
+1. e4 e5 2. Ke3 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	var illegal *ErrIllegalMove
+	if err := game.Replay(func(int, PgnMove, PgnBoard) error { return nil }); !errors.As(err, &illegal) || illegal.Ply != 3 {
+		t.Errorf("Replay() error = %v, want an illegal move at ply 3", err)
+	}
+}
+
+func TestPgnGame_FENAt(t *testing.T) {
+
+	game, err := ParseGame(`[Event "FEN"]
+[Result "*"]
+
+1. e4 c5 2. Nf3 d6 3. Bb5+ Nc6 4. O-O *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	tests := []struct {
+		ply int
+		fen string
+	}{
+		{0, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{2, "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2"},
+		{7, "r1bqkbnr/pp2pppp/2np4/1Bp5/4P3/5N2/PPPP1PPP/RNBQ1RK1 b kq - 3 4"},
+	}
+	for _, tt := range tests {
+		fen, err := game.FENAt(tt.ply)
+		if err != nil {
+			t.Fatalf("FENAt(%v) error = %v", tt.ply, err)
+		}
+		if fen != tt.fen {
+			t.Errorf("FENAt(%v) = %v, want %v", tt.ply, fen, tt.fen)
+		}
+	}
+
+	// plies out of range are rejected
+	for _, ply := range []int{-1, 8} {
+		if _, err := game.FENAt(ply); err == nil {
+			t.Errorf("FENAt(%v) error = nil", ply)
+		}
+	}
+
+	// and boards without a FEN code are given default values
+	if fen := (&PgnBoard{}).ToFEN(); fen != "8/8/8/8/8/8/8/8 w - - 0 1" {
+		t.Errorf("ToFEN() = %v", fen)
+	}
+}
+
+func TestPgnGame_RealizeFromFEN(t *testing.T) {
+
+	// games given with a FEN tag start from that position, even with Black
+	// to move
+	game, err := ParseGame(`[Event "Endgame"]
+[SetUp "1"]
+[FEN "8/8/8/4k3/8/8/4P3/4K3 b - - 3 40"]
+[Result "*"]
+
+40... Kd5 41. e4+ Kxe4 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	for ply, want := range []string{
+		"8/8/8/4k3/8/8/4P3/4K3 b - - 3 40",
+		"8/8/8/3k4/8/8/4P3/4K3 w - - 4 41",
+		"8/8/8/3k4/4P3/8/8/4K3 b - e3 0 41",
+		"8/8/8/8/4k3/8/8/4K3 w - - 0 42",
+	} {
+		if fen, err := game.FENAt(ply); err != nil || fen != want {
+			t.Errorf("FENAt(%v) = %v (error = %v), want %v", ply, fen, err, want)
+		}
+	}
+
+	// unless it is explicitly disabled
+	game.tags["SetUp"] = "0"
+	game.boards = nil
+	if fen, err := game.FENAt(0); err != nil || fen != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" {
+		t.Errorf("FENAt(0) = %v (error = %v) with SetUp 0", fen, err)
+	}
+}
+
+func TestPgnGame_Validate(t *testing.T) {
+
+	// ambiguous moves are reported along with the first illegal move, which
+	// stops the validation
+	game, err := ParseGame(`[Event "Validate"]
+
+1. e4 e5 2. Nc3 Nc6 3. Ne2 Nf6 4. Qh8 Nxe4 5. Qxe4 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	errs, err := game.Validate()
+	if err != nil || len(errs) != 2 {
+		t.Fatalf("Validate() = %v (error = %v), want 2 errors", errs, err)
+	}
+	if !errors.Is(errs[0], ErrAmbiguousMove) || errs[0].Ply != 5 || errs[0].Move != "Ne2" ||
+		errs[0].FEN != "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/2N5/PPPP1PPP/R1BQKBNR w KQkq - 2 3" {
+		t.Errorf("Validate() = %+v, want an ambiguous move in ply 5", *errs[0])
+	}
+	if errors.Is(errs[1], ErrAmbiguousMove) || errs[1].Ply != 7 || errs[1].Move != "Qh8" {
+		t.Errorf("Validate() = %+v, want an illegal move in ply 7", *errs[1])
+	}
+
+	// validating a game does not realize it, and qualified moves are not
+	// ambiguous
+	if game.Realized() != 0 {
+		t.Errorf("Validate() realized %v plies", game.Realized())
+	}
+	game, err = ParseGame(`[Event "Validate"]
+
+1. e4 e5 2. Nc3 Nc6 3. Nge2 Nf6 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if errs, err := game.Validate(); err != nil || len(errs) != 0 {
+		t.Errorf("Validate() = %v (error = %v), want no errors", errs, err)
+	}
+}
+
+func TestPgnGame_RealizeChess960(t *testing.T) {
+
+	// castling rights can be given either in Shredder-FEN or X-FEN and rooks
+	// can be placed anywhere, even in the target square of the king
+	for fen, test := range map[string]struct {
+		moves string
+		want  []string
+	}{
+		"1r2k1r1/8/8/8/8/8/8/1R2K1R1 w GBgb - 0 1": {
+			moves: "1. O-O O-O-O *",
+			want: []string{
+				"1r2k1r1/8/8/8/8/8/8/1R3RK1 b gb - 1 1",
+				"2kr2r1/8/8/8/8/8/8/1R3RK1 w - - 2 2",
+			},
+		},
+		"rk4r1/8/8/8/8/8/8/RK4R1 w KQkq - 0 1": {
+			moves: "1. O-O-O Rh8 *",
+			want: []string{
+				"rk4r1/8/8/8/8/8/8/2KR2R1 b kq - 1 1",
+				"rk5r/8/8/8/8/8/8/2KR2R1 w q - 2 2",
+			},
+		},
+	} {
+		game, err := ParseGame(fmt.Sprintf(`[Variant "Chess960"]
+[FEN "%v"]
+[Result "*"]
+
+%v`, fen, test.moves))
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		for ply, want := range append([]string{fen}, test.want...) {
+			if got, err := game.FENAt(ply); err != nil || got != want {
+				t.Errorf("FENAt(%v) = %v (error = %v), want %v", ply, got, err, want)
+			}
+		}
+	}
+
+	// castling is not possible without the corresponding rights
+	game, err := ParseGame(`[Variant "Chess960"]
+[FEN "1r2k1r1/8/8/8/8/8/8/1R2K1R1 w Bgb - 0 1"]
+[Result "*"]
+
+1. O-O *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if err := game.Realize(-1); err == nil {
+		t.Errorf("Realize() expected an error when castling without rights")
+	}
+
+	// and Chess960 games must always provide their initial position
+	game, err = ParseGame(`[Variant "Chess960"]
+[Result "*"]
+
+1. e4 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if err := game.Realize(-1); err == nil {
+		t.Errorf("Realize() expected an error without a FEN tag")
+	}
+}
+
+func TestPgnGame_RealizeFromPosition(t *testing.T) {
+
+	// lichess exports games of standard chess started from a custom position
+	// with the variant "From Position"
+	game, err := ParseGame(`[Variant "From Position"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+[SetUp "1"]
+[Result "*"]
+
+1. e4 Kd7 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if variant, err := game.Variant(); err != nil || variant.Name() != "Standard" {
+		t.Fatalf("Variant() = %v (error = %v), want Standard", variant, err)
+	}
+	if got, err := game.FENAt(2); err != nil || got != "8/3k4/8/8/4P3/8/8/4K3 w - - 1 2" {
+		t.Errorf("FENAt(2) = %v (error = %v)", got, err)
+	}
+
+	// while variants which are not registered are still rejected
+	game, err = ParseGame(`[Variant "Crazyhouse"]
+[Result "*"]
+
+1. e4 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if err := game.Realize(-1); err == nil {
+		t.Errorf("Realize() expected an error with an unknown variant")
+	}
+}
+
+func TestNewPgnBoardFromFEN(t *testing.T) {
+
+	// both complete FEN codes and those without counters are accepted
+	for fen, want := range map[string]string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1": "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"4k3/8/8/8/8/8/8/R3K2R w KQ -":                             "4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+	} {
+		board, err := NewPgnBoardFromFEN(fen)
+		if err != nil {
+			t.Fatalf("NewPgnBoardFromFEN(%v) error = %v", fen, err)
+		}
+		if board.ToFEN() != want || board.FEN() != want {
+			t.Errorf("NewPgnBoardFromFEN(%v) = %v, want %v", fen, board.ToFEN(), want)
+		}
+	}
+
+	// and malformed ones are rejected
+	for _, fen := range []string{
+		"",
+		"8/8/8/8/8/8/8/8 w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 x - - 0 1",
+		"4k3/8/8/8/8/8/8/4K4 w - - 0 1",
+		"4k3/8/8/8/8/8/4K3 w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 w KX - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 w - e4 0 1",
+		"4k3/8/8/8/8/8/8/4K3 w - - -1 1",
+		"4k3/8/8/8/8/8/8/4X3 w - - 0 1",
+	} {
+		if _, err := NewPgnBoardFromFEN(fen); err == nil {
+			t.Errorf("NewPgnBoardFromFEN(%v) error = nil", fen)
+		}
+	}
+}
+
+func TestPgnGame_Window(t *testing.T) {
+
+	game, err := ParseGame(`[Event "Test"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 1-0`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	tests := []struct {
+		from, to int
+		fen      string
+		movetext string
+		result   string
+	}{
+		{3, 5, "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2", "2... Nc6 3. Bb5", "*"},
+		{4, 6, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3", "3. Bb5 a6", "1-0"},
+		{0, 0, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "", "*"},
+	}
+	for _, tt := range tests {
+		window, err := game.Window(tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("Window(%v, %v) error = %v", tt.from, tt.to, err)
+		}
+		if fen := window.tags["FEN"]; fen != tt.fen {
+			t.Errorf("Window(%v, %v) FEN = %v, want %v", tt.from, tt.to, fen, tt.fen)
+		}
+		if window.MoveText() != tt.movetext {
+			t.Errorf("Window(%v, %v) movetext = %q, want %q", tt.from, tt.to, window.MoveText(), tt.movetext)
+		}
+		if result := window.Outcome().String(); result != tt.result || window.tags["Result"] != tt.result {
+			t.Errorf("Window(%v, %v) result = %v (tag %v), want %v", tt.from, tt.to, result, window.tags["Result"], tt.result)
+		}
+		if window.tags["Event"] != "Test" || len(window.boards) != tt.to-tt.from+1 {
+			t.Errorf("Window(%v, %v) = %v", tt.from, tt.to, window)
+		}
+	}
+
+	// the tags of the game are not modified
+	if _, ok := game.tags["FEN"]; ok {
+		t.Errorf("Window() modified the tags of the game: %v", game.tags)
+	}
+
+	// and invalid ranges are rejected
+	for _, plies := range [][2]int{{-1, 2}, {3, 2}, {0, 7}} {
+		if _, err := game.Window(plies[0], plies[1]); err == nil {
+			t.Errorf("Window(%v, %v) error = nil, want an error", plies[0], plies[1])
+		}
+	}
+}
+
 // Local Variables:
 // mode:go
 // fill-column:80
@@ -0,0 +1,121 @@
+// -*- coding: utf-8 -*-
+// pgngroups.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:48:05.251350047 (1792169285)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A group consists of consecutive games of a collection with the same value of
+// a tag, e.g., all games of the same round. Because the group is a collection
+// of games itself, all methods of collections are available in templates as
+// well, e.g., {{range .GetGames}}. When a collection is processed in chunks, a
+// group might span several of them. In this case, Continued is true for the
+// first group of every chunk but the first, and Continues is true for the last
+// group of every chunk but the last, so that templates can write the header
+// and footer of every group only once
+type PgnGroup struct {
+	*PgnCollection
+	Tag       string // name of the tag used to group games
+	Name      string // value of the tag in all games of this group
+	Continued bool   // whether this group started in the previous chunk
+	Continues bool   // whether this group goes on in the next chunk
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the groups of consecutive games of this collection with the same
+// value of the given tag, in the same order they are found in the collection.
+// Games without the tag belong to the group named "?". Games are not sorted,
+// so that collections should be sorted by the same tag first to gather all its
+// games in the same group. This is intended to be used in templates to write
+// sections of games with headers and footers, e.g.:
+//
+//	{{range .GroupBy "Round"}}{{if not .Continued}}Round {{.Name}}{{end}}
+//	{{range .GetGames}}...{{end}}{{end}}
+func (c PgnCollection) GroupBy(tag string) []PgnGroup {
+
+	groups := make([]PgnGroup, 0)
+	for idx := range c.slice {
+
+		// start a new group with the first game and every time the value of
+		// the tag changes. Games are shared with this collection
+		name := c.slice[idx].getTag(tag)
+		if len(groups) == 0 || groups[len(groups)-1].Name != name {
+			groups = append(groups, PgnGroup{
+				PgnCollection: &PgnCollection{slice: c.slice[idx:idx]},
+				Tag:           tag,
+				Name:          name,
+			})
+		}
+		group := groups[len(groups)-1].PgnCollection
+		group.slice = group.slice[:len(group.slice)+1]
+		group.nbGames++
+	}
+
+	// finally, the first and last groups might span adjacent chunks
+	if len(groups) > 0 {
+		groups[0].Continued = c.prev != nil && c.prev.getTag(tag) == groups[0].Name
+		last := &groups[len(groups)-1]
+		last.Continues = c.next != nil && c.next.getTag(tag) == last.Name
+	}
+	return groups
+}
+
+// Return a new collection for every different result of evaluating the given
+// expression over all games of this collection, e.g., "White", "ECO[:1]" or
+// "Date[:7]" to group games by player, ECO volume or month respectively.
+// Collections are indexed by the result of the expression written as a
+// string, and they contain all games with the same result in the same order
+// they are found in this collection and with their same ids. Unlike GroupBy,
+// games need not be consecutive to belong to the same collection.
+//
+// In case the expression requires the boards of games (e.g., FEN), games are
+// played first. Games are evaluated in parallel with the number of workers
+// given WithWorkers. Note that Split, instead, splits collections randomly in
+// two parts
+func (c PgnCollection) SplitBy(expression string, opts ...PgnOption) (map[string]*PgnCollection, error) {
+
+	// compute the key of every game. Because every worker accesses a
+	// different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	boards := needsBoards(expression)
+	keys := make([]string, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) (err error) {
+		if boards {
+			if err = c.slice[idx].play(); err != nil {
+				return
+			}
+		}
+		keys[idx], err = c.slice[idx].getResult(expression)
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	// and add every game to the collection of its key. All collections
+	// share the id base of this one so that games keep their ids
+	collections := make(map[string]*PgnCollection)
+	for idx, igame := range c.slice {
+		collection, ok := collections[keys[idx]]
+		if !ok {
+			created := NewPgnCollection()
+			created.idBase = c.idBase
+			collection = &created
+			collections[keys[idx]] = collection
+		}
+		collection.Add(igame)
+	}
+	return collections, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,134 @@
+// -*- coding: utf-8 -*-
+// pgngroups_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:48:29.952124532 (1792169309)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPgnCollection_GroupBy(t *testing.T) {
+
+	// games of the same round are consecutive except the last one, and the
+	// round of the fourth game is unknown
+	c := NewPgnCollection()
+	for _, round := range []string{"1", "1", "2", "", "3", "3", "3", "1"} {
+		game := PgnGame{tags: map[string]any{}}
+		if round != "" {
+			game.tags["Round"] = round
+		}
+		c.Add(game)
+	}
+	var names []string
+	var sizes []int
+	for _, group := range c.GroupBy("Round") {
+		names = append(names, group.Name)
+		sizes = append(sizes, group.Len())
+		if group.Continued || group.Continues {
+			t.Errorf("GroupBy() group %v spans chunks", group.Name)
+		}
+	}
+	if want := []string{"1", "2", "?", "3", "1"}; strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("GroupBy() = %v, want %v", names, want)
+	}
+	if sizes[0] != 2 || sizes[3] != 3 || sizes[4] != 1 {
+		t.Errorf("GroupBy() sizes = %v, want [2 1 1 3 1]", sizes)
+	}
+
+	// headers and footers of groups spanning several chunks are written only
+	// once. Every execution of the template ends with a newline which is
+	// ignored
+	templateFile := filepath.Join(t.TempDir(), "groups.tpl")
+	contents := `{{range .GroupBy "Round"}}{{if not .Continued}}[{{.Name}}:{{end}}{{range .GetGames}} {{.Id}}{{end}}{{if not .Continues}}]{{end}}{{end}}`
+	if err := os.WriteFile(templateFile, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{1, 2, 3, 8} {
+		var builder strings.Builder
+		if err := c.GamesToWriterFromTemplateParallel(&builder, templateFile, size, 2); err != nil {
+			t.Fatal(err)
+		}
+		got := strings.ReplaceAll(builder.String(), "\n", "")
+		if want := "[1: 1 2][2: 3][?: 4][3: 5 6 7][1: 8]"; got != want {
+			t.Errorf("GamesToWriterFromTemplateParallel(%v) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestPgnCollection_SplitBy(t *testing.T) {
+
+	// games are numbered from 10 so that it can be checked that they keep
+	// their ids
+	c := NewPgnCollection()
+	c.SetIdBase(10)
+	for _, pgn := range []string{
+		"[White \"a/b\"]\n[ECO \"C20\"]\n\n1. e4 e5 *",
+		"[White \"a_b\"]\n[ECO \"D02\"]\n\n1. d4 d5 *",
+		"[White \"a/b\"]\n[ECO \"A40\"]\n\n1. Nf3 d5 2. e4 *",
+		"[White \"\"]\n[ECO \"C44\"]\n\n1. e4 e5 2. Nf3 *",
+		"[White \"a_b\"]\n[ECO \"A10\"]\n\n1. c4 *",
+	} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       map[string][]int
+	}{
+		{"Tag", "White", map[string][]int{"a/b": {10, 12}, "a_b": {11, 14}, "": {13}}},
+		{"Slice", "ECO[:1]", map[string][]int{"A": {12, 14}, "C": {10, 13}, "D": {11}}},
+		{"Single", "1", map[string][]int{"1": {10, 11, 12, 13, 14}}},
+
+		// games where white has a pawn on e4 with black to move
+		{"Board", `FEN("*8/*8/*8/*8/4P3/*8/*8/*8 b * * * *")`, map[string][]int{"true": {10, 12, 13}, "false": {11, 14}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collections, err := c.SplitBy(tt.expression, WithWorkers(3))
+			if err != nil {
+				t.Fatalf("SplitBy(%q) error = %v", tt.expression, err)
+			}
+			got := make(map[string][]int)
+			for key, collection := range collections {
+				got[key] = collectionIds(*collection)
+			}
+			if !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("SplitBy(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+
+	// wrong expressions are reported
+	if _, err := c.SplitBy("White +"); err == nil {
+		t.Errorf("SplitBy() expected an error")
+	}
+}
+
+func TestUniqueFileNames(t *testing.T) {
+
+	values := []string{"a/b", "a_b", "a_b-1", "", "_", "Carlsen, M."}
+	want := []string{"a_b", "a_b-1", "a_b-1-1", "_", "_-1", "Carlsen,_M."}
+	if got := UniqueFileNames(values); !slices.Equal(got, want) {
+		t.Errorf("UniqueFileNames() = %v, want %v", got, want)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
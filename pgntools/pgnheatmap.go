@@ -0,0 +1,197 @@
+// -*- coding: utf-8 -*-
+// pgnheatmap.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:48:56.456044577 (1792165736)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+
+	"github.com/clinaresl/table"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Heatmaps count either how often squares are occupied by a piece or how often
+// the piece moves to them
+type HeatmapMode int
+
+// A heatmap counts, for every square, the number of times it is occupied or
+// visited by a piece, given with its FEN symbol, e.g., 'N' for white knights
+// and 'n' for black knights. Counts are given in an 8x8 matrix indexed by rank
+// and file, so that Counts[0][0] refers to a1 and Counts[7][7] to h8
+type PgnHeatmap struct {
+	Piece  string
+	Mode   HeatmapMode
+	Counts [8][8]int
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Squares are occupied by a piece in every position where it stands on them,
+// including the initial position of games, and they are visited by a piece
+// every time it is moved to them, which includes the rook when castling and
+// pieces given when promoting pawns
+const (
+	OccupiedSquares HeatmapMode = iota
+	VisitedSquares
+)
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a string with the name of this mode
+func (mode HeatmapMode) String() string {
+	switch mode {
+	case OccupiedSquares:
+		return "occupied"
+	case VisitedSquares:
+		return "visited"
+	}
+	return "unknown"
+}
+
+// Return the largest count of this heatmap
+func (heatmap PgnHeatmap) maxCount() (count int) {
+	for _, rank := range heatmap.Counts {
+		for _, value := range rank {
+			count = max(count, value)
+		}
+	}
+	return
+}
+
+// Add to this heatmap the counts of the given game, which is replayed without
+// realizing it
+func (heatmap *PgnHeatmap) add(game *PgnGame, piece content) error {
+
+	board, err := game.initialBoard()
+	if err != nil {
+		return err
+	}
+	if heatmap.Mode == OccupiedSquares {
+		heatmap.addBoard(board, piece)
+	}
+	previous := board
+	return game.Replay(func(ply int, move PgnMove, board PgnBoard) error {
+		if heatmap.Mode == OccupiedSquares {
+			heatmap.addBoard(board, piece)
+		} else {
+
+			// the piece visits every square where it is found now but not in
+			// the previous position
+			for square, value := range board.squares {
+				if value == piece && previous.squares[square] != piece {
+					heatmap.Counts[square/8][square%8]++
+				}
+			}
+		}
+		previous = board
+		return nil
+	})
+}
+
+// Add to this heatmap all squares occupied by the given piece in the given
+// board
+func (heatmap *PgnHeatmap) addBoard(board PgnBoard, piece content) {
+	for square, value := range board.squares {
+		if value == piece {
+			heatmap.Counts[square/8][square%8]++
+		}
+	}
+}
+
+// Heatmaps are stringers that show the counts of all squares in a table with
+// the eighth rank at the top, as seen by White
+func (heatmap PgnHeatmap) String() string {
+
+	tab, _ := table.NewTable(" c | r r r r r r r r ")
+	tab.AddRow(fmt.Sprintf("%v (%v)", heatmap.Piece, heatmap.Mode), "a", "b", "c", "d", "e", "f", "g", "h")
+	tab.AddThickRule()
+	for rank := 7; rank >= 0; rank-- {
+		row := []any{rank + 1}
+		for _, value := range heatmap.Counts[rank] {
+			row = append(row, value)
+		}
+		tab.AddRow(row...)
+	}
+	return fmt.Sprintf("%v", tab)
+}
+
+// Produces a LaTeX string with a TikZ picture of this heatmap, where every
+// square is drawn with the given size, e.g., "0.8cm", and it is shaded in
+// proportion to its count, which is also shown. Templates using it have to load
+// the package tikz (which is also loaded by pgfplots).
+//
+// It is intended to be used in LaTeX templates
+func (heatmap PgnHeatmap) GetLaTeX(size string) (output string) {
+
+	output += fmt.Sprintf(`\begin{tikzpicture}[x=%v, y=%v]`, size, size) + "\n"
+	top := max(1, heatmap.maxCount())
+	for rank := 0; rank < 8; rank++ {
+		for file := 0; file < 8; file++ {
+			count := heatmap.Counts[rank][file]
+			output += fmt.Sprintf(`\fill[red!%v] (%v,%v) rectangle ++(1,1);`, 100*count/top, file, rank)
+			output += fmt.Sprintf(` \node[font=\tiny] at (%v.5,%v.5) {%v};`, file, rank, count) + "\n"
+		}
+	}
+	output += `\draw (0,0) grid (8,8);` + "\n"
+
+	// and show the coordinates of all files and ranks
+	for idx := 0; idx < 8; idx++ {
+		output += fmt.Sprintf(`\node[font=\footnotesize] at (%v.5,-0.3) {%c};`, idx, 'a'+idx)
+		output += fmt.Sprintf(` \node[font=\footnotesize] at (-0.3,%v.5) {%v};`, idx, idx+1) + "\n"
+	}
+	output += `\end{tikzpicture}` + "\n"
+	return
+}
+
+// Return a heatmap with the number of times that every square is occupied or
+// visited, as given in mode, by the given piece in all games of this
+// collection, e.g., "N" for white knights. Games are replayed without realizing
+// them in parallel with the number of workers given WithWorkers. It returns an
+// error if the piece is not known or a game could not be played
+func (c PgnCollection) GetHeatmap(piece string, mode HeatmapMode, opts ...PgnOption) (*PgnHeatmap, error) {
+
+	runes := []rune(piece)
+	if len(runes) != 1 {
+		return nil, fmt.Errorf(" Unknown piece '%v'", piece)
+	}
+	value, ok := fenPieces[runes[0]]
+	if !ok {
+		return nil, fmt.Errorf(" Unknown piece '%v'", piece)
+	}
+
+	// compute the heatmap of every game separately. Because every worker
+	// accesses a different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	heatmaps := make([]PgnHeatmap, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		heatmaps[idx].Mode = mode
+		return heatmaps[idx].add(&c.slice[idx], value)
+	}); err != nil {
+		return nil, err
+	}
+
+	// and add them all
+	heatmap := PgnHeatmap{Piece: piece, Mode: mode}
+	for _, iheatmap := range heatmaps {
+		for rank := range heatmap.Counts {
+			for file := range heatmap.Counts[rank] {
+				heatmap.Counts[rank][file] += iheatmap.Counts[rank][file]
+			}
+		}
+	}
+	return &heatmap, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,80 @@
+// -*- coding: utf-8 -*-
+// pgnheatmap_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:49:10.806102866 (1792165750)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPgnCollection_GetHeatmap(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, pgn := range []string{
+		`[Event "A"]
+
+1. Nf3 Nc6 2. Ng1 Nb8 *`,
+		`[Event "B"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Nf6 4. O-O *`,
+	} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	// unknown pieces are rejected
+	for _, piece := range []string{"", "X", "NN"} {
+		if _, err := c.GetHeatmap(piece, OccupiedSquares); err == nil {
+			t.Errorf("GetHeatmap(%q) error = nil", piece)
+		}
+	}
+
+	// every square is given by its rank and file, so that b1 is [0][1]
+	tests := []struct {
+		piece string
+		mode  HeatmapMode
+		want  map[[2]int]int
+	}{
+		{"N", OccupiedSquares, map[[2]int]int{{0, 1}: 13, {0, 6}: 6, {2, 5}: 7}},
+		{"N", VisitedSquares, map[[2]int]int{{0, 6}: 1, {2, 5}: 2}},
+		{"R", VisitedSquares, map[[2]int]int{{0, 5}: 1}},
+		{"k", VisitedSquares, map[[2]int]int{}},
+	}
+	for _, tt := range tests {
+		heatmap, err := c.GetHeatmap(tt.piece, tt.mode, WithWorkers(2))
+		if err != nil {
+			t.Fatalf("GetHeatmap(%v, %v) error = %v", tt.piece, tt.mode, err)
+		}
+		for rank := range heatmap.Counts {
+			for file, count := range heatmap.Counts[rank] {
+				if want := tt.want[[2]int{rank, file}]; count != want {
+					t.Errorf("GetHeatmap(%v, %v) count of %c%v = %v, want %v",
+						tt.piece, tt.mode, 'a'+file, rank+1, count, want)
+				}
+			}
+		}
+	}
+
+	// and heatmaps can be shown as text or LaTeX
+	heatmap, _ := c.GetHeatmap("N", OccupiedSquares)
+	if got := heatmap.String(); !strings.Contains(got, "N (occupied)") {
+		t.Errorf("String() = %v", got)
+	}
+	if got := heatmap.GetLaTeX("0.5cm"); !strings.Contains(got, `\fill[red!100] (1,0) rectangle ++(1,1); \node[font=\tiny] at (1.5,0.5) {13};`) {
+		t.Errorf("GetLaTeX() = %v", got)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -21,6 +21,7 @@ package pgntools
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/clinaresl/table"
 )
@@ -35,12 +36,25 @@ import (
 // occurrences of all variables/boolean expressions from the root to it.
 //
 // In addition, a histogram contains the total number of observations stored in
-// it so that percentages can be computed for every inner/leaf node
+// it so that percentages can be computed for every inner/leaf node, and the
+// perspective from which games are scored, see SetPerspective
 type PgnHistogram struct {
-	names    []string
-	criteria []string
-	data     map[string]any
+	names       []string
+	criteria    []string
+	data        map[string]any
+	nbhits      uint64
+	perspective string
+}
+
+// Leaves of histograms store the number of observations along with the score
+// of all games that reached them. Points are computed from the perspective of
+// the histogram, and only those games which were properly ended (i.e., with an
+// outcome other than '*') and played by the player of the perspective, if any,
+// are scored, so that nbscored might be less than nbhits
+type histogramLeaf struct {
 	nbhits   uint64
+	nbscored uint64
+	points   float64
 }
 
 // Functions
@@ -134,6 +148,15 @@ func lessLine(sl1, sl2 []any) bool {
 // Methods
 // ----------------------------------------------------------------------------
 
+// Return the average number of points per scored game in this leaf and true,
+// or false if no game was scored in it
+func (leaf histogramLeaf) average() (float64, bool) {
+	if leaf.nbscored == 0 {
+		return 0, false
+	}
+	return leaf.points / float64(leaf.nbscored), true
+}
+
 // Return a brand new PgnHistogram defined with a string, which consists of a
 // semicolon list of variables/boolean expressions in the form: "<var/expr>+".
 // At least one should be given, and an arbitrary number of them can be
@@ -181,10 +204,10 @@ func NewPgnHistogram(spec string) (*PgnHistogram, error) {
 	}, nil
 }
 
-// Return the nbhits that are reached by using all values in the given sequence.
+// Return the leaf that is reached by using all values in the given sequence.
 // This function assumes that such value can be effectively achieved by using
 // the given sequence
-func (histogram PgnHistogram) getHits(sequence []any) uint64 {
+func (histogram PgnHistogram) getHits(sequence []any) histogramLeaf {
 
 	// The implementation is performed iteratively
 	data := histogram.data
@@ -195,27 +218,79 @@ func (histogram PgnHistogram) getHits(sequence []any) uint64 {
 	}
 
 	// Once the last value has been found, just return it
-	return data[sequence[len(sequence)-1].(string)].(uint64)
+	return data[sequence[len(sequence)-1].(string)].(histogramLeaf)
+}
+
+// Return the result of evaluating all criteria of this histogram in the given
+// game, and nil if no error was found
+func (histogram PgnHistogram) getResults(game PgnGame) ([]string, error) {
+
+	results := make([]string, len(histogram.criteria))
+	for idx, icriteria := range histogram.criteria {
+		result, err := game.getResult(icriteria)
+		if err != nil {
+			return nil, err
+		}
+		results[idx] = result
+	}
+	return results, nil
+}
+
+// Set the perspective from which games are scored in this histogram: either
+// "White" (the default) or "Black" to score games with the points of the given
+// color, or the name of a player to score games with the points of that player,
+// whatever the color. In the latter case, games which were not played by the
+// given player are not scored. Colors are case insensitive
+func (histogram *PgnHistogram) SetPerspective(perspective string) {
+	histogram.perspective = perspective
+}
+
+// Return the points of the given game from the perspective of this histogram
+// and true, or false if the game is not scored, either because it was not
+// properly ended or because it was not played by the player of the perspective
+func (histogram PgnHistogram) score(game PgnGame) (float32, bool) {
+
+	switch perspective := strings.ToLower(histogram.perspective); {
+	case perspective == "" || perspective == "white":
+		return game.outcome.Score(1)
+	case perspective == "black":
+		return game.outcome.Score(-1)
+	case game.getTag("White") == histogram.perspective:
+		return game.outcome.Score(1)
+	case game.getTag("Black") == histogram.perspective:
+		return game.outcome.Score(-1)
+	}
+	return 0, false
 }
 
 // Updates this histogram with information in the given game, and nil if no
 // error was found
 func (histogram *PgnHistogram) Add(game PgnGame) error {
 
+	// evaluate all criteria in the given game
+	results, err := histogram.getResults(game)
+	if err != nil {
+		return err
+	}
+
+	// and add a new observation with them
+	histogram.addResults(results, game)
+	return nil
+}
+
+// Add a new observation to this histogram with the results of evaluating all
+// its criteria in the given game
+func (histogram *PgnHistogram) addResults(results []string, game PgnGame) {
+
 	// get the map of this histogram
 	data := histogram.data
 
-	// process all criteria in this histogram
+	// process all results but the last one
 	idx := 0
-	for idx < len(histogram.criteria)-1 {
-
-		// execute the ith-criteria of this histogram
-		result, err := game.getResult(histogram.criteria[idx])
-		if err != nil {
-			return err
-		}
+	for idx < len(results)-1 {
 
 		// Next verify whether this result is already stored in the current map
+		result := results[idx]
 		if value, ok := data[result]; !ok {
 
 			// in case it did not exist, then create a nexted map[string]any and
@@ -232,42 +307,37 @@ func (histogram *PgnHistogram) Add(game PgnGame) error {
 		idx += 1
 	}
 
-	// Once the leaf has been found, then add a new observation. Do as before,
-	// evaluate the last criteria and add data to the histogram adding a new
-	// keyword if necessary
-	result, err := game.getResult(histogram.criteria[idx])
-	if err != nil {
-		return err
-	}
-
-	// Next verify whether this result is already stored in the current map
-	if _, ok := data[result]; !ok {
-
-		// in case it did not exist, then add the first observation
-		data[result] = uint64(1)
-	} else {
-
-		// otherwise, increment it
-		value := data[result].(uint64)
-		data[result] = value + 1
+	// Once the leaf has been found, then add a new observation. Next verify
+	// whether the last result is already stored in the current map. If not,
+	// the zero value of the leaf is used as the first observation
+	result := results[idx]
+	leaf, _ := data[result].(histogramLeaf)
+	leaf.nbhits += 1
+
+	// and score this game only in case it was properly ended and it is seen
+	// from the perspective of this histogram
+	if points, ok := histogram.score(game); ok {
+		leaf.nbscored += 1
+		leaf.points += float64(points)
 	}
+	data[result] = leaf
 
-	// Update the number of observations of this histogram and return with
-	// success
+	// Update the number of observations of this histogram
 	histogram.nbhits += 1
-	return nil
 }
 
 // Histograms are stringers, so that they can be shown on any writer
 func (histogram PgnHistogram) String() string {
 
-	// create a table to show the data in this histogram where all columns but
-	// first are criteria, and the last is the number of observations
+	// create a table to show the data in this histogram where the first
+	// columns are criteria, and the last three are the number of observations,
+	// the average number of points and the score percentage
 	nocols := 0
 	spec := " c "
 	for ; nocols < len(histogram.criteria); nocols++ {
 		spec += "| c "
 	}
+	spec += "| c | c "
 	tab, _ := table.NewTable(spec)
 
 	// Add next the headers of all columns
@@ -276,9 +346,9 @@ func (histogram PgnHistogram) String() string {
 		line = append(line, iname)
 	}
 
-	// add the header for the last column and add this line to the table
+	// add the header for the last columns and add this line to the table
 	// followed by a horizontal rule
-	line = append(line, "# Obs.")
+	line = append(line, "# Obs.", "Avg. Pts.", "Score")
 	tab.AddRow(line...)
 	tab.AddThickRule()
 
@@ -294,8 +364,15 @@ func (histogram PgnHistogram) String() string {
 	for _, ikey := range lines {
 
 		// And add the value of all criteria and, at the end, the number of hits
-		// for this specific combination
-		ikey = append(ikey, fmt.Sprintf("%v", histogram.getHits(ikey)))
+		// for this specific combination along with its score. If no game was
+		// scored in this leaf, then no score is shown at all
+		leaf := histogram.getHits(ikey)
+		avgStr, scoreStr := "-", "-"
+		if avg, ok := leaf.average(); ok {
+			avgStr = fmt.Sprintf("%.2f", avg)
+			scoreStr = fmt.Sprintf("%.1f%%", 100*avg)
+		}
+		ikey = append(ikey, fmt.Sprintf("%v", leaf.nbhits), avgStr, scoreStr)
 		contents = append(contents, ikey)
 	}
 
@@ -329,7 +406,7 @@ func (histogram PgnHistogram) String() string {
 			}
 
 			// And show the horizontal rule
-			tab.AddSingleRule(eqcols, nocols+1)
+			tab.AddSingleRule(eqcols, nocols+3)
 		}
 	}
 
@@ -0,0 +1,92 @@
+// -*- coding: utf-8 -*-
+// pgnhistogram_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:27:21.897481549 (1792171641)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPgnHistogram_Perspective(t *testing.T) {
+
+	// the player "A" wins all games with an outcome
+	c := NewPgnCollection()
+	for _, tags := range [][]string{
+		{"A", "B", "1-0", "A00"},
+		{"B", "A", "0-1", "A00"},
+		{"A", "C", "1-0", "A00"},
+		{"C", "A", "*", "B00"},
+		{"B", "C", "1-0", "B00"},
+	} {
+		game, err := ParseGame(fmt.Sprintf("[White %q]\n[Black %q]\n[Result %q]\n[ECO %q]\n\n1. e4 %v", tags[0], tags[1], tags[2], tags[3], tags[2]))
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	// every leaf is given with the number of games scored and the score
+	// percentage, or a dash if no game was scored
+	type leaf struct {
+		scored int
+		score  string
+	}
+	tests := []struct {
+		perspective string
+		want        map[string]leaf
+	}{
+		{"", map[string]leaf{"A00": {3, "66.7%"}, "B00": {1, "100.0%"}}},
+		{"white", map[string]leaf{"A00": {3, "66.7%"}, "B00": {1, "100.0%"}}},
+		{"Black", map[string]leaf{"A00": {3, "33.3%"}, "B00": {1, "0.0%"}}},
+		{"A", map[string]leaf{"A00": {3, "100.0%"}, "B00": {0, "-"}}},
+		{"C", map[string]leaf{"A00": {1, "0.0%"}, "B00": {1, "0.0%"}}},
+		{"D", map[string]leaf{"A00": {0, "-"}, "B00": {0, "-"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.perspective, func(t *testing.T) {
+			histogram, err := c.GetHistogram("ECO", WithPerspective(tt.perspective), WithWorkers(2))
+			if err != nil {
+				t.Fatalf("GetHistogram() error = %v", err)
+			}
+			if histogram.nbhits != 5 {
+				t.Errorf("GetHistogram() = %v observations, want 5", histogram.nbhits)
+			}
+			for key, want := range tt.want {
+				got := histogram.getHits([]any{key})
+				if int(got.nbscored) != want.scored {
+					t.Errorf("GetHistogram(%q) = %v games scored, want %v", key, got.nbscored, want.scored)
+				}
+				score := "-"
+				if avg, ok := got.average(); ok {
+					score = fmt.Sprintf("%.1f%%", 100*avg)
+				}
+				if score != want.score {
+					t.Errorf("GetHistogram(%q) = %v, want %v", key, score, want.score)
+				}
+			}
+		})
+	}
+
+	// the table shows the average points and the score of every row
+	histogram, err := c.GetHistogram("ECO", WithPerspective("A"))
+	if err != nil {
+		t.Fatalf("GetHistogram() error = %v", err)
+	}
+	for _, want := range []string{"1.00", "100.0%", "Avg. Pts."} {
+		if got := histogram.String(); !strings.Contains(got, want) {
+			t.Errorf("String() = %v, does not contain %q", got, want)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,209 @@
+// -*- coding: utf-8 -*-
+// pgnindex.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:10:34.032875306 (1792159834)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Every entry of an index locates a game in a PGN file with its id and the
+// range of bytes [Start, End) it occupies in the file
+type PgnIndexEntry struct {
+	Id    int   `json:"id"`
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// A player index maps the name of every player to the games played in a PGN
+// file, either with White or Black, so that the games of a player can be
+// extracted without parsing the whole file. Indexes can be saved in a sidecar
+// file next to the PGN file. To detect stale indexes, they also store the size
+// and modification time of the PGN file they were built from
+type PgnPlayerIndex struct {
+	Size    int64                      `json:"size"`
+	ModTime time.Time                  `json:"modtime"`
+	Players map[string][]PgnIndexEntry `json:"players"`
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the player index stored in the given file, and nil if no error was
+// found
+func LoadPgnPlayerIndex(filename string) (*PgnPlayerIndex, error) {
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var index PgnPlayerIndex
+	if err := json.Unmarshal(contents, &index); err != nil {
+		return nil, fmt.Errorf(" The index '%v' is not valid: %v", filename, err)
+	}
+	return &index, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the name of the sidecar file where the player index of this PgnFile
+// is stored by default, which is named after it with extension ".idx"
+func (f PgnFile) IndexName() string {
+	return f.name + ".idx"
+}
+
+// Return the player index of the given collection of games which have been
+// read from this PgnFile
+func (f PgnFile) PlayerIndex(games PgnCollection) *PgnPlayerIndex {
+
+	index := PgnPlayerIndex{
+		Size:    f.size,
+		ModTime: f.modtime,
+		Players: make(map[string][]PgnIndexEntry),
+	}
+	for _, igame := range games.slice {
+		entry := PgnIndexEntry{Id: igame.id, Start: igame.start, End: igame.end}
+		for _, tag := range []string{"White", "Black"} {
+			player := igame.getTag(tag)
+			index.Players[player] = append(index.Players[player], entry)
+		}
+	}
+
+	// games are sorted by their id so that they are extracted in the same
+	// order they were found
+	for _, entries := range index.Players {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Id < entries[j].Id
+		})
+	}
+	return &index
+}
+
+// Return true if this index was built from the current contents of the given
+// PgnFile, i.e., if its size and modification time did not change
+func (index PgnPlayerIndex) IsFresh(f PgnFile) bool {
+	return index.Size == f.size && index.ModTime.Equal(f.modtime)
+}
+
+// Return the names of all players in this index in lexicographical order
+func (index PgnPlayerIndex) Names() []string {
+	names := make([]string, 0, len(index.Players))
+	for name := range index.Players {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Write this index in the given file, and return nil if no error was found
+func (index PgnPlayerIndex) Save(filename string) error {
+
+	contents, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, contents, 0644)
+}
+
+// Return the games played by the given player in this PgnFile as found in the
+// given index, without reading the rest of the file. Games keep the ids stored
+// in the index. An error is returned if the index is stale or if any game
+// could not be parsed. WithParseHook is invoked with every game right after
+// parsing it, WithRange reads only the games in the given range of ids, and
+// WithMmap maps the file in memory if possible, so that games are read directly
+// from it
+func (f PgnFile) PlayerGames(index PgnPlayerIndex, player string, opts ...PgnOption) (*PgnCollection, error) {
+
+	// verify the index corresponds to the current contents of this file
+	if !index.IsFresh(f) {
+		return nil, fmt.Errorf(" The index of '%v' is stale", f.name)
+	}
+
+	// games given in formats other than PGN have no offsets, so that all of
+	// them are read and only those in the index are kept
+	if format, err := f.Format(); err != nil {
+		return nil, err
+	} else if format != PGNFormat {
+		return f.indexedGames(index, player, opts...)
+	}
+
+	// Open the PgnFile
+	options := newPgnOptions(opts...)
+	f.parseHook = options.parseHook
+	f.first, f.last = options.first, options.last
+	stream, err := os.Open(f.name)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	// games are read at their offsets, directly from memory if requested and
+	// possible
+	var reader io.ReaderAt = stream
+	if options.mmap {
+		if mapped, err := mapFile(stream); err == nil {
+			defer mapped.Close()
+			reader = mapped
+		}
+	}
+
+	// and read only the games of the given player
+	games := NewPgnCollection()
+	for _, entry := range index.Players[player] {
+		if !f.inRange(entry.Id) {
+			continue
+		}
+		buffer := make([]byte, entry.End-entry.Start)
+		if _, err := reader.ReadAt(buffer, entry.Start); err != nil {
+			return nil, err
+		}
+		game, err := f.parseGame(normalizeLine(string(buffer)), entry.Id)
+		if err != nil {
+			return nil, PgnDiagnostic{entry.Start, entry.End, err}
+		}
+		game.start, game.end = entry.Start, entry.End
+		games.Add(*game)
+	}
+
+	return &games, nil
+}
+
+// Return the games played by the given player in this PgnFile as found in the
+// given index, after reading all games in it
+func (f PgnFile) indexedGames(index PgnPlayerIndex, player string, opts ...PgnOption) (*PgnCollection, error) {
+
+	all, err := f.Games(opts...)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[int]bool)
+	for _, entry := range index.Players[player] {
+		selected[entry.Id] = true
+	}
+	games := NewPgnCollection()
+	for _, igame := range all.slice {
+		if selected[igame.id] {
+			games.Add(igame)
+		}
+	}
+	return &games, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,78 @@
+// -*- coding: utf-8 -*-
+// pgnindex_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:11:37.181309753 (1792159897)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPgnFile_PlayerGames(t *testing.T) {
+
+	// write a PGN file with three games in a temporary directory
+	contents := "\ufeff" + `[White "alice"]
+[Black "bob"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0` + "\r\n\r\n" + `[White "bob"]
+[Black "carol"]
+
+1. d4 d5 1/2-1/2
+
+[White "carol"]
+[Black "alice"]
+
+1. c4 e5 0-1
+`
+	name := filepath.Join(t.TempDir(), "games.pgn")
+	if err := os.WriteFile(name, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pgnfile, err := NewPgnFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	games, err := pgnfile.Games()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// save the index and load it back
+	if err := pgnfile.PlayerIndex(*games).Save(pgnfile.IndexName()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	index, err := LoadPgnPlayerIndex(pgnfile.IndexName())
+	if err != nil {
+		t.Fatalf("LoadPgnPlayerIndex() error = %v", err)
+	}
+	if !index.IsFresh(*pgnfile) {
+		t.Fatalf("IsFresh() = false, want true")
+	}
+
+	// and extract the games of every player
+	for player, want := range map[string][]int{"alice": {1, 3}, "bob": {1, 2}, "carol": {2, 3}, "dave": {}} {
+		extracted, err := pgnfile.PlayerGames(*index, player)
+		if err != nil {
+			t.Fatalf("PlayerGames(%v) error = %v", player, err)
+		}
+		if extracted.Len() != len(want) {
+			t.Fatalf("PlayerGames(%v) = %v games, want %v", player, extracted.Len(), len(want))
+		}
+		for idx, igame := range extracted.GetGames() {
+			if igame.id != want[idx] || len(igame.moves) != len(games.slice[want[idx]-1].moves) {
+				t.Errorf("PlayerGames(%v) game #%v has id %v and %v plies", player, idx, igame.id, len(igame.moves))
+			}
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,354 @@
+// -*- coding: utf-8 -*-
+// pgnjson.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:06:50.197699659 (1792163210)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Annotations are written in JSON format with their kind, which is either
+// "comment", "nag" or the name of the command given in comments (e.g., "clk"
+// for "[%clk 0:03:00]"), their value and whether they were given in the same
+// comment than the previous one
+type jsonAnnotation struct {
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	Joined bool   `json:"joined,omitempty"`
+}
+
+// Moves are written in JSON format with their number, color (1 for White and -1
+// for Black) and short algebraic notation along with the quality given to
+// them, and all their annotations. The long algebraic notation of moves and
+// the FEN code of the board reached after them are given only for the plies
+// which have been realized. The elapsed move time is given only if it is known.
+// Besides the comments, NAGs and elapsed move time, all annotations are given
+// in the same order they were found, including commands such as "[%clk ...]"
+// or "[%eval ...]". When reading moves from JSON, the long algebraic notation
+// and FEN codes are ignored, as they are computed when realizing games, and if
+// the annotations are given they are used instead of the comments, NAGs and
+// elapsed move time
+type jsonMove struct {
+	Number      int              `json:"number"`
+	Color       int              `json:"color"`
+	SAN         string           `json:"san"`
+	Quality     string           `json:"quality,omitempty"`
+	LAN         string           `json:"lan,omitempty"`
+	EMT         *float32         `json:"emt,omitempty"`
+	Comments    []string         `json:"comments,omitempty"`
+	NAGs        []int            `json:"nags,omitempty"`
+	Annotations []jsonAnnotation `json:"annotations,omitempty"`
+	FEN         string           `json:"fen,omitempty"`
+	Variations  [][]jsonMove     `json:"variations,omitempty"`
+}
+
+// Games are written in JSON format with their id, tags, the FEN code of the
+// initial board (if the game has been realized), the comments given before the
+// first move, their moves, the outcome and the comments given after it. As in
+// moves, all annotations given before the first move and after the outcome are
+// given as well, and they are preferred over the comments when reading games
+// from JSON. The FEN code of the initial board is ignored when reading games,
+// as it is given by their tags
+type jsonGame struct {
+	Id                  int              `json:"id"`
+	Tags                map[string]any   `json:"tags"`
+	FEN                 string           `json:"fen,omitempty"`
+	Leading             []string         `json:"leading,omitempty"`
+	LeadingAnnotations  []jsonAnnotation `json:"leadingAnnotations,omitempty"`
+	Moves               []jsonMove       `json:"moves"`
+	Outcome             string           `json:"outcome"`
+	Trailing            []string         `json:"trailing,omitempty"`
+	TrailingAnnotations []jsonAnnotation `json:"trailingAnnotations,omitempty"`
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the given annotations in JSON format
+func getJSONAnnotations(annotations []PgnAnnotation) []jsonAnnotation {
+
+	var result []jsonAnnotation
+	for _, annotation := range annotations {
+		kind := annotationCommands[annotation.Kind]
+		switch annotation.Kind {
+		case CommentAnnotation:
+			kind = "comment"
+		case NAGAnnotation:
+			kind = "nag"
+		}
+		result = append(result, jsonAnnotation{Kind: kind, Value: annotation.Value, Joined: annotation.Joined})
+	}
+	return result
+}
+
+// Return the annotations given in JSON format, and an error if the kind of any
+// is unknown
+func getPgnAnnotations(annotations []jsonAnnotation) ([]PgnAnnotation, error) {
+
+	var result []PgnAnnotation
+	for _, annotation := range annotations {
+		kind, ok := commandAnnotations[annotation.Kind]
+		switch annotation.Kind {
+		case "comment":
+			kind, ok = CommentAnnotation, true
+		case "nag":
+			kind, ok = NAGAnnotation, true
+		}
+		if !ok {
+			return nil, fmt.Errorf(" Unknown kind of annotation '%v'", annotation.Kind)
+		}
+		result = append(result, PgnAnnotation{Kind: kind, Value: annotation.Value, Joined: annotation.Joined})
+	}
+	return result, nil
+}
+
+// Return the given moves in JSON format. The given boards are those reached
+// after each move, and it might contain less boards than moves, or none at all
+func getJSONMoves(moves []PgnMove, boards []PgnBoard) []jsonMove {
+
+	result := make([]jsonMove, 0, len(moves))
+	for idx, move := range moves {
+		imove := jsonMove{
+			Number:  move.number,
+			Color:   move.color,
+			SAN:     move.shortAlgebraic,
+			Quality: move.quality,
+			NAGs:    move.Nags(),
+		}
+		if move.from != "" {
+			imove.LAN = move.from + move.to
+		}
+		if move.emt != -1 {
+			imove.EMT = &move.emt
+		}
+		imove.Comments = getCommentList(move.annotations)
+		imove.Annotations = getJSONAnnotations(move.annotations)
+		if idx < len(boards) {
+			imove.FEN = boards[idx].FEN()
+		}
+
+		// variations are not realized, so that they are written without
+		// boards
+		for _, variation := range move.variations {
+			imove.Variations = append(imove.Variations, getJSONMoves(variation, nil))
+		}
+		result = append(result, imove)
+	}
+	return result
+}
+
+// Return the game given in JSON format in the given contents, and any error
+// found. Games read from JSON are exactly the same games that would be parsed
+// from their PGN transcription, i.e., they are parsed but not realized
+func NewPgnGameFromJSON(contents []byte) (*PgnGame, error) {
+
+	var data jsonGame
+	if err := json.Unmarshal(contents, &data); err != nil {
+		return nil, err
+	}
+	return data.pgnGame()
+}
+
+// Return a collection with all games given in JSON format in the given reader
+// as an array, as written by GetJSON, and any error found. Games are read one
+// at a time, so that the whole array is never held in memory. Games keep the
+// ids given in JSON, if any
+func NewPgnCollectionFromJSON(reader io.Reader) (*PgnCollection, error) {
+
+	// verify that the contents start with an array
+	decoder := json.NewDecoder(reader)
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New(" An array of games was expected in JSON")
+	}
+
+	// and read all games in it
+	games := NewPgnCollection()
+	for decoder.More() {
+		var data jsonGame
+		if err := decoder.Decode(&data); err != nil {
+			return nil, err
+		}
+		game, err := data.pgnGame()
+		if err != nil {
+			return nil, fmt.Errorf(" Game %v could not be read from JSON:%w", games.Len()+1, err)
+		}
+		games.Add(*game)
+	}
+
+	// finally, consume the end of the array
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return &games, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the move given in JSON format as a PgnMove, and any error found. If no
+// annotations are given, NAGs are given right after the move, followed by the
+// elapsed move time and the comments, the first one in the same block than the
+// elapsed move time
+func (move jsonMove) pgnMove() (PgnMove, error) {
+
+	result := PgnMove{
+		number:         move.Number,
+		color:          move.Color,
+		shortAlgebraic: move.SAN,
+		quality:        move.Quality,
+		emt:            -1,
+	}
+	if move.EMT != nil {
+		result.emt = *move.EMT
+	}
+	if len(move.Annotations) > 0 {
+		annotations, err := getPgnAnnotations(move.Annotations)
+		if err != nil {
+			return PgnMove{}, err
+		}
+		result.annotations = annotations
+	} else {
+		for _, nag := range move.NAGs {
+			result.annotations = append(result.annotations, PgnAnnotation{NAGAnnotation, strconv.Itoa(nag), false})
+		}
+		if move.EMT != nil {
+			result.annotations = append(result.annotations, PgnAnnotation{EMTAnnotation, strconv.FormatFloat(float64(*move.EMT), 'f', -1, 32), false})
+		}
+		for idx, comment := range move.Comments {
+			result.annotations = append(result.annotations, PgnAnnotation{CommentAnnotation, comment, idx == 0 && move.EMT != nil})
+		}
+	}
+	for _, variation := range move.Variations {
+		line := make([]PgnMove, 0, len(variation))
+		for _, imove := range variation {
+			pgnMove, err := imove.pgnMove()
+			if err != nil {
+				return PgnMove{}, err
+			}
+			line = append(line, pgnMove)
+		}
+		result.variations = append(result.variations, line)
+	}
+	return result, nil
+}
+
+// Return the annotations given in JSON format if any, or the given comments
+// otherwise, and any error found
+func getPgnAnnotationsOrComments(annotations []jsonAnnotation, comments []string) ([]PgnAnnotation, error) {
+	if len(annotations) > 0 {
+		return getPgnAnnotations(annotations)
+	}
+	var result []PgnAnnotation
+	for _, comment := range comments {
+		result = append(result, PgnAnnotation{Kind: CommentAnnotation, Value: comment})
+	}
+	return result, nil
+}
+
+// Return the game given in JSON format as a PgnGame, and any error found. The
+// game is written in PGN format and parsed again, so that it is verified in
+// exactly the same way than games read from PGN files
+func (game jsonGame) pgnGame() (*PgnGame, error) {
+
+	outcome, err := getOutcome(game.Outcome)
+	if err != nil {
+		return nil, err
+	}
+	result := PgnGame{
+		tags:    game.Tags,
+		outcome: *outcome,
+	}
+	if result.leading, err = getPgnAnnotationsOrComments(game.LeadingAnnotations, game.Leading); err != nil {
+		return nil, err
+	}
+	if result.trailing, err = getPgnAnnotationsOrComments(game.TrailingAnnotations, game.Trailing); err != nil {
+		return nil, err
+	}
+	for _, move := range game.Moves {
+		pgnMove, err := move.pgnMove()
+		if err != nil {
+			return nil, err
+		}
+		result.moves = append(result.moves, pgnMove)
+	}
+
+	// and parse it again preserving its id
+	parsed, err := ParseGame(result.GetPGN())
+	if err != nil {
+		return nil, err
+	}
+	parsed.id = game.Id
+	return parsed, nil
+}
+
+// Return the contents of this game in JSON format, so that games are
+// json.Marshalers. The FEN codes of the boards and the long algebraic notation
+// of moves are given only for the plies which have been realized
+func (game PgnGame) MarshalJSON() ([]byte, error) {
+
+	result := jsonGame{
+		Id:                  game.id,
+		Tags:                game.tags,
+		Leading:             getCommentList(game.leading),
+		LeadingAnnotations:  getJSONAnnotations(game.leading),
+		Outcome:             game.outcome.String(),
+		Trailing:            getCommentList(game.trailing),
+		TrailingAnnotations: getJSONAnnotations(game.trailing),
+	}
+	if len(game.boards) > 0 {
+		result.FEN = game.boards[0].FEN()
+		result.Moves = getJSONMoves(game.moves, game.boards[1:])
+	} else {
+		result.Moves = getJSONMoves(game.moves, nil)
+	}
+	return json.Marshal(result)
+}
+
+// Write all games in this collection in the specified io.Writer as a JSON array
+// with one game per line. Games are not played, so that they should be realized
+// in advance to get the FEN codes of their boards. In case it was not possible
+// it returns an error and nil otherwise
+func (c PgnCollection) GetJSON(writer io.Writer) error {
+
+	// games are written one at a time so that the whole array is never held
+	// in memory
+	separator := "[\n"
+	for _, igame := range c.slice {
+		contents, err := json.Marshal(igame)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(writer, separator+string(contents)); err != nil {
+			return err
+		}
+		separator = ",\n"
+	}
+
+	// and close the array, which is empty if there are no games at all
+	if c.Len() == 0 {
+		separator = "["
+	} else {
+		separator = "\n"
+	}
+	_, err := io.WriteString(writer, separator+"]\n")
+	return err
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,235 @@
+// -*- coding: utf-8 -*-
+// pgnjson_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:07:05.009688306 (1792163225)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPgnGame_MarshalJSON(t *testing.T) {
+
+	game, err := ParseGame(`[Event "JSON"]
+[Result "1-0"]
+
+1. e4 { [%emt 2.5] best by test } $1 (1. d4 d5) 1... e5 1-0`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	// before realizing the game neither FEN codes nor the long algebraic
+	// notation are given
+	var got jsonGame
+	contents, err := json.Marshal(game)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	if err := json.Unmarshal(contents, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.Tags["Event"] != "JSON" || got.Outcome != "1-0" || len(got.Moves) != 2 || got.FEN != "" {
+		t.Fatalf("MarshalJSON() = %s", contents)
+	}
+	first := got.Moves[0]
+	if first.SAN != "e4" || first.Color != 1 || first.EMT == nil || *first.EMT != 2.5 ||
+		!slices.Equal(first.Comments, []string{"best by test"}) || !slices.Equal(first.NAGs, []int{1}) ||
+		first.LAN != "" || first.FEN != "" {
+		t.Errorf("MarshalJSON() first move = %+v", first)
+	}
+	if len(first.Variations) != 1 || len(first.Variations[0]) != 2 || first.Variations[0][1].SAN != "d5" {
+		t.Errorf("MarshalJSON() variations = %+v", first.Variations)
+	}
+	if got.Moves[1].EMT != nil {
+		t.Errorf("MarshalJSON() emt = %v, want none", *got.Moves[1].EMT)
+	}
+
+	// and afterwards, they are given for every ply
+	if err := game.Realize(-1); err != nil {
+		t.Fatalf("Realize() error = %v", err)
+	}
+	if contents, err = json.Marshal(game); err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	got = jsonGame{}
+	if err := json.Unmarshal(contents, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.FEN != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" {
+		t.Errorf("MarshalJSON() initial FEN = %v", got.FEN)
+	}
+	if got.Moves[0].LAN != "e2e4" || got.Moves[1].FEN != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2" {
+		t.Errorf("MarshalJSON() moves = %+v", got.Moves)
+	}
+}
+
+func TestPgnCollection_GetJSON(t *testing.T) {
+
+	// an empty collection is written as an empty array
+	var builder strings.Builder
+	c := NewPgnCollection()
+	if err := c.GetJSON(&builder); err != nil || builder.String() != "[]\n" {
+		t.Errorf("GetJSON() = %q (error = %v), want an empty array", builder.String(), err)
+	}
+
+	// and otherwise, every game is an element of the array
+	for _, event := range []string{"A", "B"} {
+		game, err := ParseGame("[Event \"" + event + "\"]\n[Result \"*\"]\n\n1. e4 *")
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+	builder.Reset()
+	if err := c.GetJSON(&builder); err != nil {
+		t.Fatalf("GetJSON() error = %v", err)
+	}
+	var games []jsonGame
+	if err := json.Unmarshal([]byte(builder.String()), &games); err != nil {
+		t.Fatalf("Unmarshal() error = %v in %q", err, builder.String())
+	}
+	if len(games) != 2 || games[0].Tags["Event"] != "A" || games[1].Tags["Event"] != "B" || games[1].Outcome != "*" {
+		t.Errorf("GetJSON() = %q", builder.String())
+	}
+}
+
+func TestNewPgnGameFromJSON(t *testing.T) {
+
+	// games exported to JSON are imported back exactly as they were
+	game, err := ParseGame(`[Event "JSON"]
+[WhiteElo "1908"]
+[Result "1/2-1/2"]
+
+1. e4! $1 { [%emt 2.5] best by test } { really } (1. d4 d5 $2 (1... Nf6)) 1... e5 1/2-1/2`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	game.id = 7
+	if err := game.Realize(-1); err != nil {
+		t.Fatalf("Realize() error = %v", err)
+	}
+	contents, err := json.Marshal(game)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	got, err := NewPgnGameFromJSON(contents)
+	if err != nil {
+		t.Fatalf("NewPgnGameFromJSON() error = %v", err)
+	}
+	if got.id != game.id || got.MoveText() != game.MoveText() || got.outcome != game.outcome ||
+		!reflect.DeepEqual(got.tags, game.tags) || got.Realized() != 0 {
+		t.Errorf("NewPgnGameFromJSON() = %v, want %v", got, game)
+	}
+	if !reflect.DeepEqual(got.moves[0].annotations, game.moves[0].annotations) {
+		t.Errorf("NewPgnGameFromJSON() annotations = %v, want %v", got.moves[0].annotations, game.moves[0].annotations)
+	}
+
+	// and errors are found when games can not be parsed
+	for _, contents := range []string{
+		`{"tags": {}, "moves": [], "outcome": "2-0"}`,
+		`{"tags": {}, "moves": [{"number": 1, "color": 1, "san": "e9"}], "outcome": "*"}`,
+		`[]`,
+	} {
+		if _, err := NewPgnGameFromJSON([]byte(contents)); err == nil {
+			t.Errorf("NewPgnGameFromJSON(%v) error = nil", contents)
+		}
+	}
+}
+
+func TestNewPgnGameFromJSONAnnotations(t *testing.T) {
+
+	// commands given in comments are exported along with the comments in the
+	// same order, and they are imported back
+	game, err := ParseGame(`[Event "JSON"]
+[Result "*"]
+
+{ [%clk 0:03:00] } 1. e4 { [%clk 0:03:00] [%eval 0.2] } e5 $2 { [%cal Ge2e4] weak [%lang es] } * { [%eval 0.1] }`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	contents, err := json.Marshal(game)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	var data jsonGame
+	if err := json.Unmarshal(contents, &data); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := []jsonAnnotation{{Kind: "clk", Value: "0:03:00"}, {Kind: "eval", Value: "0.2", Joined: true}}
+	if !reflect.DeepEqual(data.Moves[0].Annotations, want) {
+		t.Errorf("MarshalJSON() annotations = %+v, want %+v", data.Moves[0].Annotations, want)
+	}
+	got, err := NewPgnGameFromJSON(contents)
+	if err != nil {
+		t.Fatalf("NewPgnGameFromJSON() error = %v", err)
+	}
+	for idx := range game.moves {
+		if !reflect.DeepEqual(got.moves[idx].annotations, game.moves[idx].annotations) {
+			t.Errorf("NewPgnGameFromJSON() annotations of move %v = %v, want %v", idx, got.moves[idx].annotations, game.moves[idx].annotations)
+		}
+	}
+	if !reflect.DeepEqual(got.leading, game.leading) || !reflect.DeepEqual(got.trailing, game.trailing) {
+		t.Errorf("NewPgnGameFromJSON() = %v and %v, want %v and %v", got.leading, got.trailing, game.leading, game.trailing)
+	}
+	if got.GetPGN() != game.GetPGN() {
+		t.Errorf("NewPgnGameFromJSON() = %q, want %q", got.GetPGN(), game.GetPGN())
+	}
+
+	// games without annotations are read from their comments, while unknown
+	// kinds of annotations are rejected
+	got, err = NewPgnGameFromJSON([]byte(`{"tags": {"Event": "JSON"}, "moves": [{"number": 1, "color": 1, "san": "e4", "comments": ["best"]}], "outcome": "*"}`))
+	if err != nil || got.moves[0].Comments() != "best" {
+		t.Errorf("NewPgnGameFromJSON() = %v (error = %v), want a comment", got, err)
+	}
+	if _, err := NewPgnGameFromJSON([]byte(`{"tags": {"Event": "JSON"}, "moves": [{"number": 1, "color": 1, "san": "e4", "annotations": [{"kind": "foo", "value": "0"}]}], "outcome": "*"}`)); err == nil {
+		t.Errorf("NewPgnGameFromJSON() error = nil with an unknown kind of annotation")
+	}
+}
+
+func TestNewPgnCollectionFromJSON(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, event := range []string{"A", "B"} {
+		game, err := ParseGame("[Event \"" + event + "\"]\n[Result \"0-1\"]\n\n1. e4 e5 0-1")
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+	var builder strings.Builder
+	if err := c.GetJSON(&builder); err != nil {
+		t.Fatalf("GetJSON() error = %v", err)
+	}
+	got, err := NewPgnCollectionFromJSON(strings.NewReader(builder.String()))
+	if err != nil {
+		t.Fatalf("NewPgnCollectionFromJSON() error = %v", err)
+	}
+	if !slices.Equal(collectionIds(*got), collectionIds(c)) {
+		t.Fatalf("NewPgnCollectionFromJSON() ids = %v, want %v", collectionIds(*got), collectionIds(c))
+	}
+	for idx, igame := range got.GetGames() {
+		if !reflect.DeepEqual(igame.tags, c.slice[idx].tags) || igame.MoveText() != c.slice[idx].MoveText() {
+			t.Errorf("NewPgnCollectionFromJSON() = %v, want %v", igame.GetPGN(), c.slice[idx].GetPGN())
+		}
+	}
+
+	// anything but an array of games is rejected
+	for _, contents := range []string{`{}`, `[{"outcome": "3-0"}]`, `[`} {
+		if _, err := NewPgnCollectionFromJSON(strings.NewReader(contents)); err == nil {
+			t.Errorf("NewPgnCollectionFromJSON(%v) error = nil", contents)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
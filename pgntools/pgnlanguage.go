@@ -0,0 +1,107 @@
+// -*- coding: utf-8 -*-
+// pgnlanguage.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:57:43.457114443 (1792166263)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a copy of the given annotations with the comments in the given
+// language only. Every comment can be written in different languages by
+// preceding the text in each one with a marker such as "[%lang en]", so that
+// the language applies to all the text that follows within the same comment.
+// Text given before any marker in the same comment is acknowledged to be in
+// every language and it is always kept, whereas language markers are always
+// removed
+func selectLanguage(annotations []PgnAnnotation, language string) (result []PgnAnnotation) {
+
+	// the current language is reset at the beginning of every comment, and
+	// the first annotation kept from a comment starts it
+	current, first := "", true
+	for _, annotation := range annotations {
+
+		if !annotation.Joined {
+			current, first = "", true
+		}
+
+		// language markers just change the language of the text that
+		// follows
+		if annotation.Kind == LanguageAnnotation {
+			current = annotation.Value
+			continue
+		}
+
+		// and comments in other languages are skipped
+		if annotation.Kind == CommentAnnotation && current != "" && current != language {
+			continue
+		}
+		annotation.Joined = !first
+		first = false
+		result = append(result, annotation)
+	}
+	return
+}
+
+// Return a copy of the given sequence of moves where the comments of every
+// move, and recursively of all variations, are given in the specified language
+// only
+func selectLanguageLine(moves []PgnMove, language string) []PgnMove {
+
+	result := make([]PgnMove, len(moves))
+	for idx, move := range moves {
+		move.annotations = selectLanguage(move.annotations, language)
+		if len(move.variations) > 0 {
+			variations := make([][]PgnMove, len(move.variations))
+			for jdx, variation := range move.variations {
+				variations[jdx] = selectLanguageLine(variation, language)
+			}
+			move.variations = variations
+		}
+		result[idx] = move
+	}
+	return result
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a copy of this game with the comments in the given language only, see
+// SelectLanguage. The receiver is not modified
+func (game PgnGame) SelectLanguage(language string) PgnGame {
+
+	if language != "" {
+		game.moves = selectLanguageLine(game.moves, language)
+		game.leading = selectLanguage(game.leading, language)
+		game.trailing = selectLanguage(game.trailing, language)
+	}
+	return game
+}
+
+// Return a copy of this collection where the comments of all games are given in
+// the specified language, e.g., "en". Comments can be written in different
+// languages by preceding each text with a marker such as "[%lang en]", which
+// applies to the text that follows within the same comment. Text given before
+// any marker is kept in all languages and markers are always removed. If no
+// language is given, the collection is returned as is
+func (c PgnCollection) SelectLanguage(language string) PgnCollection {
+
+	if language == "" {
+		return c
+	}
+	games := make([]PgnGame, len(c.slice))
+	for idx, game := range c.slice {
+		games[idx] = game.SelectLanguage(language)
+	}
+	c.slice = games
+	return c
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,47 @@
+// -*- coding: utf-8 -*-
+// pgnlanguage_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:58:42.515161519 (1792166322)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import "testing"
+
+func TestPgnGame_GetPGNWithLanguage(t *testing.T) {
+
+	game, err := ParseGame("[Event \"Languages\"]\n\n1. e4 {Main line [%lang en] The best move [%lang es] La mejor jugada} e5 2. Nf3 {[%lang es] Desarrollo} (2. f4 {[%lang en] Gambit [%lang es] Gambito}) *")
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		language string
+		want     string
+	}{
+		{"all", "", "1. e4 { Main line [%lang en] The best move [%lang es] La mejor jugada } e5 2. Nf3 { [%lang es] Desarrollo } (2. f4 { [%lang en] Gambit [%lang es] Gambito }) "},
+		{"en", "en", "1. e4 { Main line The best move } e5 2. Nf3 (2. f4 { Gambit }) "},
+		{"es", "es", "1. e4 { Main line La mejor jugada } e5 2. Nf3 { Desarrollo } (2. f4 { Gambito }) "},
+		{"fr", "fr", "1. e4 { Main line } e5 2. Nf3 (2. f4) "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := game.getPGNMoves(newPgnOptions(WithLanguage(tt.language))); got != tt.want {
+				t.Errorf("getPGNMoves() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// the original game is never modified
+	if got := len(game.moves[0].annotations); got != 5 {
+		t.Errorf("getPGNMoves() modified the annotations of the game: %v", game.moves[0].annotations)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,258 @@
+// -*- coding: utf-8 -*-
+// pgnlichess.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:04:07.539932988 (1792166647)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// The games of a lichess user are downloaded according to a number of options
+// which are given as functions which modify the default configuration
+type LichessOption func(*lichessOptions)
+
+// The configuration used to download games from lichess consists of the URL of
+// the service, the client used to connect to it, the maximum number of games
+// to download, the period when they were played and the personal token of the
+// user, if any. Games are requested in pages of the same size, and requests
+// are given a number of retries after waiting when they are rate limited.
+// Finally, the options used to parse the games downloaded are given as well
+type lichessOptions struct {
+	url          string
+	client       *http.Client
+	max          int
+	since, until time.Time
+	token        string
+	pageSize     int
+	retries      int
+	wait         time.Duration
+	parsing      []PgnOption
+}
+
+// Every game is given by the lichess service in a separate line of a JSON
+// stream, along with the time when it was created in milliseconds
+type lichessGame struct {
+	Id        string `json:"id"`
+	CreatedAt int64  `json:"createdAt"`
+	Pgn       string `json:"pgn"`
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// URL of the lichess API
+const lichessURL = "https://lichess.org"
+
+// Games are requested in pages with the following number of games, and
+// requests which are rate limited are retried the following number of times
+// after waiting for a full minute, as requested by lichess
+const (
+	lichessPageSize = 500
+	lichessRetries  = 3
+	lichessWait     = time.Minute
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Download at most the given number of games. If n is zero or negative, all
+// games are downloaded
+func WithLichessMax(n int) LichessOption {
+	return func(options *lichessOptions) {
+		options.max = n
+	}
+}
+
+// Download only the games played in the given period, i.e., since the first
+// time and until the second one. Zero times are ignored
+func WithLichessPeriod(since, until time.Time) LichessOption {
+	return func(options *lichessOptions) {
+		options.since, options.until = since, until
+	}
+}
+
+// Authenticate all requests with the given personal token, which allows
+// downloading games faster
+func WithLichessToken(token string) LichessOption {
+	return func(options *lichessOptions) {
+		options.token = token
+	}
+}
+
+// Download games from the given URL instead of lichess.org, e.g., from a mirror
+// of its API
+func WithLichessURL(url string) LichessOption {
+	return func(options *lichessOptions) {
+		options.url = strings.TrimSuffix(url, "/")
+	}
+}
+
+// Parse the games downloaded with the given options, as when they are read
+// from a PgnFile with Games
+func WithLichessParsing(opts ...PgnOption) LichessOption {
+	return func(options *lichessOptions) {
+		options.parsing = opts
+	}
+}
+
+// Return the configuration resulting from applying all the given options to
+// the default one
+func newLichessOptions(opts ...LichessOption) lichessOptions {
+	options := lichessOptions{
+		url:      lichessURL,
+		client:   http.DefaultClient,
+		pageSize: lichessPageSize,
+		retries:  lichessRetries,
+		wait:     lichessWait,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// Return a new collection with the games of the given lichess user, from the
+// most recent to the oldest one, which are downloaded with the lichess API.
+// Games are requested in pages, one at a time, and requests which are rate
+// limited are retried after waiting for a minute. By default, all games are
+// downloaded, unless a maximum is given WithLichessMax or only those played in
+// a period are requested WithLichessPeriod. It returns any error found
+func NewPgnCollectionFromLichess(user string, opts ...LichessOption) (*PgnCollection, error) {
+
+	if user == "" {
+		return nil, fmt.Errorf(" No lichess user was given")
+	}
+	options := newLichessOptions(opts...)
+
+	// request pages of games until no more games are available. Every page
+	// starts at the time of the oldest game of the previous one, so that other
+	// games created at the same time are not missed, and games already
+	// downloaded are skipped
+	var pgn strings.Builder
+	seen := make(map[string]bool)
+	until := options.until
+	for downloaded := 0; options.max <= 0 || downloaded < options.max; {
+		size := options.pageSize
+		if options.max > 0 {
+			size = min(size, options.max-downloaded)
+		}
+		games, err := options.getPage(user, size, until)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, igame := range games {
+			if !seen[igame.Id] {
+				seen[igame.Id] = true
+				pgn.WriteString(strings.TrimSpace(igame.Pgn))
+				pgn.WriteString("\n\n")
+				downloaded++
+				found = true
+			}
+		}
+
+		// a page with fewer games than requested is the last one
+		if len(games) < size {
+			break
+		}
+
+		// If all games of this page were already downloaded, i.e., there are
+		// more games created at the same time than fit in a page, the next one
+		// starts right before them, as otherwise the same page would be
+		// requested forever
+		until = time.UnixMilli(games[len(games)-1].CreatedAt)
+		if !found {
+			until = until.Add(-time.Millisecond)
+		}
+	}
+
+	// and parse all games downloaded
+	return NewPgnCollectionFromReader(strings.NewReader(pgn.String()), options.parsing...)
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the games of the given user in the page with the given number of
+// games played before the given time, if it is not zero. Requests which are
+// rate limited are retried after waiting
+func (options lichessOptions) getPage(user string, size int, until time.Time) ([]lichessGame, error) {
+
+	// compute the query of this page
+	query := url.Values{}
+	query.Set("max", strconv.Itoa(size))
+	query.Set("pgnInJson", "true")
+	query.Set("clocks", "true")
+	query.Set("opening", "true")
+	if !options.since.IsZero() {
+		query.Set("since", strconv.FormatInt(options.since.UnixMilli(), 10))
+	}
+	if !until.IsZero() {
+		query.Set("until", strconv.FormatInt(until.UnixMilli(), 10))
+	}
+	address := fmt.Sprintf("%v/api/games/user/%v?%v", options.url, url.PathEscape(user), query.Encode())
+
+	for attempt := 0; ; attempt++ {
+
+		request, err := http.NewRequest(http.MethodGet, address, nil)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Accept", "application/x-ndjson")
+		if options.token != "" {
+			request.Header.Set("Authorization", "Bearer "+options.token)
+		}
+		response, err := options.client.Do(request)
+		if err != nil {
+			return nil, err
+		}
+
+		// in case the request was rate limited, wait before trying again
+		if response.StatusCode == http.StatusTooManyRequests && attempt < options.retries {
+			response.Body.Close()
+			time.Sleep(options.wait)
+			continue
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf(" The lichess API returned '%v' for the games of '%v'", response.Status, user)
+		}
+
+		// every line of the response contains a different game
+		var games []lichessGame
+		scanner := bufio.NewScanner(response.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), DefaultMaxGameSize)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var game lichessGame
+			if err := json.Unmarshal([]byte(line), &game); err != nil {
+				return nil, err
+			}
+			games = append(games, game)
+		}
+		return games, scanner.Err()
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
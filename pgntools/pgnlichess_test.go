@@ -0,0 +1,104 @@
+// -*- coding: utf-8 -*-
+// pgnlichess_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:04:18.741263120 (1792166658)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Simulate the lichess API with five games of the same user created at
+// different times, from the most recent to the oldest one, where the third and
+// fourth games were created in the same millisecond. The first request is rate
+// limited
+func newLichessServer(t *testing.T) (*httptest.Server, *int) {
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.URL.Path != "/api/games/user/alice" || r.Header.Get("Accept") != "application/x-ndjson" {
+			t.Errorf("unexpected request %v", r.URL)
+		}
+		size, _ := strconv.Atoi(r.URL.Query().Get("max"))
+		until := int64(1 << 62)
+		if value := r.URL.Query().Get("until"); value != "" {
+			until, _ = strconv.ParseInt(value, 10, 64)
+		}
+		encoder := json.NewEncoder(w)
+		for idx := 5; idx > 0 && size > 0; idx-- {
+			created := int64(idx * 1000)
+			if idx == 3 {
+				created = 4000
+			}
+			if created <= until {
+				encoder.Encode(lichessGame{
+					Id:        fmt.Sprintf("game%v", idx),
+					CreatedAt: created,
+					Pgn:       fmt.Sprintf("[Event \"Game %v\"]\n[Result \"*\"]\n\n1. e4 *\n", idx),
+				})
+				size--
+			}
+		}
+	}))
+	return server, &requests
+}
+
+func TestNewPgnCollectionFromLichess(t *testing.T) {
+
+	server, requests := newLichessServer(t)
+	defer server.Close()
+
+	// all games are downloaded in pages of two games
+	fast := func(options *lichessOptions) {
+		options.pageSize, options.wait = 2, time.Millisecond
+	}
+	games, err := NewPgnCollectionFromLichess("alice", WithLichessURL(server.URL), fast)
+	if err != nil {
+		t.Fatalf("NewPgnCollectionFromLichess() error = %v", err)
+	}
+	if games.Len() != 5 {
+		t.Fatalf("NewPgnCollectionFromLichess() = %v games, want 5", games.Len())
+	}
+	for idx, igame := range games.GetGames() {
+		if got, want := igame.getTag("Event"), fmt.Sprintf("Game %v", 5-idx); got != want {
+			t.Errorf("NewPgnCollectionFromLichess() game %v = %q, want %q", idx, got, want)
+		}
+	}
+	if *requests != 6 {
+		t.Errorf("NewPgnCollectionFromLichess() made %v requests, want 6", *requests)
+	}
+
+	// and only the number of games requested
+	*requests = 1
+	games, err = NewPgnCollectionFromLichess("alice", WithLichessURL(server.URL), WithLichessMax(3), fast)
+	if err != nil {
+		t.Fatalf("NewPgnCollectionFromLichess() error = %v", err)
+	}
+	if games.Len() != 3 {
+		t.Errorf("NewPgnCollectionFromLichess() = %v games, want 3", games.Len())
+	}
+
+	if _, err := NewPgnCollectionFromLichess(""); err == nil {
+		t.Errorf("NewPgnCollectionFromLichess() without user should fail")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
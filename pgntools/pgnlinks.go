@@ -0,0 +1,142 @@
+// -*- coding: utf-8 -*-
+// pgnlinks.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:30:12.931931262 (1792161012)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Games can be related to others in different ways
+type PgnRelation int
+
+// A link relates a game to another one, given by its id
+type PgnLink struct {
+	Id       int         // id of the related game
+	Relation PgnRelation // how both games are related
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Games are rematches when the same players play again in the same event and
+// date with colors reversed. A game is adjourned when it is continued in a
+// later game from its final position, given in the FEN tag of the continuation
+const (
+	RematchRelation      PgnRelation = iota // the other game is a rematch
+	AdjournedRelation                       // the other game continues this one
+	ContinuationRelation                    // this game continues the other one
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the key used to find games between the same players in the same event,
+// regardless of their colors
+func linkKey(game *PgnGame) string {
+	players := []string{game.getTag("White"), game.getTag("Black")}
+	if players[0] > players[1] {
+		players[0], players[1] = players[1], players[0]
+	}
+	return fmt.Sprintf("%v\x00%v\x00%v", game.getTag("Event"), players[0], players[1])
+}
+
+// Return true if both FEN codes describe the same position, i.e., if they have
+// the same piece placement and side to move. The remaining fields are ignored
+// as they are often given differently when games are adjourned
+func samePosition(fen1, fen2 string) bool {
+	fields1, fields2 := strings.Fields(fen1), strings.Fields(fen2)
+	return len(fields1) >= 2 && len(fields2) >= 2 &&
+		fields1[0] == fields2[0] && fields1[1] == fields2[1]
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a string with the name of this relation
+func (relation PgnRelation) String() string {
+	switch relation {
+	case RematchRelation:
+		return "rematch"
+	case AdjournedRelation:
+		return "adjourned"
+	case ContinuationRelation:
+		return "continuation"
+	}
+	return "unknown"
+}
+
+// Links are shown with the relation and the id of the related game
+func (link PgnLink) String() string {
+	return fmt.Sprintf("%v #%v", link.Relation, link.Id)
+}
+
+// Return the links of this game to other games, which are computed with
+// Crosslink
+func (game *PgnGame) Links() []PgnLink {
+	return game.links
+}
+
+// Link all games in this collection which are related to each other, and
+// return the number of links found. Every game is compared with the previous
+// one between the same players in the same event:
+//
+//  1. If it is given a FEN tag with the final position of the previous game
+//     and players keep their colors, it is a continuation of an adjourned game.
+//     For this, previous games are played if necessary
+//  2. Otherwise, if players swapped colors and both games were played on the
+//     same date, it is a rematch
+//
+// Links are added to both games, and they replace any links computed
+// previously
+func (c PgnCollection) Crosslink() (int, error) {
+
+	for idx := range c.slice {
+		c.slice[idx].links = nil
+	}
+
+	// remember the last game found between every pair of players in every
+	// event
+	nblinks := 0
+	last := make(map[string]int)
+	for idx := range c.slice {
+		game := &c.slice[idx]
+		key := linkKey(game)
+		if jdx, ok := last[key]; ok {
+			previous := &c.slice[jdx]
+
+			// look first for continuations of adjourned games
+			if fen, ok := game.tags["FEN"]; ok && game.getTag("White") == previous.getTag("White") {
+				if err := previous.play(); err != nil {
+					return nblinks, err
+				}
+				if samePosition(fmt.Sprintf("%v", fen), previous.boards[len(previous.boards)-1].FEN()) {
+					previous.links = append(previous.links, PgnLink{game.id, AdjournedRelation})
+					game.links = append(game.links, PgnLink{previous.id, ContinuationRelation})
+					nblinks++
+				}
+			} else if game.getTag("White") == previous.getTag("Black") &&
+				game.getTag("Date") == previous.getTag("Date") {
+				previous.links = append(previous.links, PgnLink{game.id, RematchRelation})
+				game.links = append(game.links, PgnLink{previous.id, RematchRelation})
+				nblinks++
+			}
+		}
+		last[key] = idx
+	}
+	return nblinks, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
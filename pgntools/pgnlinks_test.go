@@ -0,0 +1,85 @@
+// -*- coding: utf-8 -*-
+// pgnlinks_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:30:46.045565001 (1792161046)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPgnCollection_Crosslink(t *testing.T) {
+
+	games := []string{
+		`[Event "A"]
+[Date "2024.01.01"]
+[White "alice"]
+[Black "bob"]
+
+1. e4 e5 2. Nf3 *`,
+		`[Event "A"]
+[Date "2024.01.01"]
+[White "carol"]
+[Black "alice"]
+
+1. d4 d5 1/2-1/2`,
+		`[Event "A"]
+[Date "2024.01.02"]
+[White "alice"]
+[Black "bob"]
+[SetUp "1"]
+[FEN "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"]
+
+2... Nc6 1-0`,
+		`[Event "A"]
+[Date "2024.01.02"]
+[White "bob"]
+[Black "alice"]
+
+1. c4 c5 0-1`,
+		`[Event "B"]
+[Date "2024.01.02"]
+[White "alice"]
+[Black "bob"]
+
+1. c4 c5 0-1`,
+	}
+	c := NewPgnCollection()
+	for _, pgn := range games {
+		game, err := getGameFromString(pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	nblinks, err := c.Crosslink()
+	if err != nil {
+		t.Fatalf("Crosslink() error = %v", err)
+	}
+	if nblinks != 2 {
+		t.Errorf("Crosslink() = %v links, want 2", nblinks)
+	}
+	want := [][]PgnLink{
+		{{3, AdjournedRelation}},
+		nil,
+		{{1, ContinuationRelation}, {4, RematchRelation}},
+		{{3, RematchRelation}},
+		nil,
+	}
+	for idx, igame := range c.GetGames() {
+		if got := igame.Links(); !reflect.DeepEqual(got, want[idx]) {
+			t.Errorf("Links() of game #%v = %v, want %v", igame.Id(), got, want[idx])
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
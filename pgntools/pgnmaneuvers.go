@@ -0,0 +1,77 @@
+// -*- coding: utf-8 -*-
+// pgnmaneuvers.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:52:23.312687762 (1792165943)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import "strings"
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the number of moves of the longest maneuver of the given piece of
+// either color, i.e., the longest sequence of consecutive moves of the same
+// player with it, ignoring the moves of the other player. If chained is true,
+// every move has to start from the square where the previous one ended, so
+// that the same piece is moved all the time. Only plies in the range [from, to)
+// are considered, and castling does not count as a move of any piece. This game
+// must have been realized up to the last ply considered
+func (game *PgnGame) longestManeuver(piece content, from, to int, chained bool) (longest int) {
+
+	// the length of the current maneuver and the square where the last move
+	// ended of every player, White first
+	var lengths [2]int
+	var last [2]string
+	for ply := from; ply < min(to, game.Realized()); ply++ {
+		move := game.moves[ply]
+		player := 0
+		if move.color < 0 {
+			player = 1
+		}
+
+		moved := game.boards[ply].squares[coords[move.longAlgebraic.from]]
+		switch {
+		case strings.HasPrefix(move.shortAlgebraic, "O-O") || moved != getPieceValue(piece, move.color):
+			lengths[player] = 0
+		case chained && lengths[player] > 0 && move.longAlgebraic.from != last[player]:
+			lengths[player] = 1
+		default:
+			lengths[player]++
+		}
+		longest = max(longest, lengths[player])
+		last[player] = move.longAlgebraic.to
+	}
+	return
+}
+
+// Return the number of moves of the longest march of a king in the middlegame,
+// i.e., the number of consecutive moves of the same player with the king.
+// Castling breaks marches. The game is realized as needed
+func (game *PgnGame) kingMarch() (int, error) {
+
+	if err := game.Realize(middlegameEnd); err != nil {
+		return 0, err
+	}
+	return game.longestManeuver(WKING, middlegameStart, middlegameEnd, false), nil
+}
+
+// Return the number of moves of the longest tour of a knight, i.e., the number
+// of consecutive moves of the same player with the same knight, every one
+// starting from the square where the previous one ended. The game is played as
+// needed
+func (game *PgnGame) knightTour() (int, error) {
+
+	if err := game.play(); err != nil {
+		return 0, err
+	}
+	return game.longestManeuver(WKNIGHT, 0, len(game.moves), true), nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,49 @@
+// -*- coding: utf-8 -*-
+// pgnmaneuvers_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:52:46.338866322 (1792165966)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import "testing"
+
+func TestPgnGame_Maneuvers(t *testing.T) {
+
+	tests := []struct {
+		moves  string
+		march  int
+		knight int
+	}{
+
+		// kings march in the middlegame, and White's march is longer because
+		// Black moves a knight in the last move
+		{`1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 4. Ng1 Ng8 5. Nf3 Nf6 6. Ng1 Ng8 7. Nf3 Nf6
+8. Ng1 Ng8 9. Nf3 Nf6 10. Ng1 Ng8 11. e4 e5 12. Ke2 Ke7 13. Ke3 Ke6 14. Kf3 Kf6
+15. Kg3 Kg6 16. Kh3 Nf6 *`, 5, 10},
+
+		// kings do not march in the opening, and knight tours are broken
+		// by the moves of another knight
+		{`1. e4 e5 2. Ke2 Ke7 3. Ke3 Ke6 4. Kf3 Kf6 5. Kg3 Kg6 *`, 0, 0},
+		{`1. Nc3 e6 2. Nf3 d6 3. Ne5 Nc6 4. Nc4 Nb4 5. Na3 Nd3+ 6. exd3 *`, 0, 4},
+	}
+	for _, tt := range tests {
+		game, err := ParseGame("[Event \"?\"]\n\n" + tt.moves)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		if march, err := game.kingMarch(); err != nil || march != tt.march {
+			t.Errorf("kingMarch() = %v, %v, want %v", march, err, tt.march)
+		}
+		if knight, err := game.knightTour(); err != nil || knight != tt.knight {
+			t.Errorf("knightTour() = %v, %v, want %v", knight, err, tt.knight)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,153 @@
+// -*- coding: utf-8 -*-
+// pgnmarks.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:10:11.062065232 (1792167011)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// An arrow drawn by an annotator goes from one square to another, e.g., from
+// "f1" to "c4", with a color given with its initial, e.g., "G" for green, as in
+// "[%cal Gf1c4]"
+type PgnArrow struct {
+	Color    string
+	From, To string
+}
+
+// A square highlighted by an annotator is given with its color, as in
+// "[%csl Rd4]"
+type PgnHighlight struct {
+	Color  string
+	Square string
+}
+
+// globals
+// ----------------------------------------------------------------------------
+
+// Colors of arrows and highlighted squares are given with their initials, and
+// they are drawn in LaTeX with the following colors
+var markColors = map[string]string{
+	"R": "red",
+	"G": "green",
+	"B": "blue",
+	"Y": "yellow",
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return all marks given in the value of a graphical annotation, i.e., all
+// values separated by commas which consist of the initial of a color followed
+// by the given number of squares, e.g., "Gf1c4,Rd1d8" for arrows. Marks which
+// are not well formed are ignored
+func getMarks(value string, squares int) (marks [][]string) {
+	for _, mark := range strings.Split(value, ",") {
+		mark = strings.TrimSpace(mark)
+		if len(mark) != 1+2*squares {
+			continue
+		}
+		fields := []string{strings.ToUpper(mark[:1])}
+		for idx := 0; idx < squares; idx++ {
+			square := mark[1+2*idx : 3+2*idx]
+			if _, ok := coords[square]; !ok {
+				fields = nil
+				break
+			}
+			fields = append(fields, square)
+		}
+		if fields != nil {
+			marks = append(marks, fields)
+		}
+	}
+	return
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return all arrows drawn after this move with "[%cal ...]" in the same order
+// they were given
+func (move PgnMove) Arrows() (arrows []PgnArrow) {
+	for _, annotation := range move.annotations {
+		if annotation.Kind == ArrowAnnotation {
+			for _, mark := range getMarks(annotation.Value, 2) {
+				arrows = append(arrows, PgnArrow{Color: mark[0], From: mark[1], To: mark[2]})
+			}
+		}
+	}
+	return
+}
+
+// Return all squares highlighted after this move with "[%csl ...]" in the same
+// order they were given
+func (move PgnMove) Highlights() (highlights []PgnHighlight) {
+	for _, annotation := range move.annotations {
+		if annotation.Kind == HighlightAnnotation {
+			for _, mark := range getMarks(annotation.Value, 1) {
+				highlights = append(highlights, PgnHighlight{Color: mark[0], Square: mark[1]})
+			}
+		}
+	}
+	return
+}
+
+// Return the options of the chessboard package that draw the arrows and
+// highlighted squares given after this move, each one preceded by a comma, so
+// that they can be added to the options of a \chessboard command. If there are
+// none, the empty string is returned. Unknown colors are drawn in red
+//
+// It is intended to be used in LaTeX templates
+func (move PgnMove) GetLaTeXMarks() (output string) {
+
+	getColor := func(initial string) string {
+		if color, ok := markColors[initial]; ok {
+			return color
+		}
+		return "red"
+	}
+
+	// arrows are drawn from the center of the first square to the center of
+	// the second one
+	if arrows := move.Arrows(); len(arrows) > 0 {
+		output += ",pgfstyle=straightmove,arrow=to,linewidth=0.15ex"
+		for _, arrow := range arrows {
+			output += fmt.Sprintf(",color=%v,markmoves={%v-%v}", getColor(arrow.Color), arrow.From, arrow.To)
+		}
+	}
+
+	// and highlighted squares are drawn with a colored border
+	if highlights := move.Highlights(); len(highlights) > 0 {
+		output += ",pgfstyle=border,linewidth=0.2ex"
+		for _, highlight := range highlights {
+			output += fmt.Sprintf(",color=%v,markfields={%v}", getColor(highlight.Color), highlight.Square)
+		}
+	}
+	return
+}
+
+// Return the options of the chessboard package that draw the arrows and
+// highlighted squares given after the last move of this game, see
+// GetLaTeXMarks, so that they can be shown in its final position
+//
+// It is intended to be used in LaTeX templates
+func (game *PgnGame) GetLaTeXFinalMarks() string {
+	if len(game.moves) == 0 {
+		return ""
+	}
+	return game.moves[len(game.moves)-1].GetLaTeXMarks()
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
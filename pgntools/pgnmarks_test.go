@@ -0,0 +1,59 @@
+// -*- coding: utf-8 -*-
+// pgnmarks_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:10:27.562785162 (1792167027)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPgnMove_Marks(t *testing.T) {
+
+	game, err := ParseGame("[Event \"Marks\"]\n\n1. e4 {[%cal Gf1c4,Rd1h5] Aiming at f7 [%csl Rf7]} e5 {[%cal Bz9a1,Yb8c6]} 2. Nf3 {[%csl Ge5,Gx]} *")
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	// marks are kept as structured data, ignoring those which are not well
+	// formed
+	if got, want := game.moves[0].Arrows(), []PgnArrow{{"G", "f1", "c4"}, {"R", "d1", "h5"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Arrows() = %v, want %v", got, want)
+	}
+	if got, want := game.moves[0].Highlights(), []PgnHighlight{{"R", "f7"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Highlights() = %v, want %v", got, want)
+	}
+	if got, want := game.moves[1].Arrows(), []PgnArrow{{"Y", "b8", "c6"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Arrows() = %v, want %v", got, want)
+	}
+	if got := game.moves[0].Comments(); got != "Aiming at f7" {
+		t.Errorf("Comments() = %q, want %q", got, "Aiming at f7")
+	}
+
+	// they are written back in PGN format
+	if got := game.getPGNMoves(newPgnOptions()); !strings.Contains(got, "{ [%cal Gf1c4,Rd1h5] Aiming at f7 [%csl Rf7] }") {
+		t.Errorf("getPGNMoves() = %q", got)
+	}
+
+	// and drawn in LaTeX
+	if got, want := game.moves[0].GetLaTeXMarks(), ",pgfstyle=straightmove,arrow=to,linewidth=0.15ex,color=green,markmoves={f1-c4},color=red,markmoves={d1-h5},pgfstyle=border,linewidth=0.2ex,color=red,markfields={f7}"; got != want {
+		t.Errorf("GetLaTeXMarks() = %q, want %q", got, want)
+	}
+	if got, want := game.GetLaTeXFinalMarks(), ",pgfstyle=border,linewidth=0.2ex,color=green,markfields={e5}"; got != want {
+		t.Errorf("GetLaTeXFinalMarks() = %q, want %q", got, want)
+	}
+	if got := game.moves[1].GetLaTeXMarks(); !strings.Contains(got, "color=yellow,markmoves={b8-c6}") {
+		t.Errorf("GetLaTeXMarks() = %q", got)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,213 @@
+// -*- coding: utf-8 -*-
+// pgnmerge.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:58:00.547736718 (1792169880)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Duplicated games can be resolved in different ways
+type MergeConflicts int
+
+// The merge policy decides whether duplicated games are merged into a single
+// one, how they are resolved, and whether games are renumbered afterwards
+type PgnMergePolicy struct {
+	Deduplicate bool           // whether duplicated games are merged
+	Conflicts   MergeConflicts // which one of duplicated games is kept
+	Renumber    bool           // whether games are given consecutive ids
+}
+
+// A tag conflict happens when duplicated games have different values of the
+// same tag. The value of the game kept prevails
+type PgnTagConflict struct {
+	Game      int    // id of the game in the merged collection
+	Tag       string // name of the tag
+	Kept      string // value of the tag in the game kept
+	Discarded string // value of the tag in the game discarded
+}
+
+// The merge report summarizes the result of merging two collections
+type PgnMergeReport struct {
+	Games      int              // number of games in the merged collection
+	Added      int              // games of the other collection added
+	Duplicates int              // duplicated games discarded
+	Replaced   int              // games replaced by a duplicate found later
+	Reassigned int              // games given a new id to avoid repeated ids
+	Conflicts  []PgnTagConflict // tags with different values in duplicates
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Among duplicated games, either the first one found is kept (KeepFirstGame),
+// or the last one (KeepLastGame), or the one with more annotations, i.e.,
+// comments, NAGs, moves qualified and variations (KeepAnnotatedGame). In the
+// last case, the first one is kept in case of ties
+const (
+	KeepFirstGame MergeConflicts = iota
+	KeepLastGame
+	KeepAnnotatedGame
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the number of annotations of the given moves, including those of
+// their variations: comments, NAGs, commands, moves qualified and variations
+func countAnnotations(moves []PgnMove) (count int) {
+	for _, move := range moves {
+		count += len(move.annotations) + len(move.variations)
+		if move.quality != "" {
+			count++
+		}
+		for _, variation := range move.variations {
+			count += countAnnotations(variation)
+		}
+	}
+	return
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a key which is the same for duplicated games, i.e., games played by
+// the same players on the same date from the same position with the same
+// moves, regardless of the markers of check and checkmate
+func (game *PgnGame) duplicateKey() string {
+
+	fields := []string{game.getTag("White"), game.getTag("Black"), game.getTag("Date"), game.getTag("FEN")}
+	for _, move := range game.moves {
+		fields = append(fields, strings.TrimRight(move.shortAlgebraic, "+#"))
+	}
+	return strings.Join(fields, "\x00")
+}
+
+// Return the number of annotations of this game, including those given before
+// the first move and after the outcome
+func (game *PgnGame) annotationCount() int {
+	return countAnnotations(game.moves) + len(game.leading) + len(game.trailing)
+}
+
+// Return the game which is kept among the given duplicated games according to
+// the given policy, where the first one was found first. Tags which are missing
+// in the game kept are taken from the other one, and tags with different
+// values are reported as conflicts, which are given the id of the first game.
+// It also returns whether the first game was replaced
+func (policy PgnMergePolicy) resolve(first, second PgnGame) (PgnGame, []PgnTagConflict, bool) {
+
+	kept, discarded := first, second
+	replaced := policy.Conflicts == KeepLastGame ||
+		(policy.Conflicts == KeepAnnotatedGame && second.annotationCount() > first.annotationCount())
+	if replaced {
+		kept, discarded = second, first
+	}
+	kept.id = first.id
+
+	// tags are copied so that the games merged are not modified
+	var conflicts []PgnTagConflict
+	kept.tags = maps.Clone(kept.tags)
+	if kept.tags == nil {
+		kept.tags = make(map[string]any)
+	}
+	names := make([]string, 0, len(discarded.tags))
+	for name := range discarded.tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, ok := kept.tags[name]
+		if !ok {
+			kept.tags[name] = discarded.tags[name]
+		} else if fmt.Sprint(value) != fmt.Sprint(discarded.tags[name]) {
+			conflicts = append(conflicts, PgnTagConflict{
+				Game:      first.id,
+				Tag:       name,
+				Kept:      fmt.Sprint(value),
+				Discarded: fmt.Sprint(discarded.tags[name]),
+			})
+		}
+	}
+	return kept, conflicts, replaced
+}
+
+// Return a new collection with all games of this collection followed by all
+// games of the other one, merged according to the given policy, along with a
+// report of the merge. If requested, duplicated games (played by the same
+// players on the same date with the same moves) are merged into a single one,
+// even if they are found in the same collection, which is kept in the location
+// of the first one found with its id. Unless games are renumbered, games keep
+// their ids, but those of the other collection which are already used are
+// given new ones. Neither collection is modified
+func (c PgnCollection) Merge(other PgnCollection, policy PgnMergePolicy) (*PgnCollection, PgnMergeReport) {
+
+	var report PgnMergeReport
+	merged := NewPgnCollection()
+	merged.idBase = c.idBase
+	locations := make(map[string]int)
+	used := make(map[int]bool)
+	for idx, igame := range slices.Concat(c.slice, other.slice) {
+
+		// duplicated games are merged with the first one found
+		if policy.Deduplicate {
+			key := igame.duplicateKey()
+			if location, ok := locations[key]; ok {
+				kept, conflicts, replaced := policy.resolve(merged.slice[location], igame)
+				merged.slice[location] = kept
+				report.Duplicates++
+				report.Conflicts = append(report.Conflicts, conflicts...)
+				if replaced {
+					report.Replaced++
+				}
+				continue
+			}
+			locations[key] = len(merged.slice)
+		}
+
+		// games of the other collection whose id is already used are given
+		// a new one
+		if idx >= len(c.slice) {
+			report.Added++
+			if used[igame.id] {
+				igame.id = 0
+				report.Reassigned++
+			}
+		}
+		merged.Add(igame)
+		used[merged.slice[len(merged.slice)-1].id] = true
+	}
+
+	if policy.Renumber {
+		merged.Renumber()
+	}
+	report.Games = merged.Len()
+	return &merged, report
+}
+
+// Return a summary of this merge report, followed by all tag conflicts
+func (report PgnMergeReport) String() string {
+
+	output := fmt.Sprintf(" %v games merged: %v added, %v duplicates discarded (%v replaced), %v ids reassigned, %v tag conflicts",
+		report.Games, report.Added, report.Duplicates, report.Replaced, report.Reassigned, len(report.Conflicts))
+	for _, conflict := range report.Conflicts {
+		output += fmt.Sprintf("\n  game #%v: %v '%v' kept over '%v'", conflict.Game, conflict.Tag, conflict.Kept, conflict.Discarded)
+	}
+	return output
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
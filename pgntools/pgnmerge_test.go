@@ -0,0 +1,82 @@
+// -*- coding: utf-8 -*-
+// pgnmerge_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:58:34.156489952 (1792169914)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPgnCollection_Merge(t *testing.T) {
+
+	// the second game of the other collection is a duplicate of the first game
+	// of this collection with a comment, a different event and a new tag,
+	// whereas the first one is a new game with a repeated id
+	parse := func(pgn string) PgnGame {
+		game, err := getGameFromString(pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		return *game
+	}
+	c := NewPgnCollection()
+	c.Add(parse("[White \"alice\"]\n[Black \"bob\"]\n[Event \"Open\"]\n\n1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7 1-0"))
+	c.Add(parse("[White \"bob\"]\n[Black \"carol\"]\n\n1. d4 d5 1/2-1/2"))
+	other := NewPgnCollection()
+	other.Add(parse("[White \"carol\"]\n[Black \"alice\"]\n\n1. c4 e5 0-1"))
+	other.Add(parse("[White \"alice\"]\n[Black \"bob\"]\n[Event \"Open 2024\"]\n[Round \"3\"]\n\n1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# { Scholar's mate } 1-0"))
+
+	tests := []struct {
+		name       string
+		policy     PgnMergePolicy
+		ids        []int
+		event      string
+		comments   bool
+		duplicates int
+		replaced   int
+		conflicts  int
+	}{
+		{"concatenate", PgnMergePolicy{}, []int{1, 2, 3, 4}, "Open", false, 0, 0, 0},
+		{"first", PgnMergePolicy{Deduplicate: true}, []int{1, 2, 3}, "Open", false, 1, 0, 1},
+		{"last", PgnMergePolicy{Deduplicate: true, Conflicts: KeepLastGame}, []int{1, 2, 3}, "Open 2024", true, 1, 1, 1},
+		{"annotated", PgnMergePolicy{Deduplicate: true, Conflicts: KeepAnnotatedGame, Renumber: true}, []int{1, 2, 3}, "Open 2024", true, 1, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, report := c.Merge(other, tt.policy)
+			if ids := collectionIds(*merged); !slices.Equal(ids, tt.ids) {
+				t.Fatalf("Merge() ids = %v, want %v", ids, tt.ids)
+			}
+			first := merged.slice[0]
+			if first.getTag("Event") != tt.event || (first.getTag("Round") == "3") != tt.policy.Deduplicate ||
+				(first.moves[len(first.moves)-1].Comments() != "") != tt.comments {
+				t.Errorf("Merge() first game = %v", first.GetPGN())
+			}
+			if report.Games != len(tt.ids) || report.Added != 2-tt.duplicates || report.Reassigned != report.Added ||
+				report.Duplicates != tt.duplicates || report.Replaced != tt.replaced || len(report.Conflicts) != tt.conflicts {
+				t.Errorf("Merge() report = %+v", report)
+			}
+		})
+	}
+
+	// neither collection is modified
+	if c.Len() != 2 || other.Len() != 2 || c.slice[0].getTag("Round") != "?" {
+		t.Error("Merge() modified the collections merged")
+	}
+
+	// and duplicates are found within the same collection as well
+	if merged, report := c.Merge(c, PgnMergePolicy{Deduplicate: true}); merged.Len() != 2 || report.Duplicates != 2 || len(report.Conflicts) != 0 {
+		t.Errorf("Merge() with itself = %v games, %+v", merged.Len(), report)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,80 @@
+// -*- coding: utf-8 -*-
+// pgnmmap.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:25:16.970687625 (1792167916)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bytes"
+	"io"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Games are read line by line from any source that provides lines as slices of
+// bytes, e.g., a bufio.Reader
+type lineReader interface {
+	ReadSlice(delim byte) ([]byte, error)
+}
+
+// The contents of a file mapped in memory are accessed directly, either line by
+// line or at any offset, without copying them through intermediate buffers.
+// Mapped files have to be closed once they are not needed anymore
+type mappedFile struct {
+	data []byte // contents of the file
+	pos  int    // offset of the next line to read
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the next line of this mapped file including the given delimiter,
+// which is a slice of its contents. The last line is returned along with
+// io.EOF
+func (m *mappedFile) ReadSlice(delim byte) ([]byte, error) {
+
+	if idx := bytes.IndexByte(m.data[m.pos:], delim); idx >= 0 {
+		line := m.data[m.pos : m.pos+idx+1]
+		m.pos += idx + 1
+		return line, nil
+	}
+	line := m.data[m.pos:]
+	m.pos = len(m.data)
+	return line, io.EOF
+}
+
+// Copy into p the next contents of this mapped file, as io.Reader does
+func (m *mappedFile) Read(p []byte) (int, error) {
+
+	if m.pos >= len(m.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.pos:])
+	m.pos += n
+	return n, nil
+}
+
+// Copy into p the contents of this mapped file starting at the given offset,
+// and return the number of bytes copied along with io.EOF if fewer bytes than
+// requested were available, as io.ReaderAt does
+func (m *mappedFile) ReadAt(p []byte, off int64) (int, error) {
+
+	if off < 0 || off > int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
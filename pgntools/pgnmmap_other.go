@@ -0,0 +1,38 @@
+// -*- coding: utf-8 -*-
+// pgnmmap_other.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:25:16.941984869 (1792167916)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+//go:build !unix
+
+package pgntools
+
+import (
+	"errors"
+	"os"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Files can not be mapped in memory in this platform, so that they are always
+// read as usual
+func mapFile(file *os.File) (*mappedFile, error) {
+	return nil, errors.New(" Files can not be mapped in memory in this platform")
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Mapped files are never created in this platform
+func (m *mappedFile) Close() error {
+	return nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
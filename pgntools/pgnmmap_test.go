@@ -0,0 +1,83 @@
+// -*- coding: utf-8 -*-
+// pgnmmap_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:27:09.556880987 (1792168029)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_mappedFile(t *testing.T) {
+
+	mapped := &mappedFile{data: []byte("first\nsecond\nlast")}
+	for _, want := range []string{"first\n", "second\n", "last"} {
+		line, err := mapped.ReadSlice('\n')
+		if string(line) != want || (err == io.EOF) != (want == "last") {
+			t.Errorf("ReadSlice() = (%q, %v), want %q", line, err, want)
+		}
+	}
+	buffer := make([]byte, 6)
+	if n, err := mapped.ReadAt(buffer, 6); n != 6 || err != nil || string(buffer) != "second" {
+		t.Errorf("ReadAt() = (%v, %v, %q)", n, err, buffer)
+	}
+	if n, err := mapped.ReadAt(buffer, 14); n != 3 || err != io.EOF {
+		t.Errorf("ReadAt() = (%v, %v), want (3, EOF)", n, err)
+	}
+}
+
+func TestPgnFile_GamesMmap(t *testing.T) {
+
+	contents := `[White "alice"]
+[Black "bob"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0` + "\r\n\r\n" + `[White "bob"]
+[Black "carol"]
+
+1. d4 d5 1/2-1/2`
+	name := filepath.Join(t.TempDir(), "games.pgn")
+	if err := os.WriteFile(name, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pgnfile, err := NewPgnFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// games read from memory are exactly the same than those read as usual
+	want, err := pgnfile.Games()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := pgnfile.Games(WithMmap())
+	if err != nil {
+		t.Fatalf("Games() error = %v", err)
+	}
+	if got.Len() != want.Len() {
+		t.Fatalf("Games() = %v games, want %v", got.Len(), want.Len())
+	}
+	for idx := range want.slice {
+		if got.slice[idx].start != want.slice[idx].start || got.slice[idx].end != want.slice[idx].end ||
+			len(got.slice[idx].moves) != len(want.slice[idx].moves) {
+			t.Errorf("Games() game #%v differs", idx+1)
+		}
+	}
+
+	// and so are those read with the index
+	games, err := pgnfile.PlayerGames(*pgnfile.PlayerIndex(*want), "bob", WithMmap())
+	if err != nil || games.Len() != 2 {
+		t.Errorf("PlayerGames() = (%v, %v)", games, err)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
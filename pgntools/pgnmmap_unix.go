@@ -0,0 +1,62 @@
+// -*- coding: utf-8 -*-
+// pgnmmap_unix.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:25:16.562981973 (1792167916)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+//go:build unix
+
+package pgntools
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the contents of the given file mapped in memory in read-only mode, and
+// any error found. Files larger than the address space, e.g., in 32-bit
+// systems, can not be mapped
+func mapFile(file *os.File) (*mappedFile, error) {
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return &mappedFile{}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf(" The file '%v' is too large to be mapped in memory", file.Name())
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return &mappedFile{data: data}, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Unmap the contents of this mapped file, which can not be accessed anymore
+func (m *mappedFile) Close() error {
+
+	if m.data == nil {
+		return nil
+	}
+	err := syscall.Munmap(m.data)
+	m.data, m.pos = nil, 0
+	return err
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
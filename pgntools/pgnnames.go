@@ -0,0 +1,205 @@
+// -*- coding: utf-8 -*-
+// pgnnames.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:00:37.286196150 (1792170037)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A file namer computes the names of the files written by exporters which
+// write a different file for every game (or chunk of games). Names are given
+// with a mini-template where fields are given between braces, e.g.,
+// "{White}-{Black}-{Date}". Fields are the tags of every game, any of the
+// special fields acknowledged by GetField (such as "Id" or "Moves"), and also:
+//
+//	Base  the name of the file given to the exporter without its extension
+//	Index the index of the file written by the exporter, starting at 0
+//
+// All names are created in the same directory of the file given to the
+// exporter and with its same extension. Characters that can not be used in
+// filenames are replaced with underscores, and names that were already
+// computed by the same namer are given a suffix "-1", "-2", ... so that files
+// are never overwritten by others written in the same batch
+type PgnFileNamer struct {
+	dir, base, ext string
+	segments       []nameSegment
+	used           map[string]bool
+}
+
+// A segment of a name is either a literal text or the name of a field
+type nameSegment struct {
+	text    string
+	isField bool
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Characters that are replaced with underscores in the values of fields
+const invalidNameChars = "/\\:*?\"<>| \t\n"
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Parse the given pattern and return its segments. An error is returned in
+// case braces are not balanced or a field is empty
+func parseNamePattern(pattern string) ([]nameSegment, error) {
+
+	var segments []nameSegment
+	for pattern != "" {
+		open := strings.IndexAny(pattern, "{}")
+		if open < 0 {
+			segments = append(segments, nameSegment{text: pattern})
+			break
+		}
+		if pattern[open] == '}' {
+			return nil, fmt.Errorf(" Unbalanced '}' found in the file name pattern")
+		}
+		if open > 0 {
+			segments = append(segments, nameSegment{text: pattern[:open]})
+		}
+		end := strings.IndexAny(pattern[open+1:], "{}")
+		if end < 0 || pattern[open+1+end] == '{' {
+			return nil, fmt.Errorf(" Unbalanced '{' found in the file name pattern")
+		}
+		field := strings.TrimSpace(pattern[open+1 : open+1+end])
+		if field == "" {
+			return nil, errors.New(" Empty field found in the file name pattern")
+		}
+		segments = append(segments, nameSegment{text: field, isField: true})
+		pattern = pattern[open+end+2:]
+	}
+	if len(segments) == 0 {
+		return nil, errors.New(" Empty file name pattern")
+	}
+	return segments, nil
+}
+
+// Return a new namer of the files derived from the given filename with the
+// given pattern. An error is returned if the pattern is not well formed
+func NewPgnFileNamer(filename, pattern string) (*PgnFileNamer, error) {
+
+	segments, err := parseNamePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(filename)
+	return &PgnFileNamer{
+		dir:      filepath.Dir(filename),
+		base:     strings.TrimSuffix(filepath.Base(filename), ext),
+		ext:      ext,
+		segments: segments,
+		used:     make(map[string]bool),
+	}, nil
+}
+
+// Return the given value with all characters that can not be used in filenames
+// replaced with underscores. The empty string is returned as an underscore
+func SanitizeFileName(value string) string {
+	if value == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidNameChars, r) {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// Return the names of files for all the given values in the same order, i.e.,
+// the values with all characters that can not be used in filenames replaced,
+// see SanitizeFileName. Values whose names were already returned for previous
+// values are given a suffix "-1", "-2", ... as PgnFileNamer does, so that
+// different values, e.g., "a/b" and "a_b", are never written in the same file
+func UniqueFileNames(values []string) []string {
+
+	names := make([]string, 0, len(values))
+	used := make(map[string]bool)
+	for _, value := range values {
+		stem := SanitizeFileName(value)
+		name := stem
+		for suffix := 1; used[name]; suffix++ {
+			name = fmt.Sprintf("%v-%v", stem, suffix)
+		}
+		used[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the value of the given field for the given game which is the index-th
+// file written. Tags are given verbatim, i.e., without substituting LaTeX
+// special characters as GetField does
+func (namer *PgnFileNamer) getField(field string, game *PgnGame, index int) string {
+
+	switch field {
+	case "Base":
+		return namer.base
+	case "Index":
+		return fmt.Sprintf("%d", index)
+	}
+
+	// when no game is given, all other fields are empty
+	if game == nil {
+		return ""
+	}
+	if value, ok := game.tags[field]; ok {
+		return fmt.Sprintf("%v", value)
+	}
+	return game.GetField(field)
+}
+
+// Return the name of the file to write with information of the given game,
+// which is the index-th file written. The game can be nil in case files are
+// named only after the index. If the name was already computed before, a
+// numerical suffix is added so that the name returned is always unique
+func (namer *PgnFileNamer) Name(game *PgnGame, index int) string {
+
+	var builder strings.Builder
+	for _, segment := range namer.segments {
+		if segment.isField {
+
+			// the base name is not sanitized, as it was given by the user
+			value := namer.getField(segment.text, game, index)
+			if segment.text != "Base" {
+				value = SanitizeFileName(value)
+			}
+			builder.WriteString(value)
+		} else {
+			builder.WriteString(segment.text)
+		}
+	}
+	stem := builder.String()
+	if stem == "" {
+		stem = "_"
+	}
+
+	// and make sure the name is unique among those computed so far
+	name := filepath.Join(namer.dir, stem+namer.ext)
+	for suffix := 1; namer.used[name]; suffix++ {
+		name = filepath.Join(namer.dir, fmt.Sprintf("%v-%v%v", stem, suffix, namer.ext))
+	}
+	namer.used[name] = true
+	return name
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
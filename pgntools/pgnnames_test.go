@@ -0,0 +1,76 @@
+// -*- coding: utf-8 -*-
+// pgnnames_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:01:35.134164213 (1792170095)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNewPgnFileNamer(t *testing.T) {
+
+	for _, pattern := range []string{"", "{White", "White}", "{}", "{White{Black}}"} {
+		if _, err := NewPgnFileNamer("output.svg", pattern); err == nil {
+			t.Errorf("NewPgnFileNamer(%q) expected an error", pattern)
+		}
+	}
+	if _, err := NewPgnFileNamer("output.svg", "{White}-{Black}"); err != nil {
+		t.Errorf("NewPgnFileNamer() error = %v", err)
+	}
+}
+
+func TestPgnFileNamer_Name(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, pgn := range []string{
+		"[White \"Carlsen, M.\"]\n[Black \"Caruana\"]\n\n1. e4 e5 *",
+		"[White \"Carlsen, M.\"]\n[Black \"Caruana\"]\n\n1. d4 d5 *",
+		"[White \"Carlsen, M.\"]\n[Black \"Caruana\"]\n\n1. c4 c5 *",
+	} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	dir := filepath.Join("games", "sheets")
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"{Base}-{Id}", []string{"output-1.svg", "output-2.svg", "output-3.svg"}},
+		{"{Base}-{Index}", []string{"output-0.svg", "output-1.svg", "output-2.svg"}},
+		{"{White}-{Black}", []string{"Carlsen,_M.-Caruana.svg", "Carlsen,_M.-Caruana-1.svg", "Carlsen,_M.-Caruana-2.svg"}},
+		{"{Event}", []string{"_.svg", "_-1.svg", "_-2.svg"}},
+	}
+	for _, tt := range tests {
+		namer, err := NewPgnFileNamer(filepath.Join(dir, "output.svg"), tt.pattern)
+		if err != nil {
+			t.Fatalf("NewPgnFileNamer(%q) error = %v", tt.pattern, err)
+		}
+		var got []string
+		for idx := range c.slice {
+			name := namer.Name(&c.slice[idx], idx)
+			if filepath.Dir(name) != dir {
+				t.Errorf("Name(%q) = %v is not in %v", tt.pattern, name, dir)
+			}
+			got = append(got, filepath.Base(name))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Name(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,384 @@
+// -*- coding: utf-8 -*-
+// pgnoptions.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:00:29.051775984 (1792159229)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"io"
+	"runtime"
+	"sync"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Boards can be shown either with UTF-8 characters (by default) or using only
+// ASCII characters, which is useful for consoles that can not render the
+// former properly. Also, they can be shown from the perspective of Black and
+// with the coordinates of files and ranks around them
+type BoardStyle struct {
+	ASCII       bool // whether only ASCII characters are used
+	Flipped     bool // whether the board is shown from Black's side
+	Coordinates bool // whether files and ranks are shown
+}
+
+// Comments given after a move can be written in PGN format in different ways
+type CommentFolding int
+
+// Services of this package accept an arbitrary number of functional options
+// that modify their default behaviour. Every service considers only those
+// options which are relevant to it and silently ignores the others
+type PgnOption func(*pgnOptions)
+
+// The configuration resulting from applying all options. It is unexported so
+// that new options can be added without breaking existing code
+type pgnOptions struct {
+	workers          int                     // number of simultaneous workers
+	lenient          bool                    // whether errors are skipped
+	quarantine       io.Writer               // where rejected games are written
+	maxGameSize      *int                    // maximum size of a game in bytes
+	progress         func(done, total int64) // callback to report progress
+	boardStyle       BoardStyle              // how boards are shown
+	diagramStyle     DiagramStyle            // how diagrams are drawn in LaTeX
+	commentFolding   CommentFolding          // how comments are written in PGN format
+	commentWidth     int                     // maximum width of comments, if positive
+	lineWidth        int                     // maximum width of the movetext, if positive
+	commentLines     bool                    // whether comments are written in their own lines
+	excludedCommands []string                // commands which are not written
+	parseHook        func(*PgnGame) error    // invoked after parsing every game
+	templateVars     map[string]string       // values of meta-variables in templates
+	realize          int                     // number of plies realized after parsing
+	renderContext    PgnRenderContext        // context given to templates
+	color            bool                    // whether output is colored for terminals
+	checkMarkers     bool                    // whether missing check markers are added
+	transpositions   bool                    // whether transpositions are commented
+	language         string                  // language of the comments written
+	blunders         bool                    // whether decisive mistakes are marked
+	first, last      int                     // range of ids of the games read
+	mmap             bool                    // whether files are mapped in memory
+	memoryLimit      int64                   // maximum memory used for sorting
+	fileNames        string                  // pattern of the names of files written
+	perspective      string                  // color or player scored in histograms
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Comments given after the same move can be written in the same brace blocks
+// they were found (by default), merged in a single brace block, or every
+// comment and command in a separate brace block. NAGs are never written within
+// braces
+const (
+	RawComments CommentFolding = iota
+	MergedComments
+	SeparateComments
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Services that process games in parallel use the given number of
+// simultaneous workers. If n is zero or negative, the number of CPUs is used
+func WithWorkers(n int) PgnOption {
+	return func(options *pgnOptions) {
+		if n <= 0 {
+			n = runtime.NumCPU()
+		}
+		options.workers = n
+	}
+}
+
+// Games that can not be parsed are skipped instead of returning an error
+func WithLenient() PgnOption {
+	return func(options *pgnOptions) {
+		options.lenient = true
+	}
+}
+
+// The raw text of all games that can not be parsed is written into the given
+// writer preceded by a comment with the error found
+func WithQuarantine(quarantine io.Writer) PgnOption {
+	return func(options *pgnOptions) {
+		options.quarantine = quarantine
+	}
+}
+
+// Set the maximum size in bytes of the text of a single game. Zero or negative
+// values mean that there is no limit
+func WithMaxGameSize(size int) PgnOption {
+	return func(options *pgnOptions) {
+		options.maxGameSize = &size
+	}
+}
+
+// The given callback is invoked with the amount of work done so far and the
+// total amount of work to do. The units depend on the service, e.g., bytes
+// when reading games from a file, or games when playing them. When processing
+// games in parallel the callback is never invoked simultaneously
+func WithProgress(progress func(done, total int64)) PgnOption {
+	return func(options *pgnOptions) {
+		options.progress = progress
+	}
+}
+
+// Boards are shown with the given style
+func WithBoardStyle(style BoardStyle) PgnOption {
+	return func(options *pgnOptions) {
+		options.boardStyle = style
+	}
+}
+
+// Diagrams are drawn in LaTeX templates with the given style, available as
+// .Diagram
+func WithDiagramStyle(style DiagramStyle) PgnOption {
+	return func(options *pgnOptions) {
+		options.diagramStyle = style
+	}
+}
+
+// Comments are written in PGN format folded as given
+func WithCommentFolding(folding CommentFolding) PgnOption {
+	return func(options *pgnOptions) {
+		options.commentFolding = folding
+	}
+}
+
+// Comments are re-wrapped when written in PGN format so that no line is longer
+// than the given width, unless a single word is longer. Zero or negative values
+// mean that comments are not re-wrapped
+func WithCommentWidth(width int) PgnOption {
+	return func(options *pgnOptions) {
+		options.commentWidth = width
+	}
+}
+
+// The movetext of games is arranged in lines which are not longer than the
+// given width when written in PGN format, unless a single token is longer,
+// e.g., PgnExportWidth as required by the export format. Zero or negative
+// values mean that the movetext is written in a single line
+func WithLineWidth(width int) PgnOption {
+	return func(options *pgnOptions) {
+		options.lineWidth = width
+	}
+}
+
+// Every comment is written in PGN format in its own lines, i.e., the movetext
+// is broken before and after every brace block
+func WithCommentLines() PgnOption {
+	return func(options *pgnOptions) {
+		options.commentLines = true
+	}
+}
+
+// The given commands are not written in PGN format, e.g., "emt" removes the
+// elapsed move time given in "[%emt ...]"
+func WithoutCommands(commands ...string) PgnOption {
+	return func(options *pgnOptions) {
+		options.excludedCommands = append(options.excludedCommands, commands...)
+	}
+}
+
+// The given hook is invoked with every game right after it is parsed, so that
+// games can be enriched or validated without a second pass over the
+// collection. Games are given their ids before invoking the hook. Games for
+// which the hook returns an error are rejected as if they could not be parsed
+func WithParseHook(hook func(*PgnGame) error) PgnOption {
+	return func(options *pgnOptions) {
+		options.parseHook = hook
+	}
+}
+
+// Games are realized up to the given number of plies right after parsing them,
+// i.e., the boards after each of these plies are computed. All plies are
+// realized if the given number is negative, and none if it is zero (by
+// default)
+func WithRealize(plies int) PgnOption {
+	return func(options *pgnOptions) {
+		options.realize = plies
+	}
+}
+
+// Only the games whose ids are in the range [first, last] are read from PGN
+// files, where games are numbered in the order they are found starting from 1.
+// Games preceding the range are not parsed, and reading stops right after the
+// last one. If last is zero or negative, all games from first are read
+func WithRange(first, last int) PgnOption {
+	return func(options *pgnOptions) {
+		options.first, options.last = max(first, 1), last
+	}
+}
+
+// PGN files are mapped in memory when reading their games, if possible, which
+// avoids copying their contents through intermediate buffers and is faster for
+// very large files in 64-bit systems. Files which can not be mapped, e.g., in
+// platforms which do not support it, are read as usual
+func WithMmap() PgnOption {
+	return func(options *pgnOptions) {
+		options.mmap = true
+	}
+}
+
+// Games sorted with SortTo are kept in memory only until their size exceeds the
+// given number of bytes. Then, they are written into temporary files which are
+// merged at the end. If the limit is zero or negative (by default), all games
+// are sorted in memory
+func WithMemoryLimit(bytes int64) PgnOption {
+	return func(options *pgnOptions) {
+		options.memoryLimit = bytes
+	}
+}
+
+// Meta-variables in templates are given the values in the given map, which take
+// precedence over environment variables, e.g., PGNPARSER_name, and their
+// default values
+func WithTemplateVars(vars map[string]string) PgnOption {
+	return func(options *pgnOptions) {
+		options.templateVars = vars
+	}
+}
+
+// Templates are given the render context given, available as .Context
+func WithRenderContext(context PgnRenderContext) PgnOption {
+	return func(options *pgnOptions) {
+		options.renderContext = context
+	}
+}
+
+// Output is colored with ANSI escape sequences to be shown in a terminal
+func WithColor() PgnOption {
+	return func(options *pgnOptions) {
+		options.color = true
+	}
+}
+
+// Moves of games written in PGN format are given the markers of check and
+// checkmate when they are missing, as long as the whole game has been realized
+func WithCheckMarkers() PgnOption {
+	return func(options *pgnOptions) {
+		options.checkMarkers = true
+	}
+}
+
+// Moves of games written in PGN format that reach a position already reached
+// with a different sequence of moves are given a comment with the game and move
+// where it was reached before, see FindTranspositions
+func WithTranspositions() PgnOption {
+	return func(options *pgnOptions) {
+		options.transpositions = true
+	}
+}
+
+// Games are written with the decisive mistake of the loser qualified with "??"
+// and commented, if their moves are evaluated, see MarkBlunder
+func WithBlunders() PgnOption {
+	return func(options *pgnOptions) {
+		options.blunders = true
+	}
+}
+
+// Games are written with the comments in the given language only, e.g., "en",
+// which are those given after a marker "[%lang en]" in the same comment, along
+// with the comments given before any marker, see SelectLanguage
+func WithLanguage(language string) PgnOption {
+	return func(options *pgnOptions) {
+		options.language = language
+	}
+}
+
+// Exporters writing a different file for every game or chunk name them with
+// the given pattern, e.g., "{White}-{Black}-{Date}", see PgnFileNamer
+func WithFileNames(pattern string) PgnOption {
+	return func(options *pgnOptions) {
+		options.fileNames = pattern
+	}
+}
+
+// Histograms score games from the given perspective, either a color or the name
+// of a player, see PgnHistogram.SetPerspective
+func WithPerspective(perspective string) PgnOption {
+	return func(options *pgnOptions) {
+		options.perspective = perspective
+	}
+}
+
+// Return the file namer of the given filename with the pattern given in these
+// options or, if none was given, with the given default pattern
+func (options pgnOptions) fileNamer(filename, pattern string) (*PgnFileNamer, error) {
+	if options.fileNames != "" {
+		pattern = options.fileNames
+	}
+	return NewPgnFileNamer(filename, pattern)
+}
+
+// Return the configuration resulting from applying all the given options to
+// the default configuration, which uses only one worker
+func newPgnOptions(opts ...PgnOption) pgnOptions {
+
+	options := pgnOptions{workers: 1}
+	for _, option := range opts {
+		option(&options)
+	}
+	return options
+}
+
+// Invoke the given function with all integers in the range [0, n) using the
+// number of workers given in the options, and report progress after every
+// invocation. It returns the first error found, if any, or nil otherwise. Once
+// an error is found, no more invocations are started
+func (options pgnOptions) forEach(n int, fn func(idx int) error) error {
+
+	// feed the workers with all indexes in order
+	indexes := make(chan int)
+	stop := make(chan struct{})
+	go func() {
+		defer close(indexes)
+		for idx := 0; idx < n; idx++ {
+			select {
+			case indexes <- idx:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// start all workers. Progress is reported and the first error is recorded
+	// in mutual exclusion
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var result error
+	done := int64(0)
+	for iworker := 0; iworker < max(options.workers, 1); iworker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				err := fn(idx)
+
+				mutex.Lock()
+				if err != nil && result == nil {
+					result = err
+					close(stop)
+				}
+				done += 1
+				if options.progress != nil {
+					options.progress(done, int64(n))
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	// wait for all workers to finish
+	wg.Wait()
+	return result
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
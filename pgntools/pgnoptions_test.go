@@ -0,0 +1,89 @@
+// -*- coding: utf-8 -*-
+// pgnoptions_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 17:29:52.757264884 (1792171792)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPgnOptions_forEach(t *testing.T) {
+
+	// every index is processed exactly once whatever the number of workers,
+	// and progress is reported after every invocation with the total amount
+	// of work
+	const n = 100
+	for _, workers := range []int{1, 2, 8, 200} {
+		var visits [n]atomic.Int32
+		var reports []int64
+		options := newPgnOptions(WithWorkers(workers), WithProgress(func(done, total int64) {
+			if total != n {
+				t.Errorf("WithProgress() total = %v, want %v", total, n)
+			}
+			reports = append(reports, done)
+		}))
+		if err := options.forEach(n, func(idx int) error {
+			visits[idx].Add(1)
+			return nil
+		}); err != nil {
+			t.Fatalf("forEach() error = %v", err)
+		}
+		for idx := range visits {
+			if got := visits[idx].Load(); got != 1 {
+				t.Errorf("forEach(%v workers) visited %v %v times, want 1", workers, idx, got)
+			}
+		}
+		if len(reports) != n {
+			t.Fatalf("forEach(%v workers) reported progress %v times, want %v", workers, len(reports), n)
+		}
+		for idx, done := range reports {
+			if done != int64(idx+1) {
+				t.Errorf("forEach(%v workers) reported %v at step %v, want %v", workers, done, idx, idx+1)
+			}
+		}
+	}
+
+	// the first error found is returned, no more invocations are started, and
+	// progress is reported for every invocation, including those which failed
+	failure := errors.New("failure")
+	for _, workers := range []int{1, 4} {
+		var invocations atomic.Int64
+		var reported int64
+		options := newPgnOptions(WithWorkers(workers), WithProgress(func(done, total int64) {
+			reported = done
+		}))
+		err := options.forEach(n, func(idx int) error {
+			invocations.Add(1)
+			if idx == 10 {
+				return failure
+			}
+			return nil
+		})
+		if err != failure {
+			t.Errorf("forEach(%v workers) error = %v, want %v", workers, err, failure)
+		}
+		if got := invocations.Load(); got == n || got != reported {
+			t.Errorf("forEach(%v workers) = %v invocations and %v reported, want less than %v", workers, got, reported, n)
+		}
+	}
+
+	// nothing is done with no work at all
+	if err := newPgnOptions(WithWorkers(4)).forEach(0, func(idx int) error {
+		t.Errorf("forEach(0) invoked the function with %v", idx)
+		return nil
+	}); err != nil {
+		t.Errorf("forEach(0) error = %v", err)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
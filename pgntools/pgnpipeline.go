@@ -0,0 +1,268 @@
+// -*- coding: utf-8 -*-
+// pgnpipeline.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:31:35.394852129 (1792161095)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Pipelines consist of a sequence of stages of different kinds
+type pipelineKind int
+
+// Every stage of a pipeline is given either an expression (filters and
+// sorting) or a number of games (heads)
+type pipelineStage struct {
+	kind       pipelineKind
+	expression string
+	n          int
+}
+
+// A pipeline composes filters, sorting and heads over a collection of games.
+// Stages are recorded when they are added to the pipeline and they are executed
+// only when the result is requested, either with Collect or WriteCSV, so that
+// consecutive stages can be executed in a single pass over the games
+type PgnPipeline struct {
+	source  *PgnCollection
+	stages  []pipelineStage
+	fields  []string
+	options []PgnOption
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+const (
+	filterStage pipelineKind = iota
+	sortStage
+	headStage
+)
+
+// globals
+// ----------------------------------------------------------------------------
+
+// Fields written by default in CSV format
+var defaultPipelineFields = []string{"Id", "White", "Black", "Result", "Date", "ECO"}
+
+// Fields which are just names of variables, i.e., tags and those computed by
+// pgnparser, are looked up directly instead of being evaluated
+var rePipelineVariable = regexp.MustCompile(`^\w+$`)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return true if the given game satisfies the given expression, and false
+// otherwise. The game is played only if the expression requires its boards
+func satisfies(game *PgnGame, expression string) (bool, error) {
+	if needsBoards(expression) {
+		if err := game.play(); err != nil {
+			return false, err
+		}
+	}
+	return game.Filter(expression)
+}
+
+// Return the games of the given collection which satisfy all the given filter
+// and head stages in the order given, in a single pass over the games. The
+// pass stops as soon as any head stage has been fulfilled
+func streamStages(c *PgnCollection, stages []pipelineStage) (*PgnCollection, error) {
+
+	collection := NewPgnCollection()
+	collection.idBase = c.idBase
+
+	// count the games that went through every stage
+	counts := make([]int, len(stages))
+	for idx := range c.slice {
+		game := &c.slice[idx]
+
+		// a game is selected if it goes through all stages
+		selected := true
+		for jdx, stage := range stages {
+			if stage.kind == headStage {
+				if counts[jdx] >= stage.n {
+
+					// once a head is fulfilled no more games can be selected
+					return &collection, nil
+				}
+			} else {
+				ok, err := satisfies(game, stage.expression)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					selected = false
+					break
+				}
+			}
+			counts[jdx]++
+		}
+		if selected {
+			collection.Add(*game)
+		}
+	}
+	return &collection, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a new pipeline over this collection. The given options are used in all
+// stages, e.g., WithWorkers
+func (c *PgnCollection) Pipe(opts ...PgnOption) *PgnPipeline {
+	return &PgnPipeline{
+		source:  c,
+		options: opts,
+	}
+}
+
+// Add a stage to this pipeline that selects only the games satisfying the given
+// expression. For more information, see PgnCollection.Filter
+func (p *PgnPipeline) Filter(expression string) *PgnPipeline {
+	p.stages = append(p.stages, pipelineStage{kind: filterStage, expression: expression})
+	return p
+}
+
+// Add a stage to this pipeline that sorts games according to the given
+// criteria. For more information, see PgnCollection.Sort
+func (p *PgnPipeline) Sort(spec string) *PgnPipeline {
+	p.stages = append(p.stages, pipelineStage{kind: sortStage, expression: spec})
+	return p
+}
+
+// Add a stage to this pipeline that keeps only the first n games
+func (p *PgnPipeline) Head(n int) *PgnPipeline {
+	p.stages = append(p.stages, pipelineStage{kind: headStage, n: max(0, n)})
+	return p
+}
+
+// Set the fields written by WriteCSV. Every field is either the name of a
+// variable (e.g., a tag, "Id" or "Moves") or any expression that can be used in
+// histograms. By default, "Id", "White", "Black", "Result", "Date" and "ECO"
+// are written
+func (p *PgnPipeline) Select(fields ...string) *PgnPipeline {
+	p.fields = fields
+	return p
+}
+
+// Execute all stages of this pipeline and return the resulting collection, or
+// an error if any stage failed. Consecutive filters are merged into a single
+// one, which is executed in parallel (and in two stages) unless they are
+// followed by a head, in which case all of them are executed in a single pass
+// that stops as soon as enough games are selected. Sorting requires all games
+// selected so far
+func (p *PgnPipeline) Collect() (*PgnCollection, error) {
+
+	current := p.source
+	for idx := 0; idx < len(p.stages); {
+
+		// sorting stages are executed directly
+		if p.stages[idx].kind == sortStage {
+			sorted, err := current.Sort(p.stages[idx].expression, p.options...)
+			if err != nil {
+				return nil, err
+			}
+			current, idx = sorted, idx+1
+			continue
+		}
+
+		// otherwise, take all consecutive filters and heads
+		end, streamed := idx, false
+		for end < len(p.stages) && p.stages[end].kind != sortStage {
+			streamed = streamed || p.stages[end].kind == headStage
+			end++
+		}
+
+		// if there are no heads, all filters are merged into a single one
+		if !streamed {
+			expression := ""
+			for _, stage := range p.stages[idx:end] {
+				if expression != "" {
+					expression += " && "
+				}
+				expression += "(" + stage.expression + ")"
+			}
+			filtered, err := current.Filter(expression, p.options...)
+			if err != nil {
+				return nil, err
+			}
+			current = filtered
+		} else {
+			filtered, err := streamStages(current, p.stages[idx:end])
+			if err != nil {
+				return nil, err
+			}
+			current = filtered
+		}
+		idx = end
+	}
+
+	// if no stage was given, return a copy of the source collection
+	if current == p.source {
+		collection := NewPgnCollection()
+		collection.idBase = p.source.idBase
+		for _, igame := range p.source.slice {
+			collection.Add(igame)
+		}
+		return &collection, nil
+	}
+	return current, nil
+}
+
+// Execute all stages of this pipeline and write the selected fields of all
+// resulting games in CSV format in the given writer, with a header row that
+// contains the names of the fields. It returns any error found or nil otherwise
+func (p *PgnPipeline) WriteCSV(writer io.Writer) error {
+
+	games, err := p.Collect()
+	if err != nil {
+		return err
+	}
+
+	fields := p.fields
+	if len(fields) == 0 {
+		fields = defaultPipelineFields
+	}
+	output := csv.NewWriter(writer)
+	if err := output.Write(fields); err != nil {
+		return err
+	}
+
+	// and now write a record per game
+	for idx := range games.slice {
+		game := &games.slice[idx]
+		env := game.getEnv(fields...)
+		record := make([]string, len(fields))
+		for jdx, field := range fields {
+			if rePipelineVariable.MatchString(field) {
+				if value, ok := env[field]; ok {
+					record[jdx] = fmt.Sprintf("%v", value)
+				}
+				continue
+			}
+			if record[jdx], err = game.getResult(field); err != nil {
+				return err
+			}
+		}
+		if err := output.Write(record); err != nil {
+			return err
+		}
+	}
+	output.Flush()
+	return output.Error()
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,57 @@
+// -*- coding: utf-8 -*-
+// pgnpipeline_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:31:55.732055998 (1792161115)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPgnPipeline(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, white := range []string{"alice", "bob", "carol"} {
+		game, err := getGameFromString(`[White "` + white + `"]
+[Black "dave"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 1-0`)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	tests := []struct {
+		name     string
+		pipeline *PgnPipeline
+		want     string
+	}{
+		{"all", c.Pipe().Select("Id", "White"), "Id,White\n1,alice\n2,bob\n3,carol\n"},
+		{"head", c.Pipe().Head(2).Select("White", "Moves", "Unknown"), "White,Moves,Unknown\nalice,2,\nbob,2,\n"},
+		{"heads", c.Pipe().Head(2).Head(5).Head(1), "Id,White,Black,Result,Date,ECO\n1,alice,dave,1-0,,\n"},
+		{"empty", c.Pipe().Head(0), "Id,White,Black,Result,Date,ECO\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var builder strings.Builder
+			if err := tt.pipeline.WriteCSV(&builder); err != nil {
+				t.Fatalf("WriteCSV() error = %v", err)
+			}
+			if got := builder.String(); got != tt.want {
+				t.Errorf("WriteCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
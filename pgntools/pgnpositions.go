@@ -0,0 +1,149 @@
+// -*- coding: utf-8 -*-
+// pgnpositions.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:39:17.853674903 (1792168757)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Every occurrence of a position is located with the id of the game where it
+// was reached and the number of plies played to reach it, zero being the
+// initial position
+type PgnPositionEntry struct {
+	Id  int `json:"id"`
+	Ply int `json:"ply"`
+}
+
+// A position index maps the Zobrist hash of every position reached in a
+// collection of games to all its occurrences, so that positions can be searched
+// without playing the games again. Indexes can be saved in a file and, to detect
+// stale indexes, they might also store the size and modification time of the
+// PGN file they were built from
+type PgnPositionIndex struct {
+	Size      int64                         `json:"size"`
+	ModTime   time.Time                     `json:"modtime"`
+	Positions map[uint64][]PgnPositionEntry `json:"positions"`
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the position index stored in the given file, and nil if no error was
+// found
+func LoadPgnPositionIndex(filename string) (*PgnPositionIndex, error) {
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var index PgnPositionIndex
+	if err := json.Unmarshal(contents, &index); err != nil {
+		return nil, fmt.Errorf(" The position index '%v' is not valid: %v", filename, err)
+	}
+	return &index, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the position index of all games in this collection, and any error
+// found. Games are played if necessary, in parallel with the number of workers
+// given WithWorkers
+func (c PgnCollection) BuildPositionIndex(opts ...PgnOption) (*PgnPositionIndex, error) {
+
+	// play all games first. Because every worker accesses a different game,
+	// no synchronization is needed
+	options := newPgnOptions(opts...)
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		return c.slice[idx].play()
+	}); err != nil {
+		return nil, err
+	}
+
+	// and add every position to the index in the order games are found
+	index := PgnPositionIndex{Positions: make(map[uint64][]PgnPositionEntry)}
+	for _, igame := range c.slice {
+		for ply, board := range igame.boards {
+			index.Positions[board.hash] = append(index.Positions[board.hash], PgnPositionEntry{Id: igame.id, Ply: ply})
+		}
+	}
+	return &index, nil
+}
+
+// Return the position index of the given collection of games which have been
+// read from this PgnFile, so that stale indexes can be detected with IsFresh
+func (f PgnFile) PositionIndex(games PgnCollection, opts ...PgnOption) (*PgnPositionIndex, error) {
+
+	index, err := games.BuildPositionIndex(opts...)
+	if err != nil {
+		return nil, err
+	}
+	index.Size, index.ModTime = f.size, f.modtime
+	return index, nil
+}
+
+// Return the name of the file where the position index of this PgnFile is
+// stored by default, which is named after it with extension ".pos"
+func (f PgnFile) PositionIndexName() string {
+	return f.name + ".pos"
+}
+
+// Return true if this index was built from the current contents of the given
+// PgnFile, i.e., if its size and modification time did not change
+func (index PgnPositionIndex) IsFresh(f PgnFile) bool {
+	return index.Size == f.size && index.ModTime.Equal(f.modtime)
+}
+
+// Write this index in the given file, and return nil if no error was found
+func (index PgnPositionIndex) Save(filename string) error {
+
+	contents, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, contents, 0644)
+}
+
+// Return all occurrences of the position given with its FEN code in this index,
+// sorted by the id of the games and the number of plies. Halfmove clocks and
+// fullmove numbers are ignored, so that transpositions and repetitions are
+// found as well. An error is returned if the FEN code is not valid
+func (index PgnPositionIndex) SearchPosition(fen string) ([]PgnPositionEntry, error) {
+
+	board, err := NewPgnBoardFromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	entries := slices.Clone(index.Positions[board.Hash()])
+	slices.SortStableFunc(entries, func(a, b PgnPositionEntry) int {
+		if a.Id != b.Id {
+			return a.Id - b.Id
+		}
+		return a.Ply - b.Ply
+	})
+	return entries, nil
+}
+
+// Occurrences of positions are shown with the id of the game and the number of
+// plies played to reach the position
+func (entry PgnPositionEntry) String() string {
+	return fmt.Sprintf("game #%v (ply %v)", entry.Id, entry.Ply)
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
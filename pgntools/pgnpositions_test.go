@@ -0,0 +1,76 @@
+// -*- coding: utf-8 -*-
+// pgnpositions_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:39:41.479113024 (1792168781)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPgnCollection_BuildPositionIndex(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, pgn := range []string{
+		"1. e4 e5 2. Nf3 Nc6 3. Bb5 *",
+		"1. Nf3 Nc6 2. e4 e5 3. Bc4 *",
+		"1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 *",
+	} {
+		game, err := getGameFromString("[Event \"?\"]\n\n" + pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		c.Add(*game)
+	}
+	index, err := c.BuildPositionIndex(WithWorkers(2))
+	if err != nil {
+		t.Fatalf("BuildPositionIndex() error = %v", err)
+	}
+
+	// positions are found by transposition and repetition, regardless of
+	// their move numbers
+	for _, tt := range []struct {
+		fen  string
+		want []PgnPositionEntry
+	}{
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3", []PgnPositionEntry{{1, 4}, {2, 4}}},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", []PgnPositionEntry{{1, 0}, {2, 0}, {3, 0}, {3, 4}}},
+		{"rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R b KQkq - 1 1", []PgnPositionEntry{{2, 1}, {3, 1}, {3, 5}}},
+		{"rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R w KQkq - 1 1", nil},
+	} {
+		got, err := index.SearchPosition(tt.fen)
+		if err != nil {
+			t.Fatalf("SearchPosition() error = %v", err)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("SearchPosition(%q) = %v, want %v", tt.fen, got, tt.want)
+		}
+	}
+	if _, err := index.SearchPosition("8/8/8/8 w - -"); err == nil {
+		t.Error("SearchPosition() should fail with an invalid FEN code")
+	}
+
+	// indexes are the same after saving and loading them
+	filename := filepath.Join(t.TempDir(), "games.pos")
+	if err := index.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPgnPositionIndex(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Positions, index.Positions) {
+		t.Error("LoadPgnPositionIndex() does not return the index saved")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,100 @@
+// -*- coding: utf-8 -*-
+// pgnsample.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:01:23.491556217 (1792162883)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a new pseudo-random generator initialized with the given seed. The
+// algorithm (PCG) is fully specified so that the same sequence is generated in
+// every run and every machine. Because every service uses its own generator,
+// they can be safely used in parallel
+func newRandom(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a new collection with the games of this one in the given positions,
+// in the same order they are given. Games keep their ids
+func (c PgnCollection) subset(indexes []int) *PgnCollection {
+
+	collection := NewPgnCollection()
+	collection.idBase = c.idBase
+	for _, idx := range indexes {
+		collection.Add(c.slice[idx])
+	}
+	return &collection
+}
+
+// Return a new collection with the games of this one in a random order
+// determined by the given seed, so that the same seed always produces the same
+// order. Games keep their ids
+func (c PgnCollection) Shuffle(seed uint64) *PgnCollection {
+
+	indexes := make([]int, len(c.slice))
+	for idx := range indexes {
+		indexes[idx] = idx
+	}
+	newRandom(seed).Shuffle(len(indexes), func(i, j int) {
+		indexes[i], indexes[j] = indexes[j], indexes[i]
+	})
+	return c.subset(indexes)
+}
+
+// Return a new collection with n games of this one chosen at random without
+// replacement, in the same order they are found in this collection. The same
+// seed always produces the same sample. If n is larger than the number of
+// games all of them are returned, and an error is returned if it is negative
+func (c PgnCollection) Sample(n int, seed uint64) (*PgnCollection, error) {
+
+	if n < 0 {
+		return nil, fmt.Errorf(" Invalid size of the sample %v", n)
+	}
+
+	// choose the first n positions of a random permutation and sort them
+	indexes := newRandom(seed).Perm(len(c.slice))
+	indexes = indexes[:min(n, len(indexes))]
+	slices.Sort(indexes)
+	return c.subset(indexes), nil
+}
+
+// Split the games of this collection in two new collections chosen at random,
+// e.g., to train and test models over datasets of games. The first one
+// contains the given fraction of games (rounded down) and the second one the
+// rest, both in the same order they are found in this collection. The same
+// seed always produces the same split. It returns an error if the fraction is
+// not in the range [0, 1]
+func (c PgnCollection) Split(fraction float64, seed uint64) (*PgnCollection, *PgnCollection, error) {
+
+	if fraction < 0 || fraction > 1 {
+		return nil, nil, fmt.Errorf(" Invalid fraction %v. It should be in the range [0, 1]", fraction)
+	}
+
+	// the games in the first positions of a random permutation go to the first
+	// collection, and the others to the second one
+	indexes := newRandom(seed).Perm(len(c.slice))
+	n := int(fraction * float64(len(indexes)))
+	first, second := indexes[:n], indexes[n:]
+	slices.Sort(first)
+	slices.Sort(second)
+	return c.subset(first), c.subset(second), nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,96 @@
+// -*- coding: utf-8 -*-
+// pgnsample_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:01:34.159267866 (1792162894)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"slices"
+	"testing"
+)
+
+// Return a collection with the given number of games
+func newSampleCollection(t *testing.T, n int) PgnCollection {
+	games := NewPgnCollection()
+	for idx := 0; idx < n; idx++ {
+		game, err := ParseGame("[Event \"Sample\"]\n\n1. e4 e5 *")
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		games.Add(*game)
+	}
+	return games
+}
+
+func TestPgnCollection_Shuffle(t *testing.T) {
+
+	games := newSampleCollection(t, 20)
+	shuffled := collectionIds(*games.Shuffle(42))
+
+	// the same seed produces the same order, which is a permutation of the
+	// games
+	if again := collectionIds(*games.Shuffle(42)); !slices.Equal(shuffled, again) {
+		t.Errorf("Shuffle() = %v and %v with the same seed", shuffled, again)
+	}
+	if other := collectionIds(*games.Shuffle(43)); slices.Equal(shuffled, other) {
+		t.Errorf("Shuffle() = %v with different seeds", shuffled)
+	}
+	sorted := slices.Clone(shuffled)
+	slices.Sort(sorted)
+	if !slices.Equal(sorted, collectionIds(games)) {
+		t.Errorf("Shuffle() = %v is not a permutation of the games", shuffled)
+	}
+}
+
+func TestPgnCollection_Sample(t *testing.T) {
+
+	games := newSampleCollection(t, 20)
+	sample, err := games.Sample(5, 7)
+	if err != nil {
+		t.Fatalf("Sample() error = %v", err)
+	}
+	ids := collectionIds(*sample)
+	if len(ids) != 5 || !slices.IsSorted(ids) {
+		t.Errorf("Sample() = %v, want 5 games in order", ids)
+	}
+	if again, _ := games.Sample(5, 7); !slices.Equal(ids, collectionIds(*again)) {
+		t.Errorf("Sample() = %v and %v with the same seed", ids, collectionIds(*again))
+	}
+	if all, _ := games.Sample(30, 7); all.Len() != 20 {
+		t.Errorf("Sample() = %v games, want 20", all.Len())
+	}
+	if _, err := games.Sample(-1, 7); err == nil {
+		t.Errorf("Sample() accepted a negative size")
+	}
+}
+
+func TestPgnCollection_Split(t *testing.T) {
+
+	games := newSampleCollection(t, 20)
+	train, test, err := games.Split(0.8, 1)
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	if train.Len() != 16 || test.Len() != 4 {
+		t.Fatalf("Split() = %v and %v games, want 16 and 4", train.Len(), test.Len())
+	}
+
+	// both collections are disjoint and contain all games
+	all := append(collectionIds(*train), collectionIds(*test)...)
+	slices.Sort(all)
+	if !slices.Equal(all, collectionIds(games)) {
+		t.Errorf("Split() = %v and %v", collectionIds(*train), collectionIds(*test))
+	}
+	if _, _, err := games.Split(1.5, 1); err == nil {
+		t.Errorf("Split() accepted an invalid fraction")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,133 @@
+// -*- coding: utf-8 -*-
+// pgnsheet.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:30:25.312228073 (1792164625)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+)
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Sizes in pixels of the elements of contact sheets: squares of every board,
+// margins between boards, height of the label shown below every board and
+// height of the title of the game
+const (
+	sheetSquare = 20
+	sheetMargin = 12
+	sheetLabel  = 18
+	sheetTitle  = 30
+)
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the plies of this game shown in its contact sheet when showing a board
+// every given number of plies: the initial position, every multiple of the
+// given number and the final position
+func (game *PgnGame) getSheetPlies(every int) (plies []int) {
+	for ply := 0; ply < len(game.moves); ply += every {
+		plies = append(plies, ply)
+	}
+	return append(plies, len(game.moves))
+}
+
+// Return the label shown below the board reached after the given ply of this
+// game in its contact sheet, i.e., the last move played
+func (game *PgnGame) getSheetLabel(ply int) string {
+	if ply == 0 {
+		return "Start"
+	}
+	move := game.moves[ply-1]
+	return fmt.Sprintf("%v%v %v", move.number, move.getColorPrefix(), move.shortAlgebraic)
+}
+
+// Return a contact sheet of this game in SVG format: a single image with a grid
+// of small boards, with the given number of columns, showing the positions
+// reached every given number of plies, along with the initial and final
+// positions. Every board is labeled with the last move played, whose squares
+// are highlighted. The game is realized if necessary. It returns an error if
+// either number is not strictly positive or the game could not be realized
+func (game *PgnGame) GetContactSheet(every, columns int) (string, error) {
+
+	if every <= 0 || columns <= 0 {
+		return "", errors.New(" The number of plies between boards and the number of columns must be strictly positive")
+	}
+	if err := game.play(); err != nil {
+		return "", err
+	}
+
+	// compute the size of the whole image
+	plies := game.getSheetPlies(every)
+	columns = min(columns, len(plies))
+	rows := (len(plies) + columns - 1) / columns
+	cellWidth, cellHeight := 8*sheetSquare+sheetMargin, 8*sheetSquare+sheetLabel+sheetMargin
+	width, height := sheetMargin+columns*cellWidth, sheetTitle+rows*cellHeight
+
+	// write the title of the game
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %v %v">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&builder, `<rect width="%v" height="%v" fill="white"/>`+"\n", width, height)
+	fmt.Fprintf(&builder, `<text x="%v" y="%v" font-size="16" font-family="sans-serif">%v</text>`+"\n",
+		sheetMargin, sheetTitle-10, html.EscapeString(fmt.Sprintf("%v - %v, %v (%v)",
+			game.getTag("White"), game.getTag("Black"), game.getTag("Date"), game.outcome)))
+
+	// and every board along with its label
+	for idx, ply := range plies {
+		x := sheetMargin + (idx%columns)*cellWidth
+		y := sheetTitle + (idx/columns)*cellHeight
+		var highlighted []int
+		if ply > 0 {
+			move := game.moves[ply-1]
+			highlighted = []int{coords[move.from], coords[move.to]}
+		}
+		game.boards[ply].writeSVG(&builder, x, y, sheetSquare, highlighted...)
+		fmt.Fprintf(&builder, `<text x="%v" y="%v" font-size="12" font-family="sans-serif" text-anchor="middle">%v</text>`+"\n",
+			x+4*sheetSquare, y+8*sheetSquare+sheetLabel-5, html.EscapeString(game.getSheetLabel(ply)))
+	}
+	builder.WriteString("</svg>\n")
+	return builder.String(), nil
+}
+
+// Write the contact sheet of every game in this collection, computed with
+// GetContactSheet, in a different file. By default, files are named after the
+// given filename with the id of every game before its extension, e.g.,
+// "sheet-12.svg" for the game with id 12 if "sheet.svg" is given, unless a
+// different pattern is given with WithFileNames. It returns the names of all
+// files written and any error found
+func (c PgnCollection) GetContactSheets(filename string, every, columns int, opts ...PgnOption) ([]string, error) {
+
+	namer, err := newPgnOptions(opts...).fileNamer(filename, "{Base}-{Id}")
+	if err != nil {
+		return nil, err
+	}
+	filenames := make([]string, 0)
+	for idx := range c.slice {
+		sheet, err := c.slice[idx].GetContactSheet(every, columns)
+		if err != nil {
+			return filenames, err
+		}
+		name := namer.Name(&c.slice[idx], idx)
+		if err := os.WriteFile(name, []byte(sheet), 0644); err != nil {
+			return filenames, err
+		}
+		filenames = append(filenames, name)
+	}
+	return filenames, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,84 @@
+// -*- coding: utf-8 -*-
+// pgnsheet_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:30:37.455424108 (1792164637)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPgnGame_GetContactSheet(t *testing.T) {
+
+	game, err := ParseGame(`[White "Alice"]
+[Black "Bob & Carol"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	// the initial and final positions are always shown
+	if plies := game.getSheetPlies(3); !slices.Equal(plies, []int{0, 3, 6, 7}) {
+		t.Errorf("getSheetPlies(3) = %v, want [0 3 6 7]", plies)
+	}
+
+	// and every board is drawn with all its squares and labeled with the
+	// last move
+	sheet, err := game.GetContactSheet(3, 2)
+	if err != nil {
+		t.Fatalf("GetContactSheet() error = %v", err)
+	}
+	if got := strings.Count(sheet, "<rect"); got != 1+4*64 {
+		t.Errorf("GetContactSheet() has %v rectangles, want %v", got, 1+4*64)
+	}
+	for _, label := range []string{"Start", "2. Qh5", "3... Nf6", "4. Qxf7#", "Alice - Bob &amp; Carol"} {
+		if !strings.Contains(sheet, ">"+label) {
+			t.Errorf("GetContactSheet() does not contain '%v'", label)
+		}
+	}
+	if got := strings.Count(sheet, svgHighlight); got != 2*3 {
+		t.Errorf("GetContactSheet() has %v highlighted squares, want 6", got)
+	}
+
+	// wrong numbers of plies or columns are rejected
+	if _, err := game.GetContactSheet(0, 2); err == nil {
+		t.Errorf("GetContactSheet(0, 2) expected an error")
+	}
+}
+
+func TestPgnCollection_GetContactSheets(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, pgn := range []string{"[Event \"A\"]\n\n1. e4 e5 *", "[Event \"B\"]\n\n1. d4 d5 *"} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	// every game is written in its own file named after its id
+	dir := t.TempDir()
+	filenames, err := c.GetContactSheets(filepath.Join(dir, "sheet.svg"), 1, 3)
+	if err != nil {
+		t.Fatalf("GetContactSheets() error = %v", err)
+	}
+	if want := []string{filepath.Join(dir, "sheet-1.svg"), filepath.Join(dir, "sheet-2.svg")}; !slices.Equal(filenames, want) {
+		t.Fatalf("GetContactSheets() = %v, want %v", filenames, want)
+	}
+	for _, filename := range filenames {
+		if contents, err := os.ReadFile(filename); err != nil || !strings.HasPrefix(string(contents), "<svg") {
+			t.Errorf("GetContactSheets() wrote a wrong file '%v' (error = %v)", filename, err)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// -*- coding: utf-8 -*-
+// pgnspill.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:30:35.570870976 (1792168235)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Games sorted on disk are stored as records with the keys used for sorting
+// them and their raw text
+type spillRecord struct {
+	Keys []string
+	Text string
+}
+
+// A spill sorter sorts games with an external merge sort. Records are kept in
+// memory until their size exceeds the memory limit. Then, they are sorted and
+// written into a temporary file (a run), so that only one record per run has to
+// be kept in memory when merging them. If the memory limit is not positive, all
+// records are sorted in memory
+type spillSorter struct {
+	criteria criteriaSorting // criteria used for sorting games
+	limit    int64           // maximum number of bytes kept in memory
+	batch    []spillRecord   // records not written to disk yet
+	size     int64           // size of the batch in bytes
+	runs     []string        // names of the temporary files written so far
+}
+
+// Runs are merged using a heap with the next record of each one. Ties are
+// broken in favour of the first run, so that the sort is stable
+type spillRun struct {
+	decoder *json.Decoder
+	record  spillRecord
+	index   int
+}
+
+type spillHeap struct {
+	runs     []*spillRun
+	criteria criteriaSorting
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// memory sizes are given as a number optionally followed by a unit
+const reMemory = `^\s*(?P<value>\d+)\s*(?P<unit>[KMGT]?)B?\s*$`
+
+// globals
+// ----------------------------------------------------------------------------
+
+// multipliers of every unit of memory
+var memoryUnits = map[string]int64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the number of bytes given in the string spec, which consists of a
+// number optionally followed by a unit (K, M, G or T, possibly followed by B),
+// e.g., "512M" or "2GB". Units are case insensitive and are powers of 1024. An
+// empty string is interpreted as zero, i.e., no limit
+func ParseMemorySize(spec string) (int64, error) {
+
+	if strings.TrimSpace(spec) == "" {
+		return 0, nil
+	}
+	groups := regexp.MustCompile(reMemory).FindStringSubmatch(strings.ToUpper(spec))
+	if groups == nil {
+		return 0, fmt.Errorf(" Invalid memory size '%v'", spec)
+	}
+	value, err := strconv.ParseInt(groups[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(" Invalid memory size '%v'", spec)
+	}
+	return value * memoryUnits[groups[2]], nil
+}
+
+// Return a new spill sorter which sorts records according to the given criteria
+// keeping in memory at most limit bytes
+func newSpillSorter(criteria criteriaSorting, limit int64) *spillSorter {
+	return &spillSorter{
+		criteria: criteria,
+		limit:    limit,
+	}
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Add a record with the given keys and text to this sorter. If the memory limit
+// is exceeded, the records in memory are written to disk
+func (s *spillSorter) add(keys []string, text string) error {
+
+	s.batch = append(s.batch, spillRecord{Keys: keys, Text: text})
+	s.size += int64(len(text))
+	for _, key := range keys {
+		s.size += int64(len(key))
+	}
+	if s.limit > 0 && s.size > s.limit {
+		return s.spill()
+	}
+	return nil
+}
+
+// Sort the records in memory and write them into a new temporary file
+func (s *spillSorter) spill() error {
+
+	s.sortBatch()
+	file, err := os.CreateTemp("", "pgnparser-*.spill")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	s.runs = append(s.runs, file.Name())
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range s.batch {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	s.batch, s.size = nil, 0
+	return nil
+}
+
+// Sort the records in memory preserving the order of those which are equal
+func (s *spillSorter) sortBatch() {
+	sort.SliceStable(s.batch, func(i, j int) bool {
+		return lessKeys(s.batch[i].Keys, s.batch[j].Keys, s.criteria)
+	})
+}
+
+// Write the text of all records added to this sorter into the given writer in
+// ascending order of their keys and return the number of records written. If
+// nothing was written to disk, records are sorted in memory. Otherwise, the
+// remaining records are written to disk as well, and all runs are merged
+func (s *spillSorter) writeTo(writer io.Writer) (int, error) {
+
+	output := bufio.NewWriter(writer)
+	write := func(text string) error {
+		_, err := output.WriteString(strings.TrimSpace(text) + "\n\n")
+		return err
+	}
+
+	// In case everything fits in memory, just sort it
+	nbrecords := 0
+	if len(s.runs) == 0 {
+		s.sortBatch()
+		for _, record := range s.batch {
+			if err := write(record.Text); err != nil {
+				return nbrecords, err
+			}
+			nbrecords++
+		}
+		return nbrecords, output.Flush()
+	}
+
+	// Otherwise, write the last records to disk and merge all runs. The heap
+	// is initialized with the first record of each one
+	if len(s.batch) > 0 {
+		if err := s.spill(); err != nil {
+			return 0, err
+		}
+	}
+	h := &spillHeap{criteria: s.criteria}
+	for idx, name := range s.runs {
+		file, err := os.Open(name)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		run := &spillRun{decoder: json.NewDecoder(bufio.NewReader(file)), index: idx}
+		if err := run.decoder.Decode(&run.record); err == nil {
+			h.runs = append(h.runs, run)
+		} else if err != io.EOF {
+			return 0, err
+		}
+	}
+	heap.Init(h)
+
+	// and repeatedly take the least record, replacing it with the next one of
+	// the same run, if any
+	for h.Len() > 0 {
+		run := h.runs[0]
+		if err := write(run.record.Text); err != nil {
+			return nbrecords, err
+		}
+		nbrecords++
+		run.record = spillRecord{}
+		if err := run.decoder.Decode(&run.record); err == nil {
+			heap.Fix(h, 0)
+		} else if err == io.EOF {
+			heap.Pop(h)
+		} else {
+			return nbrecords, err
+		}
+	}
+	return nbrecords, output.Flush()
+}
+
+// Remove all temporary files written by this sorter
+func (s *spillSorter) Close() error {
+
+	var result error
+	for _, name := range s.runs {
+		if err := os.Remove(name); err != nil && result == nil {
+			result = err
+		}
+	}
+	s.runs = nil
+	return result
+}
+
+// The heap of runs implements heap.Interface
+func (h spillHeap) Len() int {
+	return len(h.runs)
+}
+
+func (h spillHeap) Less(i, j int) bool {
+	if lessKeys(h.runs[i].record.Keys, h.runs[j].record.Keys, h.criteria) {
+		return true
+	}
+	if lessKeys(h.runs[j].record.Keys, h.runs[i].record.Keys, h.criteria) {
+		return false
+	}
+	return h.runs[i].index < h.runs[j].index
+}
+
+func (h spillHeap) Swap(i, j int) {
+	h.runs[i], h.runs[j] = h.runs[j], h.runs[i]
+}
+
+func (h *spillHeap) Push(x any) {
+	h.runs = append(h.runs, x.(*spillRun))
+}
+
+func (h *spillHeap) Pop() any {
+	run := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return run
+}
+
+// Write all games in this PgnFile into the given writer sorted according to the
+// given specification string, which follows the syntax of Sort, and return the
+// number of games written. Unlike Sort, games are not kept in memory: only their
+// raw text along with their sorting keys are, and once they exceed the limit
+// given WithMemoryLimit they are sorted and written into temporary files which
+// are merged at the end. Thus, collections larger than the available memory can
+// be sorted. Games are written verbatim, as they were found in the file.
+//
+// All other options are applied when reading the games of this PgnFile, as in
+// Games
+func (f PgnFile) SortTo(writer io.Writer, spec string, opts ...PgnOption) (int, error) {
+
+	// parse the given specification string
+	criteria, err := getSortingCriteria(spec)
+	if err != nil {
+		return 0, err
+	}
+
+	// and add every game read to a spill sorter along with its sorting keys
+	options := newPgnOptions(opts...)
+	sorter := newSpillSorter(criteria, options.memoryLimit)
+	defer sorter.Close()
+	f.consume = func(game *PgnGame, text string) error {
+		keys, err := game.sortingKeys(criteria)
+		if err != nil {
+			return fmt.Errorf(" Error while sorting games: '%v'\n", err)
+		}
+		return sorter.add(keys, text)
+	}
+	if _, err := f.Games(opts...); err != nil {
+		return 0, err
+	}
+
+	// Finally, write all games in order
+	return sorter.writeTo(writer)
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
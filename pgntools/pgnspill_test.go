@@ -0,0 +1,149 @@
+// -*- coding: utf-8 -*-
+// pgnspill_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:33:05.217337814 (1792168385)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMemorySize(t *testing.T) {
+
+	for spec, want := range map[string]int64{
+		"":      0,
+		"1024":  1024,
+		"2K":    2048,
+		"512M":  512 << 20,
+		"1gb":   1 << 30,
+		" 3 MB": 3 << 20,
+	} {
+		if got, err := ParseMemorySize(spec); err != nil || got != want {
+			t.Errorf("ParseMemorySize(%q) = (%v, %v), want %v", spec, got, err, want)
+		}
+	}
+	for _, spec := range []string{"M", "-1M", "12Q", "1.5G"} {
+		if _, err := ParseMemorySize(spec); err == nil {
+			t.Errorf("ParseMemorySize(%q) should fail", spec)
+		}
+	}
+}
+
+func Test_getSortingCriteria(t *testing.T) {
+
+	criteria, err := getSortingCriteria("< WhiteElo; > Date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := criteriaSorting{{increasing, "WhiteElo"}, {decreasing, "Date"}}
+	if len(criteria) != len(want) || criteria[0] != want[0] || criteria[1] != want[1] {
+		t.Errorf("getSortingCriteria() = %v, want %v", criteria, want)
+	}
+	if _, err := getSortingCriteria("WhiteElo"); err == nil {
+		t.Error("getSortingCriteria() should fail without a direction")
+	}
+}
+
+func Test_spillSorter(t *testing.T) {
+
+	records := []spillRecord{
+		{[]string{"b", "2"}, "first"},
+		{[]string{"a", "1"}, "second"},
+		{[]string{"c", "1"}, "third"},
+		{[]string{"a", "2"}, "fourth"},
+		{[]string{"b", "2"}, "fifth"},
+		{[]string{"a", "1"}, "sixth"},
+	}
+	criteria := criteriaSorting{{increasing, "first"}, {decreasing, "second"}}
+	want := "fourth\n\nsecond\n\nsixth\n\nfirst\n\nfifth\n\nthird\n\n"
+
+	// the result is the same whether records are spilled to disk or not, and
+	// the order of records with the same keys is preserved
+	for _, limit := range []int64{0, 1, 12, 1 << 20} {
+		sorter := newSpillSorter(criteria, limit)
+		for _, record := range records {
+			if err := sorter.add(record.Keys, record.Text); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if limit == 1 && len(sorter.runs) != len(records) {
+			t.Errorf("%v runs were written, want %v", len(sorter.runs), len(records))
+		}
+		var output bytes.Buffer
+		if n, err := sorter.writeTo(&output); err != nil || n != len(records) {
+			t.Errorf("writeTo() = (%v, %v) with limit %v", n, err, limit)
+		}
+		if output.String() != want {
+			t.Errorf("writeTo() with limit %v = %q, want %q", limit, output.String(), want)
+		}
+
+		// temporary files are removed once the sorter is closed
+		runs := sorter.runs
+		if err := sorter.Close(); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range runs {
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				t.Errorf("'%v' was not removed", name)
+			}
+		}
+	}
+}
+
+func TestPgnFile_consume(t *testing.T) {
+
+	contents := `[White "alice"]
+[Black "bob"]
+
+1. e4 e5 1-0
+
+[White "bob"]
+[Black "carol"]
+
+1. d4 d5 1/2-1/2
+`
+	name := filepath.Join(t.TempDir(), "games.pgn")
+	if err := os.WriteFile(name, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pgnfile, err := NewPgnFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// games consumed are given with their raw text and consecutive ids, and
+	// they are not added to the collection
+	var ids []int
+	var texts []string
+	pgnfile.consume = func(game *PgnGame, text string) error {
+		ids = append(ids, game.Id())
+		texts = append(texts, strings.TrimSpace(text))
+		return nil
+	}
+	games, err := pgnfile.Games()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if games.Len() != 0 || games.ParseStats().Games != 2 {
+		t.Errorf("%v games were stored and %v parsed, want 0 and 2", games.Len(), games.ParseStats().Games)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("ids = %v, want [1 2]", ids)
+	}
+	if len(texts) != 2 || !strings.HasPrefix(texts[1], `[White "bob"]`) || !strings.HasSuffix(texts[1], "1/2-1/2") {
+		t.Errorf("texts = %q", texts)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,242 @@
+// -*- coding: utf-8 -*-
+// pgnstandings.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:05:31.601267220 (1792159531)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/clinaresl/table"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A scoring system defines the points awarded to a player for every win, draw
+// and loss
+type PgnScoring struct {
+	Win, Draw, Loss float64
+}
+
+// The standing of a player consists of the number of games played, won, drawn
+// and lost, and the overall number of points obtained
+type PgnStanding struct {
+	Player                     string
+	Games, Wins, Draws, Losses int
+	Points                     float64
+}
+
+// Standings consist of the standings of all players in every group of games,
+// e.g., in every event. Groups are sorted in lexicographical order and players
+// within every group are sorted in decreasing order of points, then in
+// decreasing order of wins, and finally in lexicographical order of their
+// names
+type PgnStandings struct {
+	groupBy string
+	groups  []string
+	players map[string][]PgnStanding
+}
+
+// vars
+// ----------------------------------------------------------------------------
+
+// The conventional scoring system used in chess
+var ChessScoring = PgnScoring{Win: 1, Draw: 0.5, Loss: 0}
+
+// The scoring system used in football, which has been adopted by some chess
+// tournaments to discourage draws
+var FootballScoring = PgnScoring{Win: 3, Draw: 1, Loss: 0}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a new scoring system from a string with the points awarded for every
+// win, draw and loss separated by dashes, e.g., "3-1-0", and nil. If the string
+// is not well formed an error is returned
+func NewPgnScoring(spec string) (PgnScoring, error) {
+
+	// first, get the points of every result
+	fields := strings.Split(spec, "-")
+	if len(fields) != 3 {
+		return PgnScoring{}, fmt.Errorf(" Invalid scoring '%v'. It should be 'win-draw-loss'", spec)
+	}
+	points := make([]float64, len(fields))
+	for idx, ifield := range fields {
+		value, err := strconv.ParseFloat(strings.TrimSpace(ifield), 64)
+		if err != nil {
+			return PgnScoring{}, fmt.Errorf(" Invalid points '%v' in scoring '%v'", ifield, spec)
+		}
+		points[idx] = value
+	}
+
+	// and return the scoring system
+	return PgnScoring{Win: points[0], Draw: points[1], Loss: points[2]}, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the points obtained by the player with the given color (1 for White
+// and -1 for Black) in a game with the given outcome according to this scoring
+// system. The second value is false if the game was not properly ended, in
+// which case the points returned are meaningless
+func (scoring PgnScoring) Points(outcome PgnOutcome, color int) (float64, bool) {
+
+	score, ok := outcome.Score(color)
+	if !ok {
+		return 0, false
+	}
+	switch score {
+	case 1:
+		return scoring.Win, true
+	case 0:
+		return scoring.Loss, true
+	default:
+		return scoring.Draw, true
+	}
+}
+
+// Update this standing with a game whose outcome has been scored for this
+// player with the given score (1, 0.5 or 0) and points
+func (standing *PgnStanding) add(score float32, points float64) {
+	standing.Games += 1
+	switch score {
+	case 1:
+		standing.Wins += 1
+	case 0:
+		standing.Losses += 1
+	default:
+		standing.Draws += 1
+	}
+	standing.Points += points
+}
+
+// Return the names of all groups in these standings in lexicographical order
+func (standings PgnStandings) Groups() []string {
+	return standings.groups
+}
+
+// Return the standings of all players in the given group, or nil if the group
+// does not exist
+func (standings PgnStandings) Players(group string) []PgnStanding {
+	return standings.players[group]
+}
+
+// Standings are stringers, so that they can be shown on any writer. A table is
+// shown for every group, preceded by the name of the group
+func (standings PgnStandings) String() string {
+
+	tab, _ := table.NewTable(" r | l | r r r r | r ")
+	for idx, igroup := range standings.groups {
+
+		// show the name of this group, unless all games belong to the same
+		// one
+		if standings.groupBy != "" {
+			if idx > 0 {
+				tab.AddThickRule()
+			}
+			tab.AddRow(table.Multicolumn(7, "c", fmt.Sprintf("%v: %v", standings.groupBy, igroup)))
+			tab.AddSingleRule()
+		}
+
+		// and then all players in this group along with their standing
+		tab.AddRow("#", "Player", "Games", "W", "D", "L", "Points")
+		tab.AddThickRule()
+		for jdx, iplayer := range standings.players[igroup] {
+			tab.AddRow(jdx+1, iplayer.Player, iplayer.Games,
+				iplayer.Wins, iplayer.Draws, iplayer.Losses,
+				strconv.FormatFloat(iplayer.Points, 'f', -1, 64))
+		}
+	}
+
+	return fmt.Sprintf("%v", tab)
+}
+
+// Return the standings of all players in the games of this collection grouped
+// by the value of the given tag, e.g., "Event", computed with the given scoring
+// system. If no tag is given, all games are considered to belong to the same
+// group. Games which were not properly ended are not considered
+func (c PgnCollection) Standings(groupBy string, scoring PgnScoring) (*PgnStandings, error) {
+
+	// the order of the scoring system has to be preserved
+	if scoring.Win < scoring.Draw || scoring.Draw < scoring.Loss {
+		return nil, fmt.Errorf(" Invalid scoring %v-%v-%v. Wins can not be awarded fewer points than draws, and draws fewer points than losses", scoring.Win, scoring.Draw, scoring.Loss)
+	}
+
+	// compute the standings of all players in every group. The standing of
+	// every player is stored in a map indexed by the name of the player
+	groups := make(map[string]map[string]*PgnStanding)
+	for _, igame := range c.slice {
+
+		// skip those games that were not properly ended
+		if !igame.outcome.IsScored() {
+			continue
+		}
+
+		// get the group of this game
+		group := ""
+		if groupBy != "" {
+			group = igame.getTag(groupBy)
+		}
+		if _, ok := groups[group]; !ok {
+			groups[group] = make(map[string]*PgnStanding)
+		}
+
+		// and update the standing of both players
+		for _, color := range []int{1, -1} {
+			player := igame.getTag("White")
+			if color == -1 {
+				player = igame.getTag("Black")
+			}
+			standing, ok := groups[group][player]
+			if !ok {
+				standing = &PgnStanding{Player: player}
+				groups[group][player] = standing
+			}
+			score, _ := igame.outcome.Score(color)
+			points, _ := scoring.Points(igame.outcome, color)
+			standing.add(score, points)
+		}
+	}
+
+	// Now, sort the groups and the players within every group
+	standings := PgnStandings{
+		groupBy: groupBy,
+		groups:  make([]string, 0, len(groups)),
+		players: make(map[string][]PgnStanding),
+	}
+	for igroup, iplayers := range groups {
+		standings.groups = append(standings.groups, igroup)
+		players := make([]PgnStanding, 0, len(iplayers))
+		for _, iplayer := range iplayers {
+			players = append(players, *iplayer)
+		}
+		sort.Slice(players, func(i, j int) bool {
+			if players[i].Points != players[j].Points {
+				return players[i].Points > players[j].Points
+			}
+			if players[i].Wins != players[j].Wins {
+				return players[i].Wins > players[j].Wins
+			}
+			return players[i].Player < players[j].Player
+		})
+		standings.players[igroup] = players
+	}
+	sort.Strings(standings.groups)
+
+	// and return the standings computed so far
+	return &standings, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,77 @@
+// -*- coding: utf-8 -*-
+// pgnstandings_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:05:46.768689399 (1792159546)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPgnCollection_Standings(t *testing.T) {
+
+	// Return a game played in the given event between the given players with
+	// the given outcome
+	game := func(event, white, black string, outcome PgnOutcome) PgnGame {
+		return PgnGame{
+			tags:    map[string]any{"Event": event, "White": white, "Black": black},
+			outcome: outcome,
+		}
+	}
+	c := NewPgnCollection()
+	c.Add(game("A", "alice", "bob", PgnOutcome{1, 0}))
+	c.Add(game("A", "bob", "carol", PgnOutcome{0.5, 0.5}))
+	c.Add(game("A", "carol", "alice", PgnOutcome{0.5, 0.5}))
+	c.Add(game("A", "alice", "carol", PgnOutcome{-1, -1}))
+	c.Add(game("B", "bob", "alice", PgnOutcome{1, 0}))
+
+	tests := []struct {
+		name    string
+		scoring PgnScoring
+		want    []PgnStanding // standings in event A
+	}{
+		{"chess", ChessScoring, []PgnStanding{
+			{"alice", 2, 1, 1, 0, 1.5},
+			{"carol", 2, 0, 2, 0, 1},
+			{"bob", 2, 0, 1, 1, 0.5},
+		}},
+		{"football", FootballScoring, []PgnStanding{
+			{"alice", 2, 1, 1, 0, 4},
+			{"carol", 2, 0, 2, 0, 2},
+			{"bob", 2, 0, 1, 1, 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standings, err := c.Standings("Event", tt.scoring)
+			if err != nil {
+				t.Fatalf("Standings() error = %v", err)
+			}
+			if !reflect.DeepEqual(standings.Groups(), []string{"A", "B"}) {
+				t.Fatalf("Standings() groups = %v, want [A B]", standings.Groups())
+			}
+			if got := standings.Players("A"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Standings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPgnScoring(t *testing.T) {
+	if scoring, err := NewPgnScoring("3-1-0"); err != nil || scoring != FootballScoring {
+		t.Errorf("NewPgnScoring() = %v, %v, want %v", scoring, err, FootballScoring)
+	}
+	if _, err := NewPgnScoring("3-1"); err == nil {
+		t.Errorf("NewPgnScoring() expected an error")
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,137 @@
+// -*- coding: utf-8 -*-
+// pgnstats.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:18:44.073585415 (1792160324)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// The number of games found for a given value, e.g., a player, an opening or a
+// month
+type PgnCount struct {
+	Name  string
+	Count int
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the given counts as a slice sorted in decreasing order of count, and
+// breaking ties in lexicographical order of their names. If top is strictly
+// positive, at most top counts are returned
+func sortCounts(counts map[string]int, top int) []PgnCount {
+
+	result := make([]PgnCount, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, PgnCount{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	if top > 0 && len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the number of games with every result ("1-0", "0-1", "1/2-1/2" and
+// "*") sorted in decreasing order of count
+func (c PgnCollection) ResultCounts() []PgnCount {
+
+	counts := make(map[string]int)
+	for _, igame := range c.slice {
+		counts[igame.outcome.String()] += 1
+	}
+	return sortCounts(counts, 0)
+}
+
+// Return the players with most games in this collection, either with White or
+// Black, sorted in decreasing order of the number of games. At most top
+// players are returned if top is strictly positive
+func (c PgnCollection) TopPlayers(top int) []PgnCount {
+
+	counts := make(map[string]int)
+	for _, igame := range c.slice {
+		counts[igame.getTag("White")] += 1
+		counts[igame.getTag("Black")] += 1
+	}
+	return sortCounts(counts, top)
+}
+
+// Return the openings played most often in this collection, sorted in
+// decreasing order of the number of games. Openings are identified by their
+// ECO code followed by their name, if the tag "Opening" is given. At most top
+// openings are returned if top is strictly positive
+func (c PgnCollection) TopOpenings(top int) []PgnCount {
+
+	counts := make(map[string]int)
+	for _, igame := range c.slice {
+		opening := igame.getTag("ECO")
+		if name, ok := igame.tags["Opening"]; ok {
+			opening += " " + name.(string)
+		}
+		counts[opening] += 1
+	}
+	return sortCounts(counts, top)
+}
+
+// Return the number of games played every month as given in the tag "Date"
+// in chronological order, i.e., "YYYY.MM". Months without games between the
+// first and the last one are included with a count of zero, and games with
+// unknown year or month are ignored
+func (c PgnCollection) GamesPerMonth() []PgnCount {
+
+	// count the games of every month, and remember the first and last ones
+	counts := make(map[int]int)
+	first, last := -1, -1
+	for _, igame := range c.slice {
+		year, month := dateFields(igame.tags["Date"])
+		if year == "" || month == "" {
+			continue
+		}
+
+		// months are numbered consecutively to easily fill the gaps
+		nyear, _ := strconv.Atoi(year)
+		nmonth, _ := strconv.Atoi(month)
+		index := 12*nyear + nmonth - 1
+		counts[index] += 1
+		if first < 0 || index < first {
+			first = index
+		}
+		if index > last {
+			last = index
+		}
+	}
+
+	// and return all months from the first to the last one
+	result := make([]PgnCount, 0)
+	for index := first; first >= 0 && index <= last; index++ {
+		result = append(result, PgnCount{
+			Name:  fmt.Sprintf("%04d.%02d", index/12, 1+index%12),
+			Count: counts[index],
+		})
+	}
+	return result
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,50 @@
+// -*- coding: utf-8 -*-
+// pgnstats_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:20:19.605242012 (1792160419)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPgnCollection_Counts(t *testing.T) {
+
+	// Return a game played on the given date between the given players with
+	// the given outcome
+	game := func(date, white, black string, outcome PgnOutcome) PgnGame {
+		return PgnGame{
+			tags:    map[string]any{"Date": date, "White": white, "Black": black, "ECO": "B01"},
+			outcome: outcome,
+		}
+	}
+	c := NewPgnCollection()
+	c.Add(game("2023.11.30", "alice", "bob", PgnOutcome{1, 0}))
+	c.Add(game("2024.02.01", "bob", "carol", PgnOutcome{0.5, 0.5}))
+	c.Add(game("2024.02.12", "carol", "alice", PgnOutcome{1, 0}))
+	c.Add(game("2024.??.??", "alice", "carol", PgnOutcome{-1, -1}))
+
+	if got, want := c.ResultCounts(), []PgnCount{{"1-0", 2}, {"*", 1}, {"1/2-1/2", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResultCounts() = %v, want %v", got, want)
+	}
+	if got, want := c.TopPlayers(2), []PgnCount{{"alice", 3}, {"carol", 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TopPlayers() = %v, want %v", got, want)
+	}
+	if got, want := c.TopOpenings(0), []PgnCount{{"B01", 4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TopOpenings() = %v, want %v", got, want)
+	}
+	want := []PgnCount{{"2023.11", 1}, {"2023.12", 0}, {"2024.01", 0}, {"2024.02", 2}}
+	if got := c.GamesPerMonth(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GamesPerMonth() = %v, want %v", got, want)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,183 @@
+// -*- coding: utf-8 -*-
+// pgnstudy.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:26:47.997971860 (1792164407)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Collections of games can be organized in Lichess studies with a chapter per
+// game or a chapter per opening
+type StudyChapters int
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Every game is written in its own chapter (GameChapters), or all games with
+// the same opening are merged in a single chapter (OpeningChapters)
+const (
+	GameChapters StudyChapters = iota
+	OpeningChapters
+)
+
+// Lichess studies can not have more than the following number of chapters
+const maxStudyChapters = 64
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a copy of the given moves that can be modified without affecting
+// them, including their variations
+func cloneLine(moves []PgnMove) []PgnMove {
+	clone := slices.Clone(moves)
+	for idx := range clone {
+		clone[idx].annotations = slices.Clone(clone[idx].annotations)
+		clone[idx].variations = slices.Clone(clone[idx].variations)
+		for jdx := range clone[idx].variations {
+			clone[idx].variations[jdx] = cloneLine(clone[idx].variations[jdx])
+		}
+	}
+	return clone
+}
+
+// Return true if both moves are the same regardless of their markers of check
+// and checkmate
+func sameMove(move, other PgnMove) bool {
+	return strings.TrimRight(move.shortAlgebraic, "+#") == strings.TrimRight(other.shortAlgebraic, "+#")
+}
+
+// Return the given moves after merging the given line into them: the moves of
+// the line are followed as long as they are found either in the given moves or
+// in their variations, and the rest of the line is added as a new variation
+// where it diverges
+func mergeLine(moves, line []PgnMove) []PgnMove {
+
+	for ply := range line {
+
+		// if the moves end before the line, the rest of the line just
+		// extends them
+		if ply == len(moves) {
+			return append(moves, line[ply:]...)
+		}
+		if sameMove(moves[ply], line[ply]) {
+			continue
+		}
+
+		// otherwise, follow the variation which starts with the same move, if
+		// any, or add a new one
+		for idx, variation := range moves[ply].variations {
+			if sameMove(variation[0], line[ply]) {
+				moves[ply].variations[idx] = mergeLine(variation, line[ply:])
+				return moves
+			}
+		}
+		moves[ply].variations = append(moves[ply].variations, line[ply:])
+		return moves
+	}
+	return moves
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the chapters of a study with the games of this collection, either
+// one chapter per game or per opening as given in chapters, along with their
+// names. In the second case, the first plies of all games (as many as depth,
+// or all of them if it is not positive) are merged. Games starting from
+// different positions or played with different variants are never merged
+func (c PgnCollection) getStudyChapters(chapters StudyChapters, depth int) (games []PgnGame, names []string) {
+
+	// -- one chapter per game
+	if chapters == GameChapters {
+		for _, igame := range c.slice {
+			games = append(games, igame.Clone())
+			names = append(names, fmt.Sprintf("%v - %v", igame.getTag("White"), igame.getTag("Black")))
+		}
+		return
+	}
+
+	// -- one chapter per opening, in the order they are found
+	chapter := make(map[string]int)
+	for _, igame := range c.slice {
+
+		line := igame.moves
+		if depth > 0 && depth < len(line) {
+			line = line[:depth]
+		}
+
+		key := strings.Join([]string{igame.openingName(), igame.getTag("Variant"), igame.getTag("FEN")}, "\n")
+		if idx, ok := chapter[key]; ok {
+			games[idx].moves = mergeLine(games[idx].moves, cloneLine(line))
+			continue
+		}
+
+		// new chapters keep only the tags of the first game describing the
+		// opening and its starting position
+		tags := make(map[string]any)
+		for _, name := range []string{"ECO", "Opening", "Variant", "SetUp", "FEN"} {
+			if value, ok := igame.tags[name]; ok {
+				tags[name] = value
+			}
+		}
+		tags["Result"] = "*"
+		chapter[key] = len(games)
+		games = append(games, PgnGame{tags: tags, moves: cloneLine(line), outcome: PgnOutcome{-1, -1}})
+		names = append(names, igame.openingName())
+	}
+	return
+}
+
+// Write all games in this collection in the given writer as a multi-chapter PGN
+// that can be imported in a Lichess study with the given name. Chapters are
+// either every game or every opening as given in chapters. In the second case,
+// the first plies of all games with the same opening (as many as depth, or all
+// of them if it is not positive) are merged in a single chapter, where moves
+// not played in the first game are given as variations.
+//
+// Chapters are given the tags "StudyName" and "ChapterName", and their tag
+// "Event" is "study: chapter", as in the games exported by Lichess. As Lichess
+// studies have a limited number of chapters, larger collections are split in
+// various studies named "name (1/n)", "name (2/n)", ...
+//
+// Comments are folded as requested WithCommentFolding and re-wrapped
+// WithCommentWidth. In case it was not possible it returns an error and nil
+// otherwise
+func (c PgnCollection) GetStudy(writer io.Writer, name string, chapters StudyChapters, depth int, opts ...PgnOption) error {
+
+	games, names := c.getStudyChapters(chapters, depth)
+	studies := (len(games) + maxStudyChapters - 1) / maxStudyChapters
+	for idx, igame := range games {
+
+		// name this chapter after its study
+		study := name
+		if studies > 1 {
+			study = fmt.Sprintf("%v (%v/%v)", name, 1+idx/maxStudyChapters, studies)
+		}
+		igame.tags["StudyName"] = study
+		igame.tags["ChapterName"] = names[idx]
+		igame.tags["Event"] = fmt.Sprintf("%v: %v", study, names[idx])
+
+		// and write it
+		if _, err := io.WriteString(writer, igame.GetPGN(opts...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
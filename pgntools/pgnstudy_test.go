@@ -0,0 +1,115 @@
+// -*- coding: utf-8 -*-
+// pgnstudy_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:27:01.869233243 (1792164421)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Return a collection with the given games
+func studyCollection(t *testing.T, pgns ...string) PgnCollection {
+	c := NewPgnCollection()
+	for _, pgn := range pgns {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+	return c
+}
+
+func TestPgnCollection_GetStudy(t *testing.T) {
+
+	c := studyCollection(t, `[White "Alice"]
+[Black "Bob"]
+[Opening "Italian Game"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 1-0`, `[White "Carol"]
+[Black "Dave"]
+[Opening "Italian Game"]
+[Result "0-1"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Nf6 4. Ng5 0-1`, `[White "Erin"]
+[Black "Frank"]
+[Opening "Scandinavian Defense"]
+[Result "*"]
+
+1. e4 d5 *`)
+
+	// with a chapter per game, games keep their moves and tags, but the
+	// event
+	var builder strings.Builder
+	if err := c.GetStudy(&builder, "Study", GameChapters, 0); err != nil {
+		t.Fatalf("GetStudy() error = %v", err)
+	}
+	chapters, err := NewPgnCollectionFromReader(strings.NewReader(builder.String()))
+	if err != nil || chapters.Len() != 3 {
+		t.Fatalf("GetStudy() wrote %v chapters (error = %v), want 3", chapters.Len(), err)
+	}
+	for idx, igame := range chapters.GetGames() {
+		if igame.getTag("StudyName") != "Study" || igame.getTag("White") != c.slice[idx].getTag("White") ||
+			igame.getTag("Event") != fmt.Sprintf("Study: %v - %v", igame.getTag("White"), igame.getTag("Black")) ||
+			igame.MoveText() != c.slice[idx].MoveText() {
+			t.Errorf("GetStudy() chapter %v = %v", idx, igame.GetPGN())
+		}
+	}
+
+	// with a chapter per opening, the games of the same opening are merged
+	// with variations up to the given depth
+	builder.Reset()
+	if err := c.GetStudy(&builder, "Study", OpeningChapters, 7); err != nil {
+		t.Fatalf("GetStudy() error = %v", err)
+	}
+	chapters, err = NewPgnCollectionFromReader(strings.NewReader(builder.String()))
+	if err != nil || chapters.Len() != 2 {
+		t.Fatalf("GetStudy() wrote %v chapters (error = %v), want 2", chapters.Len(), err)
+	}
+	for idx, want := range []struct {
+		chapter, moves string
+	}{
+		{"Italian Game", "1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 (3... Nf6 4. Ng5)"},
+		{"Scandinavian Defense", "1. e4 d5"},
+	} {
+		igame := chapters.GetGame(idx)
+		if igame.getTag("ChapterName") != want.chapter || igame.getTag("Event") != "Study: "+want.chapter ||
+			igame.getTag("White") != "?" || igame.MoveText() != want.moves {
+			t.Errorf("GetStudy() chapter %v = %v, want %v", idx, igame.GetPGN(), want.moves)
+		}
+	}
+
+	// and merging games does not modify them
+	if moves := c.slice[0].moves; len(moves[5].variations) != 0 {
+		t.Errorf("GetStudy() modified the games of the collection")
+	}
+}
+
+func TestPgnCollection_GetStudySplit(t *testing.T) {
+
+	// large collections are split in various studies
+	pgns := make([]string, maxStudyChapters+1)
+	for idx := range pgns {
+		pgns[idx] = fmt.Sprintf("[White \"Player %v\"]\n[Result \"*\"]\n\n1. e4 *", idx)
+	}
+	var builder strings.Builder
+	if err := studyCollection(t, pgns...).GetStudy(&builder, "Study", GameChapters, 0); err != nil {
+		t.Fatalf("GetStudy() error = %v", err)
+	}
+	chapters, err := NewPgnCollectionFromReader(strings.NewReader(builder.String()))
+	if err != nil || chapters.Len() != len(pgns) {
+		t.Fatalf("GetStudy() wrote %v chapters (error = %v), want %v", chapters.Len(), err, len(pgns))
+	}
+	if first, last := chapters.GetGame(0), chapters.GetGame(maxStudyChapters); first.getTag("StudyName") != "Study (1/2)" ||
+		last.getTag("StudyName") != "Study (2/2)" {
+		t.Errorf("GetStudy() studies = %v and %v", first.getTag("StudyName"), last.getTag("StudyName"))
+	}
+}
@@ -0,0 +1,278 @@
+// -*- coding: utf-8 -*-
+// pgnsuggest.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:36:20.254864691 (1792168580)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"slices"
+	"strings"
+)
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Moves are suggested only if they are at most at this distance from the move
+// given, so that moves completely different are not suggested
+const maxSuggestionDistance = 2
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the edit distance between both strings, i.e., the minimum number of
+// characters to insert, delete or substitute to transform one into the other
+func editDistance(s, t string) int {
+
+	prev := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		next := make([]int, len(t)+1)
+		next[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			next[j] = min(prev[j]+1, next[j-1]+1, prev[j-1]+cost)
+		}
+		prev = next
+	}
+	return prev[len(t)]
+}
+
+// Return the legal moves closest to the given move in short algebraic notation
+// in the position given with its FEN code, which is played with the rules of
+// the given variant, sorted in ascending order. Markers of check and
+// checkmate and suffix annotations are ignored when comparing moves, and among
+// the closest ones, those moving the same piece to the same square are
+// preferred, e.g., to disambiguate moves. If the position is invalid, the move
+// given is legal or no legal move is close enough, nil is returned
+func suggestMoves(fen string, variant Variant, move string) []string {
+
+	board, err := NewPgnBoardFromFEN(fen)
+	if err != nil {
+		return nil
+	}
+	board.variant = variant
+
+	// compute the distance to every legal move and keep those with the
+	// minimum distance
+	var suggestions []string
+	best := maxSuggestionDistance + 1
+	given := strings.TrimRight(move, "+#!?")
+	for _, legal := range board.LegalMoves() {
+		distance := editDistance(given, strings.TrimRight(legal, "+#"))
+		if distance == 0 {
+			return nil
+		}
+		if distance > maxSuggestionDistance {
+			continue
+		}
+		if distance < best {
+			suggestions, best = nil, distance
+		}
+		if distance == best {
+			suggestions = append(suggestions, legal)
+		}
+	}
+
+	// and prefer those moving the same piece to the same square, if any
+	same := func(legal string) bool {
+		imatches, jmatches := reTextualMove.FindStringSubmatch(given), reTextualMove.FindStringSubmatch(legal)
+		return imatches != nil && jmatches != nil && imatches[6] == "" &&
+			imatches[1] == jmatches[1] && imatches[4] == jmatches[4]
+	}
+	if slices.ContainsFunc(suggestions, same) {
+		suggestions = slices.DeleteFunc(suggestions, func(legal string) bool {
+			return !same(legal)
+		})
+	}
+	return suggestions
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// return true if the side with the given color can castle either on the king
+// side (short) or the queen side in this board, i.e., if it has the right to
+// castle with the rook given by the variant of this board, all squares between
+// the king and the rook are empty and the king does not cross any attacked
+// square
+func (board *PgnBoard) canCastle(color int, short bool) bool {
+
+	kingFrom, kingTo, rookFrom, rookTo, err := board.getVariant().Castling(board, color, short)
+	if err != nil {
+		return false
+	}
+
+	// verify the castling rights
+	fields := strings.Fields(board.fen)
+	if len(fields) < 3 {
+		return false
+	}
+	allowed := false
+	for _, letter := range fields[2] {
+		if side, rook, ok := castlingRook(board, letter); ok && side == color && rook == rookFrom {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	// the squares between the king and the rook, including their targets,
+	// must be empty
+	for square := min(kingFrom, kingTo, rookFrom, rookTo); square <= max(kingFrom, kingTo, rookFrom, rookTo); square++ {
+		if square != kingFrom && square != rookFrom && board.squares[square] != BLANK {
+			return false
+		}
+	}
+
+	// and the king can not be in check nor cross any attacked square
+	for square := min(kingFrom, kingTo); square <= max(kingFrom, kingTo); square++ {
+		if board.isAttacked(square, -color) {
+			return false
+		}
+	}
+	return true
+}
+
+// Return all legal moves of the side to move in this board in short algebraic
+// notation, including markers of check and checkmate, sorted in ascending
+// order. Moves are disambiguated with the file, rank or both of their origin
+// only if necessary, and they are verified by playing them according to the
+// rules of the variant of this board
+func (board *PgnBoard) LegalMoves() []string {
+
+	color := board.sideToMove()
+	enpassant := -1
+	if fields := strings.Fields(board.fen); len(fields) > 3 {
+		if loc, ok := coords[fields[3]]; ok {
+			enpassant = loc
+		}
+	}
+
+	// First, compute the origins of every piece that could be moved to every
+	// target square, ignoring whether they leave the king in check. Note that
+	// the first piece found in every direction is the only one that can be
+	// moved
+	type candidate struct {
+		piece          content
+		origin, target int
+	}
+	var candidates []candidate
+	for target := 0; target < 64; target++ {
+		if board.squares[target] != BLANK && getColor(board.squares[target]) == color {
+			continue
+		}
+		for _, piece := range []content{WPAWN, WKNIGHT, WBISHOP, WROOK, WQUEEN, WKING} {
+			mover := getPieceValue(piece, color)
+			for idx, direction := range threats[literal[target]][mover] {
+				if piece == WPAWN &&
+					((idx == 0 && board.squares[target] != BLANK) ||
+						(idx > 0 && board.squares[target] == BLANK && target != enpassant)) {
+					continue
+				}
+				for _, origin := range direction {
+					if board.squares[origin] == mover {
+						candidates = append(candidates, candidate{piece, origin, target})
+					}
+					if board.squares[origin] != BLANK && piece != WKNIGHT {
+						break
+					}
+				}
+			}
+		}
+	}
+
+	// Next, give a short algebraic notation to every candidate, and verify it
+	// by playing it. Moves that leave the king in check are discarded
+	moves := make([]string, 0)
+	play := func(san string) {
+		next := *board
+		if _, err := next.UpdateBoard(PgnMove{color: color, shortAlgebraic: san}); err != nil || next.InCheck(color) {
+			return
+		}
+		if next.isCheckmate(-color) {
+			san += "#"
+		} else if next.InCheck(-color) {
+			san += "+"
+		}
+		moves = append(moves, san)
+	}
+	for _, icandidate := range candidates {
+		origin, target := literal[icandidate.origin], literal[icandidate.target]
+		capture := ""
+		if board.squares[icandidate.target] != BLANK ||
+			(icandidate.piece == WPAWN && icandidate.target == enpassant) {
+			capture = "x"
+		}
+
+		// Pawns are identified by the file of their origin when capturing,
+		// and they are promoted when reaching the last rank
+		if icandidate.piece == WPAWN {
+			if capture != "" {
+				capture = origin[:1] + capture
+			}
+			if target[1] == '1' || target[1] == '8' {
+				for _, promotion := range "QRBN" {
+					play(capture + target + "=" + string(promotion))
+				}
+			} else {
+				play(capture + target)
+			}
+			continue
+		}
+
+		// Other pieces are disambiguated with the file of their origin if
+		// no other piece of the same kind moving to the same target is in the
+		// same file; otherwise, with its rank if possible, and with both
+		// otherwise
+		qualifier := ""
+		var files, ranks, others int
+		for _, jcandidate := range candidates {
+			if jcandidate.piece == icandidate.piece && jcandidate.target == icandidate.target &&
+				jcandidate.origin != icandidate.origin && board.isSafe(jcandidate.origin, jcandidate.target, enpassant) {
+				others++
+				if literal[jcandidate.origin][0] == origin[0] {
+					files++
+				}
+				if literal[jcandidate.origin][1] == origin[1] {
+					ranks++
+				}
+			}
+		}
+		if others > 0 {
+			switch {
+			case files == 0:
+				qualifier = origin[:1]
+			case ranks == 0:
+				qualifier = origin[1:]
+			default:
+				qualifier = origin
+			}
+		}
+		play(string(asciirepr[icandidate.piece]) + qualifier + capture + target)
+	}
+
+	// Finally, castling is considered on both sides
+	if board.canCastle(color, true) {
+		play("O-O")
+	}
+	if board.canCastle(color, false) {
+		play("O-O-O")
+	}
+	slices.Sort(moves)
+	return slices.Compact(moves)
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
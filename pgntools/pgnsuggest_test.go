@@ -0,0 +1,116 @@
+// -*- coding: utf-8 -*-
+// pgnsuggest_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:37:26.800755897 (1792168646)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPgnBoard_LegalMoves(t *testing.T) {
+
+	// the number of legal moves in some well-known positions used for testing
+	// move generators
+	for _, tt := range []struct {
+		fen  string
+		want int
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 20},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 48},
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 14},
+		{"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 6},
+		{"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 44},
+	} {
+		board, err := NewPgnBoardFromFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := board.LegalMoves(); len(got) != tt.want {
+			t.Errorf("LegalMoves() of '%v' = %v moves, want %v: %v", tt.fen, len(got), tt.want, got)
+		}
+	}
+
+	// moves are given in short algebraic notation with disambiguation,
+	// promotions, en passant captures, castling and markers of check
+	board, _ := NewPgnBoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	moves := board.LegalMoves()
+	for _, want := range []string{"O-O", "O-O-O", "Nxf7", "Bxa6", "Qxf6", "gxh3", "Rb1", "Kf1"} {
+		if !slices.Contains(moves, want) {
+			t.Errorf("LegalMoves() = %v, should contain '%v'", moves, want)
+		}
+	}
+	board, _ = NewPgnBoardFromFEN("4k3/1P6/8/3pP3/8/8/8/R3K2R w K d6 0 1")
+	moves = board.LegalMoves()
+	for _, want := range []string{"b8=Q+", "b8=N", "exd6", "O-O", "Rd1", "Ra8+"} {
+		if !slices.Contains(moves, want) {
+			t.Errorf("LegalMoves() = %v, should contain '%v'", moves, want)
+		}
+	}
+	if slices.Contains(moves, "O-O-O") {
+		t.Errorf("LegalMoves() = %v, should not contain 'O-O-O'", moves)
+	}
+	board, _ = NewPgnBoardFromFEN("1k6/8/8/8/8/8/4K3/R6R w - - 0 1")
+	moves = board.LegalMoves()
+	for _, want := range []string{"Rad1", "Rhd1", "Rhb1+"} {
+		if !slices.Contains(moves, want) {
+			t.Errorf("LegalMoves() = %v, should contain '%v'", moves, want)
+		}
+	}
+}
+
+func Test_suggestMoves(t *testing.T) {
+
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	for _, tt := range []struct {
+		move string
+		want []string
+	}{
+		{"Ndf3", []string{"Nf3"}},
+		{"Nf3!", nil},
+		{"e5", []string{"e3", "e4"}},
+		{"Qxh7", nil},
+	} {
+		if got := suggestMoves(fen, nil, tt.move); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("suggestMoves(%q) = %v, want %v", tt.move, got, tt.want)
+		}
+	}
+}
+
+func TestPgnGame_ValidateSuggestions(t *testing.T) {
+
+	game, err := getGameFromString("[Event \"?\"]\n\n1. Nf3 d5 2. d3 e5 3. Nbd2 Nc6 4. Ncb3 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := game.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || !reflect.DeepEqual(errs[0].Suggestions, []string{"Nb3"}) {
+		t.Fatalf("Validate() = %v, want a suggestion of 'Nb3'", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "(did you mean 'Nb3'?)") {
+		t.Errorf("Error() = %q", errs[0].Error())
+	}
+
+	// ambiguous moves are suggested to be disambiguated
+	game, _ = getGameFromString("[Event \"?\"]\n\n1. Nf3 d5 2. d3 e5 3. Nd2 *")
+	errs, _ = game.Validate()
+	if len(errs) != 1 || !errors.Is(errs[0], ErrAmbiguousMove) || !reflect.DeepEqual(errs[0].Suggestions, []string{"Nbd2", "Nfd2"}) {
+		t.Errorf("Validate() = %v, want suggestions 'Nbd2' and 'Nfd2'", errs)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,235 @@
+// -*- coding: utf-8 -*-
+// pgntablebase.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:00:19.826773133 (1792166419)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// The result of a position according to an endgame tablebase is given from the
+// point of view of the side to move, as in Syzygy tablebases
+type TablebaseResult int
+
+// A tablebase prober returns the result of the position given with its FEN
+// code, or any error found. Positions which are not in the tablebase should be
+// reported as TablebaseUnknown
+type TablebaseProber interface {
+	Probe(fen string) (TablebaseResult, error)
+}
+
+// Syzygy tablebases can be probed through an HTTP service such as the one
+// provided by lichess. The service is given with its URL, and the results of
+// all positions are remembered so that every position is requested only once.
+//
+// Note that probing a position sends its FEN code to a third party, by default
+// tablebase.lichess.ovh, which might reveal the games being analyzed, e.g., the
+// preparation of a player. Hence, no position is ever sent unless it is
+// explicitly allowed with SendPositions, and ErrNotAllowed is returned instead
+type LichessTablebase struct {
+	URL           string
+	Client        *http.Client
+	SendPositions bool
+	cache         sync.Map
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Positions are either won, drawn or lost for the side to move, or their
+// result is unknown. Wins and losses which can not be achieved within the
+// fifty-move rule (also known as cursed wins and blessed losses) are drawn
+const (
+	TablebaseUnknown TablebaseResult = iota
+	TablebaseWin
+	TablebaseDraw
+	TablebaseLoss
+)
+
+// Only positions with this number of pieces or less, including both kings, are
+// found in Syzygy tablebases
+const tablebasePieces = 7
+
+// URL of the tablebase service provided by lichess for standard chess
+const lichessTablebaseURL = "https://tablebase.lichess.ovh/standard"
+
+// globals
+// ----------------------------------------------------------------------------
+
+// The categories given by the lichess service are mapped to tablebase results
+var lichessCategories = map[string]TablebaseResult{
+	"win":          TablebaseWin,
+	"maybe-win":    TablebaseWin,
+	"cursed-win":   TablebaseDraw,
+	"draw":         TablebaseDraw,
+	"blessed-loss": TablebaseDraw,
+	"maybe-loss":   TablebaseLoss,
+	"loss":         TablebaseLoss,
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a new prober of the tablebase service provided by lichess. Positions
+// are not sent to the service until SendPositions is set, see LichessTablebase
+func NewLichessTablebase() *LichessTablebase {
+	return &LichessTablebase{
+		URL:    lichessTablebaseURL,
+		Client: http.DefaultClient,
+	}
+}
+
+// Return the tablebase result with the given name, either "win", "draw" or
+// "loss", and an error if the name is not known
+func getTablebaseResult(name string) (TablebaseResult, error) {
+	switch strings.ToLower(name) {
+	case "win":
+		return TablebaseWin, nil
+	case "draw":
+		return TablebaseDraw, nil
+	case "loss":
+		return TablebaseLoss, nil
+	}
+	return TablebaseUnknown, fmt.Errorf(" Unknown tablebase result '%v'", name)
+}
+
+// Return the number of pieces, including both kings, in the position given
+// with its FEN code
+func getPieceCount(fen string) (count int) {
+	placement, _, _ := strings.Cut(fen, " ")
+	for _, symbol := range placement {
+		if unicode.IsLetter(symbol) {
+			count++
+		}
+	}
+	return
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a string with the name of this result
+func (result TablebaseResult) String() string {
+	switch result {
+	case TablebaseWin:
+		return "win"
+	case TablebaseDraw:
+		return "draw"
+	case TablebaseLoss:
+		return "loss"
+	}
+	return "unknown"
+}
+
+// Return the result of the position given with its FEN code according to the
+// tablebase service, or any error found. If sending positions to the service
+// was not allowed, ErrNotAllowed is returned
+func (tablebase *LichessTablebase) Probe(fen string) (TablebaseResult, error) {
+
+	if !tablebase.SendPositions {
+		return TablebaseUnknown, fmt.Errorf("%w: set SendPositions to probe '%v' with %v", ErrNotAllowed, fen, tablebase.URL)
+	}
+	if result, ok := tablebase.cache.Load(fen); ok {
+		return result.(TablebaseResult), nil
+	}
+
+	// request the position to the service
+	client := tablebase.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Get(tablebase.URL + "?fen=" + url.QueryEscape(fen))
+	if err != nil {
+		return TablebaseUnknown, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return TablebaseUnknown, fmt.Errorf(" The tablebase service returned '%v' for the position '%v'", response.Status, fen)
+	}
+
+	// and decode the category of the position. Unknown categories are not
+	// remembered
+	var data struct {
+		Category string `json:"category"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&data); err != nil {
+		return TablebaseUnknown, err
+	}
+	result, ok := lichessCategories[data.Category]
+	if ok {
+		tablebase.cache.Store(fen, result)
+	}
+	return result, nil
+}
+
+// Return the result, from the point of view of the side to move, of the
+// position reached after the given number of plies of this game according to
+// the given tablebase prober. The game is realized up to that ply if
+// necessary. Positions with more than seven pieces are not probed and their
+// result is unknown
+func (game *PgnGame) TablebaseResultAt(ply int, prober TablebaseProber) (TablebaseResult, error) {
+
+	fen, err := game.FENAt(ply)
+	if err != nil {
+		return TablebaseUnknown, err
+	}
+	if getPieceCount(fen) > tablebasePieces {
+		return TablebaseUnknown, nil
+	}
+	return prober.Probe(fen)
+}
+
+// Return a new collection with the games of this one whose final position has
+// the given result, either "win", "draw" or "loss", from the point of view of
+// the side to move according to the given tablebase prober. Games ending in
+// positions with more than seven pieces are never selected. Games are probed
+// in parallel with the number of workers given WithWorkers, and they keep
+// their ids
+func (c PgnCollection) FilterTB(result string, prober TablebaseProber, opts ...PgnOption) (*PgnCollection, error) {
+
+	wanted, err := getTablebaseResult(result)
+	if err != nil {
+		return nil, err
+	}
+
+	// probe the final position of all games. Because every worker accesses a
+	// different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	selected := make([]bool, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		game := &c.slice[idx]
+		result, err := game.TablebaseResultAt(len(game.moves), prober)
+		selected[idx] = result == wanted
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	// and return the games selected in the same order
+	var indexes []int
+	for idx := range selected {
+		if selected[idx] {
+			indexes = append(indexes, idx)
+		}
+	}
+	return c.subset(indexes), nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
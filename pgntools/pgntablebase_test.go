@@ -0,0 +1,149 @@
+// -*- coding: utf-8 -*-
+// pgntablebase_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:00:42.437415536 (1792166442)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// A tablebase prober which acknowledges that all positions are won by White,
+// and counts the number of positions probed
+type whiteWinsProber struct {
+	probed int
+}
+
+func (prober *whiteWinsProber) Probe(fen string) (TablebaseResult, error) {
+	prober.probed++
+	if strings.Fields(fen)[1] == "w" {
+		return TablebaseWin, nil
+	}
+	return TablebaseLoss, nil
+}
+
+func TestPgnGame_TablebaseResultAt(t *testing.T) {
+
+	game, err := getGameFromString("[Event \"?\"]\n[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/2P5/4K3 w - - 0 1\"]\n\n1. c4 Kd7 2. c5 *")
+	if err != nil {
+		t.Fatalf("getGameFromString() error = %v", err)
+	}
+	prober := whiteWinsProber{}
+	for ply, want := range []TablebaseResult{TablebaseWin, TablebaseLoss, TablebaseWin, TablebaseLoss} {
+		if got, err := game.TablebaseResultAt(ply, &prober); err != nil || got != want {
+			t.Errorf("TablebaseResultAt(%v) = %v, %v, want %v", ply, got, err, want)
+		}
+	}
+	if _, err := game.TablebaseResultAt(4, &prober); err == nil {
+		t.Errorf("TablebaseResultAt(4) should fail")
+	}
+
+	// positions with too many pieces are not probed
+	game, err = getGameFromString("[Event \"?\"]\n\n1. e4 *")
+	if err != nil {
+		t.Fatalf("getGameFromString() error = %v", err)
+	}
+	prober = whiteWinsProber{}
+	if got, err := game.TablebaseResultAt(1, &prober); err != nil || got != TablebaseUnknown || prober.probed != 0 {
+		t.Errorf("TablebaseResultAt(1) = %v, %v with %v positions probed, want unknown", got, err, prober.probed)
+	}
+}
+
+func TestPgnCollection_FilterTB(t *testing.T) {
+
+	games := []string{
+		"[Event \"?\"]\n[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/2P5/4K3 w - - 0 1\"]\n\n1. c4 *",
+		"[Event \"?\"]\n\n1. e4 *",
+		"[Event \"?\"]\n[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/2P5/4K3 w - - 0 1\"]\n\n1. c4 Kd7 *",
+	}
+	c := NewPgnCollection()
+	for _, pgn := range games {
+		game, err := getGameFromString(pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	for _, tt := range []struct {
+		result string
+		ids    []int
+	}{
+		{"win", []int{3}},
+		{"loss", []int{1}},
+		{"draw", nil},
+	} {
+		selected, err := c.FilterTB(tt.result, &whiteWinsProber{})
+		if err != nil {
+			t.Fatalf("FilterTB(%v) error = %v", tt.result, err)
+		}
+		var ids []int
+		for idx := range selected.slice {
+			ids = append(ids, selected.slice[idx].Id())
+		}
+		if len(ids) != len(tt.ids) || (len(ids) > 0 && ids[0] != tt.ids[0]) {
+			t.Errorf("FilterTB(%v) = %v, want %v", tt.result, ids, tt.ids)
+		}
+	}
+	if _, err := c.FilterTB("mate", &whiteWinsProber{}); err == nil {
+		t.Errorf("FilterTB(mate) should fail")
+	}
+}
+
+func TestLichessTablebase_Probe(t *testing.T) {
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Query().Get("fen") {
+		case "4k3/8/8/8/8/8/2P5/4K3 w - - 0 1":
+			w.Write([]byte(`{"category": "win", "dtz": 1}`))
+		case "8/8/8/8/8/8/8/K1k5 w - - 0 1":
+			w.Write([]byte(`{"category": "draw"}`))
+		default:
+			w.Write([]byte(`{"category": "cursed-win"}`))
+		}
+	}))
+	defer server.Close()
+
+	// positions are never sent unless it is explicitly allowed
+	tablebase := NewLichessTablebase()
+	tablebase.URL = server.URL
+	if got, err := tablebase.Probe("4k3/8/8/8/8/8/2P5/4K3 w - - 0 1"); !errors.Is(err, ErrNotAllowed) || got != TablebaseUnknown || requests != 0 {
+		t.Errorf("Probe() = %v, %v with %v requests, want %v", got, err, requests, ErrNotAllowed)
+	}
+
+	tablebase.SendPositions = true
+	for _, tt := range []struct {
+		fen  string
+		want TablebaseResult
+	}{
+		{"4k3/8/8/8/8/8/2P5/4K3 w - - 0 1", TablebaseWin},
+		{"8/8/8/8/8/8/8/K1k5 w - - 0 1", TablebaseDraw},
+		{"8/8/8/8/8/8/1R6/K1k5 w - - 0 1", TablebaseDraw},
+		{"4k3/8/8/8/8/8/2P5/4K3 w - - 0 1", TablebaseWin},
+	} {
+		if got, err := tablebase.Probe(tt.fen); err != nil || got != tt.want {
+			t.Errorf("Probe(%v) = %v, %v, want %v", tt.fen, got, err, tt.want)
+		}
+	}
+
+	// positions are requested only once
+	if requests != 3 {
+		t.Errorf("Probe() made %v requests, want 3", requests)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,412 @@
+// -*- coding: utf-8 -*-
+// pgntags.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:39:44.973620275 (1792161584)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Tags of games can be edited in bulk with rules. Every rule applies to the
+// games satisfying its match expression (all games if none is given) which is
+// written with the same syntax used for filtering games. Tags given in Rename
+// are renamed first, then tags given in Set are added or overwritten, and
+// finally those in Delete are removed. Rules can be read from JSON files, e.g.:
+//
+//	[{"match": "Site contains 'Toronto'", "set": {"Event": "Candidates 2024"}},
+//	 {"rename": {"WhiteTeam": "Team"}, "delete": ["Annotator"]}]
+type TagRule struct {
+	Match  string            `json:"match"`
+	Rename map[string]string `json:"rename"`
+	Set    map[string]string `json:"set"`
+	Delete []string          `json:"delete"`
+}
+
+// The value of a tag inferred from other games is the one agreed by all games
+// that reach the same position, which is identified by its key. Positions
+// where games disagree are given the empty string
+type tagConsensus map[string]string
+
+// globals
+// ----------------------------------------------------------------------------
+
+// Tags that can be derived from the moves of games, in the order they are
+// computed by default
+var enrichableTags = []string{"PlyCount", "ECO", "Opening", "EndFEN", "Termination"}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the value of a tag given as a string as it is stored in games, i.e.,
+// as an integer if it can be interpreted as an integer number and as a string
+// otherwise
+func getTagValue(value string) any {
+	if number, err := strconv.Atoi(value); err == nil {
+		return number
+	}
+	return value
+}
+
+// Return the rules stored in the given JSON file, and nil if no error was found
+func LoadTagRules(filename string) ([]TagRule, error) {
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var rules []TagRule
+	if err := json.Unmarshal(contents, &rules); err != nil {
+		return nil, fmt.Errorf(" The rules '%v' are not valid: %v", filename, err)
+	}
+	return rules, nil
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Add the given value to the consensus of the position with the given key
+func (consensus tagConsensus) add(key, value string) {
+	if previous, ok := consensus[key]; !ok {
+		consensus[key] = value
+	} else if previous != value {
+		consensus[key] = ""
+	}
+}
+
+// Return the value agreed for the deepest position of the given boards reached
+// by other games, or the empty string if there is none
+func (consensus tagConsensus) infer(boards []PgnBoard) string {
+	for ply := len(boards) - 1; ply > 0; ply-- {
+		if value := consensus[transpositionKey(boards[ply])]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Set the given tag of this game to the given value and return true if it was
+// modified. The tags are copied the first time they are modified, as indicated
+// by cloned, so that other collections with the same game are not modified
+func (game *PgnGame) setTag(name string, value any, cloned *bool) bool {
+	if current, ok := game.tags[name]; ok && fmt.Sprint(current) == fmt.Sprint(value) {
+		return false
+	}
+	if !*cloned {
+		game.tags = maps.Clone(game.tags)
+		*cloned = true
+	}
+	game.tags[name] = value
+	return true
+}
+
+// Set the given tag of this game to the given value, which is stored as an
+// integer if it can be interpreted as an integer number. Tags are modified in
+// place, so that other copies of this game might be modified as well. To edit
+// the tags of games in a collection use TransformTags instead
+func (game *PgnGame) SetTag(name, value string) {
+	if game.tags == nil {
+		game.tags = make(map[string]any)
+	}
+	game.tags[name] = getTagValue(value)
+}
+
+// Remove the given tag from this game, if it exists. Tags are modified in
+// place, see SetTag
+func (game *PgnGame) DeleteTag(name string) {
+	delete(game.tags, name)
+}
+
+// Rename the given tag of this game keeping its value, and return true if it
+// existed. If the new tag already exists its value is overwritten. Tags are
+// modified in place, see SetTag
+func (game *PgnGame) RenameTag(from, to string) bool {
+	value, ok := game.tags[from]
+	if !ok || from == to {
+		return ok
+	}
+	delete(game.tags, from)
+	game.tags[to] = value
+	return true
+}
+
+// Compute the given tags of this game, which is played if necessary, and
+// return true if any was modified and any error found. The tags "ECO" and
+// "Opening" are inferred from the given consensus of other games
+func (game *PgnGame) enrichTags(fields []string, inferred map[string]tagConsensus) (bool, error) {
+
+	if err := game.play(); err != nil {
+		return false, err
+	}
+	board := &game.boards[len(game.boards)-1]
+
+	enriched, cloned := false, false
+	for _, field := range fields {
+		switch field {
+		case "PlyCount":
+			enriched = game.setTag(field, len(game.moves), &cloned) || enriched
+		case "EndFEN":
+			enriched = game.setTag(field, board.FEN(), &cloned) || enriched
+		case "ECO", "Opening":
+			if game.getTag(field) != "?" {
+				continue
+			}
+			if value := inferred[field].infer(game.boards); value != "" {
+				enriched = game.setTag(field, getTagValue(value), &cloned) || enriched
+			}
+		case "Termination":
+
+			// unfinished games are unterminated, and games ending in a
+			// position where the rules of the variant give an outcome ended
+			// normally
+			if game.getTag(field) != "?" {
+				continue
+			}
+			if game.outcome.scoreWhite == -1 {
+				enriched = game.setTag(field, "Unterminated", &cloned) || enriched
+			} else if _, ok := board.getVariant().Outcome(board, board.sideToMove()); ok {
+				enriched = game.setTag(field, "Normal", &cloned) || enriched
+			}
+		}
+	}
+	return enriched, nil
+}
+
+// Apply all the given rules in order to the tags of this game, so that every
+// rule is matched against the tags resulting from the previous ones. It returns
+// true if any rule matched this game and any error found. The tags are copied
+// before editing them so that other collections with the same game are not
+// modified
+func (game *PgnGame) editTags(rules []TagRule) (bool, error) {
+
+	edited := false
+	for _, rule := range rules {
+
+		// verify whether this rule matches the game
+		if rule.Match != "" {
+			if ok, err := game.Filter(rule.Match); err != nil {
+				return edited, err
+			} else if !ok {
+				continue
+			}
+		}
+
+		// and apply it
+		if !edited {
+			game.tags = maps.Clone(game.tags)
+			edited = true
+		}
+		for from, to := range rule.Rename {
+			game.RenameTag(from, to)
+		}
+		for name, value := range rule.Set {
+			game.SetTag(name, value)
+		}
+		for _, name := range rule.Delete {
+			game.DeleteTag(name)
+		}
+	}
+	return edited, nil
+}
+
+// Edit the tags of all games in this collection with the given rules, which are
+// applied in order to every game, and return the number of games matched by
+// any rule. Only tags are modified, e.g., changing the tag Result does not
+// change the outcome of a game.
+//
+// Games are edited in parallel with the number of workers given WithWorkers,
+// and WithProgress reports the number of games edited so far
+func (c PgnCollection) EditTags(rules []TagRule, opts ...PgnOption) (int, error) {
+
+	// Because every worker accesses a different game, no synchronization is
+	// needed
+	options := newPgnOptions(opts...)
+	edited := make([]bool, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) (err error) {
+		edited[idx], err = c.slice[idx].editTags(rules)
+		return
+	}); err != nil {
+		return 0, err
+	}
+
+	// and count the number of games edited
+	count := 0
+	for _, ok := range edited {
+		if ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Transform the tags of all games in this collection with the given function,
+// which can modify the tags of every game it is given with SetTag, DeleteTag
+// and RenameTag, e.g., to normalize the names of players. It returns the number
+// of games whose tags were modified. The tags of every game are copied before
+// transforming them so that other collections with the same game are not
+// modified. In case the function returns an error for any game, the tags of
+// that game are left untouched, and the error is returned.
+//
+// Games are transformed in parallel with the number of workers given
+// WithWorkers (only one by default), so that the given function must be safe
+// for concurrent use if more workers are given
+func (c PgnCollection) TransformTags(transform func(game *PgnGame) error, opts ...PgnOption) (int, error) {
+
+	// Because every worker accesses a different game, no synchronization is
+	// needed
+	options := newPgnOptions(opts...)
+	transformed := make([]bool, len(c.slice))
+	if err := options.forEach(len(c.slice), func(idx int) error {
+		game := &c.slice[idx]
+		original := game.tags
+		game.tags = maps.Clone(original)
+		if err := transform(game); err != nil {
+			game.tags = original
+			return err
+		}
+
+		// games whose tags were not modified share them again with other
+		// collections
+		if maps.EqualFunc(original, game.tags, func(a, b any) bool {
+			return fmt.Sprint(a) == fmt.Sprint(b)
+		}) {
+			game.tags = original
+		} else {
+			transformed[idx] = true
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	// and count the number of games transformed
+	count := 0
+	for _, ok := range transformed {
+		if ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Rename the given tag in all games of this collection keeping its value, and
+// return the number of games where it was renamed, see TransformTags
+func (c PgnCollection) RenameTag(from, to string) int {
+	count, _ := c.TransformTags(func(game *PgnGame) error {
+		game.RenameTag(from, to)
+		return nil
+	})
+	return count
+}
+
+// Remove the given tags from all games of this collection, e.g., private tags
+// before publishing them, and return the number of games where any was found,
+// see TransformTags
+func (c PgnCollection) DeleteTag(names ...string) int {
+	count, _ := c.TransformTags(func(game *PgnGame) error {
+		for _, name := range names {
+			game.DeleteTag(name)
+		}
+		return nil
+	})
+	return count
+}
+
+// Set the given tag to the given value in all games of this collection
+// satisfying the given expression, which is written with the same syntax used
+// for filtering games (all games if the empty string is given), e.g., to fix
+// the name of an event. It returns the number of games modified and any error
+// found while evaluating the expression, see TransformTags
+func (c PgnCollection) SetTagIf(expression, name, value string, opts ...PgnOption) (int, error) {
+	return c.TransformTags(func(game *PgnGame) error {
+		if expression != "" {
+			if ok, err := game.Filter(expression); err != nil || !ok {
+				return err
+			}
+		}
+		game.SetTag(name, value)
+		return nil
+	}, opts...)
+}
+
+// Compute the given tags of all games in this collection, which are played if
+// necessary, and return the number of games whose tags were modified. The tags
+// that can be computed are:
+//
+//  1. PlyCount: the number of plies of the main line
+//  2. ECO and Opening: the values given to the deepest position of the game
+//     reached by other games in this collection that agree on them
+//  3. EndFEN: the FEN code of the final position
+//  4. Termination: "Unterminated" for unfinished games and "Normal" for games
+//     ending in checkmate, stalemate or any other final position given by the
+//     rules of their variant
+//
+// PlyCount and EndFEN are always recomputed, whereas the other tags are given
+// only to games where they are missing or unknown ('?'). If no tags are given,
+// all of them are computed. It returns an error if any other tag is given or a
+// game could not be played
+func (c PgnCollection) EnrichTags(fields ...string) (int, error) {
+
+	if len(fields) == 0 {
+		fields = enrichableTags
+	}
+	for _, field := range fields {
+		if !slices.Contains(enrichableTags, field) {
+			return 0, fmt.Errorf(" The tag '%v' can not be computed", field)
+		}
+	}
+
+	// First, learn the values of the tags that are inferred from other games
+	// with all positions of the games where they are known
+	inferred := make(map[string]tagConsensus)
+	for _, field := range fields {
+		if field != "ECO" && field != "Opening" {
+			continue
+		}
+		consensus := make(tagConsensus)
+		for idx := range c.slice {
+			game := &c.slice[idx]
+			value := game.getTag(field)
+			if value == "?" {
+				continue
+			}
+			if err := game.play(); err != nil {
+				return 0, err
+			}
+			for _, board := range game.boards[1:] {
+				consensus.add(transpositionKey(board), value)
+			}
+		}
+		inferred[field] = consensus
+	}
+
+	// and next enrich all games
+	count := 0
+	for idx := range c.slice {
+		enriched, err := c.slice[idx].enrichTags(fields, inferred)
+		if err != nil {
+			return count, err
+		}
+		if enriched {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,211 @@
+// -*- coding: utf-8 -*-
+// pgntags_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:39:52.797528825 (1792161592)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPgnCollection_EditTags(t *testing.T) {
+
+	game, err := ParseGame(`[Event "Casual game"]
+[Annotator "nobody"]
+[Round "?"]
+
+1. e4 e5 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	c := NewPgnCollection()
+	c.Add(*game)
+	other := NewPgnCollection()
+	other.Add(*game)
+
+	// rules are read from a JSON file and applied in order
+	filename := filepath.Join(t.TempDir(), "rules.json")
+	contents := `[{"set": {"Event": "Candidates 2024", "Round": "3"}},
+		      {"set": {"Site": "Toronto"}, "delete": ["Annotator"]}]`
+	if err := os.WriteFile(filename, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadTagRules(filename)
+	if err != nil {
+		t.Fatalf("LoadTagRules() error = %v", err)
+	}
+	edited, err := c.EditTags(rules)
+	if err != nil {
+		t.Fatalf("EditTags() error = %v", err)
+	}
+	if edited != 1 {
+		t.Errorf("EditTags() = %v, want 1", edited)
+	}
+
+	tags := c.slice[0].tags
+	if tags["Event"] != "Candidates 2024" || tags["Round"] != 3 || tags["Site"] != "Toronto" {
+		t.Errorf("EditTags() tags = %v", tags)
+	}
+	if _, ok := tags["Annotator"]; ok {
+		t.Errorf("EditTags() did not delete the tag Annotator: %v", tags)
+	}
+
+	// other collections with the same game are not modified
+	if other.slice[0].tags["Event"] != "Casual game" {
+		t.Errorf("EditTags() modified other collections: %v", other.slice[0].tags)
+	}
+}
+
+func TestPgnCollection_TransformTags(t *testing.T) {
+
+	c := NewPgnCollection()
+	for _, pgn := range []string{
+		"[White \"carlsen, magnus\"]\n[Black \"Caruana\"]\n[Annotator \"me\"]\n\n1. e4 e5 *",
+		"[White \"Caruana\"]\n[Black \"Nakamura\"]\n\n1. d4 d5 *",
+	} {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+	other := NewPgnCollection()
+	other.Add(c.slice[0])
+
+	// only games whose tags are modified are counted
+	transformed, err := c.TransformTags(func(game *PgnGame) error {
+		if name := game.getTag("White"); name == "carlsen, magnus" {
+			game.SetTag("White", strings.ToUpper(name[:1])+name[1:])
+		}
+		return nil
+	})
+	if err != nil || transformed != 1 {
+		t.Errorf("TransformTags() = (%v, %v), want (1, nil)", transformed, err)
+	}
+	if got := c.slice[0].getTag("White"); got != "Carlsen, magnus" {
+		t.Errorf("TransformTags() White = %v", got)
+	}
+
+	// other collections with the same game are not modified
+	if got := other.slice[0].getTag("White"); got != "carlsen, magnus" {
+		t.Errorf("TransformTags() modified other collections: %v", got)
+	}
+
+	// games are left untouched when an error is found
+	if _, err := c.TransformTags(func(game *PgnGame) error {
+		game.DeleteTag("White")
+		return errors.New(" wrong game")
+	}); err == nil || !c.slice[0].HasTag("White") {
+		t.Errorf("TransformTags() error = %v, tags = %v", err, c.slice[0].tags)
+	}
+
+	// and the convenience helpers are all built on top of it
+	if renamed := c.RenameTag("Black", "Opponent"); renamed != 2 || c.slice[1].getTag("Opponent") != "Nakamura" || c.slice[1].HasTag("Black") {
+		t.Errorf("RenameTag() = %v, tags = %v", renamed, c.slice[1].tags)
+	}
+	if deleted := c.DeleteTag("Annotator", "Source"); deleted != 1 || c.slice[0].HasTag("Annotator") {
+		t.Errorf("DeleteTag() = %v, tags = %v", deleted, c.slice[0].tags)
+	}
+	if set, err := c.SetTagIf("", "Round", "3"); err != nil || set != 2 || c.slice[1].tags["Round"] != 3 {
+		t.Errorf("SetTagIf() = (%v, %v), tags = %v", set, err, c.slice[1].tags)
+	}
+}
+
+func TestPgnCollection_EnrichTags(t *testing.T) {
+
+	games := []string{
+		`[Event "A"]
+[ECO "C60"]
+[Opening "Ruy Lopez"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 1-0`,
+		`[Event "B"]
+[ECO "C44"]
+[Opening "King's Knight Opening: Normal Variation"]
+
+1. e4 e5 2. Nf3 Nc6 3. d4 0-1`,
+		`[Event "C"]
+
+1. Nf3 Nc6 2. e4 e5 3. Bb5 Nf6 4. O-O *`,
+		`[Event "D"]
+[Termination "Time forfeit"]
+
+1. f3 e5 2. g4 Qh4# 0-1`,
+	}
+	c := NewPgnCollection()
+	for _, pgn := range games {
+		game, err := ParseGame(pgn)
+		if err != nil {
+			t.Fatalf("ParseGame() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	// unknown tags are rejected
+	if _, err := c.EnrichTags("Annotator"); err == nil {
+		t.Errorf("EnrichTags(Annotator) error = nil")
+	}
+
+	enriched, err := c.EnrichTags()
+	if err != nil {
+		t.Fatalf("EnrichTags() error = %v", err)
+	}
+	if enriched != 4 {
+		t.Errorf("EnrichTags() = %v, want 4", enriched)
+	}
+	want := []map[string]string{
+		{"PlyCount": "6", "ECO": "C60", "Opening": "Ruy Lopez", "Termination": "?"},
+		{"PlyCount": "5", "ECO": "C44", "Opening": "King's Knight Opening: Normal Variation", "Termination": "?"},
+		{"PlyCount": "7", "ECO": "C60", "Opening": "Ruy Lopez", "Termination": "Unterminated"},
+		{"PlyCount": "4", "ECO": "?", "Opening": "?", "Termination": "Time forfeit",
+			"EndFEN": "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"},
+	}
+	for idx, igame := range c.GetGames() {
+		for name, value := range want[idx] {
+			if got := igame.getTag(name); got != value {
+				t.Errorf("EnrichTags() tag %v of game #%v = %v, want %v", name, igame.Id(), got, value)
+			}
+		}
+	}
+
+	// enriching games again does not modify them, and termination is
+	// inferred from the final position
+	if enriched, err := c.EnrichTags(); err != nil || enriched != 0 {
+		t.Errorf("EnrichTags() = %v, %v, want 0", enriched, err)
+	}
+	delete(c.slice[3].tags, "Termination")
+	if enriched, err := c.EnrichTags("Termination"); err != nil || enriched != 1 {
+		t.Errorf("EnrichTags(Termination) = %v, %v, want 1", enriched, err)
+	}
+	if got := c.slice[3].getTag("Termination"); got != "Normal" {
+		t.Errorf("EnrichTags(Termination) = %v, want Normal", got)
+	}
+
+	// boards without a variant follow the rules of standard chess
+	game, err := ParseGame("[Result \"0-1\"]\n\n1. f3 e5 2. g4 Qh4# 0-1")
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if err := game.play(); err != nil {
+		t.Fatalf("play() error = %v", err)
+	}
+	for idx := range game.boards {
+		game.boards[idx].variant = nil
+	}
+	if enriched, err := game.enrichTags([]string{"Termination"}, nil); err != nil || !enriched || game.getTag("Termination") != "Normal" {
+		t.Errorf("enrichTags(Termination) = %v, %v with %v, want Normal", enriched, err, game.getTag("Termination"))
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
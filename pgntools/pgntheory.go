@@ -0,0 +1,178 @@
+// -*- coding: utf-8 -*-
+// pgntheory.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:23:27.527058581 (1792167807)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	"github.com/clinaresl/table"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// The theory depth of a game is the number of plies it stays within the move
+// tree of a reference collection, i.e., the number of its first plies which
+// were played in the same order in any game of the reference collection. If
+// transpositions are considered, it is the last ply reaching a position also
+// reached in the reference collection, so that games can get into the theory
+// with a different move order. A game leaves the theory with a novelty if it
+// has more plies than its depth
+type PgnTheoryDepth struct {
+	Id      int
+	Depth   int
+	Novelty bool
+}
+
+// The theory depth of a collection of games with respect to a reference
+// collection consists of the theory depth of every game, along with aggregate
+// statistics computed over all of them. Depths are given in plies
+type PgnTheoryDepths struct {
+	Games     []PgnTheoryDepth
+	Novelties int
+	Min, Max  int
+	Mean      float64
+	Median    float64
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the theory depths of the given games along with their aggregate
+// statistics
+func newPgnTheoryDepths(games []PgnTheoryDepth) *PgnTheoryDepths {
+
+	result := PgnTheoryDepths{Games: games}
+	depths := make([]int, 0, len(games))
+	for _, game := range games {
+		depths = append(depths, game.Depth)
+		if game.Novelty {
+			result.Novelties++
+		}
+	}
+	if len(depths) > 0 {
+		slices.Sort(depths)
+		result.Min, result.Max = depths[0], depths[len(depths)-1]
+		sum := 0
+		for _, depth := range depths {
+			sum += depth
+		}
+		result.Mean = float64(sum) / float64(len(depths))
+		middle := len(depths) / 2
+		if len(depths)%2 == 0 {
+			result.Median = float64(depths[middle-1]+depths[middle]) / 2
+		} else {
+			result.Median = float64(depths[middle])
+		}
+	}
+	return &result
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the keys of the positions reached after every ply of this game, or of
+// the sequences of moves played up to every ply if transpositions are not
+// considered. Games are played if necessary in the first case
+func (game *PgnGame) theoryKeys(transpositions bool) ([]string, error) {
+
+	keys := make([]string, 0, len(game.moves))
+	if transpositions {
+		if err := game.play(); err != nil {
+			return nil, err
+		}
+		for _, board := range game.boards[1:] {
+			keys = append(keys, transpositionKey(board))
+		}
+		return keys, nil
+	}
+
+	// sequences of moves are identified by a hash of them, and games starting
+	// from a different position never share them
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%v\n", game.getTag("FEN"))
+	for _, move := range game.moves {
+		fmt.Fprintf(hash, "%v ", move.shortAlgebraic)
+		keys = append(keys, fmt.Sprintf("%016x", hash.Sum64()))
+	}
+	return keys, nil
+}
+
+// Return the theory depth of every game in this collection with respect to
+// the move tree of the given reference collection, along with aggregate
+// statistics, and any error found. If transpositions are considered, games
+// stay within the theory up to the last position which was also reached in the
+// reference collection, and games of both collections are played if
+// necessary. Keys of the reference collection are computed in parallel with
+// the number of workers given WithWorkers
+func (c PgnCollection) TheoryDepth(reference PgnCollection, transpositions bool, opts ...PgnOption) (*PgnTheoryDepths, error) {
+
+	// compute the keys of all games of the reference collection. Because
+	// every worker accesses a different game, no synchronization is needed
+	options := newPgnOptions(opts...)
+	keys := make([][]string, len(reference.slice))
+	if err := options.forEach(len(reference.slice), func(idx int) (err error) {
+		keys[idx], err = reference.slice[idx].theoryKeys(transpositions)
+		return
+	}); err != nil {
+		return nil, err
+	}
+	theory := make(map[string]struct{})
+	for _, ikeys := range keys {
+		for _, key := range ikeys {
+			theory[key] = struct{}{}
+		}
+	}
+
+	// and now compute the depth of every game of this collection
+	games := make([]PgnTheoryDepth, 0, len(c.slice))
+	for idx := range c.slice {
+		game := &c.slice[idx]
+		ikeys, err := game.theoryKeys(transpositions)
+		if err != nil {
+			return nil, err
+		}
+		depth := 0
+		for ply, key := range ikeys {
+			if _, ok := theory[key]; !ok && !transpositions {
+				break
+			} else if ok {
+				depth = ply + 1
+			}
+		}
+		games = append(games, PgnTheoryDepth{
+			Id:      game.id,
+			Depth:   depth,
+			Novelty: depth < len(ikeys),
+		})
+	}
+	return newPgnTheoryDepths(games), nil
+}
+
+// Theory depths are shown as a table with their aggregate statistics, with
+// depths given in plies
+func (depths PgnTheoryDepths) String() string {
+
+	tab, _ := table.NewTable(" l | r ")
+	tab.AddRow("Games", len(depths.Games))
+	tab.AddRow("Novelties", depths.Novelties)
+	tab.AddSingleRule()
+	tab.AddRow("Min depth", depths.Min)
+	tab.AddRow("Mean depth", fmt.Sprintf("%.2f", depths.Mean))
+	tab.AddRow("Median depth", fmt.Sprintf("%.2f", depths.Median))
+	tab.AddRow("Max depth", depths.Max)
+	return fmt.Sprintf("%v", tab)
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
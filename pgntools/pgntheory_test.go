@@ -0,0 +1,74 @@
+// -*- coding: utf-8 -*-
+// pgntheory_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:23:59.371130979 (1792167839)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPgnCollection_TheoryDepth(t *testing.T) {
+
+	reference, err := NewPgnCollectionFromReader(strings.NewReader(`[Result "*"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 *
+
+[Result "*"]
+
+1. d4 d5 2. c4 e6 *
+`))
+	if err != nil {
+		t.Fatalf("NewPgnCollectionFromReader() error = %v", err)
+	}
+	games, err := NewPgnCollectionFromReader(strings.NewReader(`[Result "*"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 *
+
+[Result "*"]
+
+1. d4 d5 2. c4 *
+
+[Result "*"]
+
+1. Nf3 Nc6 2. e4 e5 3. Bb5 a6 *
+`))
+	if err != nil {
+		t.Fatalf("NewPgnCollectionFromReader() error = %v", err)
+	}
+
+	// the last game transposes into the first line of the reference
+	tests := []struct {
+		transpositions bool
+		depths         []int
+		novelties      int
+		mean, median   float64
+	}{
+		{false, []int{4, 3, 0}, 2, 7.0 / 3, 3},
+		{true, []int{4, 3, 5}, 2, 4, 4},
+	}
+	for _, tt := range tests {
+		result, err := games.TheoryDepth(*reference, tt.transpositions)
+		if err != nil {
+			t.Fatalf("TheoryDepth() error = %v", err)
+		}
+		for idx, depth := range tt.depths {
+			if result.Games[idx].Depth != depth {
+				t.Errorf("TheoryDepth(%v) game %v = %v, want %v", tt.transpositions, idx, result.Games[idx].Depth, depth)
+			}
+		}
+		if result.Novelties != tt.novelties || result.Mean != tt.mean || result.Median != tt.median {
+			t.Errorf("TheoryDepth(%v) = %+v", tt.transpositions, *result)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
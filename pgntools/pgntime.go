@@ -0,0 +1,183 @@
+// -*- coding: utf-8 -*-
+// pgntime.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:42:29.780833257 (1792161749)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// The time usage of a player in a game consists of the number of moves whose
+// thinking time is known, the overall time spent in them and the longest
+// thinking time, all in seconds
+type PgnTimeUsage struct {
+	Moves          int
+	Total, Longest float64
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the number of seconds in the given clock, e.g., "0:03:00" or "1:02.5",
+// and true if it could be parsed or false otherwise
+func clockSeconds(value string) (float64, bool) {
+
+	// the last field stores the seconds, which might have decimals, and every
+	// previous field is sixty times larger than the next one
+	seconds := 0.0
+	for _, field := range strings.Split(strings.TrimSpace(value), ":") {
+		number, err := strconv.ParseFloat(field, 64)
+		if err != nil || number < 0 {
+			return 0, false
+		}
+		seconds = 60*seconds + number
+	}
+	return seconds, true
+}
+
+// Return the base time and the increment in seconds given in the time control
+// of the given tags, e.g., "180+2", and true if they are known or false
+// otherwise
+func getTimeControl(tags map[string]any) (float64, float64, bool) {
+
+	control, ok := tags["TimeControl"]
+	if !ok {
+		return 0, 0, false
+	}
+	base, increment, _ := strings.Cut(fmt.Sprintf("%v", control), "+")
+	baseSeconds, err := strconv.ParseFloat(base, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	incrementSeconds, err := strconv.ParseFloat(increment, 64)
+	if err != nil {
+		incrementSeconds = 0
+	}
+	return baseSeconds, incrementSeconds, true
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the number of seconds in the clock of the player after this move, as
+// given in "[%clk ...]", and true if it is given or false otherwise
+func (move PgnMove) Clock() (float64, bool) {
+	for _, annotation := range move.annotations {
+		if annotation.Kind == ClockAnnotation {
+			return clockSeconds(annotation.Value)
+		}
+	}
+	return 0, false
+}
+
+// Return the thinking time in seconds of every ply of this game, or -1 if it
+// is unknown. Thinking times are taken from the elapsed move time if it is
+// given. Otherwise, they are computed from the clocks of consecutive moves of
+// the same player, taking into account the increment of the time control. The
+// first move of every player is compared with the base time of the time
+// control, if it is known
+func (game *PgnGame) ThinkingTimes() []float64 {
+
+	base, increment, known := getTimeControl(game.tags)
+
+	// remember the last clock of every player, where White is stored first
+	var clocks [2]float64
+	var clocked [2]bool
+	if known {
+		clocks, clocked = [2]float64{base, base}, [2]bool{true, true}
+	}
+
+	times := make([]float64, len(game.moves))
+	for idx, move := range game.moves {
+		player := 0
+		if move.color < 0 {
+			player = 1
+		}
+
+		times[idx] = -1
+		clock, ok := move.Clock()
+		if move.emt != -1 {
+			times[idx] = float64(move.emt)
+		} else if ok && clocked[player] {
+			times[idx] = max(0, clocks[player]-clock+increment)
+		}
+		clocks[player], clocked[player] = clock, ok
+	}
+	return times
+}
+
+// Return the average thinking time in seconds of the moves whose thinking time
+// is known, or 0 if there are none
+func (usage PgnTimeUsage) Average() float64 {
+	if usage.Moves == 0 {
+		return 0
+	}
+	return usage.Total / float64(usage.Moves)
+}
+
+// Return the time usage of the player with the given color (1 for White and -1
+// for Black) in this game, see ThinkingTimes. Moves whose thinking time is
+// unknown are not considered
+func (game *PgnGame) TimeUsage(color int) (usage PgnTimeUsage) {
+	for idx, seconds := range game.ThinkingTimes() {
+		if seconds < 0 || game.moves[idx].color != color {
+			continue
+		}
+		usage.Moves++
+		usage.Total += seconds
+		usage.Longest = max(usage.Longest, seconds)
+	}
+	return
+}
+
+// Produces a LaTeX string with a pgfplots bar chart of the thinking time in
+// seconds of both players for every move, with the given width and height. If
+// the thinking time of all moves is unknown, the empty string is returned.
+// Templates using it have to load the package pgfplots.
+//
+// It is intended to be used in LaTeX templates
+func (game *PgnGame) GetLaTeXTimeChart(width, height string) (output string) {
+
+	// compute the coordinates of the thinking times of every player
+	var coordinates [2]string
+	for idx, seconds := range game.ThinkingTimes() {
+		if seconds < 0 {
+			continue
+		}
+		player := 0
+		if game.moves[idx].color < 0 {
+			player = 1
+		}
+		coordinates[player] += fmt.Sprintf("(%v,%.1f) ", game.moves[idx].number, seconds)
+	}
+	if coordinates[0] == "" && coordinates[1] == "" {
+		return
+	}
+
+	// and draw them in the same axis, White first
+	output += `\begin{tikzpicture}` + "\n"
+	output += fmt.Sprintf(`\begin{axis}[ybar, bar width=1.5pt, width=%v, height=%v, xlabel={Move}, ylabel={Seconds}, ymin=0, legend pos=north west, legend style={font=\footnotesize}]`, width, height)
+	output += "\n"
+	output += fmt.Sprintf(`\addplot[fill=LightGray, draw=Gray] coordinates {%v};`, coordinates[0]) + "\n"
+	output += fmt.Sprintf(`\addplot[fill=DimGray, draw=Black] coordinates {%v};`, coordinates[1]) + "\n"
+	output += `\legend{White, Black}` + "\n"
+	output += `\end{axis}` + "\n"
+	output += `\end{tikzpicture}` + "\n"
+
+	return
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,112 @@
+// -*- coding: utf-8 -*-
+// pgntime_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:42:38.887275292 (1792161758)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func Test_clockSeconds(t *testing.T) {
+
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"0:03:00", 180, true},
+		{"1:02.5", 62.5, true},
+		{"7", 7, true},
+		{"0:-1:00", 0, false},
+		{"fast", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := clockSeconds(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("clockSeconds(%v) = (%v, %v), want (%v, %v)", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPgnGame_GetLaTeXTimeChart(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		pgn   string
+		times []float64
+		want  []string
+	}{
+		{"clock", `[TimeControl "180+2"]
+
+1. e4 { [%clk 0:03:00] } e5 { [%clk 0:02:55] } 2. Nf3 { [%clk 0:02:50] } Nc6 { [%clk 0:02:57] } *`,
+			[]float64{2, 7, 12, 0}, []string{"(1,2.0) (2,12.0)", "(1,7.0) (2,0.0)"}},
+		{"emt", `[Event "FICS"]
+
+1. e4 {[%emt 0.0]} e5 {[%emt 1.5]} 2. Nf3 *`,
+			[]float64{0, 1.5, -1}, []string{"(1,0.0)", "(1,1.5)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := ParseGame(tt.pgn)
+			if err != nil {
+				t.Fatalf("ParseGame() error = %v", err)
+			}
+			if times := game.ThinkingTimes(); !slices.Equal(times, tt.times) {
+				t.Errorf("ThinkingTimes() = %v, want %v", times, tt.times)
+			}
+			chart := game.GetLaTeXTimeChart("6in", "2in")
+			for _, want := range tt.want {
+				if !strings.Contains(chart, want) {
+					t.Errorf("GetLaTeXTimeChart() = %v, want it to contain %v", chart, want)
+				}
+			}
+		})
+	}
+
+	// games without thinking times produce no chart
+	game, err := ParseGame("[Event \"None\"]\n\n1. e4 e5 *")
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if chart := game.GetLaTeXTimeChart("6in", "2in"); chart != "" {
+		t.Errorf("GetLaTeXTimeChart() = %v, want the empty string", chart)
+	}
+}
+
+func TestPgnGame_TimeUsage(t *testing.T) {
+
+	game, err := ParseGame(`[TimeControl "180+2"]
+
+1. e4 { [%clk 0:03:00] } e5 { [%clk 0:02:55] } 2. Nf3 { [%clk 0:02:50] } Nc6 { [%clk 0:02:57] } 3. Bb5 *`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if clock, ok := game.moves[1].Clock(); !ok || clock != 175 {
+		t.Errorf("Clock() = (%v, %v), want (175, true)", clock, ok)
+	}
+	if _, ok := game.moves[4].Clock(); ok {
+		t.Errorf("Clock() should be unknown")
+	}
+
+	// the last move of White has no clock and it is not considered
+	if got, want := game.TimeUsage(1), (PgnTimeUsage{Moves: 2, Total: 14, Longest: 12}); got != want {
+		t.Errorf("TimeUsage(1) = %v, want %v", got, want)
+	}
+	if got := game.TimeUsage(1).Average(); got != 7 {
+		t.Errorf("Average() = %v, want 7", got)
+	}
+	if got, want := game.TimeUsage(-1), (PgnTimeUsage{Moves: 2, Total: 7, Longest: 7}); got != want {
+		t.Errorf("TimeUsage(-1) = %v, want %v", got, want)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -22,6 +22,7 @@ package pgntools
 
 import (
 	"regexp"
+	"sync"
 )
 
 // global variables (to the package)
@@ -40,8 +41,11 @@ var reTags = regexp.MustCompile(`(\[\s*\w+\s*"[^"]*"\s*\]\s*)+`)
 // the following regexp matches an arbitrary sequence of moves which are
 // identified by a number, a color (symbolized by either one dot for white or
 // three dots for black) and the move in algebraic format. Moves can be followed
-// by an arbitrary number of comments
-var reMoves = regexp.MustCompile(`(?:(\d+)(\.|\.{3})\s*((?:[PNBRQK]?[a-h]?[1-8]?x?(?:[a-h][1-8]|[NBRQK])(?:\=[PNBRQK])?|O(?:-?O){1,2})[\+#]?(?:\s*[\!\?]+)?)\s*({[^{}]*}\s*)*\s*((?:[PNBRQK]?[a-h]?[1-8]?x?(?:[a-h][1-8]|[NBRQK])(?:\=[PNBRQK])?|O(?:-?O){1,2})[\+#]?(?:\s*[\!\?]+)?)\s*({[^{}]*}\s*)*\s*)+`)
+// by an arbitrary number of comments. The second move of every pair is
+// optional so that games ending with a move of white are also recognized.
+// Parenthesis opening and closing variations are also accepted after every
+// move, though they are verified to be balanced only when parsing moves
+var reMoves = regexp.MustCompile(`(?:(\d+)(\.|\.{3})\s*((?:[PNBRQK]?[a-h]?[1-8]?x?(?:[a-h][1-8]|[NBRQK])(?:\=[PNBRQK])?|O(?:-?O){1,2})[\+#]?(?:\s*[\!\?]+)?)\s*((?:{[^{}]*}|\$\d+|[()])\s*)*\s*(?:((?:[PNBRQK]?[a-h]?[1-8]?x?(?:[a-h][1-8]|[NBRQK])(?:\=[PNBRQK])?|O(?:-?O){1,2})[\+#]?(?:\s*[\!\?]+)?)\s*((?:{[^{}]*}|\$\d+|[()])\s*)*)?\s*)+`)
 
 // the outcome is one of the following strings "1-0", "0-1" or "1/2-1/2"
 var reOutcome = regexp.MustCompile(`(1\-0|0\-1|1/2\-1/2|\*)`)
@@ -49,8 +53,13 @@ var reOutcome = regexp.MustCompile(`(1\-0|0\-1|1/2\-1/2|\*)`)
 // the following regexp is used to parse the description of an entire game,
 // including the tags, list of moves and final outcome. It consists of a
 // concatenation of the previous expressions where an arbitrary number of spaces
-// is allowed between them
-var reGame = regexp.MustCompile(`\s*(\[\s*(?P<tagname>\w+)\s*"(?P<tagvalue>[^"]*)"\s*\]\s*)+\s*(?:(\d+)(\.|\.{3})\s*((?:[PNBRQK]?[a-h]?[1-8]?x?(?:[a-h][1-8]|[NBRQK])(?:\=[PNBRQK])?|O(?:-?O){1,2})[\+#]?(?:\s*[\!\?]+)?)\s*({[^{}]*}\s*)*\s*((?:[PNBRQK]?[a-h]?[1-8]?x?(?:[a-h][1-8]|[NBRQK])(?:\=[PNBRQK])?|O(?:-?O){1,2})[\+#]?(?:\s*[\!\?]+)?)\s*({[^{}]*}\s*)*\s*)+\s*(1\-0|0\-1|1/2\-1/2|\*)\s*`)
+// is allowed between them. Comments are also allowed before the first move and
+// after the outcome
+var reGame = regexp.MustCompile(`\s*(\[\s*(?P<tagname>\w+)\s*"(?P<tagvalue>[^"]*)"\s*\]\s*)+\s*(?:(?:{[^{}]*}|\$\d+)\s*)*(?:(\d+)(\.|\.{3})\s*((?:[PNBRQK]?[a-h]?[1-8]?x?(?:[a-h][1-8]|[NBRQK])(?:\=[PNBRQK])?|O(?:-?O){1,2})[\+#]?(?:\s*[\!\?]+)?)\s*((?:{[^{}]*}|\$\d+|[()])\s*)*\s*(?:((?:[PNBRQK]?[a-h]?[1-8]?x?(?:[a-h][1-8]|[NBRQK])(?:\=[PNBRQK])?|O(?:-?O){1,2})[\+#]?(?:\s*[\!\?]+)?)\s*((?:{[^{}]*}|\$\d+|[()])\s*)*)?\s*)+\s*(1\-0|0\-1|1/2\-1/2|\*)\s*(?:{[^{}]*}\s*)*`)
+
+// the following regexp matches the comments that follow the outcome of a game,
+// if any, in the same way that reGame does
+var reTrailingComments = regexp.MustCompile(`^\s*(?:{[^{}]*}\s*)*`)
 
 // grouped regexps -- they are used to extract relevant information from a
 // string
@@ -81,10 +90,20 @@ var reTextualMove = regexp.MustCompile(`([PNBRQK]?)([a-h]?[1-8]?)(x?)([a-h][1-8]
 // the whole string is parsed in chunks
 var reGroupComment = regexp.MustCompile(`^(?P<comment>{[^{}]*})\s*`)
 
-// A specific type of comments provided by ficsgames.org is the time elapsed to
-// make the current move. This is parsed in the following expression. Again,
-// note that this expression matches the beginning of the string
-var reGroupEMT = regexp.MustCompile(`^{\[%emt (?P<emt>\d+\.\d*)\]}`)
+// Numeric Annotation Glyphs (NAGs) following any move are matched with the
+// following regexp, again at the beginning of the string
+var reGroupNAG = regexp.MustCompile(`^\$(?P<nag>\d+)\s*`)
+
+// Comments might contain commands such as the time elapsed to make the current
+// move provided by ficsgames.org, e.g., "[%emt 2.1]", or the clock and the
+// evaluation provided by lichess.org, e.g., "[%clk 0:03:00]" and "[%eval
+// 0.17]". They are recognized anywhere in a comment with the following regexp
+var reGroupCommand = regexp.MustCompile(`\[%(?P<name>\w+)\s+(?P<value>[^\]]*)\]`)
+
+// Annotators qualify moves with a suffix made of exclamation and/or question
+// marks which are separated from the move in short algebraic notation with the
+// following regexp
+var reGroupQuality = regexp.MustCompile(`\s*(?P<quality>[\!\?]+)$`)
 
 // Groups are used in the following regexp to extract the score of every player
 var reGroupOutcome = regexp.MustCompile(`(?P<score1>1/2|0|1)\-(?P<score2>1/2|0|1)`)
@@ -105,6 +124,10 @@ var reSorting = `\s*(?P<direction>[<>])\s*(?P<criteria>.+)\s*`
 // correct
 var reFEN = regexp.MustCompile(`^(?P<piece>\*|[0-8pnbrqkPNBRQK\/\*]+) (?P<color>\*|[wb]) (?P<castling>-|\*|[kqKQ]+\*?) (?P<enpassant>-|[a-h]\*|\*[0-8]|[a-h][0-8]|\*) (?P<halfmove>\*|\d+) (?P<fullmove>\*|\d+)$`)
 
+// Piece placements of FEN codes and patterns are consumed in chunks, which
+// might start with a number of empty squares
+var reDigit = regexp.MustCompile(`^\d`)
+
 // Package variables
 // ----------------------------------------------------------------------------
 
@@ -133,9 +156,110 @@ var threats map[string]map[content][][]int
 // The following map relates each content with its utf-8 representation
 var utf8repr map[content]rune
 
+// and the following one relates each content with its ASCII representation
+// using the letters of the FEN notation
+var asciirepr = map[content]rune{
+	BKING: 'k', BQUEEN: 'q', BROOK: 'r', BBISHOP: 'b', BKNIGHT: 'n', BPAWN: 'p',
+	WKING: 'K', WQUEEN: 'Q', WROOK: 'R', WBISHOP: 'B', WKNIGHT: 'N', WPAWN: 'P',
+	BLANK: ' ',
+}
+
+// and the following one relates every letter of the FEN notation with the
+// content it represents
+var fenPieces = map[rune]content{
+	'k': BKING, 'q': BQUEEN, 'r': BROOK, 'b': BBISHOP, 'n': BKNIGHT, 'p': BPAWN,
+	'K': WKING, 'Q': WQUEEN, 'R': WROOK, 'B': WBISHOP, 'N': WKNIGHT, 'P': WPAWN,
+}
+
 // The following counter is used to generate LaTeX references
 var counter int = 0
 
+// The following map relates the name of every command recognized in comments
+// with the kind of annotation used to store it
+var commandAnnotations = map[string]PgnAnnotationKind{
+	"emt":  EMTAnnotation,
+	"clk":  ClockAnnotation,
+	"eval": EvalAnnotation,
+	"lang": LanguageAnnotation,
+	"cal":  ArrowAnnotation,
+	"csl":  HighlightAnnotation,
+}
+
+// and the following one relates every kind of annotation given with a command
+// with the name of the command
+var annotationCommands = map[PgnAnnotationKind]string{
+	EMTAnnotation:       "emt",
+	ClockAnnotation:     "clk",
+	EvalAnnotation:      "eval",
+	LanguageAnnotation:  "lang",
+	ArrowAnnotation:     "cal",
+	HighlightAnnotation: "csl",
+}
+
+// Regular expressions used in filters over the movetext of games are compiled
+// only once and shared by all games, even if they are processed in parallel
+var movetextRegexps sync.Map
+
+// Likewise, opening lines used in filters are parsed only once, and the
+// numbers of their moves are recognized with the following regular expression
+var openingLines sync.Map
+var reOpeningMoveNumber = regexp.MustCompile(`\d+\.+`)
+
+// The following map relates every symbol used for qualifying moves with its
+// Numeric Annotation Glyph (NAG) as defined in the PGN standard
+var qualityNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+// Moves which were not qualified with a symbol are qualified with the first NAG
+// qualifying moves given after them, if any, with the inverse of qualityNAGs
+var nagQualities = map[int]string{
+	1: "!",
+	2: "?",
+	3: "!!",
+	4: "??",
+	5: "!?",
+	6: "?!",
+}
+
+// NAGs are shown in LaTeX with the following symbols. Those qualifying moves
+// are shown right after them, as the symbols given by annotators, and the
+// others with the symbols provided by the package skak
+var nagLaTeX = map[int]string{
+	1:  "!",
+	2:  "?",
+	3:  "!!",
+	4:  "??",
+	5:  "!?",
+	6:  "?!",
+	7:  `\onlymove`,
+	10: `\equal`,
+	13: `\unclear`,
+	14: `\wbetter`,
+	15: `\bbetter`,
+	16: `\wupperhand`,
+	17: `\bupperhand`,
+	18: `\wdecisive`,
+	19: `\bdecisive`,
+	44: `\compensation`,
+}
+
+// The number of moves qualified with each symbol is available in games with the
+// names given in the following map
+var qualityFields = map[string]string{
+	"GoodMoves":        "!",
+	"Mistakes":         "?",
+	"Brilliancies":     "!!",
+	"Blunders":         "??",
+	"InterestingMoves": "!?",
+	"DubiousMoves":     "?!",
+}
+
 // functions
 // ----------------------------------------------------------------------------
 
@@ -0,0 +1,195 @@
+// -*- coding: utf-8 -*-
+// pgntraining.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:21:45.441570856 (1792160505)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Training sheets can be written in different formats
+type TrainingFormat int
+
+// A training exercise consists of the position before a move of the trained
+// player, which has to be guessed
+type trainingExercise struct {
+	game    *PgnGame
+	ply     int  // index of the move to guess
+	flipped bool // whether the player plays with Black
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+const (
+	LaTeXTraining    TrainingFormat = iota // LaTeX document using xskak
+	MarkdownTraining                       // Markdown with ASCII boards
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Write a LaTeX document with the given exercises for the given player and the
+// solutions at the end
+func writeLaTeXTraining(builder *strings.Builder, player string, exercises []trainingExercise) {
+
+	builder.WriteString("\\documentclass{article}\n\n")
+	builder.WriteString("\\usepackage[utf8]{inputenc}\n")
+	builder.WriteString("\\usepackage{xskak}\n\n")
+	builder.WriteString("\\begin{document}\n\n")
+	fmt.Fprintf(builder, "\\section*{Training: %v}\n\n", substituteLaTeX(player))
+
+	// show the position of every exercise, flipping the board when the player
+	// plays with Black
+	for idx, exercise := range exercises {
+		fmt.Fprintf(builder, "\\subsection*{Exercise %v}\n\n", idx+1)
+		fmt.Fprintf(builder, "%v\n\n", substituteLaTeX(exercise.title()))
+		fmt.Fprintf(builder, "\\begin{center}\n  \\chessboard[setfen=%v,showmover=true,inverse=%v]\n\\end{center}\n\n",
+			exercise.game.boards[exercise.ply].FEN(), exercise.flipped)
+		fmt.Fprintf(builder, "\\noindent %v\n\n", exercise.prompt())
+	}
+
+	// and then all solutions
+	builder.WriteString("\\newpage\n\\section*{Solutions}\n\n")
+	if len(exercises) > 0 {
+		builder.WriteString("\\begin{enumerate}\n")
+		for _, exercise := range exercises {
+			fmt.Fprintf(builder, "  \\item %v\n", exercise.solution())
+		}
+		builder.WriteString("\\end{enumerate}\n\n")
+	}
+	builder.WriteString("\\end{document}\n")
+}
+
+// Write a Markdown document with the given exercises for the given player and
+// the solutions at the end
+func writeMarkdownTraining(builder *strings.Builder, player string, exercises []trainingExercise) {
+
+	fmt.Fprintf(builder, "# Training: %v\n\n", player)
+
+	// show the position of every exercise, flipping the board when the player
+	// plays with Black
+	for idx, exercise := range exercises {
+		fmt.Fprintf(builder, "## Exercise %v\n\n", idx+1)
+		fmt.Fprintf(builder, "%v\n\n", exercise.title())
+		board := exercise.game.boards[exercise.ply]
+		fmt.Fprintf(builder, "```\n%v\n```\n\n", board.render(BoardStyle{ASCII: true, Flipped: exercise.flipped}))
+		fmt.Fprintf(builder, "FEN: `%v`\n\n", board.FEN())
+		fmt.Fprintf(builder, "%v\n\n", exercise.prompt())
+	}
+
+	// and then all solutions
+	builder.WriteString("## Solutions\n\n")
+	for idx, exercise := range exercises {
+		fmt.Fprintf(builder, "- Exercise %v: %v\n", idx+1, exercise.solution())
+	}
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a description of the game of this exercise
+func (exercise trainingExercise) title() string {
+	return fmt.Sprintf("%v - %v (%v, %v)",
+		exercise.game.getTag("White"), exercise.game.getTag("Black"),
+		exercise.game.getTag("Event"), exercise.game.getTag("Date"))
+}
+
+// Return the text shown along with the position of this exercise, i.e., the
+// previous move, if any, and the side to move
+func (exercise trainingExercise) prompt() string {
+
+	side := "White"
+	if exercise.game.moves[exercise.ply].color < 0 {
+		side = "Black"
+	}
+	if exercise.ply == 0 {
+		return fmt.Sprintf("%v to move", side)
+	}
+	previous := exercise.game.moves[exercise.ply-1]
+	return fmt.Sprintf("After %v %v, %v to move", comparisonLabel(previous), previous.shortAlgebraic, side)
+}
+
+// Return the solution of this exercise, i.e., the move actually played
+func (exercise trainingExercise) solution() string {
+	move := exercise.game.moves[exercise.ply]
+	return fmt.Sprintf("%v %v", comparisonLabel(move), move.annotated())
+}
+
+// Return all exercises for the given player in this collection, i.e., the
+// positions before every move of the player from the given move number on.
+// Games are played if necessary. Games where the player did not take part are
+// ignored
+func (c PgnCollection) getExercises(player string, first int) ([]trainingExercise, error) {
+
+	exercises := make([]trainingExercise, 0)
+	for idx := range c.slice {
+		game := &c.slice[idx]
+
+		// determine the color of the player in this game, if any
+		color := 0
+		if game.getTag("White") == player {
+			color = 1
+		} else if game.getTag("Black") == player {
+			color = -1
+		} else {
+			continue
+		}
+
+		// positions are required before every move
+		if err := game.play(); err != nil {
+			return nil, err
+		}
+		for ply, move := range game.moves {
+			if move.color == color && move.number >= first {
+				exercises = append(exercises, trainingExercise{
+					game:    game,
+					ply:     ply,
+					flipped: color < 0,
+				})
+			}
+		}
+	}
+	return exercises, nil
+}
+
+// Write a "guess-the-move" training sheet for the given player with all games
+// of this collection where the player took part. Every exercise shows the
+// position before a move of the player from the given move number on, shown
+// from the side of the player, and hides the move actually played. All moves
+// are given in an appendix with the solutions. The sheet is written in the
+// given format, either a LaTeX document or Markdown
+func (c PgnCollection) GetTraining(writer io.Writer, player string, first int, format TrainingFormat) error {
+
+	exercises, err := c.getExercises(player, first)
+	if err != nil {
+		return err
+	}
+
+	var builder strings.Builder
+	switch format {
+	case LaTeXTraining:
+		writeLaTeXTraining(&builder, player, exercises)
+	case MarkdownTraining:
+		writeMarkdownTraining(&builder, player, exercises)
+	default:
+		return fmt.Errorf(" Unknown format of training sheets '%v'", format)
+	}
+	_, err = io.WriteString(writer, builder.String())
+	return err
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
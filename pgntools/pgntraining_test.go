@@ -0,0 +1,65 @@
+// -*- coding: utf-8 -*-
+// pgntraining_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 14:22:40.825024246 (1792160560)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPgnCollection_GetTraining(t *testing.T) {
+
+	pgn := `[Event "Training"]
+[White "alice"]
+[Black "bob"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 1-0`
+	game, err := getGameFromString(pgn)
+	if err != nil {
+		t.Fatalf("getGameFromString() error = %v", err)
+	}
+	c := NewPgnCollection()
+	c.Add(*game)
+
+	tests := []struct {
+		name   string
+		player string
+		first  int
+		format TrainingFormat
+		want   []string
+	}{
+		{"white", "alice", 2, MarkdownTraining, []string{
+			"## Exercise 1", "After 1... e5, White to move", "## Exercise 2",
+			"- Exercise 1: 2. Nf3", "- Exercise 2: 3. Bb5",
+		}},
+		{"black", "bob", 1, LaTeXTraining, []string{
+			"setfen=rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1,showmover=true,inverse=true",
+			"\\item 1... e5", "\\item 2... Nc6",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var builder strings.Builder
+			if err := c.GetTraining(&builder, tt.player, tt.first, tt.format); err != nil {
+				t.Fatalf("GetTraining() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(builder.String(), want) {
+					t.Errorf("GetTraining() = %v, want it to contain %v", builder.String(), want)
+				}
+			}
+		})
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,136 @@
+// -*- coding: utf-8 -*-
+// pgntranspositions.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:33:27.001717971 (1792164807)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A transposition records that the position reached at some ply of a game was
+// already reached before with a different sequence of moves, either in another
+// game, given by its id, or earlier in the same game
+type PgnTransposition struct {
+	Ply    int // ply of this game where the position is reached
+	Id     int // id of the game where it was reached before
+	Number int // number of the move that reached it in that game
+}
+
+// Every position is remembered along with the first game and ply where it was
+// reached with a specific sequence of moves, given by a hash of them
+type transpositionOccurrence struct {
+	id, number int
+	line       uint64
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the key used to identify positions, which consists of the first three
+// fields of their FEN code, i.e., the piece placement, the side to move and the
+// castling rights. The en passant square is ignored because it is given after
+// every double push, even if no pawn can capture en passant
+func transpositionKey(board PgnBoard) string {
+	return strings.Join(strings.Fields(board.fen)[:3], " ")
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Transpositions are shown with the id of the game and the number of the move
+// where the position was reached before
+func (transposition PgnTransposition) String() string {
+	return fmt.Sprintf("game #%v (move %v)", transposition.Id, transposition.Number)
+}
+
+// Return the transpositions of this game, which are computed with
+// FindTranspositions
+func (game *PgnGame) Transpositions() []PgnTransposition {
+	return game.transpositions
+}
+
+// Return the text of the comment written after the move that reaches the
+// position of the given transposition
+func (game *PgnGame) getTranspositionComment(transposition PgnTransposition) string {
+	if transposition.Id == game.id {
+		return fmt.Sprintf("already reached at move %v", transposition.Number)
+	}
+	return fmt.Sprintf("also reached in %v", transposition)
+}
+
+// Find all positions of the games in this collection that were reached before
+// with a different sequence of moves, either in a previous game or earlier in
+// the same game, and return the number of transpositions found. Games are
+// played if necessary. Every transposition refers to the first game, in the
+// order of this collection, where the position was reached with a different
+// sequence of moves. The initial position of games is never considered, and
+// positions that follow a transposition found in the previous ply are not
+// reported again, so that only the moves where games transpose are annotated.
+//
+// Transpositions are stored in every game, replacing those computed
+// previously, and they are written as comments in PGN format WithTranspositions
+func (c PgnCollection) FindTranspositions() (int, error) {
+
+	// remember all positions along with the different sequences of moves used
+	// to reach them
+	nbtranspositions := 0
+	seen := make(map[string][]transpositionOccurrence)
+	for idx := range c.slice {
+		game := &c.slice[idx]
+		game.transpositions = nil
+		if err := game.play(); err != nil {
+			return nbtranspositions, err
+		}
+
+		// the sequence of moves is identified by a hash which is updated
+		// incrementally with every move, starting from the initial position
+		hasher := fnv.New64a()
+		hasher.Write([]byte(game.boards[0].fen))
+		transposed := false
+		for ply := 1; ply < len(game.boards); ply++ {
+			move := game.moves[ply-1]
+			hasher.Write([]byte(" " + move.shortAlgebraic))
+			key, line := transpositionKey(game.boards[ply]), hasher.Sum64()
+
+			// look for the first occurrence of this position reached with a
+			// different sequence of moves, and remember whether this one was
+			// already seen
+			found, known := false, false
+			for _, occurrence := range seen[key] {
+				if occurrence.line == line {
+					known = true
+				} else if !found {
+					found = true
+
+					// transpositions are reported only if the previous ply
+					// was not a transposition already
+					if !transposed {
+						game.transpositions = append(game.transpositions,
+							PgnTransposition{Ply: ply, Id: occurrence.id, Number: occurrence.number})
+						nbtranspositions++
+					}
+				}
+			}
+			transposed = found
+			if !known {
+				seen[key] = append(seen[key], transpositionOccurrence{id: game.id, number: move.number, line: line})
+			}
+		}
+	}
+	return nbtranspositions, nil
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,72 @@
+// -*- coding: utf-8 -*-
+// pgntranspositions_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 15:33:59.873147108 (1792164839)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPgnCollection_FindTranspositions(t *testing.T) {
+
+	games := []string{
+		"1. e4 e5 2. Nf3 Nc6 3. Bb5 *",
+		"1. Nf3 Nc6 2. e4 e5 3. Bb5 a6 *",
+		"1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 *",
+		"1. d4 d5 2. Nf3 Nf6 3. Ng1 Ng8 *",
+	}
+	c := NewPgnCollection()
+	for _, pgn := range games {
+		game, err := getGameFromString("[Event \"?\"]\n\n" + pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		c.Add(*game)
+	}
+
+	nbtranspositions, err := c.FindTranspositions()
+	if err != nil {
+		t.Fatalf("FindTranspositions() error = %v", err)
+	}
+	if nbtranspositions != 3 {
+		t.Errorf("FindTranspositions() = %v transpositions, want 3", nbtranspositions)
+	}
+	want := [][]PgnTransposition{
+		nil,
+		{{Ply: 4, Id: 1, Number: 2}},
+		{{Ply: 5, Id: 2, Number: 1}},
+		{{Ply: 6, Id: 4, Number: 1}},
+	}
+	for idx, igame := range c.GetGames() {
+		if got := igame.Transpositions(); !reflect.DeepEqual(got, want[idx]) {
+			t.Errorf("Transpositions() of game #%v = %v, want %v", igame.Id(), got, want[idx])
+		}
+	}
+
+	// transpositions are written as comments only when requested
+	game := c.GetGames()[1]
+	comment := "2. e4 e5 { also reached in game #1 (move 2) } 3. Bb5 a6"
+	if got := game.GetPGN(); strings.Contains(got, comment) {
+		t.Errorf("GetPGN() = %q, should not contain %q", got, comment)
+	}
+	if got := game.GetPGN(WithTranspositions()); !strings.Contains(got, comment) {
+		t.Errorf("GetPGN(WithTranspositions()) = %q, want %q", got, comment)
+	}
+	game = c.GetGames()[3]
+	comment = "3. Ng1 Ng8 { already reached at move 1 }"
+	if got := game.GetPGN(WithTranspositions()); !strings.Contains(got, comment) {
+		t.Errorf("GetPGN(WithTranspositions()) = %q, want %q", got, comment)
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
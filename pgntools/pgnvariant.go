@@ -0,0 +1,351 @@
+// -*- coding: utf-8 -*-
+// pgnvariant.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 13:53:10.472485936 (1792158790)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A Variant defines the rules of a specific flavour of chess. The replay of
+// games relies on the variant for all decisions that depend upon the rules
+// being used, so that new variants can be added just by implementing this
+// interface and registering them with RegisterVariant.
+//
+// Squares are given as integer indexes in the range [0, 64), with 0 being a1
+// and 63 being h8. Colors follow the same convention used in moves: 1 for White
+// and -1 for Black
+type Variant interface {
+
+	// Return the name of the variant as given in the tag "Variant" of PGN
+	// games
+	Name() string
+
+	// Return the initial board of a game with the given tags
+	InitialBoard(tags map[string]any) (PgnBoard, error)
+
+	// Return the squares from and to which the king and the rook of the given
+	// color are moved when castling either on the king side (short) or the
+	// queen side in the given board. An error is returned if castling is not
+	// possible
+	Castling(board *PgnBoard, color int, short bool) (kingFrom, kingTo, rookFrom, rookTo int, err error)
+
+	// Return nil if the piece in the origin square can be moved to the target
+	// square in the given board, and an error otherwise
+	ValidateMove(board *PgnBoard, origin, target int) error
+
+	// Return nil if a pawn can be promoted to the given piece and an error
+	// otherwise
+	ValidatePromotion(piece content) error
+
+	// Update the given board right after the piece in the origin square has
+	// been moved to the target square capturing the given piece (which is
+	// BLANK if the move was not a capture)
+	AfterMove(board *PgnBoard, origin, target int, captured content)
+
+	// Return the outcome of the game if the side to move with the given color
+	// has no possibility to continue the game in the given board, and true. If
+	// the game can continue, the returned outcome is meaningless and false is
+	// returned
+	Outcome(board *PgnBoard, color int) (PgnOutcome, bool)
+}
+
+// Variants whose castling rights are not fully described by the letters KQkq
+// with their usual meaning can implement this interface to update them after
+// every move
+type castlingRightsVariant interface {
+
+	// Return the castling rights that result after moving the piece in the
+	// origin square to the target square in the given board, where castling
+	// gives the castling rights before the move
+	CastlingRights(board *PgnBoard, castling string, origin, target int) string
+}
+
+// Standard chess is the default variant
+type standardVariant struct{}
+
+// Chess960 (or Fischer Random) follows the same rules than standard chess but
+// for the initial position, which is always given in the tag "FEN", and
+// castling. Castling rights can be given either with the letters KQkq (X-FEN)
+// or with the files of the rooks (Shredder-FEN)
+type chess960Variant struct {
+	standardVariant
+}
+
+// globals
+// ----------------------------------------------------------------------------
+
+// Variants are registered in the following map indexed by their name in lower
+// case. Access to the registry is protected with a mutex so that variants can
+// be registered at any time
+var variants = map[string]Variant{}
+var variantsMutex sync.RWMutex
+
+// The variant used by default, i.e., in boards and games that do not specify
+// any variant
+var defaultVariant Variant = standardVariant{}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Register the standard variant under the different names used by PGN files to
+// refer to it. Lichess exports games of standard chess started from a custom
+// position as "From Position", along with the tag "FEN"
+func init() {
+	RegisterVariant(defaultVariant)
+	registerVariantName("Chess", defaultVariant)
+	registerVariantName("From Position", defaultVariant)
+	RegisterVariant(chess960Variant{})
+	registerVariantName("Fischerandom", chess960Variant{})
+}
+
+// Register the given variant under the given name, which is case-insensitive
+func registerVariantName(name string, variant Variant) {
+	variantsMutex.Lock()
+	defer variantsMutex.Unlock()
+	variants[strings.ToLower(name)] = variant
+}
+
+// Return the color and location of the rook referred to by the given letter of
+// the castling rights in the given board, and true. Letters in KQkq refer to
+// the outermost rook on the corresponding side of the king, while any other
+// letter refers to the rook in that file. If there is no such rook, false is
+// returned
+func castlingRook(board *PgnBoard, letter rune) (color, rook int, ok bool) {
+
+	// uppercase letters refer to white and lowercase letters to black
+	color, rank, king := 1, 0, board.wking
+	if unicode.IsLower(letter) {
+		color, rank, king = -1, 56, board.bking
+	}
+
+	// the king must be in the first rank of its color for any castling right
+	// to be meaningful
+	if king/8 != rank/8 {
+		return 0, 0, false
+	}
+
+	switch unicode.ToUpper(letter) {
+	case 'K':
+		for file := 7; file > king%8; file-- {
+			if board.squares[rank+file] == getPieceValue(WROOK, color) {
+				return color, rank + file, true
+			}
+		}
+	case 'Q':
+		for file := 0; file < king%8; file++ {
+			if board.squares[rank+file] == getPieceValue(WROOK, color) {
+				return color, rank + file, true
+			}
+		}
+	default:
+		file := int(unicode.ToUpper(letter) - 'A')
+		if file >= 0 && file < 8 && board.squares[rank+file] == getPieceValue(WROOK, color) {
+			return color, rank + file, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Register the given variant so that games whose tag "Variant" equals its name
+// (ignoring case) are replayed with its rules. Registering a variant with the
+// same name than another one overrides the former
+func RegisterVariant(variant Variant) {
+	registerVariantName(variant.Name(), variant)
+}
+
+// Return the variant registered with the given name (ignoring case) and true,
+// or nil and false if no variant has been registered with that name
+func GetVariant(name string) (Variant, bool) {
+	variantsMutex.RLock()
+	defer variantsMutex.RUnlock()
+	variant, ok := variants[strings.ToLower(strings.TrimSpace(name))]
+	return variant, ok
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// -- Standard chess
+
+// The name of standard chess as used by lichess
+func (variant standardVariant) Name() string {
+	return "Standard"
+}
+
+// Games of standard chess start with the position given in the tag "FEN", if
+// any, unless the tag "SetUp" is explicitly set to "0". Otherwise, they start
+// with the usual initial position
+func (variant standardVariant) InitialBoard(tags map[string]any) (PgnBoard, error) {
+	if fen, ok := tags["FEN"]; ok && fmt.Sprintf("%v", tags["SetUp"]) != "0" {
+		return NewPgnBoardFromFEN(fmt.Sprintf("%v", fen))
+	}
+	return NewPgnBoard(), nil
+}
+
+// In standard chess the king is always moved two squares towards the rook which
+// is placed right on the other side of the king
+func (variant standardVariant) Castling(board *PgnBoard, color int, short bool) (kingFrom, kingTo, rookFrom, rookTo int, err error) {
+
+	// compute the squares in the first rank of the given color
+	kingFrom, kingTo, rookFrom, rookTo = coords["e1"], coords["c1"], coords["a1"], coords["d1"]
+	if short {
+		kingTo, rookFrom, rookTo = coords["g1"], coords["h1"], coords["f1"]
+	}
+	if color < 0 {
+		kingFrom, kingTo, rookFrom, rookTo = kingFrom+56, kingTo+56, rookFrom+56, rookTo+56
+	}
+
+	// and verify that both the king and the rook are in their squares
+	if board.squares[kingFrom] != getPieceValue(WKING, color) ||
+		board.squares[rookFrom] != getPieceValue(WROOK, color) {
+		return 0, 0, 0, 0, fmt.Errorf(" Castling is not possible: either the king or the rook are not in '%v' and '%v'\n", literal[kingFrom], literal[rookFrom])
+	}
+	return
+}
+
+// In standard chess pieces can not be moved to the square they are in, nor
+// capture pieces of the same color or kings
+func (variant standardVariant) ValidateMove(board *PgnBoard, origin, target int) error {
+
+	// a move has to change the location of a piece
+	if origin == target {
+		return fmt.Errorf(" The piece in '%v' is not moved\n", literal[origin])
+	}
+
+	// and it is not possible to capture either pieces of the same color or the
+	// king
+	if captured := board.squares[target]; captured != BLANK {
+		if getColor(captured) == getColor(board.squares[origin]) {
+			return fmt.Errorf(" The piece in '%v' can not capture a piece of the same color in '%v'\n", literal[origin], literal[target])
+		}
+		if captured == WKING || captured == BKING {
+			return fmt.Errorf(" The piece in '%v' can not capture the king in '%v'\n", literal[origin], literal[target])
+		}
+	}
+	return nil
+}
+
+// In standard chess pawns can be promoted to any piece but pawns and kings
+func (variant standardVariant) ValidatePromotion(piece content) error {
+	switch piece {
+	case WKNIGHT, WBISHOP, WROOK, WQUEEN, BKNIGHT, BBISHOP, BROOK, BQUEEN:
+		return nil
+	}
+	return fmt.Errorf(" Pawns can not be promoted to '%v'\n", string(utf8repr[piece]))
+}
+
+// In standard chess moves have no side effects other than moving the piece
+func (variant standardVariant) AfterMove(board *PgnBoard, origin, target int, captured content) {
+}
+
+// Standard games are ended by the board when the side to move is either
+// checkmated, which loses the game, or stalemated, which draws it
+func (variant standardVariant) Outcome(board *PgnBoard, color int) (PgnOutcome, bool) {
+	if board.isCheckmate(color) {
+		if color > 0 {
+			return PgnOutcome{0, 1}, true
+		}
+		return PgnOutcome{1, 0}, true
+	}
+	if board.isStalemate(color) {
+		return PgnOutcome{0.5, 0.5}, true
+	}
+	return PgnOutcome{}, false
+}
+
+// -- Chess960
+
+// The name of Chess960 as used by lichess
+func (variant chess960Variant) Name() string {
+	return "Chess960"
+}
+
+// Games of Chess960 always start with the position given in the tag "FEN"
+func (variant chess960Variant) InitialBoard(tags map[string]any) (PgnBoard, error) {
+	fen, ok := tags["FEN"]
+	if !ok {
+		return PgnBoard{}, fmt.Errorf(" Chess960 games must provide their initial position in the tag 'FEN'\n")
+	}
+	return NewPgnBoardFromFEN(fmt.Sprintf("%v", fen))
+}
+
+// In Chess960 the king and the rook referred to by the castling rights end in
+// the same squares than in standard chess, i.e., the king in the file g (short)
+// or c and the rook in the file f (short) or d. All squares between the initial
+// and final locations of both pieces must be empty but for the king and the
+// rook themselves
+func (variant chess960Variant) Castling(board *PgnBoard, color int, short bool) (kingFrom, kingTo, rookFrom, rookTo int, err error) {
+
+	// get the castling rights of this board
+	rights := "-"
+	if fields := strings.Fields(board.fen); len(fields) > 2 {
+		rights = fields[2]
+	}
+
+	// and look for a castling right of the given color on the given side of
+	// the king
+	kingFrom, rank := board.wking, 0
+	if color < 0 {
+		kingFrom, rank = board.bking, 56
+	}
+	for _, letter := range rights {
+		if rookColor, rook, ok := castlingRook(board, letter); ok && rookColor == color && (rook > kingFrom) == short {
+			rookFrom = rook
+			kingTo, rookTo = rank+2, rank+3
+			if short {
+				kingTo, rookTo = rank+6, rank+5
+			}
+
+			// verify that all squares in between are empty
+			lo := min(kingFrom, kingTo, rookFrom, rookTo)
+			hi := max(kingFrom, kingTo, rookFrom, rookTo)
+			for square := lo; square <= hi; square++ {
+				if square != kingFrom && square != rookFrom && board.squares[square] != BLANK {
+					return 0, 0, 0, 0, fmt.Errorf(" Castling is not possible: the square '%v' is not empty\n", literal[square])
+				}
+			}
+			return
+		}
+	}
+	return 0, 0, 0, 0, fmt.Errorf(" Castling is not possible: there are no castling rights in '%v' for this side\n", rights)
+}
+
+// In Chess960 castling rights are kept with the same notation they were given
+// and they are lost when either the king or the rook they refer to is moved, or
+// when the rook is captured
+func (variant chess960Variant) CastlingRights(board *PgnBoard, castling string, origin, target int) (fen string) {
+	for _, letter := range castling {
+		if color, rook, ok := castlingRook(board, letter); ok {
+			king := board.wking
+			if color < 0 {
+				king = board.bking
+			}
+			if origin != king && origin != rook && target != rook {
+				fen += string(letter)
+			}
+		}
+	}
+
+	// In case no side has any castling rights use a dash
+	if len(fen) == 0 {
+		fen = "-"
+	}
+	return
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
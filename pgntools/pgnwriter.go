@@ -0,0 +1,158 @@
+// -*- coding: utf-8 -*-
+// pgnwriter.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:21:25.665572068 (1792167685)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A PgnWriter writes games in PGN format on a writer, one after the other,
+// with the options given when it was created, see GetPGN
+type PgnWriter struct {
+	writer  io.Writer
+	options []PgnOption
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// Lines of the movetext in the export format of the PGN standard are not
+// longer than the following number of characters
+const PgnExportWidth = 80
+
+// globals
+// ----------------------------------------------------------------------------
+
+// The tags of the Seven Tag Roster are written first in the following order,
+// according to the export format of the PGN standard
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a new PgnWriter which writes games on the given writer with the given
+// options
+func NewPgnWriter(writer io.Writer, opts ...PgnOption) *PgnWriter {
+	return &PgnWriter{
+		writer:  writer,
+		options: opts,
+	}
+}
+
+// Return the names of the given tags in the order of the export format of the
+// PGN standard: the tags of the Seven Tag Roster are given first, and the
+// others are given next in alphabetical order
+func getTagNames(tags map[string]any) []string {
+
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		if !slices.Contains(sevenTagRoster, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	roster := make([]string, 0, len(sevenTagRoster))
+	for _, name := range sevenTagRoster {
+		if _, ok := tags[name]; ok {
+			roster = append(roster, name)
+		}
+	}
+	return append(roster, names...)
+}
+
+// Return the given tags in PGN format, one per line, in the order of the
+// export format of the PGN standard, see getTagNames
+func getPGNTags(tags map[string]any) (output string) {
+	for _, name := range getTagNames(tags) {
+		output += fmt.Sprintf("[%v \"%v\"]\n", name, tags[name])
+	}
+	return
+}
+
+// Return the tokens of the given movetext, i.e., its words, where commands
+// given in comments such as "[%clk 0:03:00]" are kept in the same token, so
+// that they are never split in different lines
+func getPGNTokens(movetext string) (tokens []string) {
+
+	command := false
+	for _, field := range strings.Fields(movetext) {
+		if command {
+			tokens[len(tokens)-1] += " " + field
+		} else {
+			tokens = append(tokens, field)
+		}
+		if strings.Contains(field, "[%") {
+			command = true
+		}
+		if command && (strings.Contains(field, "]") || strings.Contains(field, "}")) {
+			command = false
+		}
+	}
+	return
+}
+
+// Return the given movetext wrapped as requested in the given options. If a
+// line width is given, the movetext is arranged in lines which are not longer
+// than it, unless a single token is longer, and if comments have to be written
+// in their own lines, every brace block starts a new line and the movetext
+// resumes in the next one. Otherwise, the movetext is returned as it is
+func wrapMoveText(movetext string, options pgnOptions) string {
+
+	if options.lineWidth <= 0 && !options.commentLines {
+		return movetext
+	}
+
+	// split the tokens in groups that have to be written in different lines,
+	// and wrap every group separately
+	var groups [][]string
+	var group []string
+	for _, token := range getPGNTokens(movetext) {
+		if options.commentLines && strings.HasPrefix(token, "{") && len(group) > 0 {
+			groups, group = append(groups, group), nil
+		}
+		group = append(group, token)
+		if options.commentLines && strings.HasSuffix(token, "}") {
+			groups, group = append(groups, group), nil
+		}
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+
+	lines := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if options.lineWidth > 0 {
+			lines = append(lines, wrapTokens(group, options.lineWidth))
+		} else {
+			lines = append(lines, strings.Join(group, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Write the given game in PGN format with the options of this writer, and
+// return any error found
+func (w *PgnWriter) Write(game PgnGame) error {
+	_, err := io.WriteString(w.writer, game.GetPGN(w.options...))
+	return err
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
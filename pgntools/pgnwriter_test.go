@@ -0,0 +1,65 @@
+// -*- coding: utf-8 -*-
+// pgnwriter_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:22:17.544759580 (1792167737)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_getPGNTags(t *testing.T) {
+
+	tags := map[string]any{
+		"WhiteElo": 2100,
+		"Result":   "1-0",
+		"ECO":      "C20",
+		"White":    "Morphy",
+		"Event":    "Casual",
+	}
+	want := "[Event \"Casual\"]\n[White \"Morphy\"]\n[Result \"1-0\"]\n[ECO \"C20\"]\n[WhiteElo \"2100\"]\n"
+	if got := getPGNTags(tags); got != want {
+		t.Errorf("getPGNTags() = %q, want %q", got, want)
+	}
+}
+
+func TestPgnWriter_Write(t *testing.T) {
+
+	game, err := ParseGame(`[Result "1-0"]
+
+1. e4 {[%emt 1.2]} e5 {[%emt 0.5]} 2. Bc4 { A classical developing move [%emt 3.1] } Nc6 3. Qh5 Nf6 4. Qxf7# 1-0`)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts []PgnOption
+		want string
+	}{
+		{"default", nil, "1. e4 {[%emt 1.2]} e5 {[%emt 0.5]} 2. Bc4 { A classical developing move [%emt 3.1] } Nc6 3. Qh5 Nf6 4. Qxf7# 1-0"},
+		{"width", []PgnOption{WithLineWidth(30)}, "1. e4 {[%emt 1.2]} e5\n{[%emt 0.5]} 2. Bc4 { A\nclassical developing move\n[%emt 3.1] } Nc6 3. Qh5 Nf6 4.\nQxf7# 1-0"},
+		{"lines", []PgnOption{WithCommentLines(), WithoutCommands("emt")}, "1. e4 e5 2. Bc4\n{ A classical developing move }\nNc6 3. Qh5 Nf6 4. Qxf7# 1-0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var builder strings.Builder
+			if err := NewPgnWriter(&builder, tt.opts...).Write(*game); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if got := strings.TrimSpace(strings.TrimPrefix(builder.String(), "[Result \"1-0\"]\n\n")); got != tt.want {
+				t.Errorf("Write() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,150 @@
+// -*- coding: utf-8 -*-
+// pgnzobrist.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:34:49.666576448 (1792168489)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import "strings"
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// Zobrist keys consist of a random number for every piece in every square, for
+// the side to move, every letter of the castling rights and every file of an en
+// passant target. The hash of a position is the exclusive or of the keys of all
+// its features, so that it can be updated incrementally
+type zobristKeys struct {
+	pieces    [13][64]uint64 // indexed by the content of the square plus 6
+	black     uint64         // black to move
+	castling  [128]uint64    // indexed by the letter of the castling rights
+	enpassant [8]uint64      // indexed by the file of the en passant target
+}
+
+// consts
+// ----------------------------------------------------------------------------
+
+// The keys are generated with a fixed seed so that hashes are the same in
+// every execution
+const zobristSeed uint64 = 0x9e3779b97f4a7c15
+
+// globals
+// ----------------------------------------------------------------------------
+
+var zobrist zobristKeys
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Generate all Zobrist keys with a splitmix64 generator
+func init() {
+
+	state := zobristSeed
+	next := func() uint64 {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+
+	// empty squares are not given any key so that they do not change the hash
+	for piece := range zobrist.pieces {
+		if content(piece-6) == BLANK {
+			continue
+		}
+		for square := range zobrist.pieces[piece] {
+			zobrist.pieces[piece][square] = next()
+		}
+	}
+	zobrist.black = next()
+	for _, letter := range "KQkqABCDEFGHabcdefgh" {
+		zobrist.castling[letter] = next()
+	}
+	for file := range zobrist.enpassant {
+		zobrist.enpassant[file] = next()
+	}
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return the hash of the features of this board other than its pieces, i.e.,
+// the side to move, castling rights and en passant target. The en passant
+// target is considered only if a pawn of the side to move can capture en
+// passant, because it is given after every double push
+func (board *PgnBoard) zobristState() (hash uint64) {
+
+	fields := strings.Fields(board.fen)
+	if len(fields) < 4 {
+		return
+	}
+	if fields[1] == "b" {
+		hash ^= zobrist.black
+	}
+	for _, letter := range fields[2] {
+		if letter < 128 {
+			hash ^= zobrist.castling[letter]
+		}
+	}
+
+	// The pawn that can be captured en passant is in front of the target,
+	// and it can be captured by pawns of the side to move next to it
+	if target, ok := coords[fields[3]]; ok {
+		pawn, capturer := target+8, BPAWN
+		if fields[1] == "w" {
+			pawn, capturer = target-8, WPAWN
+		}
+		if pawn < 0 || pawn > 63 {
+			return
+		}
+		if (pawn%8 > 0 && board.squares[pawn-1] == capturer) ||
+			(pawn%8 < 7 && board.squares[pawn+1] == capturer) {
+			hash ^= zobrist.enpassant[target%8]
+		}
+	}
+	return
+}
+
+// Return the hash of this board computed from scratch
+func (board *PgnBoard) zobristHash() uint64 {
+
+	hash := board.zobristState()
+	for square, piece := range board.squares {
+		hash ^= zobrist.pieces[piece+6][square]
+	}
+	return hash
+}
+
+// Update the hash of this board incrementally, taking into account that it was
+// generated from the preceding (prec) one. Only the squares whose contents
+// changed are considered, so that any side effects of variants are also
+// taken into account
+func (board *PgnBoard) updateHash(prec PgnBoard) {
+
+	hash := prec.hash ^ prec.zobristState() ^ board.zobristState()
+	for square, piece := range board.squares {
+		if piece != prec.squares[square] {
+			hash ^= zobrist.pieces[prec.squares[square]+6][square] ^ zobrist.pieces[piece+6][square]
+		}
+	}
+	board.hash = hash
+}
+
+// Return the Zobrist hash of this board, which identifies its position: the
+// pieces in every square, the side to move, the castling rights and the en
+// passant target if a pawn can capture en passant. Halfmove clocks and fullmove
+// numbers are ignored, so that the same position reached in different moves
+// has the same hash, as required to count repetitions. Different positions
+// might have the same hash, though it is very unlikely
+func (board *PgnBoard) Hash() uint64 {
+	return board.hash
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,80 @@
+// -*- coding: utf-8 -*-
+// pgnzobrist_test.go
+// -----------------------------------------------------------------------------
+//
+// Started on <vie 16-10-2026 16:35:03.500602949 (1792168503)>
+// Carlos Linares López <carlos.linares@uc3m.es>
+//
+
+package pgntools
+
+import "testing"
+
+func TestPgnBoard_Hash(t *testing.T) {
+
+	// hashes updated incrementally are the same than those computed from
+	// scratch, including castling, en passant captures and promotions
+	pgns := []string{
+		"1. e4 d5 2. exd5 c6 3. dxc6 Nf6 4. cxb7 e5 5. bxa8=Q Bc5 6. Nf3 O-O 7. Bc4 e4 8. d4 exd3 9. O-O *",
+		"1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 *",
+		"1. e4 e5 2. Nf3 Nc6 *",
+		"1. Nf3 Nc6 2. e4 e5 *",
+	}
+	final := make([]uint64, len(pgns))
+	for idx, pgn := range pgns {
+		game, err := getGameFromString("[Event \"?\"]\n\n" + pgn)
+		if err != nil {
+			t.Fatalf("getGameFromString() error = %v", err)
+		}
+		if err := game.Realize(-1); err != nil {
+			t.Fatalf("Realize() error = %v", err)
+		}
+		boards := game.Boards()
+		for ply := range boards {
+			if got, want := boards[ply].Hash(), boards[ply].zobristHash(); got != want {
+				t.Errorf("Hash() of ply %v of %q = %x, want %x", ply, pgn, got, want)
+			}
+		}
+		final[idx] = boards[len(boards)-1].Hash()
+
+		// and they are also the same than the hash of the board given with its
+		// FEN code
+		board, err := NewPgnBoardFromFEN(boards[len(boards)-1].FEN())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if board.Hash() != final[idx] {
+			t.Errorf("Hash() of the FEN of %q = %x, want %x", pgn, board.Hash(), final[idx])
+		}
+	}
+
+	// repeated positions and transpositions have the same hash, even if their
+	// move numbers differ, but the side to move matters
+	initial := NewPgnBoard()
+	if final[1] == initial.Hash() {
+		t.Error("Hash() should depend on the side to move")
+	}
+	if final[2] != final[3] {
+		t.Errorf("Hash() of transpositions differ: %x != %x", final[2], final[3])
+	}
+
+	// en passant targets are considered only if the pawn can be captured
+	for _, tt := range []struct {
+		with, without string
+		capture       bool
+	}{
+		{"4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1", "4k3/8/8/8/4P3/8/8/4K3 b - - 0 1", false},
+		{"4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1", "4k3/8/8/8/3pP3/8/8/4K3 b - - 0 1", true},
+	} {
+		with, _ := NewPgnBoardFromFEN(tt.with)
+		without, _ := NewPgnBoardFromFEN(tt.without)
+		if (with.Hash() != without.Hash()) != tt.capture {
+			t.Errorf("Hash() of '%v' and '%v' should differ only if the pawn can be captured", tt.with, tt.without)
+		}
+	}
+}
+
+// Local Variables:
+// mode:go
+// fill-column:80
+// End:
@@ -0,0 +1,345 @@
+/*
+  serve.go
+  Description: serve subcommand of the PGN parser
+  -----------------------------------------------------------------------------
+
+  Made by Carlos Linares Lopez
+  Login   <clinares@atlas>
+*/
+
+package main
+
+// imports
+// ----------------------------------------------------------------------------
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/clinaresl/pgnparser/pgntools"
+)
+
+// typedefs
+// ----------------------------------------------------------------------------
+
+// A server gives access to all games in a collection. Because filters might
+// realize games, all accesses to the collection are serialized
+type server struct {
+	title string                  // title shown in the main page
+	games *pgntools.PgnCollection // collection of games served
+	ids   map[int]int             // location of every game by its id
+	limit int                     // maximum number of games listed
+	jobs  int                     // number of simultaneous jobs used in filters
+	mutex sync.Mutex              // serializes accesses to the collection
+}
+
+// Every game is listed with the following information
+type serverRow struct {
+	Id                             int
+	White, Black, Event, Date, ECO string
+	Result                         string
+	Moves                          int
+}
+
+// global variables
+// ----------------------------------------------------------------------------
+
+// The main page shows a form to filter games and the list of games selected
+var serverIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+input[type=text] { width: 60%; font-family: monospace; }
+table { border-collapse: collapse; margin-top: 1em; }
+th, td { padding: 0.2em 0.8em; text-align: left; border-bottom: 1px solid #ddd; }
+.error { color: darkred; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<form method="get" action="/">
+<input type="text" name="filter" value="{{.Filter}}" placeholder="e.g., White == 'clinares' && ECO >= 'C00'">
+<input type="submit" value="Filter">
+</form>
+{{with .Error}}<p class="error">{{.}}</p>{{end}}
+<p>{{.Selected}} games selected{{if gt .Selected (len .Rows)}}, only the first {{len .Rows}} are shown{{end}}</p>
+<table>
+<tr><th>Id</th><th>White</th><th>Black</th><th>Result</th><th>Event</th><th>Date</th><th>ECO</th><th>Moves</th></tr>
+{{range .Rows}}<tr><td><a href="/game/{{.Id}}">{{.Id}}</a></td><td>{{.White}}</td><td>{{.Black}}</td><td>{{.Result}}</td><td>{{.Event}}</td><td>{{.Date}}</td><td>{{.ECO}}</td><td>{{.Moves}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Every game is shown in a page with a board which is fed with the game in JSON
+// format, so that all positions can be browsed
+var serverGame = template.Must(template.New("game").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.White}} - {{.Black}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+#board { border-collapse: collapse; border: 2px solid #333; }
+#board td { width: 48px; height: 48px; font-size: 38px; text-align: center; padding: 0; }
+.light { background: #f0d9b5; }
+.dark { background: #b58863; }
+#moves { max-width: 40em; line-height: 1.6; }
+#moves span { cursor: pointer; padding: 0 0.2em; }
+#moves span.current { background: #ffd966; }
+.comment { color: #555; font-style: italic; }
+</style>
+</head>
+<body>
+<p><a href="/">&larr; All games</a></p>
+<h1>{{.White}} - {{.Black}} ({{.Result}})</h1>
+<p>{{.Event}}, {{.Date}}, {{.ECO}}</p>
+<table id="board"></table>
+<p>
+<button onclick="show(0)">&laquo;</button>
+<button onclick="show(current - 1)">&lsaquo;</button>
+<button onclick="show(current + 1)">&rsaquo;</button>
+<button onclick="show(positions.length - 1)">&raquo;</button>
+</p>
+<p id="moves"></p>
+<script>
+const pieces = { K: "♔", Q: "♕", R: "♖", B: "♗", N: "♘", P: "♙",
+                 k: "♚", q: "♛", r: "♜", b: "♝", n: "♞", p: "♟" };
+let positions = [], current = 0;
+
+// draw the piece placement of the given FEN code
+function draw(fen) {
+  const board = document.getElementById("board");
+  board.innerHTML = "";
+  fen.split(" ")[0].split("/").forEach((rank, row) => {
+    const tr = board.insertRow();
+    let column = 0;
+    for (const c of rank) {
+      const n = parseInt(c);
+      for (let i = 0; i < (isNaN(n) ? 1 : n); i++, column++) {
+        const td = tr.insertCell();
+        td.className = (row + column) % 2 == 0 ? "light" : "dark";
+        td.textContent = isNaN(n) ? pieces[c] : "";
+      }
+    }
+  });
+}
+
+// show the position after the given number of plies
+function show(ply) {
+  if (ply < 0 || ply >= positions.length) return;
+  current = ply;
+  draw(positions[ply]);
+  document.querySelectorAll("#moves span").forEach(span =>
+    span.classList.toggle("current", parseInt(span.dataset.ply) == ply));
+}
+
+fetch("/api/game/" + {{.Id}}).then(response => response.json()).then(game => {
+  positions = [game.fen].concat(game.moves.map(move => move.fen));
+  const moves = document.getElementById("moves");
+  game.moves.forEach((move, idx) => {
+    if (move.color == 1 || idx == 0) {
+      moves.append(move.number + (move.color == 1 ? ". " : "... "));
+    }
+    const span = document.createElement("span");
+    span.textContent = move.san + (move.quality || "");
+    span.dataset.ply = idx + 1;
+    span.onclick = () => show(idx + 1);
+    moves.append(span, " ");
+    (move.comments || []).forEach(comment => {
+      const em = document.createElement("span");
+      em.className = "comment";
+      em.textContent = comment;
+      moves.append(em, " ");
+    });
+  });
+  show(0);
+});
+
+document.onkeydown = event => {
+  if (event.key == "ArrowLeft") show(current - 1);
+  if (event.key == "ArrowRight") show(current + 1);
+};
+</script>
+</body>
+</html>
+`))
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return the information shown of the given game in lists of games
+func getServerRow(game pgntools.PgnGame) serverRow {
+
+	tag := func(name string) string {
+		if value, ok := game.Tags()[name]; ok {
+			return fmt.Sprintf("%v", value)
+		}
+		return "?"
+	}
+	return serverRow{
+		Id:     game.Id(),
+		White:  tag("White"),
+		Black:  tag("Black"),
+		Event:  tag("Event"),
+		Date:   tag("Date"),
+		ECO:    tag("ECO"),
+		Result: game.Outcome().String(),
+		Moves:  (len(game.Moves()) + 1) / 2,
+	}
+}
+
+// Return a new server of all games in the given collection with the given
+// title. At most limit games are listed in the main page and filters use the
+// given number of jobs
+func newServer(title string, games *pgntools.PgnCollection, limit, jobs int) *server {
+	s := server{title: title, games: games, ids: make(map[int]int), limit: limit, jobs: jobs}
+	for idx, igame := range games.GetGames() {
+		s.ids[igame.Id()] = idx
+	}
+	return &s
+}
+
+// Methods
+// ----------------------------------------------------------------------------
+
+// Return a handler which serves all pages of this server
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.index)
+	mux.HandleFunc("GET /game/{id}", s.game)
+	mux.HandleFunc("GET /api/game/{id}", s.gameJSON)
+	return mux
+}
+
+// Return the game with the given id in the path of the given request, or nil if
+// there is none. If a game is found, it is a clone which has been realized
+func (s *server) getGame(r *http.Request) *pgntools.PgnGame {
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	idx, ok := s.ids[id]
+	if !ok {
+		return nil
+	}
+	game := s.games.GetGame(idx)
+	clone := game.Clone()
+	if err := clone.Realize(-1); err != nil {
+		return nil
+	}
+	return &clone
+}
+
+// Show the list of games satisfying the filter given in the request, if any
+func (s *server) index(w http.ResponseWriter, r *http.Request) {
+
+	data := struct {
+		Title, Filter, Error string
+		Selected             int
+		Rows                 []serverRow
+	}{Title: s.title, Filter: r.URL.Query().Get("filter")}
+
+	// filter games if requested. Errors are shown in the same page
+	s.mutex.Lock()
+	selected := s.games
+	if data.Filter != "" {
+		if filtered, err := s.games.Filter(data.Filter, pgntools.WithWorkers(s.jobs)); err != nil {
+			data.Error = err.Error()
+		} else {
+			selected = filtered
+		}
+	}
+	data.Selected = selected.Len()
+	for _, igame := range selected.GetGames() {
+		if len(data.Rows) >= s.limit {
+			break
+		}
+		data.Rows = append(data.Rows, getServerRow(igame))
+	}
+	s.mutex.Unlock()
+
+	if err := serverIndex.Execute(w, data); err != nil {
+		log.Println(err)
+	}
+}
+
+// Show the page of the game with the id given in the request
+func (s *server) game(w http.ResponseWriter, r *http.Request) {
+	game := s.getGame(r)
+	if game == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := serverGame.Execute(w, getServerRow(*game)); err != nil {
+		log.Println(err)
+	}
+}
+
+// Return the game with the id given in the request in JSON format
+func (s *server) gameJSON(w http.ResponseWriter, r *http.Request) {
+	game := s.getGame(r)
+	if game == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(game); err != nil {
+		log.Println(err)
+	}
+}
+
+// Execute the serve subcommand with the given arguments, i.e., all arguments
+// given after 'serve'. It starts a local web server to browse the games found
+// in the given PGN file: the main page lists all games satisfying a filter,
+// and every game is shown in a page with a board to browse its positions
+func serveCommand(args []string) {
+
+	// parse the flags of the serve subcommand
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	address := flags.String("address", "localhost:8080", "address where the server listens. By default, 'localhost:8080'")
+	limit := flags.Int("limit", 500, "maximum number of games listed in the main page. By default, 500")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "number of simultaneous jobs used for filtering games. By default, the number of CPUs")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %v serve [options] <file.pgn>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(EXIT_FAILURE)
+	}
+
+	// read all games in the given file
+	pgnfile, err := pgntools.NewPgnFile(flags.Arg(0))
+	if err != nil {
+		log.Fatalf(" Error: %v\n", err)
+	}
+	games, err := pgnfile.Games(pgntools.WithLenient())
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// and serve them
+	s := newServer(pgnfile.Name(), games, *limit, *jobs)
+	fmt.Printf(" %v games served at http://%v\n", games.Len(), *address)
+	log.Fatalln(http.ListenAndServe(*address, s.handler()))
+}
+
+/* Local Variables: */
+/* mode:go */
+/* fill-column:80 */
+/* End: */
@@ -0,0 +1,207 @@
+/*
+  serve_test.go
+  Description: tests of the serve subcommand of the PGN parser
+  -----------------------------------------------------------------------------
+
+  Made by Carlos Linares Lopez
+  Login   <clinares@atlas>
+*/
+
+package main
+
+// imports
+// ----------------------------------------------------------------------------
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/clinaresl/pgnparser/pgntools"
+)
+
+// globals
+// ----------------------------------------------------------------------------
+
+// games served in all tests
+const serverGames = `[White "Lasker"]
+[Black "Capablanca"]
+[Event "St. Petersburg"]
+[Date "1914.05.18"]
+[ECO "C68"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 {Developing} Nc6 1-0
+
+[White "Capablanca"]
+[Black "Alekhine"]
+[Event "Buenos Aires"]
+[Date "1927.09.16"]
+[ECO "D51"]
+[Result "0-1"]
+
+1. d4 d5 0-1
+`
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a server of serverGames which lists at most limit games, along with
+// the ids of its games
+func newTestServer(t *testing.T, limit int) (http.Handler, []int) {
+	t.Helper()
+	games, err := pgntools.NewPgnCollectionFromReader(strings.NewReader(serverGames))
+	if err != nil {
+		t.Fatalf("NewPgnCollectionFromReader() error = %v", err)
+	}
+	ids := make([]int, 0)
+	for _, igame := range games.GetGames() {
+		ids = append(ids, igame.Id())
+	}
+	return newServer("test.pgn", games, limit, 2).handler(), ids
+}
+
+// Return the response to a GET request to the given target
+func get(handler http.Handler, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	return recorder
+}
+
+func TestServer_index(t *testing.T) {
+	handler, ids := newTestServer(t, 500)
+	tests := []struct {
+		name      string
+		target    string
+		selected  string
+		listed    []int
+		unlisted  []int
+		wantError bool
+	}{
+		{"No filter", "/", "2 games selected", ids, nil, false},
+		{"Valid filter", "/?filter=" + url.QueryEscape("White == 'Lasker'"), "1 games selected", ids[:1], ids[1:], false},
+		{"Filter with no games", "/?filter=" + url.QueryEscape("White == 'Tal'"), "0 games selected", nil, ids, false},
+
+		// invalid filters are shown in the same page along with all games
+		{"Invalid filter", "/?filter=" + url.QueryEscape("White =="), "2 games selected", ids, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := get(handler, tt.target)
+			if response.Code != http.StatusOK {
+				t.Fatalf("GET %v status = %v, want %v", tt.target, response.Code, http.StatusOK)
+			}
+			body := response.Body.String()
+			if !strings.Contains(body, "<p>"+tt.selected+"</p>") {
+				t.Errorf("GET %v does not contain %q", tt.target, tt.selected)
+			}
+			for _, id := range tt.listed {
+				if !strings.Contains(body, fmt.Sprintf(`<a href="/game/%v">`, id)) {
+					t.Errorf("GET %v does not list game %v", tt.target, id)
+				}
+			}
+			for _, id := range tt.unlisted {
+				if strings.Contains(body, fmt.Sprintf(`<a href="/game/%v">`, id)) {
+					t.Errorf("GET %v lists game %v", tt.target, id)
+				}
+			}
+			if got := strings.Contains(body, `<p class="error">`); got != tt.wantError {
+				t.Errorf("GET %v shows an error = %v, want %v", tt.target, got, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestServer_indexLimit(t *testing.T) {
+	handler, ids := newTestServer(t, 1)
+	body := get(handler, "/").Body.String()
+	if !strings.Contains(body, "2 games selected, only the first 1 are shown") {
+		t.Errorf("GET / does not show that only the first game is listed")
+	}
+	if strings.Contains(body, fmt.Sprintf(`<a href="/game/%v">`, ids[1])) {
+		t.Errorf("GET / lists more games than the limit")
+	}
+}
+
+func TestServer_game(t *testing.T) {
+	handler, ids := newTestServer(t, 500)
+
+	// the page of every game shows its players and fetches it in JSON format
+	target := fmt.Sprintf("/game/%v", ids[0])
+	response := get(handler, target)
+	if response.Code != http.StatusOK {
+		t.Fatalf("GET %v status = %v, want %v", target, response.Code, http.StatusOK)
+	}
+	body := response.Body.String()
+	for _, want := range []string{"<h1>Lasker - Capablanca (1-0)</h1>", fmt.Sprintf(`fetch("/api/game/" +  %v )`, ids[0])} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET %v does not contain %q", target, want)
+		}
+	}
+
+	// and games which do not exist are not found
+	for _, target := range []string{"/game/999", "/game/white", "/api/game/999", "/api/game/white"} {
+		if response := get(handler, target); response.Code != http.StatusNotFound {
+			t.Errorf("GET %v status = %v, want %v", target, response.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestServer_gameJSON(t *testing.T) {
+	handler, ids := newTestServer(t, 500)
+	target := fmt.Sprintf("/api/game/%v", ids[0])
+	response := get(handler, target)
+	if response.Code != http.StatusOK {
+		t.Fatalf("GET %v status = %v, want %v", target, response.Code, http.StatusOK)
+	}
+	if got := response.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("GET %v Content-Type = %q, want %q", target, got, "application/json")
+	}
+
+	// games are realized, so that the FEN code of every position is given as
+	// required by the board shown in the page of every game
+	var game struct {
+		Id    int            `json:"id"`
+		Tags  map[string]any `json:"tags"`
+		FEN   string         `json:"fen"`
+		Moves []struct {
+			Number   int      `json:"number"`
+			Color    int      `json:"color"`
+			SAN      string   `json:"san"`
+			Comments []string `json:"comments"`
+			FEN      string   `json:"fen"`
+		} `json:"moves"`
+		Outcome string `json:"outcome"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&game); err != nil {
+		t.Fatalf("GET %v returned invalid JSON: %v", target, err)
+	}
+	if game.Id != ids[0] || game.Tags["White"] != "Lasker" || game.Outcome != "1-0" {
+		t.Errorf("GET %v = (%v, %v, %v), want (%v, %v, %v)", target, game.Id, game.Tags["White"], game.Outcome, ids[0], "Lasker", "1-0")
+	}
+	if want := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"; game.FEN != want {
+		t.Errorf("GET %v fen = %q, want %q", target, game.FEN, want)
+	}
+	if len(game.Moves) != 4 {
+		t.Fatalf("GET %v has %v moves, want 4", target, len(game.Moves))
+	}
+	for idx, move := range game.Moves {
+		if move.Number != idx/2+1 || move.Color != 1-2*(idx%2) || move.SAN == "" || move.FEN == "" {
+			t.Errorf("GET %v move %v = %+v", target, idx, move)
+		}
+	}
+	if got := game.Moves[2]; got.SAN != "Nf3" || len(got.Comments) != 1 || got.Comments[0] != "Developing" {
+		t.Errorf("GET %v third move = %+v", target, got)
+	}
+	if want := "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3"; game.Moves[3].FEN != want {
+		t.Errorf("GET %v last fen = %q, want %q", target, game.Moves[3].FEN, want)
+	}
+}
+
+/* Local Variables: */
+/* mode:go */
+/* fill-column:80 */
+/* End: */
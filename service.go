@@ -0,0 +1,65 @@
+/*
+  service.go
+  Description: service subcommand of the PGN parser
+  -----------------------------------------------------------------------------
+
+  Made by Carlos Linares Lopez
+  Login   <clinares@atlas>
+*/
+
+package main
+
+// imports
+// ----------------------------------------------------------------------------
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/clinaresl/pgnparser/pgnservice"
+	"github.com/clinaresl/pgnparser/pgntools"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Execute the service subcommand with the given arguments, i.e., all arguments
+// given after 'service'. It starts a web server exposing the REST API of
+// pgnservice, which parses, filters and annotates the games sent to it
+func serviceCommand(args []string) {
+
+	// parse the flags of the service subcommand
+	flags := flag.NewFlagSet("service", flag.ExitOnError)
+	address := flags.String("address", "localhost:8081", "address where the service listens. By default, 'localhost:8081'")
+	lenient := flags.Bool("lenient", false, "if given, games with errors are skipped instead of stopping the stream")
+	maxGameSize := flags.Int("maxgamesize", pgntools.DefaultMaxGameSize, "maximum size in bytes of a single game. If zero, there is no limit")
+	maxBodySize := flags.Int64("maxbodysize", pgnservice.DefaultMaxBodySize, "maximum size in bytes of the body of every request. If zero, there is no limit")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %v service [options]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(EXIT_FAILURE)
+	}
+
+	// create the service with the options given
+	options := []pgntools.PgnOption{pgntools.WithMaxGameSize(*maxGameSize)}
+	if *lenient {
+		options = append(options, pgntools.WithLenient())
+	}
+
+	// and serve it
+	service := pgnservice.NewService(options...)
+	service.SetMaxBodySize(*maxBodySize)
+	fmt.Printf(" Service listening at http://%v\n", *address)
+	log.Fatalln(http.ListenAndServe(*address, service))
+}
+
+/* Local Variables: */
+/* mode:go */
+/* fill-column:80 */
+/* End: */
@@ -0,0 +1,121 @@
+/*
+  stats.go
+  Description: stats subcommand of the PGN parser
+  -----------------------------------------------------------------------------
+
+  Made by Carlos Linares Lopez
+  Login   <clinares@atlas>
+*/
+
+package main
+
+// imports
+// ----------------------------------------------------------------------------
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/clinaresl/pgnparser/pgntools"
+	"github.com/clinaresl/table"
+)
+
+// global variables
+// ----------------------------------------------------------------------------
+
+// Characters used to draw sparklines from the lowest to the highest value
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// functions
+// ----------------------------------------------------------------------------
+
+// Return a sparkline with one character per count which is proportional to the
+// largest one. Zero counts are shown with a blank space
+func sparkline(counts []pgntools.PgnCount) string {
+
+	largest := 0
+	for _, icount := range counts {
+		largest = max(largest, icount.Count)
+	}
+
+	var builder strings.Builder
+	for _, icount := range counts {
+		if icount.Count == 0 {
+			builder.WriteRune(' ')
+			continue
+		}
+		builder.WriteRune(sparks[(len(sparks)-1)*icount.Count/largest])
+	}
+	return builder.String()
+}
+
+// Return a table with the given title and counts, along with the percentage of
+// every count over the given total
+func countsTable(title string, counts []pgntools.PgnCount, total int) string {
+
+	tab, _ := table.NewTable(" l | r r")
+	tab.AddRow(title, "Games", "%")
+	tab.AddSingleRule()
+	for _, icount := range counts {
+		percentage := 0.0
+		if total > 0 {
+			percentage = 100.0 * float64(icount.Count) / float64(total)
+		}
+		tab.AddRow(icount.Name, icount.Count, fmt.Sprintf("%.2f", percentage))
+	}
+	tab.AddDoubleRule()
+	return fmt.Sprintf("%v", tab)
+}
+
+// Execute the stats subcommand with the given arguments, i.e., all arguments
+// given after 'stats'. It shows a dashboard with a summary of the games found
+// in the given PGN file
+func statsCommand(args []string) {
+
+	// parse the flags of the stats subcommand
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	top := flags.Int("top", 10, "number of players and openings shown. By default, 10")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %v stats [options] <file.pgn>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(EXIT_FAILURE)
+	}
+
+	// read all games in the given file
+	pgnfile, err := pgntools.NewPgnFile(flags.Arg(0))
+	if err != nil {
+		log.Fatalf(" Error: %v\n", err)
+	}
+	games, err := pgnfile.Games(pgntools.WithLenient())
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Summary
+	fmt.Println()
+	fmt.Println(pgnfile)
+	fmt.Println(games.ParseStats())
+
+	// Results, players and openings
+	fmt.Println(countsTable("Result", games.ResultCounts(), games.Len()))
+	fmt.Println(countsTable("Player", games.TopPlayers(*top), games.Len()))
+	fmt.Println(countsTable("Opening", games.TopOpenings(*top), games.Len()))
+
+	// Games per month
+	if months := games.GamesPerMonth(); len(months) > 0 {
+		fmt.Printf(" Games per month (%v - %v)\n", months[0].Name, months[len(months)-1].Name)
+		fmt.Printf(" %v\n", sparkline(months))
+		fmt.Println()
+	}
+}
+
+/* Local Variables: */
+/* mode:go */
+/* fill-column:80 */
+/* End: */
@@ -0,0 +1,87 @@
+{{/*
+
+	This template shows a dossier to prepare games against a
+	player. It starts with the results of the player with every
+	color, in the most frequent openings and in the typical
+	middlegame structures reached, and then it shows the most
+	recent games played by the player, each one in a different
+	page.
+
+*/}}
+\documentclass[oneside,svgnames]{article}
+
+\usepackage[a4paper, total={7.5in, 10in}]{geometry}
+
+\usepackage[utf8]{inputenc}
+\usepackage[english]{babel}
+
+\usepackage{xcolor}
+\usepackage{FiraSans}
+
+\usepackage{xskak}
+
+\usepackage{hyperref}
+\hypersetup{
+    colorlinks=true,
+    urlcolor=RoyalBlue,
+    pdfpagemode=FullScreen,
+}
+
+{{/* ----------------------------- Main Body ----------------------------- */}}
+
+\begin{document}
+
+\sffamily
+
+\begin{center}
+  {\Large Dossier: {{.GetLaTeXPlayer}}}\\[0.2cm]
+  {\small Generated from \texttt{ {{.Context.Source}} } on {{.Context.Generated.Format "2006-01-02"}}}
+\end{center}
+
+{{/* ------------------------------- Results ----------------------------- */}}
+
+\section*{Results}
+
+\begin{center}
+{{.GetLaTeXColors}}\end{center}
+
+{{/* ------------------------------ Openings ----------------------------- */}}
+
+\section*{Most frequent openings}
+
+\begin{center}
+{{.GetLaTeXOpenings 10}}\end{center}
+
+{{/* ----------------------------- Structures ---------------------------- */}}
+
+\section*{Middlegame structures}
+
+{{if .Structures}}\begin{center}
+{{.GetLaTeXStructures}}\end{center}
+{{else}}No typical structure was found in the middlegame.
+{{end}}
+
+{{/* ---------------------------- Recent games --------------------------- */}}
+
+{{range .Recent}}
+
+\newpage
+
+\section*{ {{.GetField ("White")}} -- {{.GetField ("Black")}} }
+
+\noindent
+\textcolor{Sienna}{ {{.GetField ("Date")}} } \hfill \textcolor{IndianRed}{ {{.GetField ("Event")}} ({{.GetField ("ECO")}})}
+\hrule
+
+\vspace{0.5cm}
+
+\newchessgame
+{{.GetLaTeXMovesWithComments}}\hfill \textbf{ {{.GetField ("Result")}}}\\
+
+\begin{center}
+  \chessboard[print,showmover=true]
+\end{center}
+
+{{end}}
+
+\end{document}
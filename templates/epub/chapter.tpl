@@ -0,0 +1,25 @@
+{{/*
+
+	This template writes every game of a collection in a different
+	chapter of an EPUB book. It is the same template used by default,
+	and it can be used as a starting point to write others.
+
+	Chapters are written in XHTML, and the XML declaration is
+	automatically added at the beginning of every chapter. Every
+	chapter is given the Id, Title, Tags, Moves (already in HTML),
+	Result of the game, and the relative path to the Image of its
+	final position.
+
+*/}}<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{index .Tags "Event"}}{{with index .Tags "Site"}}, {{.}}{{end}}{{with index .Tags "Date"}}, {{.}}{{end}}</p>
+<p>{{with index .Tags "ECO"}}{{.}} {{end}}{{index .Tags "Opening"}}</p>
+<p>{{.Moves}} <strong>{{.Result}}</strong></p>
+<p><img src="{{.Image}}" alt="Final position"/></p>
+</body>
+</html>
@@ -82,12 +82,24 @@
 ECO: {{.GetField ("ECO")}}}
 \hrule
 
+{{/* -------------------------------- Hooks ------------------------------ */}}
+
+{{/*
+	Games can be given custom LaTeX code with the tag "LaTeXHeader",
+	which is written verbatim right after the header, and games
+	with the tag "Brilliancy" are shown with a star along with its
+	value, e.g., the name of the prize
+*/}}
+{{with .RawTag "LaTeXHeader"}}{{.}}
+{{end}}{{if .HasTag "Brilliancy"}}\vspace{0.1cm}
+\noindent \textcolor{Goldenrod}{$\star$~{{.TagOr "Brilliancy" "Brilliancy prize"}}}
+{{end}}
 \vspace{0.5cm}
 
 {{/* -------------------------------- Moves ------------------------------ */}}
 
 \newchessgame
-{{.GetLaTeXMovesWithCommentsTabular "4.2in" "3.0in" 8}}\hfill \textbf{ {{.GetField ("Result")}}}\\
+{{.GetLaTeXMovesWithCommentsTabular "4.2in" "3.0in" 8 $.Diagram}}\hfill \textbf{ {{.GetField ("Result")}}}\\
 {{.SetLabel}}
 
 \newpage
@@ -9,6 +9,10 @@
 
 */}}
 
+{{/*
+	The preamble is written only once even if the collection is
+	processed in chunks
+*/}}{{if .IsFirstChunk}}
 \documentclass[oneside,svgnames]{report}
 
 \usepackage[a4paper, total={7.5in, 10in}]{geometry}
@@ -31,6 +35,9 @@
 
 \usepackage{xskak}
 
+\usepackage{pgfplots}
+\pgfplotsset{compat=1.16}
+
 \usepackage{hyperref}
 \hypersetup{
     colorlinks=true,
@@ -43,10 +50,12 @@
 \begin{document}
 
 \sffamily
+{{end}}
 
 {{/*
 	For all games, just show the header and then the moves
-	Finally, show a diagram with the final position of the game
+	Next, show a chart with the thinking time of both players, if
+	known, and finally a diagram with the final position of the game
 */}}
 
 {{range .GetGames}} 
@@ -69,6 +78,18 @@
 {{.GetField ("Opening")}} ({{.GetField ("ECO")}})}
 \hrule
 
+{{/* -------------------------------- Hooks ------------------------------ */}}
+
+{{/*
+	Games can be given custom LaTeX code with the tag "LaTeXHeader",
+	which is written verbatim right after the header, and games
+	with the tag "Brilliancy" are shown with a star along with its
+	value, e.g., the name of the prize
+*/}}
+{{with .RawTag "LaTeXHeader"}}{{.}}
+{{end}}{{if .HasTag "Brilliancy"}}\vspace{0.1cm}
+\noindent \textcolor{Goldenrod}{$\star$~{{.TagOr "Brilliancy" "Brilliancy prize"}}}
+{{end}}
 \vspace{0.5cm}
 
 {{/* -------------------------------- Moves ------------------------------ */}}
@@ -76,10 +97,17 @@
 \newchessgame
 {{.GetLaTeXMovesWithComments}}\hfill \textbf{ {{.GetField ("Result")}}}\\
 
+{{/* --------------------------- Thinking time --------------------------- */}}
+
+{{with .GetLaTeXTimeChart "6.5in" "2.2in"}}
+\begin{center}
+{{.}}\end{center}
+{{end}}
+
 {{/* --------------------------- Final position -------------------------- */}}
 
 \begin{center}
-  \chessboard[print,showmover=true]
+  \chessboard[print,showmover=true{{.GetLaTeXFinalMarks}}]
 \end{center}
 \noindent
 \hfill \textcolor{IndianRed}{Termination: {{.GetField ("Termination")}}}
@@ -88,4 +116,4 @@
 
 {{end}}
 
-\end{document}
+{{if .IsLastChunk}}\end{document}{{end}}